@@ -0,0 +1,335 @@
+package _test
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"image/color"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stepAnimator Advances the animator by the given time, in the
+// microseconds used for deltaTime by the window.
+func stepAnimator(animator *gfx.Animator, d time.Duration) {
+	animator.Update(d.Microseconds())
+}
+
+func newTestAnimator() *gfx.Animator {
+	animator := gfx.NewAnimator()
+	animator.Init()
+	return animator
+}
+
+func TestEasing(t *testing.T) {
+	for name, easing := range map[string]gfx.EasingFunc{
+		"Linear":         gfx.Linear,
+		"EaseStep":       gfx.EaseStep,
+		"EaseInQuad":     gfx.EaseInQuad,
+		"EaseOutQuad":    gfx.EaseOutQuad,
+		"EaseInOutQuad":  gfx.EaseInOutQuad,
+		"EaseInCubic":    gfx.EaseInCubic,
+		"EaseOutCubic":   gfx.EaseOutCubic,
+		"EaseInOutCubic": gfx.EaseInOutCubic,
+		"EaseInSine":     gfx.EaseInSine,
+		"EaseOutSine":    gfx.EaseOutSine,
+		"EaseInOutSine":  gfx.EaseInOutSine,
+		"EaseInBack":     gfx.EaseInBack,
+		"EaseOutBack":    gfx.EaseOutBack,
+		"EaseOutElastic": gfx.EaseOutElastic,
+		"EaseOutBounce":  gfx.EaseOutBounce,
+	} {
+		assert.InDelta(t, 0, easing(0), 1e-5, "%s: unexpected start", name)
+		assert.InDelta(t, 1, easing(1), 1e-5, "%s: unexpected end", name)
+	}
+
+	assert.InDelta(t, 0.5, gfx.EaseInOutQuad(0.5), 1e-5, "unexpected midpoint")
+	assert.Less(t, gfx.EaseInQuad(0.5), float32(0.5), "expected ease in to start slowly")
+	assert.Greater(t, gfx.EaseOutQuad(0.5), float32(0.5), "expected ease out to start quickly")
+}
+
+func TestTween(t *testing.T) {
+	animator := newTestAnimator()
+
+	var value float32
+	completed := 0
+	tween := gfx.NewTween(10, 20, time.Second, func(v float32) { value = v })
+	tween.OnComplete(func() { completed++ })
+	animator.Play(tween)
+
+	stepAnimator(animator, 250*time.Millisecond)
+	assert.InDelta(t, 12.5, value, 1e-4, "unexpected value")
+	assert.True(t, animator.Playing(tween), "expected tween to be playing")
+
+	animator.Pause(tween)
+	stepAnimator(animator, 500*time.Millisecond)
+	assert.InDelta(t, 12.5, value, 1e-4, "expected paused tween to hold its value")
+
+	animator.Resume(tween)
+	stepAnimator(animator, 500*time.Millisecond)
+	assert.InDelta(t, 17.5, value, 1e-4, "unexpected value")
+	assert.Equal(t, 0, completed, "unexpected completion")
+
+	stepAnimator(animator, time.Second)
+	assert.InDelta(t, 20, value, 1e-4, "expected tween to end on its final value")
+	assert.Equal(t, 1, completed, "expected a single completion")
+	assert.True(t, tween.Finished(), "expected tween to be finished")
+	assert.False(t, animator.Playing(tween), "expected finished tween to be removed")
+
+	stepAnimator(animator, time.Second)
+	assert.Equal(t, 1, completed, "expected a single completion")
+}
+
+func TestTweenDelayLoopsAndPingPong(t *testing.T) {
+	animator := newTestAnimator()
+
+	var value float32 = -1
+	tween := gfx.NewTween(0, 1, time.Second, func(v float32) { value = v })
+	tween.SetDelay(time.Second).SetLoops(3).SetPingPong(true)
+	total, finite := tween.TotalDuration()
+	assert.True(t, finite, "expected a finite animation")
+	assert.Equal(t, 4*time.Second, total, "unexpected total duration")
+	animator.Play(tween)
+
+	stepAnimator(animator, 500*time.Millisecond)
+	assert.Equal(t, float32(-1), value, "expected delayed tween not to have started")
+
+	stepAnimator(animator, time.Second)
+	assert.InDelta(t, 0.5, value, 1e-4, "unexpected value during first pass")
+
+	stepAnimator(animator, 750*time.Millisecond)
+	assert.InDelta(t, 0.75, value, 1e-4, "expected second pass to play in reverse")
+
+	stepAnimator(animator, time.Second)
+	assert.InDelta(t, 0.25, value, 1e-4, "expected third pass to play forwards")
+
+	stepAnimator(animator, time.Second)
+	assert.InDelta(t, 1, value, 1e-4, "unexpected final value")
+	assert.False(t, animator.Playing(tween), "expected tween to have finished")
+
+	forever := gfx.NewTween(0, 1, time.Second, func(v float32) { value = v })
+	forever.SetLoops(gfx.LoopForever)
+	_, finite = forever.TotalDuration()
+	assert.False(t, finite, "expected an infinite animation")
+
+	animator.Play(forever)
+	stepAnimator(animator, 10*time.Second+250*time.Millisecond)
+	assert.InDelta(t, 0.25, value, 1e-4, "unexpected value while looping")
+	assert.True(t, animator.Playing(forever), "expected looping tween to keep playing")
+
+	animator.Stop(forever)
+	assert.False(t, animator.Playing(forever), "expected tween to be stopped")
+}
+
+func TestTweenProperties(t *testing.T) {
+	animator := newTestAnimator()
+
+	transform := &gfx.ObjectTransform{}
+	transform.SetPosition(mgl32.Vec3{1, 0, 0})
+	transform.SetScale(mgl32.Vec3{1, 1, 1})
+
+	slider := gfx.NewSlider(gfx.Horizontal, false)
+	slider.SetValue(0.2)
+
+	object := gfx.NewWindowObject()
+	object.SetColor(color.RGBA{R: 0, G: 100, B: 200, A: 255})
+
+	rotation := mgl32.QuatRotate(math.Pi/2, mgl32.Vec3{0, 1, 0})
+	animator.Play(gfx.NewParallel(
+		gfx.TweenPosition(transform, mgl32.Vec3{3, 0, 0}, time.Second),
+		gfx.TweenScale(transform, mgl32.Vec3{2, 2, 2}, time.Second).SetEasing(gfx.EaseInQuad),
+		gfx.TweenRotation(transform, rotation, time.Second),
+		gfx.TweenColor(object, color.RGBA{R: 100, G: 0, B: 200, A: 255}, time.Second),
+		gfx.TweenSliderValue(slider, 0.6, time.Second),
+	))
+
+	stepAnimator(animator, 500*time.Millisecond)
+	assert.InDelta(t, 2, transform.Position().X(), 1e-4, "expected tween to start from the current position")
+	assert.InDelta(t, 1.25, transform.Scale().X(), 1e-4, "expected eased scale")
+	assert.InDelta(t, 0.4, slider.Value(), 1e-4, "unexpected slider value")
+	assert.Equal(t, color.RGBA{R: 50, G: 50, B: 200, A: 255}, object.Color(), "unexpected color")
+
+	expected := mgl32.QuatRotate(math.Pi/4, mgl32.Vec3{0, 1, 0})
+	assert.InDelta(t, 1, math.Abs(float64(transform.RotationQuat().Dot(expected))), 1e-4, "unexpected rotation")
+
+	animator.Play(gfx.TweenOpacity(object, 55, time.Second))
+	stepAnimator(animator, 2*time.Second)
+	assert.Equal(t, uint8(55), object.Opacity(), "unexpected opacity")
+	assert.Equal(t, mgl32.Vec3{3, 0, 0}, transform.Position(), "unexpected final position")
+}
+
+func TestTrack(t *testing.T) {
+	animator := newTestAnimator()
+
+	var value mgl32.Vec3
+	track := gfx.NewTrack(func(v mgl32.Vec3) { value = v },
+		gfx.Keyframe[mgl32.Vec3]{Time: 2 * time.Second, Value: mgl32.Vec3{0, 2, 0}},
+		gfx.Keyframe[mgl32.Vec3]{Time: 0, Value: mgl32.Vec3{0, 0, 0}},
+	)
+	track.AddKeyframe(time.Second, mgl32.Vec3{1, 0, 0})
+	assert.Len(t, track.Keyframes(), 3, "unexpected keyframe count")
+	assert.Equal(t, 2*time.Second, track.Duration(), "unexpected duration")
+
+	animator.Play(track)
+	stepAnimator(animator, 500*time.Millisecond)
+	assert.InDelta(t, 0.5, value.X(), 1e-4, "unexpected value in first segment")
+
+	stepAnimator(animator, time.Second)
+	assert.InDelta(t, 0.5, value.X(), 1e-4, "unexpected X in second segment")
+	assert.InDelta(t, 1, value.Y(), 1e-4, "unexpected Y in second segment")
+
+	stepAnimator(animator, time.Second)
+	assert.Equal(t, mgl32.Vec3{0, 2, 0}, value, "unexpected final value")
+	assert.True(t, track.Finished(), "expected track to be finished")
+}
+
+func TestSequence(t *testing.T) {
+	animator := newTestAnimator()
+
+	var value float32
+	var order []string
+	first := gfx.NewTween(0, 1, time.Second, func(v float32) { value = v })
+	first.OnComplete(func() { order = append(order, "first") })
+	second := gfx.NewTween(1, 3, time.Second, func(v float32) { value = v })
+	second.SetDelay(time.Second).OnComplete(func() { order = append(order, "second") })
+
+	sequence := gfx.NewSequence(first, second)
+	sequence.SetLoops(2).SetPingPong(true).OnComplete(func() { order = append(order, "sequence") })
+	assert.Equal(t, 3*time.Second, sequence.Duration(), "unexpected duration")
+
+	animator.Play(sequence)
+	stepAnimator(animator, 500*time.Millisecond)
+	assert.InDelta(t, 0.5, value, 1e-4, "unexpected value in first tween")
+
+	stepAnimator(animator, time.Second)
+	assert.InDelta(t, 1, value, 1e-4, "expected value to hold during delay")
+	assert.Equal(t, []string{"first"}, order, "unexpected completions")
+
+	stepAnimator(animator, time.Second)
+	assert.InDelta(t, 2, value, 1e-4, "unexpected value in second tween")
+
+	stepAnimator(animator, time.Second)
+	assert.InDelta(t, 3-0.5*2, value, 1e-4, "expected sequence to play in reverse")
+
+	stepAnimator(animator, 1750*time.Millisecond)
+	assert.InDelta(t, 0.75, value, 1e-4, "expected sequence to rewind the first tween")
+
+	stepAnimator(animator, time.Second)
+	assert.InDelta(t, 0, value, 1e-4, "unexpected final value")
+	assert.Equal(t, "sequence", order[len(order)-1], "expected sequence to complete last")
+	assert.False(t, animator.Playing(sequence), "expected sequence to have finished")
+}
+
+func TestAnimatorSpeedAndRestart(t *testing.T) {
+	animator := newTestAnimator()
+
+	var value float32
+	tween := gfx.NewTween(0, 1, time.Second, func(v float32) { value = v })
+	animator.SetSpeed(0.5).Play(tween)
+
+	stepAnimator(animator, time.Second)
+	assert.InDelta(t, 0.5, value, 1e-4, "expected half speed")
+
+	animator.Play(tween)
+	stepAnimator(animator, 500*time.Millisecond)
+	assert.InDelta(t, 0.25, value, 1e-4, "expected tween to restart")
+
+	animator.Seek(tween, 1500*time.Millisecond)
+	animator.SetSpeed(1)
+	stepAnimator(animator, 0)
+	assert.InDelta(t, 1, value, 1e-4, "expected tween to be seeked to its end")
+	assert.False(t, animator.Playing(tween), "expected tween to have finished")
+
+	animator.Play(tween).StopAll()
+	assert.False(t, animator.Playing(tween), "expected all animations to be stopped")
+}
+
+func TestAnimatorReentrantSetter(t *testing.T) {
+	animator := newTestAnimator()
+
+	var value float32
+	next := gfx.NewTween(0, 1, time.Second, func(v float32) { value = v })
+
+	// Setters can use the animator, e.g., to chain another animation
+	var chained bool
+	tween := gfx.NewTween(0, 1, time.Second, func(v float32) {
+		if v >= 1 && !animator.Playing(next) {
+			animator.Play(next)
+			chained = true
+		}
+	})
+	animator.Play(tween)
+
+	done := make(chan struct{})
+	go func() {
+		stepAnimator(animator, 2*time.Second)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the setter to be able to use the animator")
+	}
+
+	assert.True(t, chained, "expected the next tween to be played")
+	assert.False(t, animator.Playing(tween), "expected the tween to have finished")
+	assert.True(t, animator.Playing(next), "expected the next tween to be playing")
+
+	stepAnimator(animator, 500*time.Millisecond)
+	assert.InDelta(t, 0.5, value, 1e-4, "unexpected value of the next tween")
+}
+
+func TestAnimatorConcurrentPlay(t *testing.T) {
+	animator := newTestAnimator()
+
+	var value atomic.Value
+	setter := func(v float32) { value.Store(v) }
+	sequence := gfx.NewSequence(
+		gfx.NewTween(0, 1, time.Second, setter),
+		gfx.NewTween(1, 0, time.Second, setter),
+	)
+	sequence.SetLoops(gfx.LoopForever)
+	animator.Play(sequence)
+
+	// Play resets the sequence on the caller's goroutine while Update seeks
+	// it on another; run with -race to detect unguarded state
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			animator.Play(sequence)
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		stepAnimator(animator, 10*time.Millisecond)
+	}
+	<-done
+
+	assert.True(t, animator.Playing(sequence), "expected the sequence to be playing")
+}
+
+func TestParallel(t *testing.T) {
+	animator := newTestAnimator()
+
+	var a, b float32
+	short := gfx.NewTween(0, 1, time.Second, func(v float32) { a = v })
+	forever := gfx.NewTween(0, 1, 2*time.Second, func(v float32) { b = v })
+	forever.SetLoops(gfx.LoopForever)
+
+	parallel := gfx.NewParallel(short, forever)
+	_, finite := parallel.TotalDuration()
+	assert.False(t, finite, "expected an infinite animation")
+
+	animator.Play(parallel)
+	stepAnimator(animator, 500*time.Millisecond)
+	assert.InDelta(t, 0.5, a, 1e-4, "unexpected value of short tween")
+	assert.InDelta(t, 0.25, b, 1e-4, "unexpected value of looping tween")
+
+	stepAnimator(animator, 5*time.Second)
+	assert.InDelta(t, 1, a, 1e-4, "expected short tween to hold its final value")
+	assert.InDelta(t, 0.75, b, 1e-4, "unexpected value of looping tween")
+	assert.True(t, short.Finished(), "expected short tween to be finished")
+	assert.True(t, animator.Playing(parallel), "expected group to keep playing")
+}
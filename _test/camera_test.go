@@ -0,0 +1,86 @@
+package _test
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"testing"
+)
+
+func TestOrthographicCameraProjection(t *testing.T) {
+	camera := gfx.NewOrthographicCamera()
+	camera.SetAspectRatio(2)
+	camera.SetViewVolume(10, 0.1, 1000)
+	camera.Update(0)
+
+	width, height := camera.ViewSize()
+	assert.InDelta(t, 20, width, 1e-5, "unexpected view width")
+	assert.InDelta(t, 10, height, 1e-5, "unexpected view height")
+
+	// Points at the edge of the view volume should land on the edge of clip
+	// space, regardless of their distance from the camera.
+	viewProj := camera.ViewProjection()
+	for _, z := range []float32{0, -50, 50} {
+		clip := viewProj.Mul4x1(mgl32.Vec4{10, 5, z, 1})
+		assert.InDelta(t, 1, clip[0]/clip[3], 1e-5, "unexpected clip-space X (z = %v)", z)
+		assert.InDelta(t, 1, clip[1]/clip[3], 1e-5, "unexpected clip-space Y (z = %v)", z)
+	}
+
+	assert.Equal(t, viewProj, camera.BasicCamera.ViewProjMat, "unexpected bound ViewProjMat")
+}
+
+func TestOrthographicCameraZoomAndResize(t *testing.T) {
+	camera := gfx.NewOrthographicCamera()
+	camera.SetViewVolume(10, 0.1, 1000)
+	camera.Resize(800, 400)
+
+	camera.SetZoom(2)
+	width, height := camera.ViewSize()
+	assert.InDelta(t, 10, width, 1e-5, "unexpected view width after zoom")
+	assert.InDelta(t, 5, height, 1e-5, "unexpected view height after zoom")
+
+	// The height of the view volume is maintained when resizing, so that the
+	// scale of the drawing does not change, while its width follows the
+	// aspect ratio.
+	camera.Resize(400, 400)
+	width, height = camera.ViewSize()
+	assert.InDelta(t, 5, width, 1e-5, "unexpected view width after resize")
+	assert.InDelta(t, 5, height, 1e-5, "unexpected view height after resize")
+	assert.InDelta(t, 1, camera.AspectRatio(), 1e-5, "unexpected aspect ratio after resize")
+
+	camera.SetZoom(0)
+	assert.Equal(t, float32(2), camera.Zoom(), "expected non-positive zoom to be ignored")
+}
+
+func TestOrthographicCameraPresets(t *testing.T) {
+	camera := gfx.NewOrthographicCamera()
+	camera.SetLookAt(mgl32.Vec3{1, 2, 13}, mgl32.Vec3{1, 2, 3}, mgl32.Vec3{0, 1, 0})
+
+	testCases := []struct {
+		preset   gfx.ViewPreset
+		position mgl32.Vec3
+		up       mgl32.Vec3
+	}{
+		{gfx.FrontView, mgl32.Vec3{1, 2, 13}, mgl32.Vec3{0, 1, 0}},
+		{gfx.BackView, mgl32.Vec3{1, 2, -7}, mgl32.Vec3{0, 1, 0}},
+		{gfx.TopView, mgl32.Vec3{1, 12, 3}, mgl32.Vec3{0, 0, -1}},
+		{gfx.BottomView, mgl32.Vec3{1, -8, 3}, mgl32.Vec3{0, 0, 1}},
+		{gfx.LeftView, mgl32.Vec3{-9, 2, 3}, mgl32.Vec3{0, 1, 0}},
+		{gfx.RightView, mgl32.Vec3{11, 2, 3}, mgl32.Vec3{0, 1, 0}},
+	}
+
+	for _, tc := range testCases {
+		camera.SetViewPreset(tc.preset)
+		position, target, up := camera.LookAt()
+		assert.True(t, position.ApproxEqualThreshold(tc.position, 1e-4), "unexpected position for preset %d: %v", tc.preset, position)
+		assert.Equal(t, mgl32.Vec3{1, 2, 3}, target, "expected target to be unchanged for preset %d", tc.preset)
+		assert.Equal(t, tc.up, up, "unexpected up vector for preset %d", tc.preset)
+	}
+
+	camera.SetViewPreset(gfx.IsometricView)
+	position, target, _ := camera.LookAt()
+	offset := position.Sub(target)
+	assert.InDelta(t, 10, offset.Len(), 1e-4, "expected distance to be unchanged for isometric view")
+	assert.InDelta(t, offset[0], offset[1], 1e-4, "expected equal X/Y offsets for isometric view")
+	assert.InDelta(t, offset[1], offset[2], 1e-4, "expected equal Y/Z offsets for isometric view")
+}
@@ -0,0 +1,60 @@
+package _test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"sync/atomic"
+	"testing"
+)
+
+type frameCounter struct {
+	gfx.ObjectBase
+	frames    atomic.Int64
+	totalTime atomic.Int64
+}
+
+func (c *frameCounter) Update(deltaTime int64) (ok bool) {
+	c.frames.Add(1)
+	c.totalTime.Add(deltaTime)
+	return true
+}
+
+func TestManualClockStep(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		counter := &frameCounter{}
+		win.AddObject(counter)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+
+		assert.Equal(t, int64(0), counter.frames.Load(), "expected no frames to be processed before stepping")
+
+		gfx.Step(10, 5000)
+		assert.Equal(t, int64(10), counter.frames.Load(), "unexpected frame count after stepping")
+		assert.Equal(t, int64(50000), counter.totalTime.Load(), "unexpected total delta time after stepping")
+
+		_test.SleepNFrames(5)
+		assert.Equal(t, int64(15), counter.frames.Load(), "unexpected frame count after stepping")
+		assert.Equal(t, int64(50000+5*_test.FrameDeltaTime), counter.totalTime.Load(), "unexpected total delta time after stepping")
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
+
+func TestStepWithRealTimeClock(t *testing.T) {
+	assert.Panics(t, func() { gfx.Step(1, 1000) }, "expected Step to panic when not using a ManualClock")
+}
@@ -8,6 +8,7 @@ const (
 	WindowHeight    = 1000
 	TargetFramerate = 200 // recommend a refresh rate of 120Hz with this value; *see note...
 	VSyncEnabled    = true
+	FrameDeltaTime  = 1_000_000 / TargetFramerate // microseconds; used when stepping
 )
 
 var (
@@ -0,0 +1,132 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"math"
+	"testing"
+)
+
+func assertCameraPosition(t *testing.T, camera *gfx.BasicCamera, expected mgl32.Vec3, msg string) {
+	position, _, _ := camera.LookAt()
+	for i := 0; i < 3; i++ {
+		assert.InDelta(t, expected[i], position[i], 1e-3, msg)
+	}
+}
+
+func TestOrbitCameraController(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		camera := gfx.NewCamera()
+		camera.SetLookAt(mgl32.Vec3{0, 0, 5}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+		win.AddObject(camera)
+
+		controller := gfx.NewOrbitCameraController(camera).SetInertia(false)
+		win.AddService(controller)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+
+		controller.Orbit(90, 0)
+		_test.StepNFrames(1)
+		assertCameraPosition(t, camera, mgl32.Vec3{5, 0, 0}, "unexpected position after orbiting")
+
+		controller.Orbit(0, 120)
+		_test.StepNFrames(1)
+		_, maxPitch := controller.PitchLimits()
+		position, _, _ := camera.LookAt()
+		assert.InDelta(t, 5*math.Sin(float64(mgl32.DegToRad(maxPitch))), position.Y(), 1e-3, "expected the pitch to be limited")
+
+		controller.Reset()
+		_test.StepNFrames(1)
+		assertCameraPosition(t, camera, mgl32.Vec3{0, 0, 5}, "unexpected position after reset")
+
+		controller.SetDistanceLimits(1, 10).Zoom(-5)
+		_test.StepNFrames(1)
+		assertCameraPosition(t, camera, mgl32.Vec3{0, 0, 10}, "expected the distance to be limited")
+
+		controller.Reset().Pan(0.5, 0)
+		_test.StepNFrames(1)
+		_, target, _ := camera.LookAt()
+		assert.InDelta(t, 2.5, target.X(), 1e-3, "unexpected target after panning")
+
+		// With inertia, movements ease out over multiple frames
+		controller.Reset()
+		_test.StepNFrames(1)
+		controller.SetInertia(true).Orbit(90, 0)
+		_test.StepNFrames(1)
+		position, _, _ = camera.LookAt()
+		assert.Greater(t, position.X(), float32(0), "expected the camera to have started moving")
+		assert.Less(t, position.X(), float32(5), "expected the camera to still be moving")
+		_test.StepNFrames(200)
+		assertCameraPosition(t, camera, mgl32.Vec3{5, 0, 0}, "unexpected position after easing out")
+
+		// Dragging the mouse orbits the camera and scrolling dollies it
+		controller.SetInertia(false).Reset()
+		_test.StepNFrames(1)
+		win.OverrideMouseState(&gfx.MouseState{X: 0, Y: 0, PrimaryDown: true})
+		_test.StepNFrames(1)
+		win.OverrideMouseState(&gfx.MouseState{X: -0.5, Y: 0, PrimaryDown: true})
+		_test.StepNFrames(1)
+		assertCameraPosition(t, camera, mgl32.Vec3{5, 0, 0}, "unexpected position after dragging")
+		win.OverrideMouseState(&gfx.MouseState{X: -0.5, Y: 0, ScrollY: 1})
+		_test.StepNFrames(1)
+		position, target, _ = camera.LookAt()
+		assert.Less(t, position.Sub(target).Len(), float32(5), "expected scrolling up to dolly the camera in")
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
+
+func TestFirstPersonCameraController(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		camera := gfx.NewCamera()
+		camera.SetLookAt(mgl32.Vec3{0, 0, 5}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+		win.AddObject(camera)
+
+		controller := gfx.NewFirstPersonCameraController(camera)
+		win.AddService(controller)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+
+		controller.Move(mgl32.Vec3{0, 0, 1})
+		_test.StepNFrames(1)
+		assertCameraPosition(t, camera, mgl32.Vec3{0, 0, 4}, "unexpected position after moving forward")
+
+		controller.Look(90, 0).Move(mgl32.Vec3{0, 0, 2})
+		_test.StepNFrames(1)
+		assertCameraPosition(t, camera, mgl32.Vec3{-2, 0, 4}, "unexpected position after turning and moving")
+
+		position, target, _ := camera.LookAt()
+		assert.InDelta(t, 4, position.Sub(target).Len(), 1e-3, "expected the look distance to be maintained")
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
@@ -0,0 +1,112 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"image/color"
+	"testing"
+)
+
+// countPixelsStepped Steps the engine until the window has rendered the
+// frame requested by Window.ToImage(), then returns the number of pixels
+// of the given color.
+func countPixelsStepped(win *gfx.Window, rgba color.RGBA) (count int) {
+	img := _test.ToImageStepped(win)
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if img.RGBAAt(x, y) == rgba {
+				count++
+			}
+		}
+	}
+	return
+}
+
+func TestShape3DDebugMode(t *testing.T) {
+	shape := gfx.NewShape3D()
+	assert.Equal(t, gfx.DebugNone, shape.DebugMode(), "expected no debug mode by default")
+	assert.Equal(t, float32(0), shape.DebugNormalLength(), "unexpected normal length")
+
+	shape.SetDebugMode(gfx.DebugWireframe | gfx.DebugBounds)
+	assert.Equal(t, gfx.DebugWireframe|gfx.DebugBounds, shape.DebugMode(), "unexpected debug mode")
+
+	shape.ToggleDebugMode(gfx.DebugWireframe)
+	assert.Equal(t, gfx.DebugBounds, shape.DebugMode(), "expected the wireframe to be toggled off")
+
+	shape.ToggleDebugMode(gfx.DebugFaceNormals | gfx.DebugBounds)
+	assert.Equal(t, gfx.DebugFaceNormals, shape.DebugMode(), "unexpected debug mode")
+
+	shape.SetDebugMode(gfx.DebugAll)
+	for _, mode := range []gfx.DebugMode{gfx.DebugWireframe, gfx.DebugVertexNormals, gfx.DebugFaceNormals,
+		gfx.DebugTangentFrames, gfx.DebugBounds, gfx.DebugGizmo} {
+		assert.NotEqual(t, gfx.DebugNone, shape.DebugMode()&mode, "expected mode %d to be included", mode)
+	}
+
+	shape.SetDebugNormalLength(-1)
+	assert.Equal(t, float32(0), shape.DebugNormalLength(), "expected the normal length to be clamped")
+	shape.SetDebugNormalLength(.25)
+	assert.Equal(t, float32(.25), shape.DebugNormalLength(), "unexpected normal length")
+
+	// Keys can be bound before the shape has been added to a window
+	shape.BindDebugKey(glfw.KeyF1, gfx.DebugWireframe)
+}
+
+func TestShape3DDebugRendering(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		shader := gfx.NewBasicShader("test_shader", _test.ColorVertShader, _test.ColorFragShader)
+		win.Assets().Add(shader)
+
+		camera := gfx.NewCamera()
+		camera.SetProjection(45, win.AspectRatio(), .1, 100)
+		camera.SetLookAt(mgl32.Vec3{.5, .5, 5}, mgl32.Vec3{.5, .5, 0}, mgl32.Vec3{0, 1, 0})
+		win.AddObject(camera)
+
+		model := _test.NewColoredQuad()
+		model.Meshes()[0].Faces()[0].AttachedMaterial().AttachShader(shader)
+
+		quad := gfx.NewShape3D()
+		quad.SetModel(model)
+		quad.SetCamera(camera)
+		quad.BindDebugKey(glfw.KeyF1, gfx.DebugWireframe)
+		win.AddObject(quad)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+
+		assert.Zero(t, countPixelsStepped(win, gfx.DebugWireframeColor), "expected no wireframe")
+		assert.Zero(t, countPixelsStepped(win, gfx.DebugBoundsColor), "expected no bounds")
+		assert.Zero(t, countPixelsStepped(win, gfx.DebugGizmoAxisXColor), "expected no gizmo")
+
+		quad.SetDebugMode(gfx.DebugWireframe)
+		assert.Greater(t, countPixelsStepped(win, gfx.DebugWireframeColor), 0, "expected the wireframe to be rendered")
+
+		quad.SetDebugMode(gfx.DebugBounds | gfx.DebugGizmo)
+		assert.Zero(t, countPixelsStepped(win, gfx.DebugWireframeColor), "expected no wireframe")
+		assert.Greater(t, countPixelsStepped(win, gfx.DebugBoundsColor), 0, "expected the bounds to be rendered")
+		assert.Greater(t, countPixelsStepped(win, gfx.DebugGizmoAxisXColor), 0, "expected the gizmo to be rendered")
+
+		quad.SetDebugMode(gfx.DebugNone)
+		assert.Zero(t, countPixelsStepped(win, gfx.DebugBoundsColor), "expected no bounds")
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
@@ -0,0 +1,167 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"image"
+	"image/color"
+	"testing"
+)
+
+const (
+	invertEffectFragmentShader = `#version 410 core
+
+in vec2 UV;
+out vec4 FragColor;
+
+uniform sampler2D u_SceneMap;
+
+void main() {
+    FragColor = vec4(1.0 - texture(u_SceneMap, UV).rgb, 1.0);
+}
+`
+)
+
+func assertImageColor(t *testing.T, img *image.RGBA, x, y int, expected color.RGBA, description string) {
+	actual := img.RGBAAt(x, y)
+	tolerance := 2
+	diff := func(a, b uint8) int {
+		if a > b {
+			return int(a - b)
+		}
+		return int(b - a)
+	}
+	assert.True(t, diff(actual.R, expected.R) <= tolerance &&
+		diff(actual.G, expected.G) <= tolerance &&
+		diff(actual.B, expected.B) <= tolerance,
+		"unexpected color at %s: expected %v, got %v", description, expected, actual)
+}
+
+func TestEffects(t *testing.T) {
+	bloom := gfx.NewBloomEffect()
+	assert.Equal(t, "BloomEffect", bloom.Name(), "unexpected name")
+	assert.True(t, bloom.Enabled(), "expected the effect to be enabled")
+	assert.False(t, bloom.Initialized(), "expected the effect to not be initialized")
+	assert.Nil(t, bloom.Window(), "expected no window")
+	bloom.SetThreshold(.5).SetIntensity(-1).SetBlurPasses(-1)
+	assert.Equal(t, float32(.5), bloom.Threshold(), "unexpected threshold")
+	assert.Equal(t, float32(0), bloom.Intensity(), "expected the intensity to be clamped")
+	assert.Equal(t, 0, bloom.BlurPasses(), "expected the blur passes to be clamped")
+
+	grading := gfx.NewColorGradingEffect(nil)
+	assert.Nil(t, grading.Lut(), "expected no LUT")
+	assert.Equal(t, float32(1), grading.Intensity(), "unexpected intensity")
+	grading.SetIntensity(2)
+	assert.Equal(t, float32(1), grading.Intensity(), "expected the intensity to be clamped")
+
+	vignette := gfx.NewVignetteEffect()
+	assert.Equal(t, gfx.Black, vignette.Color(), "unexpected color")
+	vignette.SetColor(gfx.Blue).SetIntensity(.25).SetRadius(.5).SetSoftness(0)
+	assert.Equal(t, gfx.Blue, vignette.Color(), "unexpected color")
+	assert.Equal(t, float32(.25), vignette.Intensity(), "unexpected intensity")
+	assert.Equal(t, float32(.5), vignette.Radius(), "unexpected radius")
+	assert.Greater(t, vignette.Softness(), float32(0), "expected the softness to be clamped")
+
+	toneMapping := gfx.NewToneMappingEffect(gfx.AcesToneMapping)
+	assert.Equal(t, gfx.AcesToneMapping, toneMapping.Operator(), "unexpected operator")
+	assert.Equal(t, float32(1), toneMapping.Exposure(), "unexpected exposure")
+	assert.Equal(t, float32(2.2), toneMapping.Gamma(), "unexpected gamma")
+	toneMapping.SetOperator(gfx.ReinhardToneMapping).SetExposure(2)
+	assert.Equal(t, gfx.ReinhardToneMapping, toneMapping.Operator(), "unexpected operator")
+	assert.Equal(t, float32(2), toneMapping.Exposure(), "unexpected exposure")
+
+	custom := gfx.NewShaderEffect("invert", invertEffectFragmentShader)
+	assert.Equal(t, "invert", custom.Name(), "unexpected name")
+	assert.NotNil(t, custom.Shader(), "expected a shader")
+
+	win := gfx.NewWindow()
+	win.AddEffects(bloom, gfx.NewFxaaEffect(), custom)
+	if assert.Equal(t, 3, len(win.Effects()), "unexpected effect count") {
+		assert.Equal(t, win, bloom.Window(), "expected the window to be assigned")
+	}
+	win.RemoveEffect(bloom)
+	if assert.Equal(t, 2, len(win.Effects()), "unexpected effect count") {
+		assert.Equal(t, custom, win.Effects()[1], "unexpected effect order")
+	}
+}
+
+func TestNeutralLut(t *testing.T) {
+	lut := gfx.NewNeutralLut(4)
+	assert.Equal(t, 16, lut.Bounds().Dx(), "unexpected width")
+	assert.Equal(t, 4, lut.Bounds().Dy(), "unexpected height")
+
+	// Blue selects the slice, red increases to the right and green
+	// increases toward the top (the bottom of the image)
+	assert.Equal(t, color.NRGBA{R: 0, G: 255, B: 0, A: 255}, lut.NRGBAAt(0, 0), "unexpected color")
+	assert.Equal(t, color.NRGBA{R: 255, G: 0, B: 0, A: 255}, lut.NRGBAAt(3, 3), "unexpected color")
+	assert.Equal(t, color.NRGBA{R: 85, G: 170, B: 170, A: 255}, lut.NRGBAAt(9, 1), "unexpected color")
+	assert.Equal(t, color.NRGBA{R: 255, G: 0, B: 255, A: 255}, lut.NRGBAAt(15, 3), "unexpected color")
+}
+
+func TestEffectRendering(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		quad := gfx.NewQuad()
+		quad.SetColor(gfx.Red)
+		quad.SetScale(mgl32.Vec3{.5, .5})
+		quad.SetMaintainAspectRatio(false)
+
+		toneMapping := gfx.NewToneMappingEffect(gfx.NoToneMapping)
+		vignette := gfx.NewVignetteEffect().
+			SetColor(gfx.Blue).
+			SetIntensity(1).
+			SetRadius(.5).
+			SetSoftness(.01)
+		vignette.SetEnabled(false)
+		invert := gfx.NewShaderEffect("invert", invertEffectFragmentShader)
+
+		win.AddObjects(quad)
+		win.AddEffects(toneMapping, vignette, invert)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+
+		centerX, centerY := _test.WindowWidth/2, _test.WindowHeight/2
+		cornerX, cornerY := 2, 2
+
+		img := _test.ToImageStepped(win)
+		assertImageColor(t, img, centerX, centerY, color.RGBA{G: 255, B: 255, A: 255}, "center, inverted")
+		assertImageColor(t, img, cornerX, cornerY, gfx.White, "corner, inverted")
+
+		invert.SetEnabled(false)
+		_test.StepNFrames(2)
+		img = _test.ToImageStepped(win)
+		assertImageColor(t, img, centerX, centerY, gfx.Red, "center, tone mapped")
+		assertImageColor(t, img, cornerX, cornerY, gfx.Black, "corner, tone mapped")
+
+		vignette.SetEnabled(true)
+		_test.StepNFrames(2)
+		img = _test.ToImageStepped(win)
+		assertImageColor(t, img, centerX, centerY, gfx.Red, "center, vignette")
+		assertImageColor(t, img, cornerX, cornerY, gfx.Blue, "corner, vignette")
+
+		toneMapping.SetEnabled(false)
+		vignette.SetEnabled(false)
+		_test.StepNFrames(2)
+		img = _test.ToImageStepped(win)
+		assertImageColor(t, img, centerX, centerY, gfx.Red, "center, no effects")
+		assertImageColor(t, img, cornerX, cornerY, gfx.Black, "corner, no effects")
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
@@ -0,0 +1,394 @@
+package _test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/gltf"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+const gltfDocument = `{
+  "asset": { "version": "2.0" },
+  "scene": 0,
+  "scenes": [ { "nodes": [ 0 ] } ],
+  "nodes": [
+    { "name": "Parent", "translation": [ 10, 0, 0 ], "children": [ 1 ] },
+    { "name": "Child", "scale": [ 2, 2, 2 ], "mesh": 0 }
+  ],
+  "meshes": [
+    {
+      "name": "Triangle",
+      "primitives": [
+        { "attributes": { "POSITION": 0 }, "indices": 1, "material": 0 },
+        { "attributes": { "POSITION": 0 }, "mode": 1 }
+      ]
+    }
+  ],
+  "materials": [
+    {
+      "name": "Red",
+      "pbrMetallicRoughness": { "baseColorFactor": [ 1, 0, 0, 0.5 ], "roughnessFactor": 0.5 },
+      "emissiveFactor": [ 0, 0, 1 ],
+      "alphaMode": "BLEND"
+    }
+  ],
+  "accessors": [
+    { "bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3" },
+    { "bufferView": 1, "componentType": 5123, "count": 3, "type": "SCALAR" }
+  ],
+  "bufferViews": [
+    { "buffer": 0, "byteOffset": 0, "byteLength": 36 },
+    { "buffer": 0, "byteOffset": 36, "byteLength": 6 }
+  ],
+  "buffers": [ { %s"byteLength": 42 } ]
+}`
+
+func gltfBuffer() []byte {
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.LittleEndian, []float32{
+		0, 0, 0,
+		1, 0, 0,
+		0, 1, 0,
+	})
+	_ = binary.Write(buf, binary.LittleEndian, []uint16{0, 1, 2})
+	return buf.Bytes()
+}
+
+func gltfWithDataUri() string {
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(gltfBuffer())
+	return fmt.Sprintf(gltfDocument, fmt.Sprintf(`"uri": "%s", `, uri))
+}
+
+func glbWithBinChunk() []byte {
+	jsonChunk := []byte(fmt.Sprintf(gltfDocument, ""))
+	for len(jsonChunk)%4 != 0 {
+		jsonChunk = append(jsonChunk, ' ')
+	}
+
+	binChunk := gltfBuffer()
+	for len(binChunk)%4 != 0 {
+		binChunk = append(binChunk, 0)
+	}
+
+	glb := &bytes.Buffer{}
+	_ = binary.Write(glb, binary.LittleEndian, []uint32{
+		0x46546C67, 2, uint32(12 + 8 + len(jsonChunk) + 8 + len(binChunk)),
+		uint32(len(jsonChunk)), 0x4E4F534A,
+	})
+	glb.Write(jsonChunk)
+	_ = binary.Write(glb, binary.LittleEndian, []uint32{uint32(len(binChunk)), 0x004E4942})
+	glb.Write(binChunk)
+	return glb.Bytes()
+}
+
+func assertGltfTriangle(t *testing.T, model *gltf.Model) {
+	if !assert.NoError(t, model.Load(), "unexpected load error") {
+		return
+	}
+
+	assert.Equal(t, 1, len(model.Meshes()), "unexpected mesh count")
+	assert.Equal(t, 1, len(model.Warnings()), "expected a warning for the non-triangle primitive")
+
+	mesh := model.Meshes()[0]
+	assert.Equal(t, "Child", mesh.Name(), "unexpected mesh name")
+	assert.Equal(t, 1, len(mesh.Faces()), "unexpected face count")
+
+	// Parent translation and child scale should be baked into the vertices
+	expected := []float32{10, 0, 0, 12, 0, 0, 10, 2, 0}
+	face := mesh.Faces()[0]
+	for i, index := range face.VertexIndices() {
+		for j := 0; j < 3; j++ {
+			assert.InDelta(t, expected[i*3+j], model.Vertices()[index*3+j], 1e-5, "unexpected vertex position")
+		}
+	}
+
+	// Missing normals should be computed from the triangle
+	normalIdx := face.NormalIndices()[0] * 3
+	assert.InDelta(t, 1.0, model.Normals()[normalIdx+2], 1e-5, "unexpected normal")
+
+	assert.Equal(t, len(model.Vertices()), len(model.Tangents()), "unexpected tangent count")
+	assert.Equal(t, len(model.Vertices()), len(model.Bitangents()), "unexpected bitangent count")
+	assert.Equal(t, len(model.Vertices())/3*2, len(model.UVs()), "unexpected UV count")
+
+	mat, ok := face.AttachedMaterial().(*gltf.BasicMaterial)
+	if !assert.True(t, ok, "unexpected material type") {
+		return
+	}
+	assert.Equal(t, "Red", mat.Name(), "unexpected material name")
+	assert.Equal(t, float32(1), mat.Properties.Diffuse[0], "unexpected diffuse color")
+	assert.Equal(t, float32(1), mat.Properties.Emissive[2], "unexpected emissive color")
+	assert.InDelta(t, 0.5, mat.Properties.Transparency, 1e-5, "unexpected transparency")
+	assert.NotNil(t, mat.DiffuseMap, "expected a default diffuse map")
+	assert.NotNil(t, mat.NormalMap, "expected a default normal map")
+	assert.NotNil(t, mat.SpecularMap, "expected a default specular map")
+}
+
+func TestGLTFLoading(t *testing.T) {
+	model := gltf.NewModel("TestModel", gltfWithDataUri())
+	assertGltfTriangle(t, model)
+}
+
+func TestGLBLoading(t *testing.T) {
+	model := gltf.NewModel("TestModel", glbWithBinChunk())
+	assertGltfTriangle(t, model)
+}
+
+func TestGLTFIndices(t *testing.T) {
+	model := gltf.NewModel("TestModel", gltfWithDataUri())
+	if !assert.NoError(t, model.Load(), "unexpected load error") {
+		return
+	}
+
+	// The model supplies the indices of its faces, so that the renderer
+	// can use its vertices as they are rather than deduplicating them
+	var indexed gfx.IndexedModel = model
+	var expected []uint32
+	for _, mesh := range model.Meshes() {
+		for _, face := range mesh.Faces() {
+			for _, index := range face.VertexIndices() {
+				expected = append(expected, uint32(index))
+			}
+		}
+	}
+	assert.Equal(t, 3, len(expected), "unexpected index count")
+	assert.Equal(t, expected, indexed.Indices(), "unexpected indices")
+}
+
+func TestGLTFNormalizedAccessor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	_ = binary.Write(buf, binary.LittleEndian, []uint16{0, 0, math.MaxUint16, 0, 0, math.MaxUint16})
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	doc := fmt.Sprintf(`{
+	  "asset": { "version": "2.0" },
+	  "nodes": [ { "mesh": 0 } ],
+	  "meshes": [ { "primitives": [ { "attributes": { "POSITION": 0, "TEXCOORD_0": 1 } } ] } ],
+	  "accessors": [
+	    { "bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3" },
+	    { "bufferView": 1, "componentType": 5123, "normalized": true, "count": 3, "type": "VEC2" }
+	  ],
+	  "bufferViews": [
+	    { "buffer": 0, "byteOffset": 0, "byteLength": 36 },
+	    { "buffer": 0, "byteOffset": 36, "byteLength": 12 }
+	  ],
+	  "buffers": [ { "uri": "%s", "byteLength": 48 } ]
+	}`, uri)
+
+	model := gltf.NewModel("TestModel", doc)
+	if !assert.NoError(t, model.Load(), "unexpected load error") {
+		return
+	}
+
+	// V is flipped to match the texture coordinate system used by OpenGL
+	expected := []float32{0, 1, 1, 1, 0, 0}
+	for i, uv := range model.UVs() {
+		assert.InDelta(t, expected[i], uv, 1e-5, "unexpected texture coordinate")
+	}
+}
+
+func TestGLTFParseError(t *testing.T) {
+	doc := fmt.Sprintf(gltfDocument, "")
+	model := gltf.NewModel("BadModel", doc)
+	err := model.Load()
+
+	var parseErr *gltf.ParseError
+	if !assert.True(t, errors.As(err, &parseErr), "expected a *ParseError") {
+		return
+	}
+	assert.Equal(t, "glTF", parseErr.Format, "unexpected format")
+	assert.Equal(t, "buffers[0]", parseErr.Property, "unexpected property")
+	assert.Equal(t, err, model.Err(), "expected Err() to return the load error")
+	assert.False(t, model.Init(), "expected Init() to fail")
+
+	model = gltf.NewModel("BadVersion", `{ "asset": { "version": "1.0" } }`)
+	assert.Error(t, model.Load(), "expected an unsupported version error")
+
+	model = gltf.NewModel("BadGLB", []byte{0x67, 0x6C, 0x54, 0x46, 1, 0, 0, 0, 12, 0, 0, 0})
+	err = model.Load()
+	if assert.True(t, errors.As(err, &parseErr), "expected a *ParseError") {
+		assert.Equal(t, "GLB", parseErr.Format, "unexpected format")
+	}
+}
+
+func TestGLTFAccessorBounds(t *testing.T) {
+	assertAccessorError := func(doc, property string) {
+		var parseErr *gltf.ParseError
+		if err := gltf.NewModel("BadAccessor", doc).Load(); assert.True(t, errors.As(err, &parseErr), "expected a *ParseError") {
+			assert.Equal(t, property, parseErr.Property, "unexpected property")
+		}
+	}
+
+	// The count must be validated against the buffer view before allocating
+	doc := strings.Replace(gltfWithDataUri(), `"count": 3, "type": "VEC3"`, `"count": 1000000000000, "type": "VEC3"`, 1)
+	assertAccessorError(doc, "accessors[0]")
+
+	doc = strings.Replace(gltfWithDataUri(), `"bufferView": 0, `, ``, 1)
+	doc = strings.Replace(doc, `"count": 3, "type": "VEC3"`, `"count": 1000000000000, "type": "VEC3"`, 1)
+	assertAccessorError(doc, "accessors[0]")
+
+	sparse := `"sparse": { "count": %d, "indices": { "bufferView": 1, "byteOffset": %d, "componentType": 5123 }, ` +
+		`"values": { "bufferView": 0, "byteOffset": %d } }, "type": "VEC3"`
+	for _, args := range [][3]int{{-1, 0, 0}, {1, -2, 0}, {1, 0, -12}} {
+		doc = strings.Replace(gltfWithDataUri(), `"type": "VEC3"`, fmt.Sprintf(sparse, args[0], args[1], args[2]), 1)
+		assertAccessorError(doc, "accessors[0].sparse")
+	}
+
+	doc = strings.Replace(gltfWithDataUri(), `"type": "VEC3"`, fmt.Sprintf(sparse, 1, 0, 0), 1)
+	assert.NoError(t, gltf.NewModel("SparseAccessor", doc).Load(), "unexpected load error")
+}
+
+const gltfSkinDocument = `{
+  "asset": { "version": "2.0" },
+  "scenes": [ { "nodes": [ 0, 1 ] } ],
+  "nodes": [
+    { "name": "Body", "translation": [ 100, 0, 0 ], "mesh": 0, "skin": 0 },
+    { "name": "Hip", "translation": [ 0, 1, 0 ], "children": [ 2 ] },
+    { "name": "Knee", "translation": [ 0, 1, 0 ], "children": [ 3 ] },
+    { "name": "Prop", "translation": [ 5, 0, 0 ], "mesh": 1 }
+  ],
+  "meshes": [
+    { "primitives": [ { "attributes": { "POSITION": 0, "JOINTS_0": 1, "WEIGHTS_0": 2 } } ] },
+    { "primitives": [ { "attributes": { "POSITION": 0 } } ] }
+  ],
+  "skins": [ { "joints": [ 2, 1 ], "inverseBindMatrices": 3 } ],
+  "animations": [
+    {
+      "name": "Wave",
+      "channels": [
+        { "sampler": 0, "target": { "node": 2, "path": "rotation" } },
+        { "sampler": 1, "target": { "node": 1, "path": "translation" } }
+      ],
+      "samplers": [
+        { "input": 4, "output": 5 },
+        { "input": 4, "output": 6, "interpolation": "STEP" }
+      ]
+    }
+  ],
+  "accessors": [
+    { "bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3" },
+    { "bufferView": 1, "componentType": 5121, "count": 3, "type": "VEC4" },
+    { "bufferView": 2, "componentType": 5126, "count": 3, "type": "VEC4" },
+    { "bufferView": 3, "componentType": 5126, "count": 2, "type": "MAT4" },
+    { "bufferView": 4, "componentType": 5126, "count": 2, "type": "SCALAR" },
+    { "bufferView": 5, "componentType": 5126, "count": 2, "type": "VEC4" },
+    { "bufferView": 6, "componentType": 5126, "count": 2, "type": "VEC3" }
+  ],
+  "bufferViews": [
+    { "buffer": 0, "byteOffset": 0, "byteLength": 36 },
+    { "buffer": 0, "byteOffset": 36, "byteLength": 12 },
+    { "buffer": 0, "byteOffset": 48, "byteLength": 48 },
+    { "buffer": 0, "byteOffset": 96, "byteLength": 128 },
+    { "buffer": 0, "byteOffset": 224, "byteLength": 8 },
+    { "buffer": 0, "byteOffset": 232, "byteLength": 32 },
+    { "buffer": 0, "byteOffset": 264, "byteLength": 24 }
+  ],
+  "buffers": [ { "uri": "%s", "byteLength": 288 } ]
+}`
+
+func gltfSkinWithDataUri() string {
+	buf := &bytes.Buffer{}
+	_ = binary.Write(buf, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	_ = binary.Write(buf, binary.LittleEndian, []uint8{0, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0})
+	_ = binary.Write(buf, binary.LittleEndian, []float32{0.5, 0.5, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0})
+	kneeIbm := mgl32.Translate3D(0, -2, 0)
+	hipIbm := mgl32.Translate3D(0, -1, 0)
+	_ = binary.Write(buf, binary.LittleEndian, kneeIbm[:])
+	_ = binary.Write(buf, binary.LittleEndian, hipIbm[:])
+	_ = binary.Write(buf, binary.LittleEndian, []float32{0, 2})
+	_ = binary.Write(buf, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, math.Sqrt2 / 2, math.Sqrt2 / 2})
+	_ = binary.Write(buf, binary.LittleEndian, []float32{0, 1, 0, 0, 3, 0})
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	return fmt.Sprintf(gltfSkinDocument, uri)
+}
+
+func TestGLTFSkin(t *testing.T) {
+	model := gltf.NewModel("SkinnedModel", gltfSkinWithDataUri())
+	if !assert.NoError(t, model.Load(), "unexpected load error") {
+		return
+	}
+
+	var skinned gfx.SkinnedModel = model
+	joints := skinned.Joints()
+	if !assert.Equal(t, 2, len(joints), "unexpected joint count") {
+		return
+	}
+	assert.Equal(t, "Hip", joints[0].Name, "unexpected joint name")
+	assert.Equal(t, -1, joints[0].Parent, "unexpected joint parent")
+	assert.Equal(t, "Knee", joints[1].Name, "unexpected joint name")
+	assert.Equal(t, 0, joints[1].Parent, "unexpected joint parent")
+	assert.InDelta(t, 1.0, joints[1].Translation[1], 1e-5, "unexpected joint translation")
+	assert.InDelta(t, -2.0, joints[1].InverseBindMatrix[13], 1e-5, "unexpected inverse bind matrix")
+
+	// The skinned mesh is left in its bind pose, ignoring its node's transform,
+	// with the joints of the skin remapped to those of the model
+	assert.Equal(t, 2*3*3, len(model.Vertices()), "unexpected vertex count")
+	assert.Equal(t, len(model.Vertices())/3*4, len(skinned.JointIndices()), "unexpected joint index count")
+	assert.Equal(t, len(model.Vertices())/3*4, len(skinned.JointWeights()), "unexpected joint weight count")
+	assert.InDelta(t, 1.0, model.Vertices()[3], 1e-5, "unexpected skinned vertex position")
+	assert.Equal(t, []float32{1, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0}, skinned.JointIndices()[:12], "unexpected joint indices")
+	assert.Equal(t, []float32{0.5, 0.5, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0}, skinned.JointWeights()[:12], "unexpected joint weights")
+
+	// The mesh attached to the knee is bound rigidly to it
+	assert.InDelta(t, 5.0, model.Vertices()[9], 1e-5, "unexpected rigid vertex position")
+	assert.InDelta(t, 2.0, model.Vertices()[10], 1e-5, "unexpected rigid vertex position")
+	assert.Equal(t, []float32{1, 0, 0, 0}, skinned.JointIndices()[12:16], "unexpected rigid joint indices")
+	assert.Equal(t, []float32{1, 0, 0, 0}, skinned.JointWeights()[12:16], "unexpected rigid joint weights")
+
+	clips := skinned.AnimationClips()
+	if !assert.Equal(t, 1, len(clips), "unexpected clip count") {
+		return
+	}
+	assert.Equal(t, "Wave", clips[0].Name, "unexpected clip name")
+	assert.Equal(t, 2*time.Second, clips[0].Duration(), "unexpected clip duration")
+	if assert.Equal(t, 2, len(clips[0].Channels), "unexpected channel count") {
+		assert.Equal(t, 1, clips[0].Channels[0].Joint, "unexpected channel joint")
+		assert.Equal(t, 2, len(clips[0].Channels[0].Rotations), "unexpected rotation keyframe count")
+		assert.Equal(t, 0, clips[0].Channels[1].Joint, "unexpected channel joint")
+		if assert.Equal(t, 2, len(clips[0].Channels[1].Translations), "unexpected translation keyframe count") {
+			assert.NotNil(t, clips[0].Channels[1].Translations[1].Easing, "expected step easing")
+		}
+	}
+}
+
+func TestGLTFSkinPlayback(t *testing.T) {
+	model := gltf.NewModel("SkinnedModel", gltfSkinWithDataUri())
+	if !assert.NoError(t, model.Load(), "unexpected load error") {
+		return
+	}
+
+	shape := gfx.NewShape3D()
+	shape.SetModel(model)
+	assert.Equal(t, []string{"Wave"}, shape.ClipNames(), "unexpected clip names")
+
+	transform, ok := shape.JointTransform("Knee")
+	if assert.True(t, ok, "expected the joint to be found") {
+		assert.InDelta(t, 2.0, transform[13], 1e-5, "unexpected rest pose")
+	}
+
+	shape.PlayClip("Wave").SeekClip(time.Second)
+	assert.Equal(t, "Wave", shape.ClipName(), "unexpected clip name")
+	assert.True(t, shape.ClipPlaying(), "expected the clip to be playing")
+
+	// The knee has rotated halfway, while the hip has yet to step
+	transform, _ = shape.JointTransform("Knee")
+	assert.InDelta(t, 2.0, transform[13], 1e-5, "unexpected knee translation")
+	assert.InDelta(t, math.Sqrt2/2, transform[1], 1e-3, "unexpected knee rotation")
+
+	shape.SetClipLooping(false).SeekClip(2 * time.Second)
+	transform, _ = shape.JointTransform("Knee")
+	assert.InDelta(t, 4.0, transform[13], 1e-5, "unexpected knee translation")
+	assert.InDelta(t, 1.0, transform[1], 1e-3, "unexpected knee rotation")
+
+	_, ok = shape.JointTransform("Elbow")
+	assert.False(t, ok, "expected an unknown joint to not be found")
+}
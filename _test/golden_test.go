@@ -0,0 +1,286 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"github.com/tonybillings/gfx/gfxtest"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newSolidImage(width, height int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// failureRecorder Captures failures instead of reporting them,
+// for testing the behavior of failed matches.
+type failureRecorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *failureRecorder) Helper() {}
+
+func (r *failureRecorder) Errorf(_ string, _ ...any) {
+	r.failed = true
+}
+
+func (r *failureRecorder) Logf(_ string, _ ...any) {}
+
+// assertGoldenStepped Steps the engine until the window has
+// rendered the frame requested by gfxtest.AssertGolden().
+func assertGoldenStepped(t *testing.T, win *gfx.Window, name string, opts gfxtest.Options) bool {
+	resultChan := make(chan bool)
+	go func() {
+		resultChan <- gfxtest.AssertGolden(t, win, name, opts)
+	}()
+
+	for {
+		select {
+		case ok := <-resultChan:
+			return ok
+		default:
+			_test.StepNFrames(1)
+		}
+	}
+}
+
+// assertGoldenScene Renders the objects returned by newObjects to a
+// headless window and compares the frame against the golden image with
+// the given name.  The golden images are not checked in, so unless one
+// exists in the default golden directory (or is being generated there, see
+// gfxtest.Updating()), the test writes its own reference into a temporary
+// directory and compares the next frame against it, as TestGoldenWindow
+// does.
+func assertGoldenScene(t *testing.T, name string, newObjects func(win *gfx.Window) []any) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	opts := gfxtest.DefaultOptions()
+	_, err := os.Stat(filepath.Join(opts.GoldenDir, name+".png"))
+	selfReference := err != nil && !gfxtest.Updating()
+	if selfReference {
+		opts.GoldenDir = t.TempDir()
+	}
+
+	go func() {
+		win := gfx.NewWindow(&gfx.WindowHints{Headless: true}).
+			SetTitle(_test.WindowTitle).
+			SetWidth(300).
+			SetHeight(200).
+			SetClearColor(_test.BackgroundColor)
+
+		win.AddObjects(newObjects(win)...)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+
+		_test.StepNFrames(5)
+
+		if selfReference {
+			opts.Update = true
+			assert.True(t, assertGoldenStepped(t, win, name, opts), "expected golden image to be written")
+			opts.Update = false
+			_test.StepNFrames(1)
+		}
+
+		assertGoldenStepped(t, win, name, opts)
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
+
+// newIdleMouseSurface Returns a surface with the mouse outside the
+// window, so widgets are rendered in their default state.
+func newIdleMouseSurface() gfx.MouseSurface {
+	surface := gfx.NewWindow()
+	surface.OverrideMouseState(&gfx.MouseState{X: -2, Y: -2})
+	return surface
+}
+
+func TestGoldenCompare(t *testing.T) {
+	opts := gfxtest.DefaultOptions()
+	opts.ChannelTolerance = 2
+	opts.MaxDiffRatio = 0.01
+
+	expected := newSolidImage(100, 100, color.RGBA{R: 100, G: 100, B: 100, A: 255})
+
+	actual := newSolidImage(100, 100, color.RGBA{R: 102, G: 99, B: 100, A: 255})
+	result := gfxtest.Compare(actual, expected, opts)
+	assert.True(t, result.Passed, "expected comparison within tolerance to pass: %s", result)
+	assert.Equal(t, 0, result.DiffPixels, "unexpected differing pixel count")
+	assert.Equal(t, uint8(2), result.MaxDiff, "unexpected max channel difference")
+
+	actual.SetRGBA(10, 10, gfx.Red)
+	result = gfxtest.Compare(actual, expected, opts)
+	assert.True(t, result.Passed, "expected comparison below max ratio to pass: %s", result)
+	assert.Equal(t, 1, result.DiffPixels, "unexpected differing pixel count")
+
+	for x := 0; x < 100; x++ {
+		actual.SetRGBA(x, 20, gfx.Red)
+	}
+	result = gfxtest.Compare(actual, expected, opts)
+	assert.False(t, result.Passed, "expected comparison above max ratio to fail: %s", result)
+	assert.Equal(t, 101, result.DiffPixels, "unexpected differing pixel count")
+	assert.Equal(t, gfx.Red, result.Diff.RGBAAt(50, 20), "expected differing pixel to be highlighted")
+
+	result = gfxtest.Compare(newSolidImage(50, 100, gfx.Red), expected, opts)
+	assert.False(t, result.Passed, "expected comparison of different sizes to fail")
+	assert.Nil(t, result.Diff, "expected no diff image for different sizes")
+}
+
+func TestGoldenUpdateAndMatch(t *testing.T) {
+	opts := gfxtest.DefaultOptions()
+	opts.GoldenDir = t.TempDir()
+
+	img := newSolidImage(64, 64, gfx.Blue)
+
+	opts.Update = true
+	assert.True(t, gfxtest.MatchGolden(t, img, "solid_blue", opts), "expected golden image to be written")
+	_, err := os.Stat(filepath.Join(opts.GoldenDir, "solid_blue.png"))
+	assert.NoError(t, err, "expected golden image file to exist")
+
+	opts.Update = false
+	assert.True(t, gfxtest.MatchGolden(t, img, "solid_blue", opts), "expected image to match golden image")
+
+	recorder := &failureRecorder{TB: t}
+	assert.False(t, gfxtest.MatchGolden(recorder, newSolidImage(64, 64, gfx.Red), "solid_blue", opts), "expected mismatch")
+	assert.True(t, recorder.failed, "expected mismatch to be reported")
+	_, err = os.Stat(filepath.Join(opts.GoldenDir, "solid_blue_diff.png"))
+	assert.NoError(t, err, "expected diff image file to exist")
+	_, err = os.Stat(filepath.Join(opts.GoldenDir, "solid_blue_actual.png"))
+	assert.NoError(t, err, "expected actual image file to exist")
+}
+
+func TestGoldenWindow(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	opts := gfxtest.DefaultOptions()
+	opts.GoldenDir = t.TempDir()
+
+	go func() {
+		win := gfx.NewWindow(&gfx.WindowHints{Headless: true}).
+			SetTitle(_test.WindowTitle).
+			SetWidth(300).
+			SetHeight(200)
+
+		quad := gfx.NewQuad()
+		quad.SetScale(mgl32.Vec3{.5, .5})
+		quad.SetColor(gfx.Orange)
+
+		circ := gfx.NewCircle(.1)
+		circ.SetScale(mgl32.Vec3{.25, .25})
+		circ.SetColor(gfx.Purple)
+
+		win.AddObjects(quad, circ)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+
+		_test.StepNFrames(2)
+
+		// Render once to create the golden image, then again to compare
+		opts.Update = true
+		assert.True(t, assertGoldenStepped(t, win, "quad_and_circle", opts), "expected golden image to be written")
+
+		opts.Update = false
+		assert.True(t, assertGoldenStepped(t, win, "quad_and_circle", opts), "expected frame to match golden image")
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
+
+func TestGoldenLabel(t *testing.T) {
+	assertGoldenScene(t, "label", func(_ *gfx.Window) []any {
+		lbl := gfx.NewLabel()
+		lbl.SetFontSize(.5)
+		lbl.SetColor(gfx.Magenta)
+		lbl.SetText("gfx")
+		return []any{lbl}
+	})
+}
+
+func TestGoldenButton(t *testing.T) {
+	assertGoldenScene(t, "button", func(_ *gfx.Window) []any {
+		btn := gfx.NewButton()
+		btn.SetFillColor(gfx.Blue)
+		btn.SetText("OK").SetTextColor(gfx.White)
+		btn.SetScale(mgl32.Vec3{.5, .25})
+		btn.SetMouseSurface(newIdleMouseSurface())
+		return []any{btn}
+	})
+}
+
+func TestGoldenSlider(t *testing.T) {
+	assertGoldenScene(t, "slider", func(_ *gfx.Window) []any {
+		slider := gfx.NewSlider(gfx.Horizontal)
+		slider.SetFillColor(gfx.Gray)
+		slider.Button().SetFillColor(gfx.Magenta)
+		slider.SetScale(mgl32.Vec3{.6, .1})
+		slider.SetValue(.25)
+		slider.SetMouseSurface(newIdleMouseSurface())
+		return []any{slider}
+	})
+}
+
+func TestGoldenShape2D(t *testing.T) {
+	assertGoldenScene(t, "shape2d", func(_ *gfx.Window) []any {
+		triangle := gfx.NewTriangle(.2)
+		triangle.SetScale(mgl32.Vec3{.4, .4})
+		triangle.SetPositionX(-.5)
+		triangle.SetColor(gfx.Green)
+
+		circle := gfx.NewCircle(1)
+		circle.SetScale(mgl32.Vec3{.3, .3})
+		circle.SetColor(gfx.Orange)
+
+		quad := gfx.NewQuad()
+		quad.SetScale(mgl32.Vec3{.3, .3})
+		quad.SetPositionX(.5)
+		quad.SetRotationZ(.5)
+		quad.SetColor(gfx.Purple)
+
+		return []any{triangle, circle, quad}
+	})
+}
+
+func TestGoldenShape3D(t *testing.T) {
+	assertGoldenScene(t, "shape3d", func(win *gfx.Window) []any {
+		shader := gfx.NewBasicShader("test_shader", _test.ColorVertShader, _test.ColorFragShader)
+		win.Assets().Add(shader)
+
+		camera := gfx.NewCamera()
+		camera.SetProjection(45, win.AspectRatio(), .1, 100)
+		camera.SetLookAt(mgl32.Vec3{1, 1, 3}, mgl32.Vec3{.5, .5, 0}, mgl32.Vec3{0, 1, 0})
+
+		model := _test.NewColoredQuad(gfx.Red, gfx.Green, gfx.Blue, gfx.White)
+		model.Meshes()[0].Faces()[0].AttachedMaterial().AttachShader(shader)
+
+		quad := gfx.NewShape3D()
+		quad.SetModel(model)
+		quad.SetCamera(camera)
+
+		return []any{camera, quad}
+	})
+}
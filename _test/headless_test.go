@@ -0,0 +1,66 @@
+package _test
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+const (
+	headlessWinWidth  = 800
+	headlessWinHeight = 600
+)
+
+func TestHeadlessWindow(t *testing.T) {
+	_test.Begin()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow(&gfx.WindowHints{Headless: true}).
+			SetTitle(_test.WindowTitle).
+			SetWidth(headlessWinWidth).
+			SetHeight(headlessWinHeight).
+			SetClearColor(_test.BackgroundColor)
+
+		quad := gfx.NewQuad()
+		quad.SetScale(mgl32.Vec3{.5, .5})
+		quad.SetColor(gfx.Red)
+
+		validator := _test.NewSceneValidator(t, win)
+		validator.AddPixelSampler(func() (x, y float32) { return 0, 0 }, gfx.Red, "center of quad")
+		validator.AddPixelSampler(func() (x, y float32) { return -.9, -.9 }, _test.BackgroundColor, "bottom-left corner")
+
+		win.AddObjects(quad, validator)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+
+		assert.True(t, win.Headless(), "expected window to be headless")
+
+		_test.SleepAFewFrames()
+		validator.Validate()
+
+		img, err := png.Decode(bytes.NewReader(win.ToPNG()))
+		if !assert.NoError(t, err, "expected ToPNG to return a valid PNG") {
+			cancelFunc()
+			return
+		}
+
+		assert.Equal(t, headlessWinWidth, img.Bounds().Dx(), "unexpected image width")
+		assert.Equal(t, headlessWinHeight, img.Bounds().Dy(), "unexpected image height")
+		assert.Equal(t, color.RGBAModel.Convert(gfx.Red), color.RGBAModel.Convert(img.At(headlessWinWidth/2, headlessWinHeight/2)), "unexpected color at center of image")
+		assert.Equal(t, color.RGBAModel.Convert(_test.BackgroundColor), color.RGBAModel.Convert(img.At(5, 5)), "unexpected color at corner of image")
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
@@ -0,0 +1,66 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"math"
+	"testing"
+)
+
+func TestIndexedModelRendering(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		shader := gfx.NewBasicShader("test_shader", _test.ColorVertShader, _test.ColorFragShader)
+		win.Assets().Add(shader)
+
+		camera := gfx.NewCamera()
+		camera.SetProjection(45, win.AspectRatio(), .1, 100)
+		camera.SetLookAt(mgl32.Vec3{.5, .5, 5}, mgl32.Vec3{.5, .5, 0}, mgl32.Vec3{0, 1, 0})
+		win.AddObject(camera)
+
+		// The first quad is drawn with its own indices, while the indices of
+		// the second are not valid for its colors, so it must be drawn by
+		// deduplicating its vertices instead
+		indexed := _test.NewIndexedQuad(gfx.Red, true)
+		fallback := _test.NewIndexedQuad(gfx.Green, false)
+
+		for i, model := range []*_test.IndexedModel{indexed, fallback} {
+			model.Meshes()[0].Faces()[0].AttachedMaterial().AttachShader(shader)
+
+			quad := gfx.NewShape3D()
+			quad.SetModel(model)
+			quad.SetCamera(camera)
+			quad.SetPosition(mgl32.Vec3{float32(i)*1.5 - .75, 0, 0})
+			win.AddObject(quad)
+		}
+
+		// Half the height of the view at the quads, in world units
+		halfHeight := 5 * float32(math.Tan(float64(mgl32.DegToRad(22.5))))
+
+		validator := _test.NewSceneValidator(t, win)
+		validator.AddPixelSampler(func() (x, y float32) { return -.75 / (halfHeight * win.AspectRatio()), 0 }, gfx.Red, "indexed quad")
+		validator.AddPixelSampler(func() (x, y float32) { return .75 / (halfHeight * win.AspectRatio()), 0 }, gfx.Green, "deduplicated quad")
+		validator.AddPixelSampler(func() (x, y float32) { return 0, 0 }, _test.BackgroundColor, "between the quads")
+		win.AddObject(validator)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
@@ -0,0 +1,121 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"github.com/tonybillings/gfx/primitive"
+	"sync"
+	"testing"
+)
+
+func TestInstance3D(t *testing.T) {
+	shape := gfx.NewInstancedShape3D()
+	shape.SetPosition(mgl32.Vec3{10, 0, 0})
+
+	instance := shape.AddInstance()
+	assert.Equal(t, gfx.White, instance.Tint(), "expected instances to be untinted")
+	assert.True(t, instance.Visible(), "expected instances to be visible")
+
+	// Instances are positioned relative to the shape
+	instance.SetPosition(mgl32.Vec3{0, 2, 0})
+	worldPos := instance.WorldMatrix().Mul4x1(mgl32.Vec4{0, 0, 0, 1}).Vec3()
+	assert.Equal(t, mgl32.Vec3{10, 2, 0}, worldPos, "unexpected instance world position")
+
+	// Instances can be added and updated from any goroutine
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			inst := shape.AddInstance()
+			inst.SetPosition(mgl32.Vec3{float32(i), 0, 0})
+			inst.SetTint(gfx.Red).SetVisibility(i%2 == 0)
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 11, shape.InstanceCount(), "unexpected instance count")
+
+	shape.RemoveInstance(instance)
+	assert.Equal(t, 10, shape.InstanceCount(), "unexpected instance count after removal")
+	assert.NotContains(t, shape.Instances(), instance, "expected the instance to be removed")
+
+	shape.ClearInstances()
+	assert.Equal(t, 0, shape.InstanceCount(), "expected no instances")
+}
+
+func TestInstancedShape3DRendering(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		camera := gfx.NewCamera()
+		camera.SetProjection(45, win.AspectRatio(), .1, 100)
+		camera.SetLookAt(mgl32.Vec3{0, 0, 20}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+		win.AddObject(camera)
+
+		sun := gfx.NewDirectionalLight()
+		sun.Direction = mgl32.Vec3{-1, -1, -1}
+		sun.CastShadows = true // render the instances into the shadow map, too
+		lighting := gfx.NewBasicLighting(sun)
+		win.AddService(gfx.NewShadowMapper(lighting).SetBounds(mgl32.Vec3{}, 10))
+
+		box := primitive.NewBox("box", 1, 1, 1, 1)
+		win.Assets().Add(box)
+
+		shape := gfx.NewInstancedShape3D()
+		shape.SetModel(box)
+		shape.SetCamera(camera)
+		shape.SetLighting(lighting)
+
+		var instances []*gfx.Instance3D
+		for x := -5; x <= 5; x++ {
+			for y := -5; y <= 5; y++ {
+				instance := shape.AddInstance()
+				instance.SetPosition(mgl32.Vec3{float32(x) * 1.5, float32(y) * 1.5, 0})
+				instance.SetScale(mgl32.Vec3{.5, .5, .5})
+				instance.SetTint(gfx.DefaultColors[(x+y+10)%len(gfx.DefaultColors)])
+				instances = append(instances, instance)
+			}
+		}
+
+		win.AddObject(shape)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+
+		// The center instance (0, 0) is the 61st
+		center := instances[60]
+		ray := gfx.Ray{Origin: mgl32.Vec3{0, 0, 20}, Direction: mgl32.Vec3{0, 0, -1}}
+		hit, ok := shape.Raycast(ray)
+		assert.True(t, ok, "expected the center instance to be hit")
+		assert.Equal(t, center, hit.Instance, "unexpected instance")
+		assert.InDelta(t, .25, hit.Position.Z(), 1e-4, "unexpected hit position")
+		assert.InDelta(t, 19.75, hit.Distance, 1e-4, "unexpected hit distance")
+
+		// Hidden instances are neither drawn nor hit
+		center.SetVisibility(false)
+		_test.StepNFrames(1)
+		_, ok = shape.Raycast(ray)
+		assert.False(t, ok, "expected the hidden instance to be missed")
+
+		for _, instance := range instances {
+			instance.SetRotation(mgl32.Vec3{0, .5, 0})
+		}
+		_test.StepNFrames(2)
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
@@ -0,0 +1,100 @@
+package _test
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"math"
+	"testing"
+)
+
+func TestBasicLighting(t *testing.T) {
+	sun := gfx.NewDirectionalLight()
+	sun.Direction = mgl32.Vec3{0, 0, -1}
+
+	bulb := gfx.NewPointLight()
+	bulb.Position = mgl32.Vec3{1, 2, 3}
+	bulb.Range = 10
+
+	flashlight := gfx.NewSpotLight()
+	flashlight.Color = mgl32.Vec3{1, 0, 0}
+	flashlight.InnerConeAngle = 0
+	flashlight.OuterConeAngle = 60
+
+	lighting := gfx.NewBasicLighting(sun, bulb).AddLight(flashlight)
+	lighting.UpdateShaderBinding()
+
+	props := lighting.Properties
+	assert.Equal(t, int32(3), props.LightCount, "unexpected light count")
+	assert.Equal(t, float32(gfx.DirectionalLightType), props.Lights[0].Color[3], "unexpected light type")
+	assert.Equal(t, mgl32.Vec4{0, 0, -1, 0}, props.Lights[0].Direction, "unexpected direction")
+	assert.Equal(t, float32(gfx.PointLightType), props.Lights[1].Color[3], "unexpected light type")
+	assert.Equal(t, mgl32.Vec4{1, 2, 3, 10}, props.Lights[1].Position, "unexpected position/range")
+	assert.Equal(t, mgl32.Vec4{1, 0, 0, float32(gfx.SpotLightType)}, props.Lights[2].Color, "unexpected color/type")
+	assert.InDelta(t, 1.0, props.Lights[2].Direction[3], 1e-3, "unexpected inner cone cosine")
+	assert.InDelta(t, math.Cos(math.Pi/3), props.Lights[2].Attenuation[3], 1e-5, "unexpected outer cone cosine")
+
+	// Disabled lights are not sent to the shader
+	bulb.SetEnabled(false)
+	lighting.UpdateShaderBinding()
+	assert.Equal(t, int32(2), props.LightCount, "unexpected light count")
+	assert.Equal(t, float32(gfx.SpotLightType), props.Lights[1].Color[3], "unexpected light type")
+
+	lighting.SetMaxLights(1)
+	lighting.UpdateShaderBinding()
+	assert.Equal(t, int32(1), props.LightCount, "unexpected light count")
+
+	lighting.SetMaxLights(gfx.MaxLightCount).RemoveLight(sun)
+	lighting.UpdateShaderBinding()
+	assert.Equal(t, int32(1), props.LightCount, "unexpected light count")
+	assert.Equal(t, 2, len(lighting.Lights()), "unexpected light count")
+
+	for i := 0; i < gfx.MaxLightCount+5; i++ {
+		lighting.AddLight(gfx.NewPointLight())
+	}
+	lighting.UpdateShaderBinding()
+	assert.Equal(t, int32(gfx.MaxLightCount), props.LightCount, "expected the light count to be capped")
+}
+
+func TestQuadDirectionalLighting(t *testing.T) {
+	lighting := gfx.NewQuadDirectionalLighting()
+	lighting.Lights[0].Color = mgl32.Vec3{1, 1, 1}
+	lighting.Lights[0].Direction = mgl32.Vec3{0, 0, -1}
+	lighting.Lights[1].Color = mgl32.Vec3{0, 1, 0}
+	lighting.LightCount = 2
+	lighting.UpdateShaderBinding()
+
+	props := lighting.BasicLighting
+	assert.Equal(t, int32(2), props.LightCount, "unexpected light count")
+	assert.Equal(t, mgl32.Vec4{1, 1, 1, float32(gfx.DirectionalLightType)}, props.Lights[0].Color, "unexpected color/type")
+	assert.Equal(t, mgl32.Vec4{0, 0, -1, 0}, props.Lights[0].Direction, "unexpected direction")
+	assert.Equal(t, mgl32.Vec4{0, 1, 0, float32(gfx.DirectionalLightType)}, props.Lights[1].Color, "unexpected color/type")
+}
+
+func TestShadowMapper(t *testing.T) {
+	sun := gfx.NewDirectionalLight()
+	sun.CastShadows = true
+
+	lighting := gfx.NewBasicLighting(sun)
+	lighting.UpdateShaderBinding()
+	assert.Equal(t, float32(-1), lighting.Properties.Lights[0].Shadow[0], "expected no shadow map layer before rendering")
+
+	mapper := gfx.NewShadowMapper(lighting, gfx.HighQuality)
+	assert.Equal(t, lighting, mapper.Lighting(), "unexpected lighting")
+	assert.Equal(t, gfx.HighQuality, mapper.Quality(), "unexpected quality")
+
+	mapper.SetBias(0.01).SetBounds(mgl32.Vec3{1, 2, 3}, 50)
+	center, radius := mapper.Bounds()
+	assert.Equal(t, float32(0.01), mapper.Bias(), "unexpected bias")
+	assert.Equal(t, mgl32.Vec3{1, 2, 3}, center, "unexpected bounds center")
+	assert.Equal(t, float32(50), radius, "unexpected bounds radius")
+
+	assert.Panics(t, func() { gfx.NewShadowMapper(nil) }, "expected a panic for nil lighting")
+
+	shape := gfx.NewShape3D()
+	assert.True(t, shape.CastShadows(), "expected shapes to cast shadows by default")
+	assert.True(t, shape.ReceiveShadows(), "expected shapes to receive shadows by default")
+	shape.SetCastShadows(false).SetReceiveShadows(false)
+	assert.False(t, shape.CastShadows(), "unexpected cast shadows flag")
+	assert.False(t, shape.ReceiveShadows(), "unexpected receive shadows flag")
+}
@@ -0,0 +1,204 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"testing"
+)
+
+func TestLine3D(t *testing.T) {
+	line := gfx.NewLine3D(mgl32.Vec3{1, 2, 3}, mgl32.Vec3{4, 5, 6})
+	assert.Equal(t, "Line3D", line.Name(), "unexpected name")
+	assert.Equal(t, mgl32.Vec3{1, 2, 3}, line.From(), "unexpected start point")
+	assert.Equal(t, mgl32.Vec3{4, 5, 6}, line.To(), "unexpected end point")
+	assert.Equal(t, float32(2), line.Thickness(), "unexpected default thickness")
+	assert.True(t, line.DepthTest(), "expected depth testing by default")
+
+	line.SetEndpoints(mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+	assert.Equal(t, mgl32.Vec3{0, 1, 0}, line.To(), "unexpected end point")
+
+	line.SetThickness(0).SetDepthTest(false)
+	assert.Greater(t, line.Thickness(), float32(0), "expected the thickness to be clamped")
+	assert.False(t, line.DepthTest(), "expected depth testing to be disabled")
+}
+
+func TestPolyline3D(t *testing.T) {
+	line := gfx.NewPolyline3D(mgl32.Vec3{0, 0, 0}, mgl32.Vec3{1, 0, 0})
+	assert.Equal(t, "Polyline3D", line.Name(), "unexpected name")
+	assert.Equal(t, 2, len(line.Points()), "unexpected point count")
+
+	line.AddPoint(mgl32.Vec3{1, 1, 0}, gfx.Red).AddPoint(mgl32.Vec3{0, 1, 0})
+	assert.Equal(t, 4, len(line.Points()), "unexpected point count")
+
+	line.SetMaxPoints(3)
+	if points := line.Points(); assert.Equal(t, 3, len(points), "expected the oldest point to be discarded") {
+		assert.Equal(t, mgl32.Vec3{1, 0, 0}, points[0], "unexpected first point")
+		assert.Equal(t, mgl32.Vec3{0, 1, 0}, points[2], "unexpected last point")
+	}
+
+	line.AddPoint(mgl32.Vec3{0, 2, 0})
+	if points := line.Points(); assert.Equal(t, 3, len(points), "expected the oldest point to be discarded") {
+		assert.Equal(t, mgl32.Vec3{1, 1, 0}, points[0], "unexpected first point")
+	}
+
+	line.SetClosed(true)
+	assert.True(t, line.Closed(), "expected the line to be closed")
+
+	line.ClearPoints()
+	assert.Equal(t, 0, len(line.Points()), "expected no points")
+
+	line.SetPoints([]mgl32.Vec3{{}, {1, 1, 1}}, gfx.Red, gfx.Blue)
+	assert.Equal(t, 2, len(line.Points()), "unexpected point count")
+}
+
+func TestGridFloorAndAxes(t *testing.T) {
+	grid := gfx.NewGridFloor(10, 20)
+	assert.Equal(t, "GridFloor", grid.Name(), "unexpected name")
+	assert.Equal(t, float32(10), grid.Size(), "unexpected size")
+	assert.Equal(t, 20, grid.Divisions(), "unexpected divisions")
+	assert.Equal(t, gfx.Gray, grid.Color(), "unexpected default color")
+	assert.Equal(t, float32(1), grid.Thickness(), "unexpected default thickness")
+
+	grid.SetSize(4).SetDivisions(0)
+	assert.Equal(t, float32(4), grid.Size(), "unexpected size")
+	assert.Equal(t, 1, grid.Divisions(), "expected the divisions to be clamped")
+
+	axes := gfx.NewAxes(2)
+	assert.Equal(t, "Axes", axes.Name(), "unexpected name")
+	assert.Equal(t, float32(2), axes.Length(), "unexpected length")
+	axes.SetLength(-1)
+	assert.Equal(t, float32(0), axes.Length(), "expected the length to be clamped")
+}
+
+func TestLine3DRendering(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		camera := gfx.NewCamera()
+		camera.SetProjection(45, win.AspectRatio(), .1, 100)
+		camera.SetLookAt(mgl32.Vec3{0, 0, 5}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+		win.AddObject(camera)
+
+		line := gfx.NewLine3D(mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{1, 0, 0})
+		line.SetColor(gfx.Red)
+		line.SetThickness(5).SetCamera(camera)
+
+		// Starts behind the camera, to ensure such segments are clipped
+		trajectory := gfx.NewPolyline3D(mgl32.Vec3{0, .5, 10}, mgl32.Vec3{0, .5, 0}, mgl32.Vec3{.5, .5, 0})
+		trajectory.SetThickness(5).SetCamera(camera)
+		trajectory.SetColor(gfx.Green)
+
+		// At the depth of the origin, the window spans 2*tan(22.5°)*5 (~4.14)
+		// units vertically, so the horizontal segment of the trajectory is
+		// at y ~.2414 and ends at x ~.1271, while its first segment runs
+		// from there to the top of the window
+		validator := _test.NewSceneValidator(t, win)
+		validator.AddPixelSampler(func() (x, y float32) { return 0, 0 }, gfx.Red, "center of the line")
+		validator.AddPixelSampler(func() (x, y float32) { return -.5, .5 }, _test.BackgroundColor, "above the line")
+		validator.AddPixelSampler(func() (x, y float32) { return .9, 0 }, _test.BackgroundColor, "beyond the end of the line")
+		validator.AddPixelSampler(func() (x, y float32) { return 0, .6 }, gfx.Green, "trajectory, clipped segment")
+		validator.AddPixelSampler(func() (x, y float32) { return .06, .2414 }, gfx.Green, "trajectory, last segment")
+		validator.AddPixelSampler(func() (x, y float32) { return .2, .2414 }, _test.BackgroundColor, "beyond the end of the trajectory")
+		validator.AddPixelSampler(func() (x, y float32) { return 0, -.6 }, _test.BackgroundColor, "behind the camera, mirrored")
+		validator.AddPixelSampler(func() (x, y float32) { return .1271, -.12 }, _test.BackgroundColor, "trajectory, added segment")
+
+		win.AddObjects(line, trajectory, validator)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		// Only the vertices of the last segment are updated
+		trajectory.AddPoint(mgl32.Vec3{.5, -.5, 0})
+		validator.Samplers[7].ExpectedColor = gfx.Green
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		line.SetColor(gfx.Blue)
+		validator.Samplers[0].ExpectedColor = gfx.Blue
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		line.SetVisibility(false)
+		validator.Samplers[0].ExpectedColor = _test.BackgroundColor
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
+
+func TestGridFloorAndAxesRendering(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		// Looking down at the XZ plane, with -Z at the top of the window
+		camera := gfx.NewCamera()
+		camera.SetProjection(45, win.AspectRatio(), .1, 100)
+		camera.SetLookAt(mgl32.Vec3{0, 10, 0}, mgl32.Vec3{}, mgl32.Vec3{0, 0, -1})
+		win.AddObject(camera)
+
+		grid := gfx.NewGridFloor(4, 4)
+		grid.SetCenterColor(gfx.Yellow).SetThickness(3).SetCamera(camera)
+		grid.SetColor(gfx.White)
+
+		// Above the grid, such that the axes are drawn over its center lines
+		axes := gfx.NewAxes(1)
+		axes.SetThickness(3).SetCamera(camera)
+		axes.SetPosition(mgl32.Vec3{0, 1, 0})
+
+		// At the depth of the grid, the window spans 2*tan(22.5°)*10 (~8.28)
+		// units vertically, so the line at x=1 is at ~.1270 horizontally
+		// and the line at z=1 is at ~-.2414 vertically
+		validator := _test.NewSceneValidator(t, win)
+		validator.AddPixelSampler(func() (x, y float32) { return .1270, .12 }, gfx.White, "grid, line at x=1")
+		validator.AddPixelSampler(func() (x, y float32) { return .06, -.2414 }, gfx.White, "grid, line at z=1")
+		validator.AddPixelSampler(func() (x, y float32) { return .06, .12 }, _test.BackgroundColor, "grid, inside a cell")
+		validator.AddPixelSampler(func() (x, y float32) { return 0, .12 }, gfx.Yellow, "grid, center line along Z")
+		validator.AddPixelSampler(func() (x, y float32) { return -.06, 0 }, gfx.Yellow, "grid, center line along X")
+		validator.AddPixelSampler(func() (x, y float32) { return .06, 0 }, gfx.Red, "X axis")
+		validator.AddPixelSampler(func() (x, y float32) { return 0, -.12 }, gfx.Blue, "Z axis")
+		validator.AddPixelSampler(func() (x, y float32) { return .2, 0 }, gfx.Yellow, "beyond the X axis")
+
+		win.AddObjects(grid, axes, validator)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		grid.SetDivisions(2)
+		axes.SetLength(2)
+		validator.Samplers[0].ExpectedColor = _test.BackgroundColor
+		validator.Samplers[1].ExpectedColor = _test.BackgroundColor
+		validator.Samplers[7].ExpectedColor = gfx.Red
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
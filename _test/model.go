@@ -45,6 +45,16 @@ type Material struct {
 	DiffuseMap gfx.Texture
 }
 
+// IndexedModel A Model that supplies its own indices (see gfx.IndexedModel).
+type IndexedModel struct {
+	*Model
+	indices []uint32
+}
+
+func (m *IndexedModel) Indices() []uint32 {
+	return m.indices
+}
+
 /******************************************************************************
  gfx.Model/gfx.Mesh/gfx.Face Implementation
 ******************************************************************************/
@@ -198,3 +208,24 @@ func NewTexturedQuad() *Model {
 
 	return model
 }
+
+// NewIndexedQuad Returns a quad of the given color that supplies its own
+// indices.  Unless perVertexColors is true, the faces take their color from
+// an extra entry of the color array (the others being magenta), so that
+// the indices are not valid for its colors and the renderer must
+// deduplicate the vertices itself.
+func NewIndexedQuad(rgba color.RGBA, perVertexColors bool) *IndexedModel {
+	model := NewColoredQuad(rgba, rgba, rgba, rgba)
+	if !perVertexColors {
+		model = NewColoredQuad()
+		model.colors = append(model.colors, float32(rgba.R)/255.0, float32(rgba.G)/255.0, float32(rgba.B)/255.0)
+		for _, face := range model.meshes[0].faces {
+			face.colors = []int{4, 4, 4}
+		}
+	}
+
+	return &IndexedModel{
+		Model:   model,
+		indices: []uint32{0, 1, 2, 2, 3, 0},
+	}
+}
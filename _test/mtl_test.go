@@ -1,7 +1,9 @@
 package _test
 
 import (
+	"errors"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
 	"github.com/tonybillings/gfx/obj"
 	"testing"
 )
@@ -101,3 +103,93 @@ func TestMTLLoading(t *testing.T) {
 		t.Errorf("unexpected transparency value: expected %v, got %v", Transparency, mat2.Properties.Transparency)
 	}
 }
+
+var badMtlFile = `
+newmtl GoodMat
+	Kd 1 1 1
+newmtl BadMat
+	Kd 1 x 1
+	Ns 10
+`
+
+func TestMTLParseError(t *testing.T) {
+	mtl := obj.NewMaterialLibrary("BadLibrary", badMtlFile)
+
+	err := mtl.Load()
+
+	var parseErr *obj.ParseError
+	if assert.True(t, errors.As(err, &parseErr), "expected a ParseError") {
+		assert.Equal(t, "MTL", parseErr.Format, "unexpected format")
+		assert.Equal(t, 5, parseErr.Line, "unexpected line")
+		assert.Equal(t, "Kd", parseErr.Directive, "unexpected directive")
+	}
+	assert.Equal(t, err, mtl.Err(), "expected Err to return the load error")
+}
+
+func TestMTLLenientMode(t *testing.T) {
+	mtl := obj.NewMaterialLibrary("BadLibrary", badMtlFile).SetLenient(true)
+
+	assert.NoError(t, mtl.Load(), "expected no error in lenient mode")
+	assert.Equal(t, 1, len(mtl.Warnings()), "unexpected warning count")
+	assert.Equal(t, 2, len(mtl.GetNames()), "unexpected material count")
+
+	if mat := mtl.Get("BadMat"); assert.NotNil(t, mat, "expected to find BadMat") {
+		assert.Equal(t, float32(10), mat.Properties.Shininess, "expected lines after the bad line to be parsed")
+	}
+}
+
+var pbrMtlFile = `
+newmtl Brass
+	Kd 0.8 0.6 0.2
+	Ke 0.1 0.1 0.1
+	Tr 0.25
+	Pr 0.3
+	Pm 1.0
+	map_Kd brass_albedo.png
+	map_Pr brass_roughness.png
+	map_Pm brass_metallic.png
+	map_Ke brass_emissive.png
+
+newmtl Plastic
+	Kd 1 0 0
+`
+
+func TestMTLPbrMaterials(t *testing.T) {
+	mtl := obj.NewMaterialLibrary("PbrLibrary", pbrMtlFile)
+	if !assert.NoError(t, mtl.Load(), "unexpected load error") {
+		return
+	}
+
+	assert.Equal(t, 2, len(mtl.GetNames()), "unexpected material count")
+	assert.NotNil(t, mtl.Get("Brass"), "expected the basic version to remain available")
+	assert.Nil(t, mtl.GetPbr("Plastic"), "expected no PBR version without PBR directives")
+
+	brass := mtl.GetPbr("Brass")
+	if !assert.NotNil(t, brass, "expected a PBR version of Brass") {
+		return
+	}
+
+	assert.Equal(t, "Brass", brass.Name(), "unexpected name")
+	assert.Equal(t, mgl32.Vec4{0.8, 0.6, 0.2, 1.0}, brass.Properties.BaseColor, "unexpected base color")
+	assert.Equal(t, mgl32.Vec4{0.1, 0.1, 0.1}, brass.Properties.Emissive, "unexpected emissive color")
+	assert.Equal(t, float32(0.25), brass.Properties.Transparency, "unexpected transparency")
+	assert.Equal(t, float32(0.3), brass.Properties.Roughness, "unexpected roughness")
+	assert.Equal(t, float32(1.0), brass.Properties.Metallic, "unexpected metallic")
+	assert.Equal(t, float32(1.0), brass.Properties.Occlusion, "unexpected occlusion")
+
+	assert.Equal(t, "brass_albedo.png", brass.BaseColorMap.Name(), "unexpected base color map")
+	assert.Same(t, mtl.Get("Brass").DiffuseMap, brass.BaseColorMap, "expected the diffuse map to be shared")
+	assert.Equal(t, "brass_roughness.png", brass.RoughnessMap.Name(), "unexpected roughness map")
+	assert.Equal(t, "brass_metallic.png", brass.MetallicMap.Name(), "unexpected metallic map")
+	assert.Equal(t, "brass_emissive.png", brass.EmissiveMap.Name(), "unexpected emissive map")
+	assert.NotNil(t, brass.NormalMap, "expected a default normal map")
+	assert.NotNil(t, brass.OcclusionMap, "expected a default occlusion map")
+
+	assert.True(t, obj.NewModel("Model", "").PbrEnabled(), "expected PBR materials to be enabled by default")
+
+	// Out-of-range values (e.g., roughness exported as a percentage) are clamped
+	mtl = obj.NewMaterialLibrary("TestLibrary", mtlFile)
+	if assert.NoError(t, mtl.Load(), "unexpected load error") {
+		assert.Equal(t, float32(1.0), mtl.GetPbr("FubarMat001").Properties.Roughness, "expected roughness to be clamped")
+	}
+}
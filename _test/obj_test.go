@@ -1,6 +1,7 @@
 package _test
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/tonybillings/gfx/obj"
 	"testing"
@@ -112,3 +113,55 @@ func TestOBJLoading(t *testing.T) {
 	assert.Equal(t, 444, vt2, "unexpected uv data for face1, uv2")
 	assert.Equal(t, -111, vt3, "unexpected uv data for face1, uv3")
 }
+
+var badObjFile = `
+v 1 2 3
+v 4 5 6
+v 7 8 9
+v 1 2 oops
+g BadMesh
+f 1 2 3
+f 1 2 9
+bogus directive
+f -3 -2 -1
+`
+
+func TestOBJParseError(t *testing.T) {
+	model := obj.NewModel("BadModel", badObjFile)
+
+	assert.NotPanics(t, func() { model.Load() }, "expected Load to not panic")
+	assert.False(t, model.Init(), "expected Init to fail")
+
+	var parseErr *obj.ParseError
+	if assert.True(t, errors.As(model.Err(), &parseErr), "expected a ParseError") {
+		assert.Equal(t, "OBJ", parseErr.Format, "unexpected format")
+		assert.Equal(t, "BadModel", parseErr.File, "unexpected file")
+		assert.Equal(t, 5, parseErr.Line, "unexpected line")
+		assert.Equal(t, "v", parseErr.Directive, "unexpected directive")
+		assert.NotNil(t, parseErr.Err, "expected a cause")
+	}
+}
+
+func TestOBJLenientMode(t *testing.T) {
+	model := obj.NewModel("BadModel", badObjFile).SetLenient(true)
+
+	assert.NoError(t, model.Load(), "expected no error in lenient mode")
+	assert.Nil(t, model.Err(), "expected no error in lenient mode")
+
+	warnings := model.Warnings()
+	if assert.Equal(t, 3, len(warnings), "unexpected warning count") {
+		assert.Equal(t, 5, warnings[0].Line, "unexpected line for first warning")
+		assert.Equal(t, "v", warnings[0].Directive, "unexpected directive for first warning")
+		assert.Equal(t, 8, warnings[1].Line, "unexpected line for second warning")
+		assert.Equal(t, "f", warnings[1].Directive, "unexpected directive for second warning")
+		assert.Equal(t, 9, warnings[2].Line, "unexpected line for third warning")
+		assert.Equal(t, "bogus", warnings[2].Directive, "unexpected directive for third warning")
+	}
+
+	if assert.Equal(t, 1, len(model.Meshes()), "unexpected mesh count") {
+		faces := model.Meshes()[0].Faces()
+		if assert.Equal(t, 2, len(faces), "unexpected face count") {
+			assert.Equal(t, []int{0, 1, 2}, faces[1].VertexIndices(), "expected relative indices to be resolved")
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"testing"
+)
+
+func assertVec3InDelta(t *testing.T, expected, actual mgl32.Vec3, msg string) {
+	for i := 0; i < 3; i++ {
+		assert.InDelta(t, expected[i], actual[i], 1e-4, "%s: expected %v, got %v", msg, expected, actual)
+	}
+}
+
+func TestRayIntersectTriangle(t *testing.T) {
+	a, b, c := mgl32.Vec3{0, 0, 0}, mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 1, 0}
+
+	ray := gfx.Ray{Origin: mgl32.Vec3{.25, .25, 1}, Direction: mgl32.Vec3{0, 0, -1}}
+	distance, barycentric, ok := ray.IntersectTriangle(a, b, c)
+	assert.True(t, ok, "expected the ray to hit the triangle")
+	assert.InDelta(t, 1, distance, 1e-5, "unexpected distance")
+	assertVec3InDelta(t, mgl32.Vec3{.5, .25, .25}, barycentric, "unexpected barycentric weights")
+
+	// Triangles can be hit from either side
+	ray = gfx.Ray{Origin: mgl32.Vec3{.25, .25, -1}, Direction: mgl32.Vec3{0, 0, 1}}
+	_, _, ok = ray.IntersectTriangle(a, b, c)
+	assert.True(t, ok, "expected the ray to hit the back of the triangle")
+
+	ray = gfx.Ray{Origin: mgl32.Vec3{1, 1, 1}, Direction: mgl32.Vec3{0, 0, -1}}
+	_, _, ok = ray.IntersectTriangle(a, b, c)
+	assert.False(t, ok, "expected the ray to miss the triangle")
+
+	ray = gfx.Ray{Origin: mgl32.Vec3{.25, .25, 1}, Direction: mgl32.Vec3{0, 0, 1}}
+	_, _, ok = ray.IntersectTriangle(a, b, c)
+	assert.False(t, ok, "expected the triangle behind the ray to be missed")
+}
+
+func TestRayIntersectBox(t *testing.T) {
+	boxMin, boxMax := mgl32.Vec3{-1, -1, -1}, mgl32.Vec3{1, 1, 1}
+
+	ray := gfx.Ray{Origin: mgl32.Vec3{0, 0, -5}, Direction: mgl32.Vec3{0, 0, 1}}
+	distance, ok := ray.IntersectBox(boxMin, boxMax)
+	assert.True(t, ok, "expected the ray to hit the box")
+	assert.InDelta(t, 4, distance, 1e-5, "unexpected distance")
+
+	ray = gfx.Ray{Origin: mgl32.Vec3{0, 0, 0}, Direction: mgl32.Vec3{1, 0, 0}}
+	distance, ok = ray.IntersectBox(boxMin, boxMax)
+	assert.True(t, ok, "expected a ray starting inside the box to hit it")
+	assert.Equal(t, float32(0), distance, "unexpected distance")
+
+	ray = gfx.Ray{Origin: mgl32.Vec3{2, 0, -5}, Direction: mgl32.Vec3{0, 0, 1}}
+	_, ok = ray.IntersectBox(boxMin, boxMax)
+	assert.False(t, ok, "expected the parallel ray to miss the box")
+
+	ray = gfx.Ray{Origin: mgl32.Vec3{0, 0, -5}, Direction: mgl32.Vec3{0, 0, -1}}
+	_, ok = ray.IntersectBox(boxMin, boxMax)
+	assert.False(t, ok, "expected the box behind the ray to be missed")
+}
+
+func TestUnprojectRay(t *testing.T) {
+	view := mgl32.LookAtV(mgl32.Vec3{0, 0, 10}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+
+	ortho := mgl32.Ortho(-2, 2, -1, 1, .1, 100).Mul4(view)
+	ray := gfx.UnprojectRay(ortho, 1, 1)
+	assertVec3InDelta(t, mgl32.Vec3{2, 1, 9.9}, ray.Origin, "unexpected orthographic ray origin")
+	assertVec3InDelta(t, mgl32.Vec3{0, 0, -1}, ray.Direction, "unexpected orthographic ray direction")
+
+	perspective := mgl32.Perspective(mgl32.DegToRad(90), 1, .1, 100).Mul4(view)
+	ray = gfx.UnprojectRay(perspective, 0, 0)
+	assertVec3InDelta(t, mgl32.Vec3{0, 0, 9.9}, ray.Origin, "unexpected perspective ray origin")
+	assertVec3InDelta(t, mgl32.Vec3{0, 0, -1}, ray.Direction, "unexpected perspective ray direction")
+
+	// With a 90 degree FoV, the edge of the screen is at 45 degrees
+	ray = gfx.UnprojectRay(perspective, 1, 0)
+	assertVec3InDelta(t, mgl32.Vec3{1, 0, -1}.Normalize(), ray.Direction, "unexpected perspective ray direction")
+}
+
+func TestViewportWindowToViewport(t *testing.T) {
+	vp := gfx.NewViewport(1000, 1000).Set(.5, 0, .5, 1) // right half of the window
+
+	x, y := vp.WindowToViewport(.5, .5)
+	assert.InDelta(t, 0, x, 1e-5, "unexpected viewport X")
+	assert.InDelta(t, .5, y, 1e-5, "unexpected viewport Y")
+
+	x, _ = vp.WindowToViewport(0, 0)
+	assert.InDelta(t, -1, x, 1e-5, "unexpected viewport X on the left edge")
+
+	x, _ = vp.WindowToViewport(-.5, 0)
+	assert.Less(t, x, float32(-1), "expected a position outside of the viewport")
+}
+
+func TestShape3DPicking(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		shader := gfx.NewBasicShader("test_shader", _test.ColorVertShader, _test.ColorFragShader)
+		win.Assets().Add(shader)
+
+		camera := gfx.NewCamera()
+		camera.SetProjection(45, win.AspectRatio(), .1, 100)
+		camera.SetLookAt(mgl32.Vec3{.5, .5, 5}, mgl32.Vec3{.5, .5, 0}, mgl32.Vec3{0, 1, 0})
+		win.AddObject(camera)
+
+		model := _test.NewColoredQuad()
+		model.Meshes()[0].Faces()[0].AttachedMaterial().AttachShader(shader)
+
+		quad := gfx.NewShape3D()
+		quad.SetModel(model)
+		quad.SetCamera(camera)
+
+		bounds := gfx.NewBoundingShape3D()
+		entered, left, clicked := 0, 0, 0
+		bounds.OnMouseEnter(func(sender gfx.WindowObject, _ *gfx.MouseState) {
+			assert.Equal(t, quad, sender, "expected the sender to be the shape")
+			entered++
+		})
+		bounds.OnMouseLeave(func(_ gfx.WindowObject, _ *gfx.MouseState) {
+			left++
+		})
+		bounds.OnPMouseClick(func(_ gfx.WindowObject, _ *gfx.MouseState) {
+			clicked++
+		})
+		quad.AddChild(bounds)
+
+		win.AddObject(quad)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(1)
+
+		// Just below the diagonal of the quad, so within its first face
+		hit, ok := win.Pick3D(.05, -.05)
+		assert.True(t, ok, "expected the quad to be hit")
+		assert.Equal(t, quad, hit.Shape, "unexpected shape")
+		assert.Equal(t, 0, hit.MeshIndex, "unexpected mesh index")
+		assert.Equal(t, 0, hit.FaceIndex, "unexpected face index")
+		assert.InDelta(t, 0, hit.Position.Z(), 1e-4, "unexpected hit position")
+		assert.InDelta(t, 1, hit.Barycentric.X()+hit.Barycentric.Y()+hit.Barycentric.Z(), 1e-4, "unexpected barycentric weights")
+
+		hit, ok = win.Pick3D(-.05, .05)
+		assert.True(t, ok, "expected the quad to be hit")
+		assert.Equal(t, 1, hit.FaceIndex, "unexpected face index")
+
+		_, ok = win.Pick3D(.9, .9)
+		assert.False(t, ok, "expected the quad to be missed")
+
+		win.OverrideMouseState(&gfx.MouseState{X: .9, Y: .9})
+		_test.StepNFrames(1)
+		win.OverrideMouseState(&gfx.MouseState{X: .05, Y: -.05})
+		_test.StepNFrames(1)
+		assert.Equal(t, 1, entered, "expected MouseEnter event to be triggered")
+		assert.True(t, bounds.MouseOver(), "expected the mouse to be over the shape")
+		assert.Equal(t, 0, bounds.Hit().FaceIndex, "unexpected face index")
+
+		win.OverrideMouseState(&gfx.MouseState{X: .05, Y: -.05, PrimaryDown: true})
+		_test.StepNFrames(1)
+		win.OverrideMouseState(&gfx.MouseState{X: .05, Y: -.05})
+		_test.StepNFrames(1)
+		assert.Equal(t, 1, clicked, "expected PMouseClick event to be triggered")
+
+		win.OverrideMouseState(&gfx.MouseState{X: .9, Y: .9})
+		_test.StepNFrames(1)
+		assert.Equal(t, 1, left, "expected MouseLeave event to be triggered")
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
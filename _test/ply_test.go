@@ -0,0 +1,133 @@
+package _test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx/ply"
+	"testing"
+)
+
+var plyFile = `ply
+format ascii 1.0
+comment Three colored points
+element vertex 3
+property float x
+property float y
+property float z
+property float nx
+property float ny
+property float nz
+property uchar red
+property uchar green
+property uchar blue
+property float intensity
+element face 1
+property list uchar int vertex_indices
+end_header
+0 0 0 0 0 1 255 0 0 0.25
+1 0 0 0 0 1 0 255 0 0.5
+0 1 0 0 0 1 0 0 255 0.75
+3 0 1 2
+`
+
+func plyBinary(order binary.ByteOrder, format string) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("ply\nformat " + format + " 1.0\n")
+	buf.WriteString("element vertex 3\nproperty float x\nproperty float y\nproperty float z\n")
+	buf.WriteString("property float nx\nproperty float ny\nproperty float nz\n")
+	buf.WriteString("property uchar red\nproperty uchar green\nproperty uchar blue\nproperty float intensity\n")
+	buf.WriteString("element face 1\nproperty list uchar int vertex_indices\nend_header\n")
+
+	vertex := func(x, y, z float32, r, g, b uint8, intensity float32) {
+		_ = binary.Write(buf, order, []float32{x, y, z, 0, 0, 1})
+		buf.Write([]byte{r, g, b})
+		_ = binary.Write(buf, order, intensity)
+	}
+	vertex(0, 0, 0, 255, 0, 0, 0.25)
+	vertex(1, 0, 0, 0, 255, 0, 0.5)
+	vertex(0, 1, 0, 0, 0, 255, 0.75)
+
+	buf.WriteByte(3)
+	_ = binary.Write(buf, order, []int32{0, 1, 2})
+	return buf.Bytes()
+}
+
+func TestPLYLoading(t *testing.T) {
+	for _, cloud := range []*ply.PointCloud{
+		ply.NewPointCloud("AsciiCloud", plyFile),
+		ply.NewPointCloud("LittleEndianCloud", plyBinary(binary.LittleEndian, "binary_little_endian")),
+		ply.NewPointCloud("BigEndianCloud", plyBinary(binary.BigEndian, "binary_big_endian")),
+	} {
+		if !assert.NoError(t, cloud.Load(), "unexpected load error") {
+			continue
+		}
+
+		assert.Equal(t, 3, cloud.Count(), "unexpected point count")
+		assert.Equal(t, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0}, cloud.Positions(), "unexpected positions")
+		assert.Equal(t, 9, len(cloud.Normals()), "unexpected normal count")
+		assert.Equal(t, float32(1), cloud.Normals()[2], "unexpected normal")
+		assert.Equal(t, []float32{1, 0, 0, 1, 0, 1, 0, 1, 0, 0, 1, 1}, cloud.Colors(), "unexpected colors")
+		assert.Equal(t, []float32{0.25, 0.5, 0.75}, cloud.Property("intensity"), "unexpected intensity")
+		assert.Nil(t, cloud.Property("vertex_indices"), "expected list properties to be skipped")
+
+		points := cloud.Points()
+		if assert.Equal(t, 3, len(points), "unexpected point count") {
+			assert.Equal(t, uint8(255), points[1].Color.G, "unexpected point color")
+			assert.Equal(t, float32(0.5), points[1].Scalar, "expected intensity to be the default scalar")
+			assert.Equal(t, float32(1), points[2].Position.Y(), "unexpected point position")
+		}
+
+		cloud.SetScalarProperty("x")
+		assert.Equal(t, float32(1), cloud.Points()[1].Scalar, "unexpected point scalar")
+	}
+
+	cloud := ply.NewPointCloud("AsciiCloud", plyFile)
+	if assert.NoError(t, cloud.Load(), "unexpected load error") {
+		assert.Equal(t, []string{"Three colored points"}, cloud.Comments(), "unexpected comments")
+	}
+}
+
+func TestPLYParseError(t *testing.T) {
+	cloud := ply.NewPointCloud("BadHeader", "ply\nformat ascii 1.0\nelement vertex 1\nproperty quad x\nend_header\n")
+	err := cloud.Load()
+
+	var parseErr *ply.ParseError
+	if !assert.True(t, errors.As(err, &parseErr), "expected a *ParseError") {
+		return
+	}
+	assert.Equal(t, "PLY", parseErr.Format, "unexpected format")
+	assert.Equal(t, 4, parseErr.Line, "unexpected line")
+	assert.Equal(t, err, cloud.Err(), "expected Err() to return the load error")
+	assert.False(t, cloud.Init(), "expected Init() to fail")
+
+	cloud = ply.NewPointCloud("BadData", "ply\nformat ascii 1.0\nelement vertex 2\nproperty float x\nproperty float y\nproperty float z\nend_header\n0 0 0\n1 x 0\n")
+	err = cloud.Load()
+	if assert.True(t, errors.As(err, &parseErr), "expected a *ParseError") {
+		assert.Equal(t, "ASCII PLY", parseErr.Format, "unexpected format")
+		assert.Equal(t, 9, parseErr.Line, "unexpected line")
+	}
+
+	// The vertex count must be validated against the data before allocating
+	cloud = ply.NewPointCloud("HugeCloud", "ply\nformat ascii 1.0\nelement vertex 1000000000000\nproperty float x\nproperty float y\nproperty float z\nend_header\n0 0 0\n")
+	err = cloud.Load()
+	if assert.True(t, errors.As(err, &parseErr), "expected a *ParseError") {
+		assert.Equal(t, "ASCII PLY", parseErr.Format, "unexpected format")
+	}
+
+	huge := plyBinary(binary.LittleEndian, "binary_little_endian")
+	huge = bytes.Replace(huge, []byte("element vertex 3\n"), []byte("element vertex 1000000000000\n"), 1)
+	cloud = ply.NewPointCloud("HugeBinaryCloud", huge)
+	err = cloud.Load()
+	if assert.True(t, errors.As(err, &parseErr), "expected a *ParseError") {
+		assert.Equal(t, "binary little-endian PLY", parseErr.Format, "unexpected format")
+	}
+
+	truncated := plyBinary(binary.LittleEndian, "binary_little_endian")
+	cloud = ply.NewPointCloud("TruncatedCloud", truncated[:len(truncated)-30])
+	err = cloud.Load()
+	if assert.True(t, errors.As(err, &parseErr), "expected a *ParseError") {
+		assert.Equal(t, "binary little-endian PLY", parseErr.Format, "unexpected format")
+	}
+}
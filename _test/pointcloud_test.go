@@ -0,0 +1,109 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"testing"
+)
+
+func TestPointCloudAddPoints(t *testing.T) {
+	cloud := gfx.NewPointCloud(4)
+	assert.Equal(t, 4, cloud.Capacity(), "unexpected capacity")
+	assert.Equal(t, 0, cloud.PointCount(), "expected an empty cloud")
+
+	cloud.AddPoints([]gfx.Point{
+		{Position: mgl32.Vec3{0, 0, 0}, Color: gfx.Red, Scalar: 2},
+		{Position: mgl32.Vec3{1, 0, 0}, Color: gfx.Green, Scalar: 5},
+		{Position: mgl32.Vec3{2, 0, 0}, Color: gfx.Blue, Scalar: 3},
+	})
+	assert.Equal(t, 3, cloud.PointCount(), "unexpected point count")
+
+	minValue, maxValue := cloud.ScalarRange()
+	assert.Equal(t, float32(2), minValue, "unexpected min scalar")
+	assert.Equal(t, float32(5), maxValue, "unexpected max scalar")
+
+	// Once full, the oldest points are overwritten
+	cloud.AddPoints([]gfx.Point{{Scalar: -1}, {Scalar: 10}})
+	assert.Equal(t, 4, cloud.PointCount(), "expected the cloud to be full")
+
+	minValue, maxValue = cloud.ScalarRange()
+	assert.Equal(t, float32(-1), minValue, "unexpected min scalar")
+	assert.Equal(t, float32(10), maxValue, "unexpected max scalar")
+
+	cloud.SetScalarRange(0, 1)
+	minValue, maxValue = cloud.ScalarRange()
+	assert.Equal(t, float32(0), minValue, "unexpected min scalar")
+	assert.Equal(t, float32(1), maxValue, "unexpected max scalar")
+
+	cloud.SetPoints([]gfx.Point{{}, {}, {}, {}, {}, {}})
+	assert.Equal(t, 4, cloud.PointCount(), "expected points beyond the capacity to be dropped")
+
+	cloud.SetCapacity(10)
+	assert.Equal(t, 10, cloud.Capacity(), "unexpected capacity")
+	assert.Equal(t, 0, cloud.PointCount(), "expected changing the capacity to clear the cloud")
+
+	cloud.SetColormap(make(gfx.Colormap, 20))
+	assert.Equal(t, 16, len(cloud.Colormap()), "expected the colormap to be truncated")
+}
+
+func TestPointCloudRendering(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		// Without a camera, positions are in normalized device coordinates
+		cloud := gfx.NewPointCloud(2)
+		cloud.SetPointSize(20)
+		cloud.SetColorMode(gfx.PerPointColor)
+		cloud.AddPoints([]gfx.Point{
+			{Position: mgl32.Vec3{-.5, 0, 0}, Color: gfx.Red, Scalar: 0},
+			{Position: mgl32.Vec3{.5, 0, 0}, Color: gfx.Green, Scalar: 1},
+		})
+
+		validator := _test.NewSceneValidator(t, win)
+		validator.AddPixelSampler(func() (x, y float32) { return -.5, 0 }, gfx.Red, "first point")
+		validator.AddPixelSampler(func() (x, y float32) { return .5, 0 }, gfx.Green, "second point")
+		validator.AddPixelSampler(func() (x, y float32) { return 0, 0 }, _test.BackgroundColor, "between the points")
+
+		win.AddObjects(cloud, validator)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		cloud.SetColor(gfx.Blue)
+		cloud.SetColorMode(gfx.SingleColorPoints)
+		validator.Samplers[0].ExpectedColor = gfx.Blue
+		validator.Samplers[1].ExpectedColor = gfx.Blue
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		cloud.SetColormap(gfx.Colormap{gfx.Magenta, gfx.Yellow})
+		cloud.SetColorMode(gfx.ColormapPointColor)
+		validator.Samplers[0].ExpectedColor = gfx.Magenta
+		validator.Samplers[1].ExpectedColor = gfx.Yellow
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		cloud.ClearPoints()
+		validator.Samplers[0].ExpectedColor = _test.BackgroundColor
+		validator.Samplers[1].ExpectedColor = _test.BackgroundColor
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
@@ -0,0 +1,164 @@
+package _test
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx/primitive"
+	"testing"
+)
+
+func vec3At(values []float32, index int) mgl32.Vec3 {
+	return mgl32.Vec3{values[index*3], values[index*3+1], values[index*3+2]}
+}
+
+func vec2At(values []float32, index int) mgl32.Vec2 {
+	return mgl32.Vec2{values[index*2], values[index*2+1]}
+}
+
+// assertPrimitive Checks that every face of the model is wound
+// counter-clockwise with respect to its vertex normals, which must be of
+// unit length (as must the tangents/bitangents, which must also be
+// perpendicular to the normal and aligned with the texture coordinates).
+func assertPrimitive(t *testing.T, model *primitive.Model, name string) {
+	vertices := model.Vertices()
+	normals := model.Normals()
+	uvs := model.UVs()
+	tangents := model.Tangents()
+	bitangents := model.Bitangents()
+
+	count := model.VertexCount()
+	assert.Greater(t, count, 0, "%s: expected vertices", name)
+	assert.Equal(t, len(vertices), len(normals), "%s: unexpected normal count", name)
+	assert.Equal(t, len(vertices), len(tangents), "%s: unexpected tangent count", name)
+	assert.Equal(t, len(vertices), len(bitangents), "%s: unexpected bitangent count", name)
+	assert.Equal(t, count*2, len(uvs), "%s: unexpected UV count", name)
+
+	for i := 0; i < count; i++ {
+		n, tan, bitan := vec3At(normals, i), vec3At(tangents, i), vec3At(bitangents, i)
+		assert.InDelta(t, 1, n.Len(), 1e-4, "%s: expected unit normal at vertex %d", name, i)
+		assert.InDelta(t, 1, tan.Len(), 1e-4, "%s: expected unit tangent at vertex %d", name, i)
+		assert.InDelta(t, 1, bitan.Len(), 1e-4, "%s: expected unit bitangent at vertex %d", name, i)
+		assert.InDelta(t, 0, n.Dot(tan), 1e-4, "%s: expected tangent perpendicular to normal at vertex %d", name, i)
+	}
+
+	meshes := model.Meshes()
+	assert.Len(t, meshes, 1, "%s: unexpected mesh count", name)
+
+	faces := meshes[0].Faces()
+	assert.Equal(t, model.FaceCount(), len(faces), "%s: unexpected face count", name)
+	for i, face := range faces {
+		indices := face.VertexIndices()
+		assert.Len(t, indices, 3, "%s: expected triangles", name)
+		assert.Equal(t, indices, face.UvIndices(), "%s: expected shared indices", name)
+		assert.NotNil(t, face.AttachedMaterial(), "%s: expected a material", name)
+
+		p0, p1, p2 := vec3At(vertices, indices[0]), vec3At(vertices, indices[1]), vec3At(vertices, indices[2])
+		faceNormal := p1.Sub(p0).Cross(p2.Sub(p0))
+		vertexNormal := vec3At(normals, indices[0]).Add(vec3At(normals, indices[1])).Add(vec3At(normals, indices[2]))
+		if !assert.Greater(t, faceNormal.Dot(vertexNormal), float32(0), "%s: face %d is wound clockwise", name, i) {
+			return
+		}
+
+		// The tangent should point towards increasing U
+		uv0, uv1 := vec2At(uvs, indices[0]), vec2At(uvs, indices[1])
+		if du := uv1.X() - uv0.X(); du*du > 1e-6 {
+			edge := p1.Sub(p0)
+			tan := vec3At(tangents, indices[0])
+			dv := uv1.Y() - uv0.Y()
+			bitan := vec3At(bitangents, indices[0])
+			assert.Greater(t, edge.Dot(tan)*du+edge.Dot(bitan)*dv, float32(0), "%s: tangent frame does not follow UVs at face %d", name, i)
+		}
+	}
+}
+
+func TestPrimitiveBox(t *testing.T) {
+	box := primitive.NewBox("box", 2, 4, 6, 2)
+	assertPrimitive(t, box, "box")
+	assert.Equal(t, 6*9, box.VertexCount(), "unexpected vertex count")
+	assert.Equal(t, 6*2*4, box.FaceCount(), "unexpected face count")
+
+	vertices := box.Vertices()
+	for i := 0; i < box.VertexCount(); i++ {
+		v := vec3At(vertices, i)
+		onSurface := v.X() == -1 || v.X() == 1 || v.Y() == -2 || v.Y() == 2 || v.Z() == -3 || v.Z() == 3
+		assert.True(t, onSurface, "expected vertex %v to lie on the surface of the box", v)
+	}
+}
+
+func TestPrimitivePlane(t *testing.T) {
+	plane := primitive.NewPlane("plane", 10, 4, 5, 2)
+	assertPrimitive(t, plane, "plane")
+	assert.Equal(t, 6*3, plane.VertexCount(), "unexpected vertex count")
+	assert.Equal(t, 5*2*2, plane.FaceCount(), "unexpected face count")
+
+	normals := plane.Normals()
+	for i := 0; i < plane.VertexCount(); i++ {
+		assert.Equal(t, mgl32.Vec3{0, 1, 0}, vec3At(normals, i), "expected plane to face up")
+	}
+}
+
+func TestPrimitiveIndices(t *testing.T) {
+	plane := primitive.NewPlane("plane", 10, 4, 5, 2)
+	indices := plane.Indices()
+	assert.Equal(t, plane.FaceCount()*3, len(indices), "unexpected index count")
+
+	// Neighboring faces share their vertices instead of duplicating them
+	references := make(map[uint32]int)
+	for i, face := range plane.Meshes()[0].Faces() {
+		for j, index := range face.VertexIndices() {
+			assert.Equal(t, uint32(index), indices[i*3+j], "unexpected index for face %d", i)
+		}
+	}
+	for _, index := range indices {
+		assert.Less(t, index, uint32(plane.VertexCount()), "index out of range")
+		references[index]++
+	}
+	assert.Equal(t, plane.VertexCount(), len(references), "expected every vertex to be referenced")
+	assert.Equal(t, 6, references[8], "expected an inner vertex to be shared by six faces")
+}
+
+func TestPrimitiveSpheres(t *testing.T) {
+	for _, model := range []*primitive.Model{
+		primitive.NewUVSphere("uv_sphere", 2, 16, 8),
+		primitive.NewIcosphere("icosphere", 2, 2),
+		primitive.NewCapsule("capsule", 2, 4, 16, 4),
+	} {
+		assertPrimitive(t, model, model.Name())
+
+		vertices := model.Vertices()
+		normals := model.Normals()
+		for i := 0; i < model.VertexCount(); i++ {
+			v, n := vec3At(vertices, i), vec3At(normals, i)
+			assert.InDelta(t, 1, n.Dot(v.Normalize()), 1e-3, "%s: expected normal to point away from the center", model.Name())
+			assert.InDelta(t, 2, v.Len(), 1e-4, "%s: expected vertex to lie on the sphere", model.Name())
+		}
+	}
+
+	assert.Equal(t, 20*4*4, primitive.NewIcosphere("icosphere", 1, 2).FaceCount(), "unexpected icosphere face count")
+	assert.Equal(t, 2*16*8-2*16, primitive.NewUVSphere("uv_sphere", 1, 16, 8).FaceCount(), "unexpected UV sphere face count")
+}
+
+func TestPrimitiveSolidsOfRevolution(t *testing.T) {
+	for _, model := range []*primitive.Model{
+		primitive.NewCylinder("cylinder", 1, 2, 12),
+		primitive.NewCone("cone", 1, 2, 12),
+		primitive.NewTorus("torus", 2, 0.5, 24, 12),
+		primitive.NewCapsule("tall_capsule", 0.5, 3, 12, 3),
+	} {
+		assertPrimitive(t, model, model.Name())
+	}
+
+	assert.Equal(t, 12*2+12*2, primitive.NewCylinder("cylinder", 1, 2, 12).FaceCount(), "unexpected cylinder face count")
+	assert.Equal(t, 12+12, primitive.NewCone("cone", 1, 2, 12).FaceCount(), "unexpected cone face count")
+	assert.Equal(t, 24*12*2, primitive.NewTorus("torus", 2, 0.5, 24, 12).FaceCount(), "unexpected torus face count")
+
+	capsule := primitive.NewCapsule("capsule", 0.5, 3, 12, 3)
+	vertices := capsule.Vertices()
+	minY, maxY := float32(0), float32(0)
+	for i := 0; i < capsule.VertexCount(); i++ {
+		minY = min(minY, vertices[i*3+1])
+		maxY = max(maxY, vertices[i*3+1])
+	}
+	assert.InDelta(t, -1.5, minY, 1e-5, "unexpected capsule bottom")
+	assert.InDelta(t, 1.5, maxY, 1e-5, "unexpected capsule top")
+}
@@ -0,0 +1,118 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"image/gif"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestFrameRecorderPngSequence(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	outputDir := path.Join(t.TempDir(), "frames")
+
+	go func() {
+		win := gfx.NewWindow(&gfx.WindowHints{Headless: true}).
+			SetTitle(_test.WindowTitle).
+			SetWidth(200).
+			SetHeight(200)
+
+		quad := gfx.NewQuad()
+		quad.SetScale(mgl32.Vec3{.5, .5})
+		quad.SetColor(gfx.Green)
+		win.AddObject(quad)
+
+		recorder := gfx.NewFrameRecorder(gfx.PngSequenceRecording, outputDir).
+			SetFrameInterval(2)
+		win.AddService(recorder)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+
+		assert.NoError(t, recorder.Start(), "expected Start to succeed")
+		assert.True(t, recorder.Recording(), "expected recorder to be recording")
+
+		_test.StepNFrames(10)
+
+		assert.NoError(t, recorder.Stop(), "expected Stop to succeed")
+		assert.False(t, recorder.Recording(), "expected recorder to have stopped")
+
+		_test.StepNFrames(4) // should not be captured
+
+		entries, err := os.ReadDir(outputDir)
+		assert.NoError(t, err, "expected output directory to exist")
+		assert.Equal(t, 5, len(entries), "unexpected number of frame files")
+		assert.Equal(t, 5, recorder.SavedFrames(), "unexpected saved frame count")
+		assert.Equal(t, 0, recorder.DroppedFrames(), "unexpected dropped frame count")
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
+
+func TestFrameRecorderAnimatedGif(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	outputFile := path.Join(t.TempDir(), "recording.gif")
+
+	go func() {
+		win := gfx.NewWindow(&gfx.WindowHints{Headless: true}).
+			SetTitle(_test.WindowTitle).
+			SetWidth(200).
+			SetHeight(200)
+
+		recorder := gfx.NewFrameRecorder(gfx.AnimatedGifRecording, outputFile)
+		win.AddService(recorder)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+
+		assert.NoError(t, recorder.Start(), "expected Start to succeed")
+		_test.StepNFrames(6)
+		assert.NoError(t, recorder.Stop(), "expected Stop to succeed")
+
+		file, err := os.Open(outputFile)
+		if assert.NoError(t, err, "expected GIF file to exist") {
+			anim, decodeErr := gif.DecodeAll(file)
+			_ = file.Close()
+			if assert.NoError(t, decodeErr, "expected a valid GIF") {
+				assert.Equal(t, 6, len(anim.Image), "unexpected GIF frame count")
+				assert.Equal(t, 200, anim.Config.Width, "unexpected GIF width")
+			}
+		}
+
+		// Frames captured after the maximum has been reached are dropped
+		recorder.SetGifMaxFrames(4)
+		assert.NoError(t, recorder.Start(), "expected Start to succeed")
+		_test.StepNFrames(6)
+		assert.NoError(t, recorder.Stop(), "expected Stop to succeed")
+		assert.Equal(t, 4, recorder.SavedFrames(), "unexpected saved frame count")
+		assert.Equal(t, 2, recorder.DroppedFrames(), "unexpected dropped frame count")
+
+		file, err = os.Open(outputFile)
+		if assert.NoError(t, err, "expected GIF file to exist") {
+			anim, decodeErr := gif.DecodeAll(file)
+			_ = file.Close()
+			if assert.NoError(t, decodeErr, "expected a valid GIF") {
+				assert.Equal(t, 4, len(anim.Image), "unexpected GIF frame count")
+			}
+		}
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
@@ -0,0 +1,229 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"github.com/tonybillings/gfx/primitive"
+	"testing"
+)
+
+func TestRenderTarget(t *testing.T) {
+	target := gfx.NewRenderTarget("target", 256, 128)
+	assert.Equal(t, "target", target.Name(), "unexpected name")
+	assert.Equal(t, 256, target.Width(), "unexpected width")
+	assert.Equal(t, 128, target.Height(), "unexpected height")
+	assert.False(t, target.Initialized(), "expected the target to not be initialized")
+	assert.False(t, target.Init(), "expected init to fail without a window")
+
+	var texture gfx.Texture = target
+	assert.Equal(t, uint32(0), texture.GlName(), "unexpected GL name")
+
+	target.SetSize(0, 64)
+	assert.Equal(t, 1, target.Width(), "expected the width to be clamped")
+	assert.Equal(t, 64, target.Height(), "unexpected height")
+
+	quad := gfx.NewQuad()
+	circle := gfx.NewCircle(.1)
+	target.AddObjects(quad, circle)
+	assert.Equal(t, 2, len(target.Objects()), "unexpected object count")
+
+	target.RemoveObject(quad)
+	if assert.Equal(t, 1, len(target.Objects()), "unexpected object count") {
+		assert.Equal(t, circle, target.Objects()[0], "unexpected object")
+	}
+}
+
+func TestRenderTargetRendering(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		inner := gfx.NewQuad()
+		inner.SetColor(gfx.Red)
+		inner.SetScale(mgl32.Vec3{.5, .5})
+		inner.SetMaintainAspectRatio(false)
+
+		target := gfx.NewRenderTarget("target", 64, 64, gfx.NewTextureConfig(gfx.LowestQuality, gfx.Clamp))
+		target.SetClearColor(gfx.Green)
+		target.AddObject(inner)
+
+		screen := gfx.NewQuad()
+		screen.SetTexture(target)
+		screen.SetMaintainAspectRatio(false)
+
+		validator := _test.NewSceneValidator(t, win)
+		validator.AddPixelSampler(func() (x, y float32) { return 0, 0 }, gfx.Red, "inner quad, center")
+		validator.AddPixelSampler(func() (x, y float32) { return .4, -.4 }, gfx.Red, "inner quad, bottom-right")
+		validator.AddPixelSampler(func() (x, y float32) { return -.9, .9 }, gfx.Green, "clear color, top-left")
+		validator.AddPixelSampler(func() (x, y float32) { return .9, -.9 }, gfx.Green, "clear color, bottom-right")
+
+		// The screen is added before the target to show that the order
+		// does not matter
+		win.AddObjects(screen, target, validator)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		inner.SetColor(gfx.Blue)
+		target.SetClearColor(gfx.Yellow)
+		validator.Samplers[0].ExpectedColor = gfx.Blue
+		validator.Samplers[1].ExpectedColor = gfx.Blue
+		validator.Samplers[2].ExpectedColor = gfx.Yellow
+		validator.Samplers[3].ExpectedColor = gfx.Yellow
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		target.SetEnabled(false)
+		inner.SetColor(gfx.Red)
+		_test.StepNFrames(2)
+		validator.Validate() // the last frame rendered remains
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
+
+func TestRenderTargetMaterial(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		camera := gfx.NewCamera()
+		camera.SetProjection(45, win.AspectRatio(), .1, 100)
+		camera.SetLookAt(mgl32.Vec3{0, 0, 5}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+		win.AddObject(camera)
+
+		inner := gfx.NewQuad()
+		inner.SetColor(gfx.Red)
+		inner.SetScale(mgl32.Vec3{.5, .5})
+		inner.SetMaintainAspectRatio(false)
+
+		target := gfx.NewRenderTarget("target", 64, 64, gfx.NewTextureConfig(gfx.LowestQuality, gfx.Clamp))
+		target.SetClearColor(gfx.Green)
+		target.AddObject(inner)
+
+		box := primitive.NewBox("box", 2, 2, 2, 1)
+		box.SetDefaultShader(win.Assets().Get(gfx.Shape3DNoLightsShader).(gfx.Shader))
+		box.Material().Properties.Diffuse = mgl32.Vec4{1, 1, 1, 1}
+		box.Material().DiffuseMap = target
+		win.Assets().Add(box)
+
+		shape := gfx.NewShape3D()
+		shape.SetModel(box)
+		shape.SetCamera(camera)
+
+		// Shown once the box has been closed, to ensure the target is
+		// still rendered
+		screen := gfx.NewQuad()
+		screen.SetTexture(target)
+		screen.SetMaintainAspectRatio(false)
+		screen.SetVisibility(false)
+
+		// The front face of the box spans about 60% of the window's height
+		validator := _test.NewSceneValidator(t, win)
+		validator.AddPixelSampler(func() (x, y float32) { return 0, 0 }, gfx.Red, "inner quad, center")
+		validator.AddPixelSampler(func() (x, y float32) { return .25, .5 }, gfx.Green, "clear color, corner")
+		validator.AddPixelSampler(func() (x, y float32) { return -.9, .9 }, _test.BackgroundColor, "beyond the box")
+
+		win.AddObjects(target, shape, screen, validator)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		// Closing the model (and its material) must not close the target,
+		// which is owned by the window
+		shape.SetVisibility(false)
+		win.Assets().Dispose("box")
+		_test.StepNFrames(2)
+		assert.False(t, box.Initialized(), "expected the model to be closed")
+		assert.True(t, target.Initialized(), "expected the target to remain initialized")
+
+		screen.SetVisibility(true)
+		validator.Samplers[1].GetPixelPosFunc = func() (x, y float32) { return .9, .9 }
+		validator.Samplers[2].ExpectedColor = gfx.Green
+		_test.StepNFrames(2)
+		validator.Validate()
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
+
+// reentrantQuad A quad that calls back into the target it is drawn into
+// each time it is updated.
+type reentrantQuad struct {
+	*gfx.Shape2D
+	target *gfx.RenderTarget
+}
+
+func (q *reentrantQuad) Update(deltaTime int64) (ok bool) {
+	if q.target.Width() > 0 && len(q.target.Objects()) > 0 {
+		q.target.SetClearColor(gfx.Blue)
+	}
+	return q.Shape2D.Update(deltaTime)
+}
+
+func TestRenderTargetReentrantObject(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		target := gfx.NewRenderTarget("target", 64, 64, gfx.NewTextureConfig(gfx.LowestQuality, gfx.Clamp))
+		target.SetClearColor(gfx.Green)
+
+		inner := &reentrantQuad{Shape2D: gfx.NewQuad(), target: target}
+		inner.SetColor(gfx.Red)
+		inner.SetScale(mgl32.Vec3{.5, .5})
+		inner.SetMaintainAspectRatio(false)
+		target.AddObject(inner)
+
+		screen := gfx.NewQuad()
+		screen.SetTexture(target)
+		screen.SetMaintainAspectRatio(false)
+
+		validator := _test.NewSceneValidator(t, win)
+		validator.AddPixelSampler(func() (x, y float32) { return 0, 0 }, gfx.Red, "inner quad, center")
+		validator.AddPixelSampler(func() (x, y float32) { return -.9, .9 }, gfx.Blue, "clear color, top-left")
+
+		win.AddObjects(target, screen, validator)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(3)
+		validator.Validate()
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
@@ -0,0 +1,185 @@
+package _test
+
+import (
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"github.com/tonybillings/gfx/primitive"
+	"math"
+	"testing"
+)
+
+func assertSceneVec3(t *testing.T, expected, actual mgl32.Vec3, msg string) {
+	for i := 0; i < 3; i++ {
+		assert.InDelta(t, expected[i], actual[i], 1e-4, "%s: expected %v, got %v", msg, expected, actual)
+	}
+}
+
+// newArm Returns a simple, two-jointed arm: the shoulder sits on top of the
+// base and the elbow at the end of the upper arm, which is two units long.
+func newArm() (base, shoulder, elbow *gfx.SceneNode) {
+	base = gfx.NewSceneNode("base")
+	shoulder = gfx.NewSceneNode("shoulder")
+	shoulder.SetPosition(mgl32.Vec3{0, 1, 0})
+	elbow = gfx.NewSceneNode("elbow")
+	elbow.SetPosition(mgl32.Vec3{2, 0, 0})
+
+	base.AddChild(shoulder)
+	shoulder.AddChild(elbow)
+	return
+}
+
+func TestSceneNodeTransforms(t *testing.T) {
+	base, shoulder, elbow := newArm()
+	assertSceneVec3(t, mgl32.Vec3{2, 1, 0}, elbow.WorldPosition(), "unexpected elbow position")
+
+	// Rotating the shoulder swings the elbow around it
+	shoulder.SetRotation(mgl32.Vec3{0, 0, math.Pi / 2})
+	assertSceneVec3(t, mgl32.Vec3{0, 3, 0}, elbow.WorldPosition(), "unexpected elbow position after rotating the shoulder")
+	assertSceneVec3(t, mgl32.Vec3{0, 0, math.Pi / 2}, elbow.WorldRotation(), "unexpected elbow rotation")
+
+	// Rotations accumulate down the tree, as do translations and scales
+	elbow.SetRotationQuat(mgl32.QuatRotate(math.Pi/2, mgl32.Vec3{0, 0, 1}))
+	assertSceneVec3(t, mgl32.Vec3{0, 0, math.Pi}, elbow.WorldRotation(), "unexpected accumulated rotation")
+
+	base.SetPosition(mgl32.Vec3{10, 0, 0})
+	base.SetScale(mgl32.Vec3{2, 2, 2})
+	assertSceneVec3(t, mgl32.Vec3{10, 6, 0}, elbow.WorldPosition(), "unexpected elbow position after moving the base")
+	assertSceneVec3(t, mgl32.Vec3{2, 2, 2}, elbow.WorldScale(), "unexpected elbow scale")
+
+	point := elbow.WorldMatrix().Mul4x1(mgl32.Vec4{1, 0, 0, 1}).Vec3()
+	assertSceneVec3(t, mgl32.Vec3{8, 6, 0}, point, "unexpected position of a point in the elbow's space")
+}
+
+func TestSceneNodePaths(t *testing.T) {
+	scene := gfx.NewScene()
+	base, shoulder, elbow := newArm()
+	scene.AddNode(base)
+
+	assert.Equal(t, elbow, scene.Node("base/shoulder/elbow"), "unexpected node")
+	assert.Equal(t, elbow, scene.Node("/base/shoulder/elbow"), "expected a leading separator to be ignored")
+	assert.Equal(t, elbow, base.Find("shoulder/elbow"), "unexpected node found relative to the base")
+	assert.Equal(t, scene.Root(), scene.Node(""), "expected the root node")
+	assert.Nil(t, scene.Node("base/elbow"), "expected no node")
+	assert.Equal(t, "base/shoulder/elbow", elbow.Path(), "unexpected path")
+
+	// Adding a node to another parent moves it
+	scene.AddNode(elbow)
+	assert.Equal(t, scene.Root(), elbow.Parent(), "unexpected parent")
+	assert.Empty(t, shoulder.Children(), "expected the node to be removed from its previous parent")
+	assert.Equal(t, "elbow", elbow.Path(), "unexpected path")
+
+	scene.RemoveNode(elbow)
+	assert.Nil(t, elbow.Parent(), "expected the node to be removed")
+	assert.Nil(t, scene.Node("elbow"), "expected the node to be removed")
+}
+
+func TestSceneNodeInheritance(t *testing.T) {
+	base, shoulder, elbow := newArm()
+
+	shoulder.SetEnabled(false)
+	assert.True(t, base.WorldEnabled(), "expected the base to be enabled")
+	assert.False(t, elbow.WorldEnabled(), "expected the elbow to be disabled along with the shoulder")
+	assert.True(t, elbow.Enabled(), "expected the elbow's own state to be unchanged")
+
+	base.SetVisibility(false)
+	assert.False(t, elbow.WorldVisible(), "expected the elbow to be hidden along with the base")
+	base.SetVisibility(true)
+	assert.True(t, elbow.WorldVisible(), "expected the elbow to be visible")
+}
+
+func TestSceneRendering(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		camera := gfx.NewCamera()
+		camera.SetProjection(45, win.AspectRatio(), .1, 100)
+		camera.SetLookAt(mgl32.Vec3{0, 2, 10}, mgl32.Vec3{0, 2, 0}, mgl32.Vec3{0, 1, 0})
+		win.AddObject(camera)
+
+		lamp := gfx.NewPointLight()
+		lighting := gfx.NewBasicLighting(lamp)
+
+		box := primitive.NewBox("box", 1, 1, 1, 1)
+		win.Assets().Add(box)
+
+		base, shoulder, elbow := newArm()
+		base.AddChild(gfx.NewModelNode("base_mesh", box))
+		elbow.AddChild(gfx.NewModelNode("hand", box))
+		shoulder.AddChild(gfx.NewLightNode("lamp", lamp))
+
+		scene := gfx.NewScene()
+		scene.SetCamera(camera).SetLighting(lighting)
+		scene.AddNode(base)
+		win.AddObject(scene)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+
+		lamp.Lock()
+		assertSceneVec3(t, mgl32.Vec3{0, 1, 0}, lamp.Position, "expected the lamp to follow its node")
+		lamp.Unlock()
+
+		// The hand is at (2, 1, 0), so a ray cast at it from above hits its top face
+		hand := scene.Node("base/shoulder/elbow/hand")
+		ray := gfx.Ray{Origin: mgl32.Vec3{2, 10, 0}, Direction: mgl32.Vec3{0, -1, 0}}
+		hit, ok := hand.Shape().Raycast(ray)
+		assert.True(t, ok, "expected the hand to be hit")
+		assertSceneVec3(t, mgl32.Vec3{2, 1.5, 0}, hit.Position, "unexpected hit position")
+		assert.Equal(t, hand, hit.Shape.SceneNode(), "unexpected node")
+
+		// After rotating the shoulder, the hand is at (0, 3, 0)
+		shoulder.SetRotation(mgl32.Vec3{0, 0, math.Pi / 2})
+		_test.StepNFrames(1)
+		_, ok = hand.Shape().Raycast(ray)
+		assert.False(t, ok, "expected the hand to have moved")
+		ray.Origin = mgl32.Vec3{0, 10, 0}
+		hit, ok = hand.Shape().Raycast(ray)
+		assert.True(t, ok, "expected the hand to be hit")
+		assertSceneVec3(t, mgl32.Vec3{0, 3.5, 0}, hit.Position, "unexpected hit position")
+
+		// Hidden nodes are not picked
+		hit, ok = scene.Pick(0, 0)
+		assert.True(t, ok, "expected a shape to be picked")
+		elbow.SetVisibility(false)
+		base.Child("base_mesh").SetVisibility(false)
+		_test.StepNFrames(1)
+		_, ok = scene.Pick(0, 0)
+		assert.False(t, ok, "expected hidden shapes not to be picked")
+
+		// The node only changes the state of its light when its own changes
+		lamp.SetEnabled(false)
+		_test.StepNFrames(1)
+		assert.False(t, lamp.Enabled(), "expected the lamp to stay disabled")
+		lamp.SetEnabled(true)
+		shoulder.SetEnabled(false)
+		_test.StepNFrames(1)
+		assert.False(t, lamp.Enabled(), "expected the lamp to be disabled along with the shoulder")
+		shoulder.SetEnabled(true)
+		_test.StepNFrames(1)
+		assert.True(t, lamp.Enabled(), "expected the lamp to be enabled along with the shoulder")
+
+		// The shapes of removed nodes are closed, until added back
+		scene.RemoveNode(hand)
+		_test.StepNFrames(1)
+		assert.False(t, hand.Shape().Initialized(), "expected the shape of the removed node to be closed")
+		elbow.AddChild(hand)
+		_test.StepNFrames(1)
+		assert.True(t, hand.Shape().Initialized(), "expected the shape to be initialized again")
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
@@ -0,0 +1,215 @@
+package _test
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/_test"
+	"github.com/tonybillings/gfx/obj"
+	"image/color"
+	"testing"
+)
+
+var skyboxFaceColors = [6]color.RGBA{gfx.Red, gfx.Green, gfx.Blue, gfx.Yellow, gfx.Magenta, gfx.White}
+
+// hdrPanorama Returns a Radiance HDR panorama (with flat scanlines) whose
+// top half is blue and bottom half is red.
+func hdrPanorama() []byte {
+	const width, height = 8, 4
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 4 +X 8\n")
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if y < height/2 {
+				buf.Write([]byte{0, 0, 128, 129})
+			} else {
+				buf.Write([]byte{128, 0, 0, 129})
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestTextureCube(t *testing.T) {
+	cube := gfx.NewTextureCube("sky", skyboxFaceColors)
+	assert.Equal(t, "sky", cube.Name(), "unexpected name")
+	assert.False(t, cube.Initialized(), "expected the texture to not be initialized")
+
+	faces, ok := cube.Source().([]any)
+	if assert.True(t, ok, "unexpected source type") {
+		assert.Equal(t, 6, len(faces), "unexpected face count")
+		assert.Equal(t, gfx.Blue, faces[gfx.CubeFacePositiveY], "unexpected face source")
+	}
+
+	panorama := gfx.NewTextureCubeFromPanorama("panorama", "sky.hdr")
+	assert.Equal(t, "sky.hdr", panorama.Source(), "unexpected source")
+
+	// The resolution is checked against the size of the data before the
+	// image is allocated (or any GL calls are made)
+	huge := gfx.NewTextureCubeFromPanorama("huge", []byte("#?RADIANCE\n\n-Y 1000000 +X 2000000\n\x02\x02"))
+	assert.PanicsWithError(t, "decode HDR image error: resolution exceeds the data size: -Y 1000000 +X 2000000",
+		func() { huge.Init() }, "expected the resolution to be rejected")
+}
+
+func TestSkyboxRendering(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		camera := gfx.NewCamera()
+		camera.SetProjection(45, win.AspectRatio(), .1, 100)
+		camera.SetLookAt(mgl32.Vec3{}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0})
+		win.AddObject(camera)
+
+		faces := gfx.NewTextureCube("faces", skyboxFaceColors, gfx.NewTextureConfig(gfx.LowestQuality))
+		panorama := gfx.NewTextureCubeFromPanorama("panorama", hdrPanorama(), gfx.NewTextureConfig(gfx.LowestQuality))
+		win.Assets().Add(faces)
+		win.Assets().Add(panorama)
+
+		skybox := gfx.NewSkybox(faces)
+		skybox.SetCamera(camera)
+
+		facesValidator := _test.NewSceneValidator(t, win)
+		facesValidator.AddPixelSampler(func() (x, y float32) { return 0, 0 }, gfx.White, "front face")
+		facesValidator.AddPixelSampler(func() (x, y float32) { return .9, .9 }, gfx.White, "front face, corner")
+
+		win.AddObjects(skybox, facesValidator)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+		facesValidator.Validate()
+
+		// Only the orientation of the camera matters
+		camera.SetLookAt(mgl32.Vec3{50, 50, 50}, mgl32.Vec3{51, 50, 50}, mgl32.Vec3{0, 1, 0})
+		facesValidator.Samplers[0].ExpectedColor = gfx.Red
+		facesValidator.Samplers[1].ExpectedColor = gfx.Red
+		facesValidator.Validate()
+
+		camera.SetLookAt(mgl32.Vec3{}, mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0})
+		skybox.SetTexture(panorama)
+		facesValidator.Samplers[0].GetPixelPosFunc = func() (x, y float32) { return 0, .5 }
+		facesValidator.Samplers[0].ExpectedColor = gfx.Blue
+		facesValidator.Samplers[1].GetPixelPosFunc = func() (x, y float32) { return 0, -.5 }
+		facesValidator.Samplers[1].ExpectedColor = gfx.Red
+		facesValidator.Validate()
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
+
+// mirrorObjFile A 2x2 quad facing +Z, centered at the origin.
+var mirrorObjFile = `
+mtllib mirror.mtl
+v -1 -1 0
+v 1 -1 0
+v 1 1 0
+v -1 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+usemtl Mirror
+f 1/1/1 2/2/1 3/3/1
+f 3/3/1 4/4/1 1/1/1
+`
+
+// mirrorMtlFile A white, fully metallic and smooth material, such that the
+// PBR version reflects the environment map without attenuation.
+var mirrorMtlFile = `
+newmtl Mirror
+Kd 1.0 1.0 1.0
+Pm 1.0
+Pr 0.0
+`
+
+func TestEnvironmentMapRendering(t *testing.T) {
+	_test.BeginStepped()
+	defer _test.End()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+
+	go func() {
+		win := gfx.NewWindow().
+			SetTitle(_test.WindowTitle).
+			SetWidth(_test.WindowWidth).
+			SetHeight(_test.WindowHeight)
+
+		camera := gfx.NewCamera()
+		camera.SetProjection(45, win.AspectRatio(), .1, 100)
+		camera.SetLookAt(mgl32.Vec3{0, 0, 5}, mgl32.Vec3{}, mgl32.Vec3{0, 1, 0})
+		win.AddObject(camera)
+
+		win.Assets().Add(gfx.NewBinaryAsset("mirror.mtl", []byte(mirrorMtlFile)))
+
+		basicModel := obj.NewModel("BasicMirror", mirrorObjFile).SetPbrEnabled(false)
+		basicModel.ComputeTangents(true)
+		pbrModel := obj.NewModel("PbrMirror", mirrorObjFile)
+		pbrModel.ComputeTangents(true)
+		win.Assets().Add(basicModel)
+		win.Assets().Add(pbrModel)
+
+		// Not added to the asset library, so the shapes initialize and close
+		// it themselves; the view is reflected towards the +Z (white) face
+		white := gfx.NewTextureCube("white", [6]color.RGBA{gfx.Red, gfx.Green, gfx.Blue, gfx.Yellow, gfx.White, gfx.Magenta},
+			gfx.NewTextureConfig(gfx.LowestQuality))
+		green := gfx.NewTextureCube("green", [6]color.RGBA{gfx.Red, gfx.Red, gfx.Blue, gfx.Yellow, gfx.Green, gfx.Magenta},
+			gfx.NewTextureConfig(gfx.LowestQuality))
+		win.Assets().Add(green)
+
+		basicMirror := gfx.NewShape3D()
+		basicMirror.SetModel(basicModel)
+		basicMirror.SetEnvironmentMap(white).SetReflectivity(1)
+		basicMirror.SetCamera(camera)
+		basicMirror.SetPosition(mgl32.Vec3{-1.5, 0, 0})
+
+		pbrMirror := gfx.NewShape3D()
+		pbrMirror.SetModel(pbrModel)
+		pbrMirror.SetEnvironmentMap(white).SetReflectivity(1)
+		pbrMirror.SetCamera(camera)
+		pbrMirror.SetPosition(mgl32.Vec3{1.5, 0, 0})
+
+		validator := _test.NewSceneValidator(t, win)
+		validator.AddPixelSampler(func() (x, y float32) { return -.38, 0 }, gfx.White, "basic material")
+		validator.AddPixelSampler(func() (x, y float32) { return .38, 0 }, gfx.White, "PBR material")
+		validator.AddPixelSampler(func() (x, y float32) { return 0, 0 }, _test.BackgroundColor, "between the shapes")
+
+		win.AddObjects(basicMirror, pbrMirror, validator)
+
+		gfx.InitWindowAsync(win)
+		<-win.ReadyChan()
+		_test.StepNFrames(2)
+		validator.Validate()
+		assert.True(t, white.Initialized(), "expected the environment map to be initialized by the shapes")
+
+		// The replaced cube map is closed by the shape that initialized it,
+		// then initialized again by the other shape still using it
+		basicMirror.SetEnvironmentMap(green)
+		validator.Samplers[0].ExpectedColor = gfx.Green
+		_test.StepNFrames(2)
+		validator.Validate()
+		assert.True(t, white.Initialized(), "expected the environment map to still be initialized")
+
+		pbrMirror.SetEnvironmentMap(green)
+		_test.StepNFrames(2)
+		assert.False(t, white.Initialized(), "expected the replaced environment map to be closed")
+		assert.True(t, green.Initialized(), "expected the asset library to own the environment map")
+
+		cancelFunc()
+	}()
+
+	gfx.Run(ctx, cancelFunc)
+}
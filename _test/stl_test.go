@@ -0,0 +1,126 @@
+package _test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/tonybillings/gfx/stl"
+	"testing"
+)
+
+// Two faces sharing the edge along the X axis, meeting at a right angle
+var stlFile = `
+solid Hinge
+  facet normal 0 0 1
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 1 0
+    endloop
+  endfacet
+  facet normal 0 0 0
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 0 -1
+    endloop
+  endfacet
+endsolid Hinge
+`
+
+func stlBinary() []byte {
+	buf := &bytes.Buffer{}
+	header := make([]byte, 80)
+	copy(header, "solid BinaryHinge")
+	buf.Write(header)
+	_ = binary.Write(buf, binary.LittleEndian, uint32(2))
+	_ = binary.Write(buf, binary.LittleEndian, []float32{0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1, 0})
+	_ = binary.Write(buf, binary.LittleEndian, uint16(0))
+	_ = binary.Write(buf, binary.LittleEndian, []float32{0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, -1})
+	_ = binary.Write(buf, binary.LittleEndian, uint16(0))
+	return buf.Bytes()
+}
+
+func assertStlNormal(t *testing.T, model *stl.Model, face int, corner int, expected [3]float32) {
+	index := model.Meshes()[0].Faces()[face].NormalIndices()[corner] * 3
+	for i := 0; i < 3; i++ {
+		assert.InDelta(t, expected[i], model.Normals()[index+i], 1e-5, "unexpected normal")
+	}
+}
+
+func TestSTLLoading(t *testing.T) {
+	for _, model := range []*stl.Model{
+		stl.NewModel("AsciiModel", stlFile),
+		stl.NewModel("BinaryModel", stlBinary()),
+	} {
+		if !assert.NoError(t, model.Load(), "unexpected load error") {
+			continue
+		}
+
+		assert.Equal(t, 1, len(model.Meshes()), "unexpected mesh count")
+		assert.Equal(t, 2, len(model.Meshes()[0].Faces()), "unexpected face count")
+		assert.Equal(t, 18, len(model.Vertices()), "unexpected vertex count")
+		assert.Equal(t, len(model.Normals()), len(model.Tangents()), "unexpected tangent count")
+		assert.Equal(t, len(model.Normals()), len(model.Bitangents()), "unexpected bitangent count")
+		assert.NotEmpty(t, model.UVs(), "expected texture coordinates")
+
+		// The second face has no stored normal, so it's derived from the winding order
+		assertStlNormal(t, model, 0, 0, [3]float32{0, 0, 1})
+		assertStlNormal(t, model, 1, 0, [3]float32{0, 1, 0})
+
+		face := model.Meshes()[0].Faces()[0]
+		assert.Equal(t, model.Material(), face.AttachedMaterial(), "unexpected material")
+	}
+
+	model := stl.NewModel("AsciiModel", stlFile)
+	if assert.NoError(t, model.Load(), "unexpected load error") {
+		assert.Equal(t, "Hinge", model.Meshes()[0].Name(), "unexpected mesh name")
+	}
+}
+
+func TestSTLWeldAndSmooth(t *testing.T) {
+	model := stl.NewModel("WeldedModel", stlFile).SetWeldVertices(true)
+	if !assert.NoError(t, model.Load(), "unexpected load error") {
+		return
+	}
+	assert.Equal(t, 12, len(model.Vertices()), "expected the shared vertices to be welded")
+
+	model = stl.NewModel("SharpModel", stlFile).SetSmoothingAngle(45)
+	if !assert.NoError(t, model.Load(), "unexpected load error") {
+		return
+	}
+	assertStlNormal(t, model, 0, 0, [3]float32{0, 0, 1})
+	assertStlNormal(t, model, 1, 0, [3]float32{0, 1, 0})
+
+	model = stl.NewModel("SmoothModel", stlFile).SetSmoothingAngle(95)
+	if !assert.NoError(t, model.Load(), "unexpected load error") {
+		return
+	}
+	assertStlNormal(t, model, 0, 0, [3]float32{0, 0.70710678, 0.70710678}) // shared vertex
+	assertStlNormal(t, model, 0, 2, [3]float32{0, 0, 1})                   // unshared vertex
+}
+
+func TestSTLParseError(t *testing.T) {
+	model := stl.NewModel("BadModel", "solid Bad\n facet normal 0 0 1\n  vertex 0 0\n")
+	err := model.Load()
+
+	var parseErr *stl.ParseError
+	if !assert.True(t, errors.As(err, &parseErr), "expected a *ParseError") {
+		return
+	}
+	assert.Equal(t, "ASCII STL", parseErr.Format, "unexpected format")
+	assert.Equal(t, 3, parseErr.Line, "unexpected line")
+	assert.Equal(t, err, model.Err(), "expected Err() to return the load error")
+	assert.False(t, model.Init(), "expected Init() to fail")
+
+	truncated := stlBinary()
+	truncated = truncated[:len(truncated)-10]
+	truncated[0] = 'x' // not "solid", so treated as binary
+	model = stl.NewModel("TruncatedModel", truncated)
+	err = model.Load()
+	if assert.True(t, errors.As(err, &parseErr), "expected a *ParseError") {
+		assert.Equal(t, "binary STL", parseErr.Format, "unexpected format")
+		assert.Equal(t, 2, parseErr.Line, "unexpected triangle")
+	}
+}
@@ -3,12 +3,14 @@ package _test
 import (
 	"fmt"
 	"github.com/tonybillings/gfx"
+	"image"
 	"runtime"
 	"time"
 )
 
 var (
 	startRoutineCount int
+	steppingEnabled   bool
 )
 
 func PanicOnErr(err error) {
@@ -32,9 +34,23 @@ func Begin() {
 	gfx.SetVSyncEnabled(VSyncEnabled)
 }
 
+// BeginStepped Same as Begin() but the engine will only update
+// windows when stepped, each time with a fixed delta time (see
+// StepNFrames()), making the Sleep*Frames() functions deterministic.
+func BeginStepped() {
+	Begin()
+	gfx.SetClock(gfx.NewManualClock())
+	steppingEnabled = true
+}
+
 func End() {
 	gfx.Close()
 
+	if steppingEnabled {
+		gfx.SetClock(gfx.NewRealTimeClock())
+		steppingEnabled = false
+	}
+
 	endRoutineCount := runtime.NumGoroutine()
 	if endRoutineCount != startRoutineCount {
 		panic(fmt.Errorf("routine leak detected: expected %d, got %d", startRoutineCount, endRoutineCount))
@@ -42,13 +58,43 @@ func End() {
 }
 
 func SleepACoupleFrames() {
-	time.Sleep(time.Duration((1000/TargetFramerate)*2) * time.Millisecond)
+	SleepNFrames(2)
 }
 
 func SleepAFewFrames() {
-	time.Sleep(time.Duration((1000/TargetFramerate)*3) * time.Millisecond)
+	SleepNFrames(3)
 }
 
 func SleepNFrames(n int) {
+	if steppingEnabled {
+		StepNFrames(n)
+		return
+	}
 	time.Sleep(time.Duration((1000/TargetFramerate)*n) * time.Millisecond)
 }
+
+// StepNFrames Advance the engine by exactly n frames, using a delta time
+// based on the target framerate.  Requires the test to have called
+// BeginStepped() instead of Begin().
+func StepNFrames(n int) {
+	gfx.Step(n, FrameDeltaTime)
+}
+
+// ToImageStepped Steps the engine until the window has rendered the frame
+// requested by Window.ToImage(), returning the image.  Requires the test to
+// have called BeginStepped() instead of Begin().
+func ToImageStepped(win *gfx.Window) *image.RGBA {
+	imgChan := make(chan *image.RGBA)
+	go func() {
+		imgChan <- win.ToImage()
+	}()
+
+	for {
+		select {
+		case img := <-imgChan:
+			return img
+		default:
+			StepNFrames(1)
+		}
+	}
+}
@@ -0,0 +1,989 @@
+package gfx
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"image/color"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAnimatorName = "Animator"
+
+	// LoopForever Can be passed to SetLoops() to repeat an animation until it
+	// is stopped.
+	LoopForever = -1
+)
+
+/******************************************************************************
+ Easing
+******************************************************************************/
+
+// EasingFunc Maps the linear progress of an animation (from 0 to 1) to the
+// eased progress used to interpolate its values, which should also start at
+// 0 and end at 1, but may overshoot in between.
+type EasingFunc func(t float32) float32
+
+func Linear(t float32) float32 {
+	return t
+}
+
+// EaseStep Holds the start value until the end is reached, as used by
+// keyframes that should not be interpolated.
+func EaseStep(t float32) float32 {
+	if t < 1 {
+		return 0
+	}
+	return 1
+}
+
+func EaseInQuad(t float32) float32 {
+	return t * t
+}
+
+func EaseOutQuad(t float32) float32 {
+	return 1 - (1-t)*(1-t)
+}
+
+func EaseInOutQuad(t float32) float32 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return 1 - (-2*t+2)*(-2*t+2)/2
+}
+
+func EaseInCubic(t float32) float32 {
+	return t * t * t
+}
+
+func EaseOutCubic(t float32) float32 {
+	return 1 - (1-t)*(1-t)*(1-t)
+}
+
+func EaseInOutCubic(t float32) float32 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	return 1 - (-2*t+2)*(-2*t+2)*(-2*t+2)/2
+}
+
+func EaseInSine(t float32) float32 {
+	return 1 - float32(math.Cos(float64(t)*math.Pi/2))
+}
+
+func EaseOutSine(t float32) float32 {
+	return float32(math.Sin(float64(t) * math.Pi / 2))
+}
+
+func EaseInOutSine(t float32) float32 {
+	return -(float32(math.Cos(float64(t)*math.Pi)) - 1) / 2
+}
+
+// EaseInBack Pulls back slightly before moving towards the end value.
+func EaseInBack(t float32) float32 {
+	const c1 = 1.70158
+	const c3 = c1 + 1
+	return c3*t*t*t - c1*t*t
+}
+
+// EaseOutBack Overshoots the end value slightly before settling on it.
+func EaseOutBack(t float32) float32 {
+	const c1 = 1.70158
+	const c3 = c1 + 1
+	return 1 + c3*(t-1)*(t-1)*(t-1) + c1*(t-1)*(t-1)
+}
+
+// EaseOutElastic Oscillates around the end value before settling on it.
+func EaseOutElastic(t float32) float32 {
+	if t <= 0 || t >= 1 {
+		return t
+	}
+	const c4 = 2 * math.Pi / 3
+	return float32(math.Pow(2, -10*float64(t))*math.Sin((float64(t)*10-0.75)*c4)) + 1
+}
+
+// EaseOutBounce Bounces off the end value, like a dropped ball.
+func EaseOutBounce(t float32) float32 {
+	const n1 = 7.5625
+	const d1 = 2.75
+	switch {
+	case t < 1/d1:
+		return n1 * t * t
+	case t < 2/d1:
+		t -= 1.5 / d1
+		return n1*t*t + 0.75
+	case t < 2.5/d1:
+		t -= 2.25 / d1
+		return n1*t*t + 0.9375
+	default:
+		t -= 2.625 / d1
+		return n1*t*t + 0.984375
+	}
+}
+
+/******************************************************************************
+ Animatable
+******************************************************************************/
+
+// Animatable The types of values that can be interpolated by tweens and
+// keyframe tracks.  Quaternions are interpolated along the shortest arc and
+// colors per channel.
+type Animatable interface {
+	float32 | mgl32.Vec3 | mgl32.Quat | color.RGBA
+}
+
+func interpolate[T Animatable](from, to T, t float32) T {
+	switch a := any(from).(type) {
+	case float32:
+		b := any(to).(float32)
+		return any(a + (b-a)*t).(T)
+	case mgl32.Vec3:
+		b := any(to).(mgl32.Vec3)
+		return any(a.Add(b.Sub(a).Mul(t))).(T)
+	case mgl32.Quat:
+		b := any(to).(mgl32.Quat)
+		if a.Dot(b) < 0 {
+			b = b.Scale(-1)
+		}
+		return any(mgl32.QuatSlerp(a, b, t)).(T)
+	case color.RGBA:
+		b := any(to).(color.RGBA)
+		return any(color.RGBA{
+			R: interpolateChannel(a.R, b.R, t),
+			G: interpolateChannel(a.G, b.G, t),
+			B: interpolateChannel(a.B, b.B, t),
+			A: interpolateChannel(a.A, b.A, t),
+		}).(T)
+	}
+	return to
+}
+
+func interpolateChannel(from, to uint8, t float32) uint8 {
+	value := float32(from) + (float32(to)-float32(from))*t
+	return uint8(math.Round(float64(max(0, min(255, value)))))
+}
+
+/******************************************************************************
+ Animation
+******************************************************************************/
+
+// Animation Animations change the properties of objects over time once
+// played by an Animator, which advances them using the window's deltaTime.
+// Every animation can be delayed, looped (optionally playing every other
+// pass in reverse, as a ping-pong) and notify when it completes.  See
+// Tween, Track, Sequence and Parallel.
+type Animation interface {
+	// Duration shall return the length of a single pass through the
+	// animation, excluding its delay.
+	Duration() time.Duration
+
+	// TotalDuration shall return the length of the whole animation,
+	// including its delay and loops, or false if it loops forever.
+	TotalDuration() (duration time.Duration, finite bool)
+
+	Delay() time.Duration
+	SetDelay(time.Duration) Animation
+
+	Loops() int
+	SetLoops(int) Animation
+
+	PingPong() bool
+	SetPingPong(bool) Animation
+
+	// OnComplete shall add a function to be called once the animation has
+	// completed, from the goroutine updating the Animator.
+	OnComplete(func()) Animation
+
+	// Finished shall return true if the animation has completed.
+	Finished() bool
+
+	seek(t time.Duration, callbacks *[]func()) (done bool)
+	reset()
+}
+
+// animationImpl Implemented by the types embedding AnimationBase, which
+// handles the delay, loops and callbacks and leaves the rest to them.
+type animationImpl interface {
+	Animation
+
+	duration() time.Duration
+
+	// finite shall return false if a single pass through the animation
+	// never ends, as with groups containing animations that loop forever.
+	finite() bool
+
+	// apply shall set the animated properties to their state at the given
+	// time within a single pass through the animation.
+	apply(passTime time.Duration, callbacks *[]func())
+
+	resetImpl()
+}
+
+/******************************************************************************
+ AnimationBase
+******************************************************************************/
+
+type AnimationBase struct {
+	impl animationImpl
+
+	delay    time.Duration
+	loops    int
+	pingPong bool
+
+	onComplete []func()
+	finished   bool
+
+	stateMutex sync.Mutex
+}
+
+/******************************************************************************
+ Animation Implementation
+******************************************************************************/
+
+func (a *AnimationBase) Duration() time.Duration {
+	return a.impl.duration()
+}
+
+func (a *AnimationBase) TotalDuration() (duration time.Duration, finite bool) {
+	a.stateMutex.Lock()
+	delay, loops := a.delay, a.loops
+	a.stateMutex.Unlock()
+
+	if loops < 0 {
+		return 0, false
+	}
+	if !a.impl.finite() {
+		return 0, false
+	}
+
+	return delay + a.impl.duration()*time.Duration(max(1, loops)), true
+}
+
+func (a *AnimationBase) Delay() (delay time.Duration) {
+	a.stateMutex.Lock()
+	delay = a.delay
+	a.stateMutex.Unlock()
+	return
+}
+
+// SetDelay sets the time to wait before starting the animation, once played
+// or reached within a Sequence.  Defaults to 0.
+func (a *AnimationBase) SetDelay(delay time.Duration) Animation {
+	a.stateMutex.Lock()
+	a.delay = max(0, delay)
+	a.stateMutex.Unlock()
+	return a.impl
+}
+
+func (a *AnimationBase) Loops() (loops int) {
+	a.stateMutex.Lock()
+	loops = a.loops
+	a.stateMutex.Unlock()
+	return
+}
+
+// SetLoops sets the number of passes through the animation, which will play
+// forever if given LoopForever.  Defaults to 1.
+func (a *AnimationBase) SetLoops(loops int) Animation {
+	a.stateMutex.Lock()
+	a.loops = loops
+	a.stateMutex.Unlock()
+	return a.impl
+}
+
+func (a *AnimationBase) PingPong() (enabled bool) {
+	a.stateMutex.Lock()
+	enabled = a.pingPong
+	a.stateMutex.Unlock()
+	return
+}
+
+// SetPingPong determines whether every other pass through the animation
+// (see SetLoops()) is played in reverse.  Defaults to false.
+func (a *AnimationBase) SetPingPong(enabled bool) Animation {
+	a.stateMutex.Lock()
+	a.pingPong = enabled
+	a.stateMutex.Unlock()
+	return a.impl
+}
+
+func (a *AnimationBase) OnComplete(handler func()) Animation {
+	a.stateMutex.Lock()
+	a.onComplete = append(a.onComplete, handler)
+	a.stateMutex.Unlock()
+	return a.impl
+}
+
+func (a *AnimationBase) Finished() (finished bool) {
+	a.stateMutex.Lock()
+	finished = a.finished
+	a.stateMutex.Unlock()
+	return
+}
+
+// seek Sets the animated properties to their state at the given time since
+// the animation started (including its delay), adding the completion
+// callbacks to the given slice if it has just completed.
+func (a *AnimationBase) seek(t time.Duration, callbacks *[]func()) (done bool) {
+	a.stateMutex.Lock()
+	delay, loops, pingPong := a.delay, a.loops, a.pingPong
+	a.stateMutex.Unlock()
+
+	if t < delay {
+		a.setFinished(false, callbacks)
+		return false
+	}
+	t -= delay
+
+	duration := a.impl.duration()
+	passTime := duration
+	if !a.impl.finite() {
+		// A group containing an animation that plays forever is never done,
+		// nor can it be looped
+		passTime = t
+	} else if duration > 0 {
+		pass := int(t / duration)
+		passTime = t - duration*time.Duration(pass)
+		if loops >= 0 && pass >= max(1, loops) {
+			pass = max(1, loops) - 1
+			passTime = duration
+			done = true
+		}
+		if pingPong && pass%2 == 1 {
+			passTime = duration - passTime
+		}
+	} else {
+		done = loops >= 0
+	}
+
+	a.impl.apply(passTime, callbacks)
+	a.setFinished(done, callbacks)
+	return
+}
+
+func (a *AnimationBase) setFinished(finished bool, callbacks *[]func()) {
+	a.stateMutex.Lock()
+	if finished && !a.finished {
+		*callbacks = append(*callbacks, a.onComplete...)
+	}
+	a.finished = finished
+	a.stateMutex.Unlock()
+}
+
+func (a *AnimationBase) reset() {
+	a.stateMutex.Lock()
+	a.finished = false
+	a.stateMutex.Unlock()
+	a.impl.resetImpl()
+}
+
+/******************************************************************************
+ Tween
+******************************************************************************/
+
+// Tween An Animation that interpolates a single value from one value to
+// another, passing the result to the given setter function.  If created with
+// a getter function rather than a start value (see NewTweenFrom()), the start
+// value is taken when the tween first starts, allowing it to continue from
+// wherever a previous animation left off.
+type Tween[T Animatable] struct {
+	AnimationBase
+
+	from, to T
+	fromSet  bool
+	getter   func() T
+	setter   func(T)
+
+	length time.Duration
+	easing EasingFunc
+}
+
+func (t *Tween[T]) duration() time.Duration {
+	return t.length
+}
+
+func (t *Tween[T]) finite() bool {
+	return true
+}
+
+func (t *Tween[T]) apply(passTime time.Duration, _ *[]func()) {
+	t.stateMutex.Lock()
+	if !t.fromSet {
+		t.from = t.getter()
+		t.fromSet = true
+	}
+	from, to, easing := t.from, t.to, t.easing
+	t.stateMutex.Unlock()
+
+	progress := float32(1)
+	if t.length > 0 {
+		progress = float32(passTime) / float32(t.length)
+	}
+
+	t.setter(interpolate(from, to, easing(progress)))
+}
+
+func (t *Tween[T]) resetImpl() {
+	t.stateMutex.Lock()
+	if t.getter != nil {
+		t.fromSet = false
+	}
+	t.stateMutex.Unlock()
+}
+
+func (t *Tween[T]) Easing() (easing EasingFunc) {
+	t.stateMutex.Lock()
+	easing = t.easing
+	t.stateMutex.Unlock()
+	return
+}
+
+// SetEasing sets the function used to ease the interpolation (see EaseInQuad,
+// etc).  Defaults to Linear.
+func (t *Tween[T]) SetEasing(easing EasingFunc) *Tween[T] {
+	if easing == nil {
+		easing = Linear
+	}
+	t.stateMutex.Lock()
+	t.easing = easing
+	t.stateMutex.Unlock()
+	return t
+}
+
+func NewTween[T Animatable](from, to T, duration time.Duration, setter func(T)) *Tween[T] {
+	t := &Tween[T]{
+		from:    from,
+		to:      to,
+		fromSet: true,
+		setter:  setter,
+		length:  max(0, duration),
+		easing:  Linear,
+	}
+	t.impl = t
+	t.loops = 1
+	return t
+}
+
+// NewTweenFrom Creates a tween that starts from the value returned by the
+// getter function when the tween starts.
+func NewTweenFrom[T Animatable](getter func() T, to T, duration time.Duration, setter func(T)) *Tween[T] {
+	t := NewTween(to, to, duration, setter)
+	t.getter = getter
+	t.fromSet = false
+	return t
+}
+
+// TweenPosition Creates a tween moving the given Transform to the given
+// position, from wherever it is when the tween starts.
+func TweenPosition(transform Transform, to mgl32.Vec3, duration time.Duration) *Tween[mgl32.Vec3] {
+	return NewTweenFrom(transform.Position, to, duration, func(v mgl32.Vec3) { transform.SetPosition(v) })
+}
+
+// TweenRotation Creates a tween rotating the given Transform (using its
+// quaternion rotation) to the given orientation.
+func TweenRotation(transform Transform, to mgl32.Quat, duration time.Duration) *Tween[mgl32.Quat] {
+	return NewTweenFrom(transform.RotationQuat, to, duration, func(q mgl32.Quat) { transform.SetRotationQuat(q) })
+}
+
+// TweenScale Creates a tween scaling the given Transform to the given scale.
+func TweenScale(transform Transform, to mgl32.Vec3, duration time.Duration) *Tween[mgl32.Vec3] {
+	return NewTweenFrom(transform.Scale, to, duration, func(v mgl32.Vec3) { transform.SetScale(v) })
+}
+
+// TweenColor Creates a tween changing the color of the given WindowObject.
+func TweenColor(object WindowObject, to color.RGBA, duration time.Duration) *Tween[color.RGBA] {
+	return NewTweenFrom(object.Color, to, duration, func(c color.RGBA) { object.SetColor(c) })
+}
+
+// TweenOpacity Creates a tween changing the opacity of the given
+// WindowObject.
+func TweenOpacity(object WindowObject, to uint8, duration time.Duration) *Tween[float32] {
+	getter := func() float32 { return float32(object.Opacity()) }
+	setter := func(v float32) { object.SetOpacity(uint8(math.Round(float64(max(0, min(255, v)))))) }
+	return NewTweenFrom(getter, float32(to), duration, setter)
+}
+
+// TweenSliderValue Creates a tween changing the value of the given Slider,
+// raising its OnValueChanged events as it does.
+func TweenSliderValue(slider *Slider, to float32, duration time.Duration) *Tween[float32] {
+	return NewTweenFrom(slider.Value, to, duration, slider.SetValue)
+}
+
+/******************************************************************************
+ Track
+******************************************************************************/
+
+// Keyframe A value that a Track passes through at the given time.  The
+// easing function is applied to the segment of the track that ends at this
+// keyframe, with nil meaning Linear.
+type Keyframe[T Animatable] struct {
+	Time   time.Duration
+	Value  T
+	Easing EasingFunc
+}
+
+// Track An Animation that interpolates a single value through a series of
+// keyframes, passing the result to the given setter function.  The
+// duration of the track is the time of its last keyframe.
+type Track[T Animatable] struct {
+	AnimationBase
+
+	keyframes []Keyframe[T]
+	setter    func(T)
+}
+
+func (t *Track[T]) duration() time.Duration {
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	if len(t.keyframes) == 0 {
+		return 0
+	}
+	return t.keyframes[len(t.keyframes)-1].Time
+}
+
+func (t *Track[T]) finite() bool {
+	return true
+}
+
+func (t *Track[T]) apply(passTime time.Duration, _ *[]func()) {
+	t.stateMutex.Lock()
+	keyframes := t.keyframes
+	t.stateMutex.Unlock()
+
+	if len(keyframes) > 0 {
+		t.setter(sampleKeyframes(keyframes, passTime))
+	}
+}
+
+func (t *Track[T]) resetImpl() {}
+
+// AddKeyframe adds a keyframe to the track, which are kept in order of time.
+func (t *Track[T]) AddKeyframe(time time.Duration, value T, easing ...EasingFunc) *Track[T] {
+	keyframe := Keyframe[T]{Time: time, Value: value}
+	if len(easing) > 0 {
+		keyframe.Easing = easing[0]
+	}
+
+	t.stateMutex.Lock()
+	keyframes := make([]Keyframe[T], len(t.keyframes), len(t.keyframes)+1)
+	copy(keyframes, t.keyframes)
+	keyframes = append(keyframes, keyframe)
+	sort.SliceStable(keyframes, func(i, j int) bool { return keyframes[i].Time < keyframes[j].Time })
+	t.keyframes = keyframes
+	t.stateMutex.Unlock()
+	return t
+}
+
+func (t *Track[T]) Keyframes() []Keyframe[T] {
+	t.stateMutex.Lock()
+	keyframes := make([]Keyframe[T], len(t.keyframes))
+	copy(keyframes, t.keyframes)
+	t.stateMutex.Unlock()
+	return keyframes
+}
+
+func NewTrack[T Animatable](setter func(T), keyframes ...Keyframe[T]) *Track[T] {
+	t := &Track[T]{
+		setter: setter,
+	}
+	t.impl = t
+	t.loops = 1
+
+	for _, keyframe := range keyframes {
+		t.AddKeyframe(keyframe.Time, keyframe.Value, keyframe.Easing)
+	}
+	return t
+}
+
+// sampleKeyframes Returns the value at the given time of the given
+// keyframes, which must be in order of time, holding the value of the
+// first/last keyframe before/after them.
+func sampleKeyframes[T Animatable](keyframes []Keyframe[T], t time.Duration) (value T) {
+	if len(keyframes) == 0 {
+		return
+	}
+
+	i := sort.Search(len(keyframes), func(i int) bool { return keyframes[i].Time >= t })
+	switch i {
+	case 0:
+		return keyframes[0].Value
+	case len(keyframes):
+		return keyframes[len(keyframes)-1].Value
+	}
+
+	prev, next := keyframes[i-1], keyframes[i]
+	progress := float32(1)
+	if span := next.Time - prev.Time; span > 0 {
+		progress = float32(t-prev.Time) / float32(span)
+	}
+	if next.Easing != nil {
+		progress = next.Easing(progress)
+	}
+	return interpolate(prev.Value, next.Value, progress)
+}
+
+/******************************************************************************
+ Sequence
+******************************************************************************/
+
+// Sequence An Animation that plays its animations one after another.  An
+// animation that loops forever prevents those after it from being played.
+type Sequence struct {
+	AnimationBase
+
+	animations []Animation
+
+	// childTimes The time each animation was last seeked to, or -1 if not
+	// yet started.
+	childTimes []time.Duration
+}
+
+func (s *Sequence) duration() (duration time.Duration) {
+	for _, animation := range s.animations {
+		total, finite := animation.TotalDuration()
+		if !finite {
+			return
+		}
+		duration += total
+	}
+	return
+}
+
+func (s *Sequence) finite() bool {
+	for _, animation := range s.animations {
+		if _, finite := animation.TotalDuration(); !finite {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Sequence) apply(passTime time.Duration, callbacks *[]func()) {
+	localTimes := make([]time.Duration, len(s.animations))
+	offset := time.Duration(0)
+	for i, animation := range s.animations {
+		localTimes[i] = passTime - offset
+		total, finite := animation.TotalDuration()
+		if !finite {
+			total = time.Duration(math.MaxInt64 - int64(offset))
+		}
+		offset += total
+	}
+
+	// The child times are reset by Play on the caller's goroutine, so they
+	// are copied under the lock and the children are seeked without it
+	s.stateMutex.Lock()
+	childTimes := make([]time.Duration, len(s.childTimes))
+	copy(childTimes, s.childTimes)
+	s.stateMutex.Unlock()
+
+	// Rewind the animations that are now in the future (as when looping or
+	// playing in reverse), last to first, so that earlier animations of the
+	// same property take precedence
+	for i := len(s.animations) - 1; i >= 0; i-- {
+		if localTimes[i] < 0 && childTimes[i] > 0 {
+			s.animations[i].seek(0, callbacks)
+			childTimes[i] = 0
+		}
+	}
+
+	for i, animation := range s.animations {
+		if localTimes[i] >= 0 {
+			animation.seek(localTimes[i], callbacks)
+			childTimes[i] = localTimes[i]
+		}
+	}
+
+	s.stateMutex.Lock()
+	copy(s.childTimes, childTimes)
+	s.stateMutex.Unlock()
+}
+
+func (s *Sequence) resetImpl() {
+	for _, animation := range s.animations {
+		animation.reset()
+	}
+
+	s.stateMutex.Lock()
+	for i := range s.childTimes {
+		s.childTimes[i] = -1
+	}
+	s.stateMutex.Unlock()
+}
+
+func (s *Sequence) Animations() []Animation {
+	animations := make([]Animation, len(s.animations))
+	copy(animations, s.animations)
+	return animations
+}
+
+func NewSequence(animations ...Animation) *Sequence {
+	s := &Sequence{
+		animations: animations,
+		childTimes: make([]time.Duration, len(animations)),
+	}
+	s.impl = s
+	s.loops = 1
+	s.resetImpl()
+	return s
+}
+
+/******************************************************************************
+ Parallel
+******************************************************************************/
+
+// Parallel An Animation that plays its animations at the same time, lasting
+// as long as the longest of them.
+type Parallel struct {
+	AnimationBase
+
+	animations []Animation
+}
+
+func (p *Parallel) duration() (duration time.Duration) {
+	for _, animation := range p.animations {
+		if total, finite := animation.TotalDuration(); finite {
+			duration = max(duration, total)
+		}
+	}
+	return
+}
+
+func (p *Parallel) finite() bool {
+	for _, animation := range p.animations {
+		if _, finite := animation.TotalDuration(); !finite {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Parallel) apply(passTime time.Duration, callbacks *[]func()) {
+	for _, animation := range p.animations {
+		animation.seek(passTime, callbacks)
+	}
+}
+
+func (p *Parallel) resetImpl() {
+	for _, animation := range p.animations {
+		animation.reset()
+	}
+}
+
+func (p *Parallel) Animations() []Animation {
+	animations := make([]Animation, len(p.animations))
+	copy(animations, p.animations)
+	return animations
+}
+
+func NewParallel(animations ...Animation) *Parallel {
+	p := &Parallel{
+		animations: animations,
+	}
+	p.impl = p
+	p.loops = 1
+	return p
+}
+
+/******************************************************************************
+ Animator
+******************************************************************************/
+
+// Animator A Service that plays animations (see Animation), advancing them
+// each time the window updates its objects by the time elapsed since the
+// previous update.  Animations are removed once finished, after calling
+// their completion callbacks.  Animations can be played, paused and stopped
+// from any goroutine, including from the setters and callbacks of other
+// animations.
+type Animator struct {
+	ServiceBase
+
+	playing []*playingAnimation
+	speed   float32
+
+	stateMutex sync.Mutex
+}
+
+type playingAnimation struct {
+	animation Animation
+	elapsed   time.Duration
+	paused    bool
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (a *Animator) Update(deltaTime int64) (ok bool) {
+	if !a.ServiceBase.Update(deltaTime) {
+		return false
+	}
+
+	// The animations are seeked without holding the lock, as doing so calls
+	// their setters, which may in turn use the animator
+	a.stateMutex.Lock()
+	elapsed := time.Duration(float64(deltaTime) * float64(a.speed) * float64(time.Microsecond))
+	playing := make([]*playingAnimation, len(a.playing))
+	positions := make([]time.Duration, len(a.playing))
+	for i, p := range a.playing {
+		if !p.paused {
+			p.elapsed += elapsed
+		}
+		playing[i], positions[i] = p, p.elapsed
+	}
+	a.stateMutex.Unlock()
+
+	var callbacks []func()
+	var finished map[*playingAnimation]time.Duration
+	for i, p := range playing {
+		if done := p.animation.seek(positions[i], &callbacks); done {
+			if finished == nil {
+				finished = make(map[*playingAnimation]time.Duration)
+			}
+			finished[p] = positions[i]
+		}
+	}
+
+	// Finished animations are kept if they have since been restarted (or
+	// moved) by Play()/Seek()
+	if finished != nil {
+		a.stateMutex.Lock()
+		remaining := a.playing[:0]
+		for _, p := range a.playing {
+			if position, ok := finished[p]; !ok || p.elapsed != position {
+				remaining = append(remaining, p)
+			}
+		}
+		for i := len(remaining); i < len(a.playing); i++ {
+			a.playing[i] = nil
+		}
+		a.playing = remaining
+		a.stateMutex.Unlock()
+	}
+
+	for _, callback := range callbacks {
+		callback()
+	}
+
+	return true
+}
+
+/******************************************************************************
+ Animator Functions
+******************************************************************************/
+
+func (a *Animator) find(animation Animation) *playingAnimation {
+	for _, p := range a.playing {
+		if p.animation == animation {
+			return p
+		}
+	}
+	return nil
+}
+
+// Play starts playing the given animation from the beginning, restarting it
+// if it is already playing.
+func (a *Animator) Play(animation Animation) *Animator {
+	animation.reset()
+
+	a.stateMutex.Lock()
+	if p := a.find(animation); p != nil {
+		p.elapsed = 0
+		p.paused = false
+	} else {
+		a.playing = append(a.playing, &playingAnimation{animation: animation})
+	}
+	a.stateMutex.Unlock()
+	return a
+}
+
+// Stop stops playing the given animation, leaving its properties as they
+// are and without calling its completion callbacks.
+func (a *Animator) Stop(animation Animation) *Animator {
+	a.stateMutex.Lock()
+	for i, p := range a.playing {
+		if p.animation == animation {
+			a.playing = append(a.playing[:i], a.playing[i+1:]...)
+			break
+		}
+	}
+	a.stateMutex.Unlock()
+	return a
+}
+
+func (a *Animator) StopAll() *Animator {
+	a.stateMutex.Lock()
+	a.playing = nil
+	a.stateMutex.Unlock()
+	return a
+}
+
+func (a *Animator) Pause(animation Animation) *Animator {
+	a.stateMutex.Lock()
+	if p := a.find(animation); p != nil {
+		p.paused = true
+	}
+	a.stateMutex.Unlock()
+	return a
+}
+
+func (a *Animator) Resume(animation Animation) *Animator {
+	a.stateMutex.Lock()
+	if p := a.find(animation); p != nil {
+		p.paused = false
+	}
+	a.stateMutex.Unlock()
+	return a
+}
+
+// Seek moves the given playing animation to the given time since it started
+// (including its delay), which is applied on the next update.
+func (a *Animator) Seek(animation Animation, t time.Duration) *Animator {
+	a.stateMutex.Lock()
+	if p := a.find(animation); p != nil {
+		p.elapsed = max(0, t)
+	}
+	a.stateMutex.Unlock()
+	return a
+}
+
+// Playing returns true if the given animation is being played (even if
+// paused).
+func (a *Animator) Playing(animation Animation) (playing bool) {
+	a.stateMutex.Lock()
+	playing = a.find(animation) != nil
+	a.stateMutex.Unlock()
+	return
+}
+
+func (a *Animator) Speed() (speed float32) {
+	a.stateMutex.Lock()
+	speed = a.speed
+	a.stateMutex.Unlock()
+	return
+}
+
+// SetSpeed sets the rate at which time passes for every animation, e.g.,
+// 0.5 to play them in slow motion.  Defaults to 1.
+func (a *Animator) SetSpeed(speed float32) *Animator {
+	a.stateMutex.Lock()
+	a.speed = max(0, speed)
+	a.stateMutex.Unlock()
+	return a
+}
+
+/******************************************************************************
+ New Animator Function
+******************************************************************************/
+
+func NewAnimator() *Animator {
+	a := &Animator{
+		speed: 1,
+	}
+
+	a.SetName(defaultAnimatorName)
+	a.SetEnabled(true)
+	return a
+}
@@ -1,5 +1,9 @@
 package gfx
 
+import (
+	"image"
+)
+
 /******************************************************************************
  Async Wrappers
 ******************************************************************************/
@@ -19,6 +23,11 @@ type asyncByteSliceInvocation struct {
 	ReturnChan chan *[]byte
 }
 
+type asyncImageInvocation struct {
+	Func       func() *image.RGBA
+	ReturnChan chan *image.RGBA
+}
+
 /******************************************************************************
  New Functions
 ******************************************************************************/
@@ -43,3 +52,10 @@ func newAsyncByteSliceInvocation(f func() []byte) *asyncByteSliceInvocation {
 		ReturnChan: make(chan *[]byte, 1),
 	}
 }
+
+func newAsyncImageInvocation(f func() *image.RGBA) *asyncImageInvocation {
+	return &asyncImageInvocation{
+		Func:       f,
+		ReturnChan: make(chan *image.RGBA, 1),
+	}
+}
@@ -5,6 +5,13 @@ import (
 	"sync"
 )
 
+const (
+	defaultOrthographicViewHeight = 10
+	defaultOrthographicDistance   = 100
+	defaultOrthographicNear       = 0.1
+	defaultOrthographicFar        = 1000
+)
+
 /******************************************************************************
  Camera
 ******************************************************************************/
@@ -33,6 +40,24 @@ type Camera interface {
 	ViewProjection() mgl32.Mat4
 }
 
+/******************************************************************************
+ LookAtCamera
+******************************************************************************/
+
+// LookAtCamera cameras are positioned by specifying the location of the
+// camera, the point it is looking at and its up direction, which allows
+// them to be moved around by the camera controllers (e.g.,
+// OrbitCameraController).
+type LookAtCamera interface {
+	Camera
+
+	// LookAt shall return the position, target and up vector of the camera.
+	LookAt() (position, target, up mgl32.Vec3)
+
+	// SetLookAt shall change the position, target and up vector of the camera.
+	SetLookAt(position, target, up mgl32.Vec3)
+}
+
 /******************************************************************************
  CameraBase
 ******************************************************************************/
@@ -136,6 +161,27 @@ func (c *BasicCamera) ViewProjection() (viewProj mgl32.Mat4) {
 	return
 }
 
+/******************************************************************************
+ LookAtCamera Implementation
+******************************************************************************/
+
+func (c *BasicCamera) LookAt() (position, target, up mgl32.Vec3) {
+	c.stateMutex.Lock()
+	position = c.Properties.Position.Vec3()
+	target = c.Properties.Target.Vec3()
+	up = c.Properties.Up.Vec3()
+	c.stateMutex.Unlock()
+	return
+}
+
+func (c *BasicCamera) SetLookAt(position, target, up mgl32.Vec3) {
+	c.stateMutex.Lock()
+	c.Properties.Position = position.Vec4(c.Properties.Position[3])
+	c.Properties.Target = target.Vec4(c.Properties.Target[3])
+	c.Properties.Up = up.Vec4(c.Properties.Up[3])
+	c.stateMutex.Unlock()
+}
+
 /******************************************************************************
  BasicCamera Functions
 ******************************************************************************/
@@ -167,3 +213,273 @@ func NewCamera() *BasicCamera {
 	c.SetProjection(45.0, 16.0/9.0, 0.1, 1000.0)
 	return c
 }
+
+/******************************************************************************
+ ViewPreset
+******************************************************************************/
+
+// ViewPreset Standard views of a scene (using a Y-up coordinate system),
+// as used in engineering drawings.
+type ViewPreset int
+
+const (
+	FrontView     ViewPreset = iota // looking along -Z
+	BackView                        // looking along +Z
+	TopView                         // looking along -Y, with -Z up
+	BottomView                      // looking along +Y, with +Z up
+	LeftView                        // looking along +X
+	RightView                       // looking along -X
+	IsometricView                   // looking along (-1, -1, -1), from the front-right-top
+)
+
+// direction Returns the direction from the target to the camera
+// and the camera's up vector for the preset.
+func (p ViewPreset) direction() (toCamera, up mgl32.Vec3) {
+	switch p {
+	case BackView:
+		return mgl32.Vec3{0, 0, -1}, mgl32.Vec3{0, 1, 0}
+	case TopView:
+		return mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 0, -1}
+	case BottomView:
+		return mgl32.Vec3{0, -1, 0}, mgl32.Vec3{0, 0, 1}
+	case LeftView:
+		return mgl32.Vec3{-1, 0, 0}, mgl32.Vec3{0, 1, 0}
+	case RightView:
+		return mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 1, 0}
+	case IsometricView:
+		return mgl32.Vec3{1, 1, 1}.Normalize(), mgl32.Vec3{0, 1, 0}
+	default:
+		return mgl32.Vec3{0, 0, 1}, mgl32.Vec3{0, 1, 0}
+	}
+}
+
+/******************************************************************************
+ OrthographicCamera
+******************************************************************************/
+
+// OrthographicCamera A camera using a parallel (orthographic) projection,
+// where objects appear the same size regardless of their distance from the
+// camera, as required for measurement-accurate drawings.  The view volume
+// is defined by its height (in world units) and its width is derived from
+// the aspect ratio, which is updated as the window is resized, so the
+// scale of the drawing is maintained.  The view properties are bound to
+// the BasicCamera uniform block, so it can be used with the default shaders.
+type OrthographicCamera struct {
+	CameraBase
+
+	viewHeight  float32
+	aspectRatio float32
+	zoom        float32
+	near, far   float32
+	projection  mgl32.Mat4
+
+	BasicCamera *BasicCameraProperties
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (c *OrthographicCamera) Update(_ int64) (ok bool) {
+	c.stateMutex.Lock()
+	c.BasicCamera.ViewProjMat = c.projection.Mul4(c.view())
+	c.stateMutex.Unlock()
+	return true
+}
+
+/******************************************************************************
+ Resizer Implementation
+******************************************************************************/
+
+func (c *OrthographicCamera) Resize(newWidth, newHeight int) {
+	if newWidth <= 0 || newHeight <= 0 {
+		return
+	}
+	c.stateMutex.Lock()
+	c.aspectRatio = float32(newWidth) / float32(newHeight)
+	c.updateProjection()
+	c.stateMutex.Unlock()
+}
+
+/******************************************************************************
+ Camera Implementation
+******************************************************************************/
+
+func (c *OrthographicCamera) Location() (loc mgl32.Vec4) {
+	c.stateMutex.Lock()
+	loc = c.BasicCamera.Position
+	c.stateMutex.Unlock()
+	return
+}
+
+func (c *OrthographicCamera) View() (view mgl32.Mat4) {
+	c.stateMutex.Lock()
+	view = c.view()
+	c.stateMutex.Unlock()
+	return
+}
+
+func (c *OrthographicCamera) Projection() (proj mgl32.Mat4) {
+	c.stateMutex.Lock()
+	proj = c.projection
+	c.stateMutex.Unlock()
+	return
+}
+
+func (c *OrthographicCamera) ViewProjection() (viewProj mgl32.Mat4) {
+	c.stateMutex.Lock()
+	viewProj = c.projection.Mul4(c.view())
+	c.stateMutex.Unlock()
+	return
+}
+
+/******************************************************************************
+ LookAtCamera Implementation
+******************************************************************************/
+
+func (c *OrthographicCamera) LookAt() (position, target, up mgl32.Vec3) {
+	c.stateMutex.Lock()
+	position = c.BasicCamera.Position.Vec3()
+	target = c.BasicCamera.Target.Vec3()
+	up = c.BasicCamera.Up.Vec3()
+	c.stateMutex.Unlock()
+	return
+}
+
+func (c *OrthographicCamera) SetLookAt(position, target, up mgl32.Vec3) {
+	c.stateMutex.Lock()
+	c.BasicCamera.Position = position.Vec4(c.BasicCamera.Position[3])
+	c.BasicCamera.Target = target.Vec4(c.BasicCamera.Target[3])
+	c.BasicCamera.Up = up.Vec4(c.BasicCamera.Up[3])
+	c.stateMutex.Unlock()
+}
+
+/******************************************************************************
+ OrthographicCamera Functions
+******************************************************************************/
+
+func (c *OrthographicCamera) view() mgl32.Mat4 {
+	return mgl32.LookAtV(c.BasicCamera.Position.Vec3(), c.BasicCamera.Target.Vec3(), c.BasicCamera.Up.Vec3())
+}
+
+func (c *OrthographicCamera) updateProjection() {
+	halfHeight := c.viewHeight / c.zoom / 2
+	halfWidth := halfHeight * c.aspectRatio
+	c.projection = mgl32.Ortho(-halfWidth, halfWidth, -halfHeight, halfHeight, c.near, c.far)
+}
+
+// scaleZoom Multiplies the zoom factor by the given amount, allowing the
+// OrbitCameraController to zoom (rather than dolly) orthographic cameras.
+func (c *OrthographicCamera) scaleZoom(factor float32) {
+	c.stateMutex.Lock()
+	c.zoom *= factor
+	c.updateProjection()
+	c.stateMutex.Unlock()
+}
+
+// SetViewVolume sets the height of the view volume (in world units, at a
+// zoom factor of 1) and the distances to its near/far planes, which, unlike
+// with perspective projections, may be negative.
+func (c *OrthographicCamera) SetViewVolume(height, near, far float32) *OrthographicCamera {
+	c.stateMutex.Lock()
+	c.viewHeight = height
+	c.near = near
+	c.far = far
+	c.updateProjection()
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *OrthographicCamera) ViewVolume() (height, near, far float32) {
+	c.stateMutex.Lock()
+	height, near, far = c.viewHeight, c.near, c.far
+	c.stateMutex.Unlock()
+	return
+}
+
+// ViewSize returns the width and height, in world units, of the region
+// currently visible to the camera, taking the zoom factor into account.
+func (c *OrthographicCamera) ViewSize() (width, height float32) {
+	c.stateMutex.Lock()
+	height = c.viewHeight / c.zoom
+	width = height * c.aspectRatio
+	c.stateMutex.Unlock()
+	return
+}
+
+func (c *OrthographicCamera) Zoom() (zoom float32) {
+	c.stateMutex.Lock()
+	zoom = c.zoom
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetZoom sets the zoom factor, where 2 makes objects appear twice as large.
+func (c *OrthographicCamera) SetZoom(zoom float32) *OrthographicCamera {
+	if zoom <= 0 {
+		return c
+	}
+	c.stateMutex.Lock()
+	c.zoom = zoom
+	c.updateProjection()
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *OrthographicCamera) AspectRatio() (ratio float32) {
+	c.stateMutex.Lock()
+	ratio = c.aspectRatio
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetAspectRatio sets the ratio of the width to the height of the view
+// volume, which is otherwise updated as the window is resized.
+func (c *OrthographicCamera) SetAspectRatio(ratio float32) *OrthographicCamera {
+	c.stateMutex.Lock()
+	c.aspectRatio = ratio
+	c.updateProjection()
+	c.stateMutex.Unlock()
+	return c
+}
+
+// SetViewPreset moves the camera to one of the standard views, keeping
+// its current target and distance from the target.
+func (c *OrthographicCamera) SetViewPreset(preset ViewPreset) *OrthographicCamera {
+	toCamera, up := preset.direction()
+
+	c.stateMutex.Lock()
+	target := c.BasicCamera.Target.Vec3()
+	distance := c.BasicCamera.Position.Vec3().Sub(target).Len()
+	if distance == 0 {
+		distance = defaultOrthographicDistance
+	}
+	c.BasicCamera.Position = target.Add(toCamera.Mul(distance)).Vec4(c.BasicCamera.Position[3])
+	c.BasicCamera.Up = up.Vec4(c.BasicCamera.Up[3])
+	c.stateMutex.Unlock()
+	return c
+}
+
+/******************************************************************************
+ New OrthographicCamera Function
+******************************************************************************/
+
+// NewOrthographicCamera creates a camera looking at the origin from the
+// front, with a view volume 10 units high.
+func NewOrthographicCamera() *OrthographicCamera {
+	c := &OrthographicCamera{
+		viewHeight:  defaultOrthographicViewHeight,
+		aspectRatio: 16.0 / 9.0,
+		zoom:        1,
+		near:        defaultOrthographicNear,
+		far:         defaultOrthographicFar,
+		BasicCamera: &BasicCameraProperties{
+			Position: mgl32.Vec4{0, 0, defaultOrthographicDistance},
+			Target:   mgl32.Vec4{0, 0, 0},
+			Up:       mgl32.Vec4{0, 1, 0},
+		},
+	}
+
+	c.updateProjection()
+	return c
+}
@@ -0,0 +1,187 @@
+package gfx
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	manualClockIdleInterval = 5 // milliseconds
+)
+
+/******************************************************************************
+ Clock
+******************************************************************************/
+
+// Clock Used by the main processing loop (see gfx.Run()) to determine
+// when the next frame should be processed and how much time has passed
+// since the previous one.  By default, the engine uses a RealTimeClock,
+// which derives that time from the system clock.  Use a ManualClock,
+// along with gfx.Step(), when deterministic, reproducible updates are
+// needed, such as when testing.
+type Clock interface {
+	// Start shall be called by the engine when the main processing
+	// loop begins, prior to the first call to Tick().
+	Start()
+
+	// Tick shall block until the next frame is due and then return the
+	// amount of time (in microseconds) that has passed since the last
+	// frame.  If ok is false, no frame is due and the engine will only
+	// process its queues/events before calling Tick() again.  Tick shall
+	// return early, with ok set to false, once the done channel is closed.
+	Tick(done <-chan struct{}) (deltaTime int64, ok bool)
+
+	// Stop shall be called by the engine when the main processing
+	// loop ends, after the last call to Tick().
+	Stop()
+}
+
+/******************************************************************************
+ RealTimeClock
+******************************************************************************/
+
+// RealTimeClock The default clock, which uses the system clock to
+// produce frames at an interval based on the target framerate but
+// limited by system performance.
+type RealTimeClock struct {
+	lastTick int64
+}
+
+func (c *RealTimeClock) Start() {
+	c.lastTick = time.Now().UnixMicro()
+}
+
+func (c *RealTimeClock) Tick(done <-chan struct{}) (deltaTime int64, ok bool) {
+	select {
+	case <-done:
+		return 0, false
+	default:
+	}
+
+	drawInterval := int64(1000000 / targetFramerate.Load())
+
+	deltaTime = time.Now().UnixMicro() - c.lastTick
+	if deltaTime < drawInterval {
+		// This is done to give more CPU time to other, higher
+		// priority tasks/processes.  Setting a lower target framerate
+		// will give them more time to work, while a higher value
+		// increases the potential rendering performance of the windows.
+		time.Sleep(time.Microsecond * time.Duration(drawInterval-deltaTime))
+		deltaTime = time.Now().UnixMicro() - c.lastTick
+	}
+	c.lastTick = time.Now().UnixMicro()
+
+	return deltaTime, true
+}
+
+func (c *RealTimeClock) Stop() {}
+
+/******************************************************************************
+ ManualClock
+******************************************************************************/
+
+type clockStep struct {
+	frames    int
+	deltaTime int64
+	doneChan  chan struct{}
+}
+
+// ManualClock A clock that will only produce frames when stepped, either
+// via Step() or gfx.Step(), and always with the given (fixed) delta time.
+// When not stepping, windows are not updated but the engine will continue
+// to initialize/close windows and poll for events.
+type ManualClock struct {
+	stepChan    chan *clockStep
+	currentStep *clockStep
+	stopChan    chan struct{}
+	stateMutex  sync.Mutex
+}
+
+func (c *ManualClock) Start() {
+	c.stateMutex.Lock()
+	if c.stopChan == nil {
+		c.stopChan = make(chan struct{})
+	}
+	c.stateMutex.Unlock()
+}
+
+func (c *ManualClock) Tick(done <-chan struct{}) (deltaTime int64, ok bool) {
+	// Calling Tick() again means the previous frame has been processed
+	if c.currentStep != nil {
+		if c.currentStep.frames > 0 {
+			c.currentStep.frames--
+			return c.currentStep.deltaTime, true
+		}
+		close(c.currentStep.doneChan)
+		c.currentStep = nil
+	}
+
+	select {
+	case <-done:
+		return 0, false
+	case step := <-c.stepChan:
+		if step.frames <= 0 {
+			close(step.doneChan)
+			return 0, false
+		}
+		step.frames--
+		c.currentStep = step
+		return step.deltaTime, true
+	case <-time.After(manualClockIdleInterval * time.Millisecond):
+		return 0, false
+	}
+}
+
+func (c *ManualClock) Stop() {
+	c.stateMutex.Lock()
+	if c.stopChan != nil {
+		close(c.stopChan)
+		c.stopChan = nil
+	}
+	c.currentStep = nil
+	c.stateMutex.Unlock()
+}
+
+// Step Process the given number of frames, each with the given delta
+// time (in microseconds), blocking until they have been processed.
+// Returns immediately if the main processing loop is not running.
+func (c *ManualClock) Step(frames int, deltaTime int64) {
+	c.stateMutex.Lock()
+	stopChan := c.stopChan
+	c.stateMutex.Unlock()
+
+	if stopChan == nil {
+		return
+	}
+
+	step := &clockStep{
+		frames:    frames,
+		deltaTime: deltaTime,
+		doneChan:  make(chan struct{}),
+	}
+
+	select {
+	case c.stepChan <- step:
+	case <-stopChan:
+		return
+	}
+
+	select {
+	case <-step.doneChan:
+	case <-stopChan:
+	}
+}
+
+/******************************************************************************
+ New Clock Functions
+******************************************************************************/
+
+func NewRealTimeClock() *RealTimeClock {
+	return &RealTimeClock{}
+}
+
+func NewManualClock() *ManualClock {
+	return &ManualClock{
+		stepChan: make(chan *clockStep),
+	}
+}
@@ -0,0 +1,822 @@
+package gfx
+
+import (
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+	"sync"
+)
+
+const (
+	defaultOrbitCameraControllerName       = "OrbitCameraController"
+	defaultFirstPersonCameraControllerName = "FirstPersonCameraController"
+
+	defaultOrbitRotateSpeed = math.Pi
+	defaultOrbitPanSpeed    = 0.5
+	defaultOrbitZoomSpeed   = 0.1
+	defaultOrbitDamping     = 10
+	defaultOrbitMinDistance = 0.01
+	defaultMinPitch         = -89
+	defaultMaxPitch         = 89
+
+	defaultFirstPersonMoveSpeed        = 5
+	defaultFirstPersonSprintMultiplier = 3
+	defaultFirstPersonLookSpeed        = math.Pi
+
+	untrackedMousePosition = -999
+	controllerEpsilon      = 1e-5
+)
+
+/******************************************************************************
+ orthographicZoomer
+******************************************************************************/
+
+// orthographicZoomer cameras are zoomed by scaling their view volume, as
+// moving an orthographic camera towards its target has no visible effect.
+type orthographicZoomer interface {
+	scaleZoom(factor float32)
+}
+
+/******************************************************************************
+ OrbitCameraController
+******************************************************************************/
+
+// OrbitCameraController A Service that moves a LookAtCamera (e.g., BasicCamera)
+// in response to the mouse of the window it is added to:
+//
+//	primary button + drag:   orbit the camera around its target
+//	secondary button + drag: pan the camera (and its target)
+//	scroll wheel:            dolly the camera towards/away from its target
+//
+// The same movements can also be requested programmatically (see Orbit(),
+// Pan() and Zoom()).  When inertia is enabled, movements are eased out
+// based on the damping factor, so the camera glides to a stop after the
+// mouse is released.  The orbit angles are measured in the frame of the
+// camera's up vector, with the yaw measured from the +Z axis.
+type OrbitCameraController struct {
+	ServiceBase
+
+	camera LookAtCamera
+
+	rotateSpeed float32
+	panSpeed    float32
+	zoomSpeed   float32
+	damping     float32
+	inertia     bool
+
+	minDistance, maxDistance float32
+	minPitch, maxPitch       float32
+	minYaw, maxYaw           float32
+
+	homePosition, homeTarget, homeUp mgl32.Vec3
+	homeSet                          bool
+	resetRequested                   bool
+
+	pendingYaw   float32
+	pendingPitch float32
+	pendingZoom  float32
+	pendingPan   mgl32.Vec2
+
+	lastMouse MouseState
+
+	stateMutex sync.Mutex
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (c *OrbitCameraController) Init() (ok bool) {
+	if c.Initialized() {
+		return true
+	}
+
+	c.stateMutex.Lock()
+	if !c.homeSet {
+		c.homePosition, c.homeTarget, c.homeUp = c.camera.LookAt()
+		c.homeSet = true
+	}
+	c.stateMutex.Unlock()
+
+	c.window.EnableMouseTracking()
+	c.lastMouse = *c.window.Mouse()
+
+	return c.ServiceBase.Init()
+}
+
+func (c *OrbitCameraController) Update(deltaTime int64) (ok bool) {
+	if !c.ServiceBase.Update(deltaTime) {
+		return false
+	}
+
+	mouse := c.window.Mouse()
+
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+
+	c.handleMouse(mouse)
+
+	if c.resetRequested {
+		c.resetRequested = false
+		c.clearPending()
+		c.camera.SetLookAt(c.homePosition, c.homeTarget, c.homeUp)
+		return true
+	}
+
+	portion := float32(1)
+	if c.inertia {
+		portion = 1 - float32(math.Exp(-float64(c.damping)*float64(deltaTime)/1_000_000))
+	}
+
+	yaw := takePortion(&c.pendingYaw, portion)
+	pitch := takePortion(&c.pendingPitch, portion)
+	zoom := takePortion(&c.pendingZoom, portion)
+	panX := takePortion(&c.pendingPan[0], portion)
+	panY := takePortion(&c.pendingPan[1], portion)
+
+	if yaw != 0 || pitch != 0 || zoom != 0 || panX != 0 || panY != 0 {
+		c.apply(yaw, pitch, zoom, mgl32.Vec2{panX, panY})
+	}
+
+	return true
+}
+
+/******************************************************************************
+ OrbitCameraController Functions
+******************************************************************************/
+
+func (c *OrbitCameraController) handleMouse(mouse *MouseState) {
+	last := c.lastMouse
+	c.lastMouse = *mouse
+
+	if mouse.X == untrackedMousePosition || last.X == untrackedMousePosition {
+		return
+	}
+
+	dx := mouse.X - last.X
+	dy := mouse.Y - last.Y
+
+	if mouse.PrimaryDown && last.PrimaryDown {
+		c.pendingYaw -= dx * c.rotateSpeed
+		c.pendingPitch -= dy * c.rotateSpeed
+	} else if mouse.SecondaryDown && last.SecondaryDown {
+		c.pendingPan = c.pendingPan.Add(mgl32.Vec2{dx, dy}.Mul(c.panSpeed))
+	}
+
+	if scroll := mouse.ScrollY - last.ScrollY; scroll != 0 {
+		c.pendingZoom -= scroll * c.zoomSpeed
+	}
+}
+
+func (c *OrbitCameraController) clearPending() {
+	c.pendingYaw = 0
+	c.pendingPitch = 0
+	c.pendingZoom = 0
+	c.pendingPan = mgl32.Vec2{}
+}
+
+// apply Moves the camera by the given (yaw/pitch) angles, in radians, the
+// given (logarithmic) change in distance and the given pan offset, which is
+// relative to the distance between the camera and its target.
+func (c *OrbitCameraController) apply(yaw, pitch, zoom float32, pan mgl32.Vec2) {
+	position, target, up := c.camera.LookAt()
+	frame := upFrame(up)
+
+	distance, curYaw, curPitch := toSpherical(position.Sub(target), frame)
+
+	curYaw = clampAngle(curYaw+yaw, c.minYaw, c.maxYaw)
+	curPitch = clampAngle(curPitch+pitch, c.minPitch, c.maxPitch)
+	if zoomer, ok := c.camera.(orthographicZoomer); ok {
+		if zoom != 0 {
+			zoomer.scaleZoom(float32(math.Exp(float64(-zoom))))
+		}
+	} else {
+		distance = mgl32.Clamp(distance*float32(math.Exp(float64(zoom))), c.minDistance, c.maxDistance)
+	}
+
+	offset := fromSpherical(distance, curYaw, curPitch, frame)
+
+	if pan[0] != 0 || pan[1] != 0 {
+		forward := offset.Mul(-1).Normalize()
+		right := forward.Cross(frame.Rotate(mgl32.Vec3{0, 1, 0})).Normalize()
+		camUp := right.Cross(forward)
+		target = target.Sub(right.Mul(pan[0] * distance)).Sub(camUp.Mul(pan[1] * distance))
+	}
+
+	c.camera.SetLookAt(target.Add(offset), target, up)
+}
+
+func (c *OrbitCameraController) Camera() LookAtCamera {
+	return c.camera
+}
+
+// Orbit rotates the camera around its target by the given yaw and pitch
+// angles, in degrees.
+func (c *OrbitCameraController) Orbit(yaw, pitch float32) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.pendingYaw += mgl32.DegToRad(yaw)
+	c.pendingPitch += mgl32.DegToRad(pitch)
+	c.stateMutex.Unlock()
+	return c
+}
+
+// Pan moves the camera and its target along the camera's right/up
+// vectors, by the given amounts relative to the distance between them.
+func (c *OrbitCameraController) Pan(x, y float32) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.pendingPan = c.pendingPan.Add(mgl32.Vec2{-x, -y})
+	c.stateMutex.Unlock()
+	return c
+}
+
+// Zoom dollies the camera towards (positive values) or away from (negative
+// values) its target, scaling the distance between them by e^-amount.  For
+// an OrthographicCamera, its zoom factor is scaled by e^amount instead.
+func (c *OrbitCameraController) Zoom(amount float32) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.pendingZoom -= amount
+	c.stateMutex.Unlock()
+	return c
+}
+
+// Reset returns the camera to its home position (see SetHome()).
+func (c *OrbitCameraController) Reset() *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.resetRequested = true
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *OrbitCameraController) Home() (position, target, up mgl32.Vec3) {
+	c.stateMutex.Lock()
+	position, target, up = c.homePosition, c.homeTarget, c.homeUp
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetHome sets the position, target and up vector the camera returns to
+// when Reset() is called.  Defaults to the state of the camera when the
+// controller is initialized.
+func (c *OrbitCameraController) SetHome(position, target, up mgl32.Vec3) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.homePosition, c.homeTarget, c.homeUp = position, target, up
+	c.homeSet = true
+	c.stateMutex.Unlock()
+	return c
+}
+
+// SaveHome sets the home position to the current state of the camera.
+func (c *OrbitCameraController) SaveHome() *OrbitCameraController {
+	position, target, up := c.camera.LookAt()
+	return c.SetHome(position, target, up)
+}
+
+func (c *OrbitCameraController) RotateSpeed() (speed float32) {
+	c.stateMutex.Lock()
+	speed = c.rotateSpeed
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetRotateSpeed sets the angle, in radians, the camera orbits when the
+// mouse is dragged across half the width/height of the window.
+func (c *OrbitCameraController) SetRotateSpeed(speed float32) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.rotateSpeed = speed
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *OrbitCameraController) PanSpeed() (speed float32) {
+	c.stateMutex.Lock()
+	speed = c.panSpeed
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetPanSpeed sets the distance the camera pans, relative to its distance
+// from the target, when the mouse is dragged across half the width/height
+// of the window.
+func (c *OrbitCameraController) SetPanSpeed(speed float32) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.panSpeed = speed
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *OrbitCameraController) ZoomSpeed() (speed float32) {
+	c.stateMutex.Lock()
+	speed = c.zoomSpeed
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetZoomSpeed sets the amount passed to Zoom() for each step of the
+// scroll wheel.
+func (c *OrbitCameraController) SetZoomSpeed(speed float32) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.zoomSpeed = speed
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *OrbitCameraController) Inertia() (enabled bool) {
+	c.stateMutex.Lock()
+	enabled = c.inertia
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetInertia enables/disables the easing out of movements.  Enabled by
+// default.
+func (c *OrbitCameraController) SetInertia(enabled bool) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.inertia = enabled
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *OrbitCameraController) Damping() (damping float32) {
+	c.stateMutex.Lock()
+	damping = c.damping
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetDamping sets the rate (per second) at which movements come to a stop
+// when inertia is enabled; lower values result in more of a glide.
+func (c *OrbitCameraController) SetDamping(damping float32) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.damping = damping
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *OrbitCameraController) DistanceLimits() (min, max float32) {
+	c.stateMutex.Lock()
+	min, max = c.minDistance, c.maxDistance
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetDistanceLimits limits how close to/far from its target the camera
+// can be moved.
+func (c *OrbitCameraController) SetDistanceLimits(min, max float32) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.minDistance, c.maxDistance = min, max
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *OrbitCameraController) PitchLimits() (min, max float32) {
+	c.stateMutex.Lock()
+	min, max = mgl32.RadToDeg(c.minPitch), mgl32.RadToDeg(c.maxPitch)
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetPitchLimits limits the elevation of the camera, in degrees, where 0
+// is level with the target and 90 is directly above it.  Defaults to -89
+// and 89 degrees, as the camera cannot look straight along its up vector.
+func (c *OrbitCameraController) SetPitchLimits(min, max float32) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.minPitch = mgl32.DegToRad(mgl32.Clamp(min, defaultMinPitch, defaultMaxPitch))
+	c.maxPitch = mgl32.DegToRad(mgl32.Clamp(max, defaultMinPitch, defaultMaxPitch))
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *OrbitCameraController) YawLimits() (min, max float32) {
+	c.stateMutex.Lock()
+	min, max = mgl32.RadToDeg(c.minYaw), mgl32.RadToDeg(c.maxYaw)
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetYawLimits limits the azimuth of the camera, in degrees, measured
+// from the +Z axis in the range -180 to 180.  Not limited by default.
+func (c *OrbitCameraController) SetYawLimits(min, max float32) *OrbitCameraController {
+	c.stateMutex.Lock()
+	c.minYaw, c.maxYaw = mgl32.DegToRad(min), mgl32.DegToRad(max)
+	c.stateMutex.Unlock()
+	return c
+}
+
+/******************************************************************************
+ New OrbitCameraController Function
+******************************************************************************/
+
+// NewOrbitCameraController creates a controller for the given camera,
+// which must be added to the window (along with the camera) via AddService().
+func NewOrbitCameraController(camera LookAtCamera) *OrbitCameraController {
+	if camera == nil {
+		panic("camera cannot be nil")
+	}
+
+	c := &OrbitCameraController{
+		camera:      camera,
+		rotateSpeed: defaultOrbitRotateSpeed,
+		panSpeed:    defaultOrbitPanSpeed,
+		zoomSpeed:   defaultOrbitZoomSpeed,
+		damping:     defaultOrbitDamping,
+		inertia:     true,
+		minDistance: defaultOrbitMinDistance,
+		maxDistance: math.MaxFloat32,
+		minPitch:    mgl32.DegToRad(defaultMinPitch),
+		maxPitch:    mgl32.DegToRad(defaultMaxPitch),
+		minYaw:      -math.MaxFloat32,
+		maxYaw:      math.MaxFloat32,
+		lastMouse:   MouseState{X: untrackedMousePosition, Y: untrackedMousePosition},
+	}
+
+	c.SetName(defaultOrbitCameraControllerName)
+	c.enabled.Store(true)
+	return c
+}
+
+/******************************************************************************
+ FirstPersonCameraController
+******************************************************************************/
+
+// FirstPersonCameraController A Service that moves a LookAtCamera (e.g.,
+// BasicCamera) through the scene using the keyboard and mouse of the window
+// it is added to:
+//
+//	W/S:          move forward/backward, in the direction the camera is looking
+//	A/D:          move left/right
+//	Q/E:          move down/up, along the camera's up vector
+//	Left Shift:   move faster (see SetSprintMultiplier())
+//	mouse + drag: look around (or without dragging, if the cursor is captured)
+type FirstPersonCameraController struct {
+	ServiceBase
+
+	camera LookAtCamera
+
+	moveSpeed        float32
+	sprintMultiplier float32
+	lookSpeed        float32
+	minPitch         float32
+	maxPitch         float32
+
+	captureCursor   bool
+	cursorCaptured  bool
+	keysDown        map[glfw.Key]bool
+	lastMouse       MouseState
+	pendingYaw      float32
+	pendingPitch    float32
+	pendingMovement mgl32.Vec3
+
+	stateMutex sync.Mutex
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (c *FirstPersonCameraController) Init() (ok bool) {
+	if c.Initialized() {
+		return true
+	}
+
+	c.window.EnableMouseTracking()
+	c.lastMouse = *c.window.Mouse()
+
+	for _, key := range []glfw.Key{glfw.KeyW, glfw.KeyA, glfw.KeyS, glfw.KeyD, glfw.KeyQ, glfw.KeyE, glfw.KeyLeftShift} {
+		c.window.AddKeyEventHandler(c, key, glfw.Press, c.keyEventHandler)
+		c.window.AddKeyEventHandler(c, key, glfw.Release, c.keyEventHandler)
+	}
+
+	return c.ServiceBase.Init()
+}
+
+func (c *FirstPersonCameraController) Update(deltaTime int64) (ok bool) {
+	if !c.ServiceBase.Update(deltaTime) {
+		return false
+	}
+
+	mouse := c.window.Mouse()
+
+	c.stateMutex.Lock()
+	defer c.stateMutex.Unlock()
+
+	c.updateCursorMode()
+	c.handleMouse(mouse)
+
+	seconds := float32(deltaTime) / 1_000_000
+	movement := c.pendingMovement.Add(c.keyboardDirection().Mul(c.currentSpeed() * seconds))
+	c.pendingMovement = mgl32.Vec3{}
+
+	if c.pendingYaw != 0 || c.pendingPitch != 0 || movement.Len() > controllerEpsilon {
+		c.apply(c.pendingYaw, c.pendingPitch, movement)
+		c.pendingYaw = 0
+		c.pendingPitch = 0
+	}
+
+	return true
+}
+
+func (c *FirstPersonCameraController) Close() {
+	if !c.Initialized() {
+		return
+	}
+
+	c.window.RemoveKeyEventHandlers(c)
+
+	c.stateMutex.Lock()
+	if c.cursorCaptured {
+		c.window.glwin.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+		c.cursorCaptured = false
+	}
+	c.stateMutex.Unlock()
+
+	c.ServiceBase.Close()
+}
+
+/******************************************************************************
+ FirstPersonCameraController Functions
+******************************************************************************/
+
+func (c *FirstPersonCameraController) keyEventHandler(_ *Window, key glfw.Key, action glfw.Action) {
+	c.stateMutex.Lock()
+	c.keysDown[key] = action == glfw.Press
+	c.stateMutex.Unlock()
+}
+
+func (c *FirstPersonCameraController) updateCursorMode() {
+	if c.captureCursor == c.cursorCaptured {
+		return
+	}
+
+	if c.captureCursor {
+		c.window.glwin.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+	} else {
+		c.window.glwin.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	}
+	c.cursorCaptured = c.captureCursor
+	c.lastMouse.X = untrackedMousePosition // the cursor will jump
+}
+
+func (c *FirstPersonCameraController) handleMouse(mouse *MouseState) {
+	last := c.lastMouse
+	c.lastMouse = *mouse
+
+	if mouse.X == untrackedMousePosition || last.X == untrackedMousePosition {
+		return
+	}
+
+	if c.cursorCaptured || (mouse.PrimaryDown && last.PrimaryDown) {
+		c.pendingYaw -= (mouse.X - last.X) * c.lookSpeed
+		c.pendingPitch += (mouse.Y - last.Y) * c.lookSpeed
+	}
+}
+
+// keyboardDirection Returns the direction of movement requested via the
+// keyboard, relative to the camera (x = right, y = up, z = forward).
+func (c *FirstPersonCameraController) keyboardDirection() (dir mgl32.Vec3) {
+	if c.keysDown[glfw.KeyD] {
+		dir[0]++
+	}
+	if c.keysDown[glfw.KeyA] {
+		dir[0]--
+	}
+	if c.keysDown[glfw.KeyE] {
+		dir[1]++
+	}
+	if c.keysDown[glfw.KeyQ] {
+		dir[1]--
+	}
+	if c.keysDown[glfw.KeyW] {
+		dir[2]++
+	}
+	if c.keysDown[glfw.KeyS] {
+		dir[2]--
+	}
+	if dir.Len() > 0 {
+		dir = dir.Normalize()
+	}
+	return
+}
+
+func (c *FirstPersonCameraController) currentSpeed() float32 {
+	if c.keysDown[glfw.KeyLeftShift] {
+		return c.moveSpeed * c.sprintMultiplier
+	}
+	return c.moveSpeed
+}
+
+// apply Turns the camera by the given (yaw/pitch) angles, in radians, and
+// then moves it (and its target) by the given amounts, relative to the
+// direction it is facing (x = right, y = up, z = forward).
+func (c *FirstPersonCameraController) apply(yaw, pitch float32, movement mgl32.Vec3) {
+	position, target, up := c.camera.LookAt()
+	frame := upFrame(up)
+
+	lookDistance, curYaw, curPitch := toSpherical(target.Sub(position), frame)
+	if lookDistance < controllerEpsilon {
+		lookDistance = 1
+	}
+
+	curYaw += yaw
+	curPitch = clampAngle(curPitch+pitch, c.minPitch, c.maxPitch)
+	forward := fromSpherical(1, curYaw, curPitch, frame)
+
+	worldUp := frame.Rotate(mgl32.Vec3{0, 1, 0})
+	right := forward.Cross(worldUp).Normalize()
+
+	position = position.
+		Add(right.Mul(movement[0])).
+		Add(worldUp.Mul(movement[1])).
+		Add(forward.Mul(movement[2]))
+
+	c.camera.SetLookAt(position, position.Add(forward.Mul(lookDistance)), up)
+}
+
+func (c *FirstPersonCameraController) Camera() LookAtCamera {
+	return c.camera
+}
+
+// Move moves the camera by the given amounts, relative to the direction it
+// is facing (x = right, y = up, z = forward).
+func (c *FirstPersonCameraController) Move(movement mgl32.Vec3) *FirstPersonCameraController {
+	c.stateMutex.Lock()
+	c.pendingMovement = c.pendingMovement.Add(movement)
+	c.stateMutex.Unlock()
+	return c
+}
+
+// Look turns the camera by the given yaw and pitch angles, in degrees.
+func (c *FirstPersonCameraController) Look(yaw, pitch float32) *FirstPersonCameraController {
+	c.stateMutex.Lock()
+	c.pendingYaw += mgl32.DegToRad(yaw)
+	c.pendingPitch += mgl32.DegToRad(pitch)
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *FirstPersonCameraController) MoveSpeed() (speed float32) {
+	c.stateMutex.Lock()
+	speed = c.moveSpeed
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetMoveSpeed sets the speed of the camera, in world units per second.
+func (c *FirstPersonCameraController) SetMoveSpeed(speed float32) *FirstPersonCameraController {
+	c.stateMutex.Lock()
+	c.moveSpeed = speed
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *FirstPersonCameraController) SprintMultiplier() (multiplier float32) {
+	c.stateMutex.Lock()
+	multiplier = c.sprintMultiplier
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetSprintMultiplier sets the factor by which the speed of the camera is
+// multiplied while the Left Shift key is held down.
+func (c *FirstPersonCameraController) SetSprintMultiplier(multiplier float32) *FirstPersonCameraController {
+	c.stateMutex.Lock()
+	c.sprintMultiplier = multiplier
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *FirstPersonCameraController) LookSpeed() (speed float32) {
+	c.stateMutex.Lock()
+	speed = c.lookSpeed
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetLookSpeed sets the angle, in radians, the camera turns when the mouse
+// is moved across half the width/height of the window.
+func (c *FirstPersonCameraController) SetLookSpeed(speed float32) *FirstPersonCameraController {
+	c.stateMutex.Lock()
+	c.lookSpeed = speed
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *FirstPersonCameraController) PitchLimits() (min, max float32) {
+	c.stateMutex.Lock()
+	min, max = mgl32.RadToDeg(c.minPitch), mgl32.RadToDeg(c.maxPitch)
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetPitchLimits limits how far down/up the camera can look, in degrees.
+// Defaults to -89 and 89 degrees.
+func (c *FirstPersonCameraController) SetPitchLimits(min, max float32) *FirstPersonCameraController {
+	c.stateMutex.Lock()
+	c.minPitch = mgl32.DegToRad(mgl32.Clamp(min, defaultMinPitch, defaultMaxPitch))
+	c.maxPitch = mgl32.DegToRad(mgl32.Clamp(max, defaultMinPitch, defaultMaxPitch))
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *FirstPersonCameraController) CaptureCursor() (enabled bool) {
+	c.stateMutex.Lock()
+	enabled = c.captureCursor
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetCaptureCursor hides the cursor and locks it to the window, allowing
+// the camera to be turned by moving the mouse without holding down a
+// button, as is typical in first-person games.  Disabled by default.
+func (c *FirstPersonCameraController) SetCaptureCursor(enabled bool) *FirstPersonCameraController {
+	c.stateMutex.Lock()
+	c.captureCursor = enabled
+	c.stateMutex.Unlock()
+	return c
+}
+
+/******************************************************************************
+ New FirstPersonCameraController Function
+******************************************************************************/
+
+// NewFirstPersonCameraController creates a controller for the given camera,
+// which must be added to the window (along with the camera) via AddService().
+func NewFirstPersonCameraController(camera LookAtCamera) *FirstPersonCameraController {
+	if camera == nil {
+		panic("camera cannot be nil")
+	}
+
+	c := &FirstPersonCameraController{
+		camera:           camera,
+		moveSpeed:        defaultFirstPersonMoveSpeed,
+		sprintMultiplier: defaultFirstPersonSprintMultiplier,
+		lookSpeed:        defaultFirstPersonLookSpeed,
+		minPitch:         mgl32.DegToRad(defaultMinPitch),
+		maxPitch:         mgl32.DegToRad(defaultMaxPitch),
+		keysDown:         make(map[glfw.Key]bool),
+		lastMouse:        MouseState{X: untrackedMousePosition, Y: untrackedMousePosition},
+	}
+
+	c.SetName(defaultFirstPersonCameraControllerName)
+	c.enabled.Store(true)
+	return c
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+// upFrame Returns the rotation from a Y-up frame to one using the given up
+// vector, in which the spherical coordinates used by the controllers are
+// measured.
+func upFrame(up mgl32.Vec3) mgl32.Quat {
+	if up.Len() < controllerEpsilon {
+		return mgl32.QuatIdent()
+	}
+	return mgl32.QuatBetweenVectors(mgl32.Vec3{0, 1, 0}, up.Normalize())
+}
+
+// toSpherical Returns the length, yaw (azimuth, from +Z) and pitch
+// (elevation) of the given vector, in the given frame.
+func toSpherical(v mgl32.Vec3, frame mgl32.Quat) (length, yaw, pitch float32) {
+	local := frame.Inverse().Rotate(v)
+	length = local.Len()
+	if length < controllerEpsilon {
+		return
+	}
+	yaw = float32(math.Atan2(float64(local.X()), float64(local.Z())))
+	pitch = float32(math.Asin(float64(mgl32.Clamp(local.Y()/length, -1, 1))))
+	return
+}
+
+func fromSpherical(length, yaw, pitch float32, frame mgl32.Quat) mgl32.Vec3 {
+	cosPitch := float32(math.Cos(float64(pitch)))
+	local := mgl32.Vec3{
+		length * cosPitch * float32(math.Sin(float64(yaw))),
+		length * float32(math.Sin(float64(pitch))),
+		length * cosPitch * float32(math.Cos(float64(yaw))),
+	}
+	return frame.Rotate(local)
+}
+
+func clampAngle(angle, min, max float32) float32 {
+	if min <= -math.Pi && max >= math.Pi {
+		return angle
+	}
+	return mgl32.Clamp(angle, min, max)
+}
+
+// takePortion Removes and returns the given portion of the pending value,
+// which is zeroed once it becomes negligible.
+func takePortion(pending *float32, portion float32) float32 {
+	if *pending == 0 {
+		return 0
+	}
+	if float32(math.Abs(float64(*pending))) < controllerEpsilon {
+		value := *pending
+		*pending = 0
+		return value
+	}
+	value := *pending * portion
+	*pending -= value
+	return value
+}
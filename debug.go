@@ -0,0 +1,412 @@
+package gfx
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"image/color"
+)
+
+const (
+	defaultDebugNormalScale = 0.05
+	defaultDebugDepthBias   = 0.0005
+	debugGizmoSize          = 10
+	debugGizmoAxisLength    = debugGizmoSize / 2
+)
+
+var (
+	DebugWireframeColor    = White
+	DebugVertexNormalColor = color.RGBA{G: 255, B: 255, A: 255}
+	DebugFaceNormalColor   = Magenta
+	DebugTangentColor      = Red
+	DebugBitangentColor    = Green
+	DebugNormalColor       = Blue
+	DebugBoundsColor       = Yellow
+	DebugGizmoGridColor    = DarkGray
+	DebugGizmoAxisXColor   = Red
+	DebugGizmoAxisYColor   = Green
+	DebugGizmoAxisZColor   = Blue
+)
+
+var (
+	debugBoundsEdges = [12][2]int{{0, 1}, {1, 3}, {3, 2}, {2, 0}, {4, 5}, {5, 7}, {7, 6}, {6, 4}, {0, 4}, {1, 5}, {2, 6}, {3, 7}}
+)
+
+/******************************************************************************
+ DebugMode
+******************************************************************************/
+
+// DebugMode Flags specifying which debug visualizations are rendered over a
+// Shape3D, which can be combined (e.g., DebugWireframe | DebugBounds).
+type DebugMode uint32
+
+const (
+	// DebugWireframe Renders the edges of every face of the model.
+	DebugWireframe DebugMode = 1 << iota
+
+	// DebugVertexNormals Renders the normal of each vertex of every face,
+	// as a line starting at the vertex.
+	DebugVertexNormals
+
+	// DebugFaceNormals Renders the geometric normal of every face, as a line
+	// starting at its center, showing which side of the face is its front.
+	DebugFaceNormals
+
+	// DebugTangentFrames Renders the tangent (red), bitangent (green) and
+	// normal (blue) of each vertex of every face, for models that supply
+	// tangents, as used by normal mapping.
+	DebugTangentFrames
+
+	// DebugBounds Renders the axis-aligned box bounding each mesh, in the
+	// local space of the mesh.
+	DebugBounds
+
+	// DebugGizmo Renders the world axes (X in red, Y in green and Z in
+	// blue) and a grid on the XZ plane, centered on the world origin.
+	DebugGizmo
+
+	DebugNone DebugMode = 0
+	DebugAll            = DebugWireframe | DebugVertexNormals | DebugFaceNormals | DebugTangentFrames | DebugBounds | DebugGizmo
+)
+
+/******************************************************************************
+ debugLineBuffer
+******************************************************************************/
+
+// debugLineBuffer Holds the lines rendered for one or more debug modes, each
+// mode occupying a contiguous range of the buffer.
+type debugLineBuffer struct {
+	worldMat func() mgl32.Mat4
+	vao      uint32
+	vbo      uint32
+	ranges   map[DebugMode][2]int32 // first vertex, vertex count
+}
+
+func (b *debugLineBuffer) draw(mode DebugMode, worldMatLoc int32) {
+	worldMat := b.worldMat()
+	gl.UniformMatrix4fv(worldMatLoc, 1, false, &worldMat[0])
+
+	gl.BindVertexArray(b.vao)
+	for m, r := range b.ranges {
+		if mode&m != 0 && r[1] > 0 {
+			gl.DrawArrays(gl.LINES, r[0], r[1])
+		}
+	}
+}
+
+func (b *debugLineBuffer) close() {
+	gl.BindVertexArray(0)
+	gl.DeleteVertexArrays(1, &b.vao)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.DeleteBuffers(1, &b.vbo)
+}
+
+func newDebugLineBuffer(shader Shader, builder *debugLineBuilder, worldMat func() mgl32.Mat4) *debugLineBuffer {
+	b := &debugLineBuffer{
+		worldMat: worldMat,
+		ranges:   builder.ranges,
+	}
+
+	gl.GenVertexArrays(1, &b.vao)
+	gl.GenBuffers(1, &b.vbo)
+
+	gl.BindVertexArray(b.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.vbo)
+	if len(builder.vertices) > 0 {
+		gl.BufferData(gl.ARRAY_BUFFER, len(builder.vertices)*sizeOfFloat32, gl.Ptr(builder.vertices), gl.STATIC_DRAW)
+	}
+
+	initLineVertexAttribs(shader)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+
+	return b
+}
+
+/******************************************************************************
+ debugLineBuilder
+******************************************************************************/
+
+type debugLineBuilder struct {
+	vertices []float32
+	ranges   map[DebugMode][2]int32
+	mode     DebugMode
+	first    int32
+}
+
+func (b *debugLineBuilder) begin(mode DebugMode) {
+	b.mode = mode
+	b.first = int32(len(b.vertices) / lineVertexSize)
+}
+
+func (b *debugLineBuilder) line(from, to mgl32.Vec3, rgba color.RGBA) {
+	c := RgbaToFloatArray(rgba)
+	b.vertices = append(b.vertices,
+		from[0], from[1], from[2], c[0], c[1], c[2], c[3],
+		to[0], to[1], to[2], c[0], c[1], c[2], c[3])
+}
+
+func (b *debugLineBuilder) end() {
+	b.ranges[b.mode] = [2]int32{b.first, int32(len(b.vertices)/lineVertexSize) - b.first}
+}
+
+func newDebugLineBuilder() *debugLineBuilder {
+	return &debugLineBuilder{
+		ranges: make(map[DebugMode][2]int32),
+	}
+}
+
+/******************************************************************************
+ debugRenderer
+******************************************************************************/
+
+// debugRenderer Renders the debug modes of a Shape3D, building the lines of
+// each mesh the first time they are needed from the vertex data of the model
+// (in its bind pose, for a SkinnedModel).
+type debugRenderer struct {
+	shader         Shader
+	worldMatLoc    int32
+	viewProjMatLoc int32
+	depthBiasLoc   int32
+
+	instance     *modelInstance
+	normalLength float32
+	meshes       []*debugLineBuffer
+	gizmo        *debugLineBuffer
+}
+
+func (r *debugRenderer) render(mode DebugMode, camera Camera, instance *modelInstance, normalLength float32) {
+	if instance != r.instance || normalLength != r.normalLength {
+		r.closeMeshes()
+		r.instance = instance
+		r.normalLength = normalLength
+	}
+
+	if r.meshes == nil && mode&^DebugGizmo != 0 {
+		r.initMeshes()
+	}
+
+	if r.gizmo == nil && mode&DebugGizmo != 0 {
+		r.initGizmo()
+	}
+
+	viewProjMat := camera.ViewProjection()
+
+	gl.DepthFunc(gl.LEQUAL)
+
+	r.shader.Activate()
+	gl.UniformMatrix4fv(r.viewProjMatLoc, 1, false, &viewProjMat[0])
+	gl.Uniform1f(r.depthBiasLoc, defaultDebugDepthBias)
+
+	for _, mesh := range r.meshes {
+		mesh.draw(mode, r.worldMatLoc)
+	}
+
+	if mode&DebugGizmo != 0 {
+		gl.Uniform1f(r.depthBiasLoc, 0)
+		r.gizmo.draw(mode, r.worldMatLoc)
+	}
+
+	gl.DepthFunc(gl.LESS)
+}
+
+func (r *debugRenderer) initMeshes() {
+	model := r.instance.model
+	meshes := model.Meshes()
+
+	normalLength := r.normalLength
+	if normalLength <= 0 {
+		normalLength = r.autoNormalLength()
+	}
+
+	r.meshes = make([]*debugLineBuffer, len(r.instance.meshes))
+	for i, meshInst := range r.instance.meshes {
+		builder := newDebugLineBuilder()
+		buildDebugMeshLines(builder, model, meshes[i], meshInst, normalLength)
+		r.meshes[i] = newDebugLineBuffer(r.shader, builder, meshInst.WorldMatrix)
+	}
+}
+
+// autoNormalLength Returns the length of the normals when not specified,
+// relative to the size of the largest mesh of the model.
+func (r *debugRenderer) autoNormalLength() float32 {
+	size := float32(0)
+	for _, mesh := range r.instance.meshes {
+		boundsMin, boundsMax := mesh.LocalBounds()
+		size = max(size, boundsMax.Sub(boundsMin).Len())
+	}
+	if size <= 0 {
+		return 1
+	}
+	return size * defaultDebugNormalScale
+}
+
+func (r *debugRenderer) initGizmo() {
+	builder := newDebugLineBuilder()
+	builder.begin(DebugGizmo)
+
+	// The same lines as a GridFloor and Axes, with the axes drawn over the
+	// center lines of the grid (as the depth test passes for equal depths)
+	buildGridLines(builder, debugGizmoSize, debugGizmoSize, DebugGizmoGridColor, DebugGizmoGridColor)
+	buildAxesLines(builder, debugGizmoAxisLength, DebugGizmoAxisXColor, DebugGizmoAxisYColor, DebugGizmoAxisZColor)
+
+	builder.end()
+	r.gizmo = newDebugLineBuffer(r.shader, builder, mgl32.Ident4)
+}
+
+func (r *debugRenderer) closeMeshes() {
+	for _, mesh := range r.meshes {
+		mesh.close()
+	}
+	r.meshes = nil
+}
+
+func (r *debugRenderer) close() {
+	r.closeMeshes()
+	if r.gizmo != nil {
+		r.gizmo.close()
+		r.gizmo = nil
+	}
+	r.instance = nil
+}
+
+func newDebugRenderer(shader Shader) *debugRenderer {
+	return &debugRenderer{
+		shader:         shader,
+		worldMatLoc:    shader.GetUniformLocation("u_WorldMat"),
+		viewProjMatLoc: shader.GetUniformLocation("u_ViewProjMat"),
+		depthBiasLoc:   shader.GetUniformLocation("u_DepthBias"),
+	}
+}
+
+/******************************************************************************
+ Debug Line Functions
+******************************************************************************/
+
+// buildDebugMeshLines Adds the lines of every debug mode (other than the
+// gizmo) for the given mesh, in the local space of the mesh.
+func buildDebugMeshLines(builder *debugLineBuilder, model Model, mesh Mesh, meshInst *meshInstance, normalLength float32) {
+	positions := model.Vertices()
+	normals := model.Normals()
+	tangents := model.Tangents()
+	bitangents := model.Bitangents()
+	faces := mesh.Faces()
+
+	vec3 := func(values []float32, index int) mgl32.Vec3 {
+		if index < 0 || index*3+2 >= len(values) {
+			return mgl32.Vec3{}
+		}
+		return mgl32.Vec3{values[index*3], values[index*3+1], values[index*3+2]}
+	}
+
+	facePositions := func(face Face) []mgl32.Vec3 {
+		indices := face.VertexIndices()
+		vertices := make([]mgl32.Vec3, len(indices))
+		for i, index := range indices {
+			vertices[i] = vec3(positions, index)
+		}
+		return vertices
+	}
+
+	// hasIndices Returns true if the given attribute indices can be
+	// paired with the vertex indices of the face.
+	hasIndices := func(values []float32, indices []int, face Face) bool {
+		return len(values) > 0 && len(indices) == len(face.VertexIndices())
+	}
+
+	builder.begin(DebugWireframe)
+	for _, face := range faces {
+		vertices := facePositions(face)
+		for i := range vertices {
+			builder.line(vertices[i], vertices[(i+1)%len(vertices)], DebugWireframeColor)
+		}
+	}
+	builder.end()
+
+	builder.begin(DebugVertexNormals)
+	for _, face := range faces {
+		normalIndices := face.NormalIndices()
+		if !hasIndices(normals, normalIndices, face) {
+			continue
+		}
+		for i, vertex := range facePositions(face) {
+			normal := vec3(normals, normalIndices[i])
+			builder.line(vertex, vertex.Add(normal.Normalize().Mul(normalLength)), DebugVertexNormalColor)
+		}
+	}
+	builder.end()
+
+	builder.begin(DebugFaceNormals)
+	for _, face := range faces {
+		vertices := facePositions(face)
+		if len(vertices) < 3 {
+			continue
+		}
+		center := mgl32.Vec3{}
+		for _, vertex := range vertices {
+			center = center.Add(vertex)
+		}
+		center = center.Mul(1 / float32(len(vertices)))
+		normal := vertices[1].Sub(vertices[0]).Cross(vertices[2].Sub(vertices[0]))
+		if normal.Len() == 0 {
+			continue
+		}
+		builder.line(center, center.Add(normal.Normalize().Mul(normalLength)), DebugFaceNormalColor)
+	}
+	builder.end()
+
+	builder.begin(DebugTangentFrames)
+	for _, face := range faces {
+		tangentIndices := face.TangentIndices()
+		normalIndices := face.NormalIndices()
+		if !hasIndices(tangents, tangentIndices, face) || !hasIndices(normals, normalIndices, face) {
+			continue
+		}
+		bitangentIndices := face.BitangentIndices()
+		for i, vertex := range facePositions(face) {
+			tangent := vec3(tangents, tangentIndices[i]).Normalize()
+			normal := vec3(normals, normalIndices[i]).Normalize()
+			bitangent := normal.Cross(tangent)
+			if hasIndices(bitangents, bitangentIndices, face) {
+				bitangent = vec3(bitangents, bitangentIndices[i]).Normalize()
+			}
+			builder.line(vertex, vertex.Add(tangent.Mul(normalLength)), DebugTangentColor)
+			builder.line(vertex, vertex.Add(bitangent.Mul(normalLength)), DebugBitangentColor)
+			builder.line(vertex, vertex.Add(normal.Mul(normalLength)), DebugNormalColor)
+		}
+	}
+	builder.end()
+
+	builder.begin(DebugBounds)
+	boundsMin, boundsMax := meshInst.LocalBounds()
+	if boundsMin[0] <= boundsMax[0] {
+		var corners [8]mgl32.Vec3
+		for i := range corners {
+			for axis := 0; axis < 3; axis++ {
+				if i&(1<<axis) != 0 {
+					corners[i][axis] = boundsMax[axis]
+				} else {
+					corners[i][axis] = boundsMin[axis]
+				}
+			}
+		}
+		for _, edge := range debugBoundsEdges {
+			builder.line(corners[edge[0]], corners[edge[1]], DebugBoundsColor)
+		}
+	}
+	builder.end()
+}
+
+/******************************************************************************
+ debugKeyBinding
+******************************************************************************/
+
+// debugKeyBinding Toggles the debug modes of a Shape3D when its key is
+// pressed, also serving as the receiver of the window's key event handler.
+type debugKeyBinding struct {
+	key   glfw.Key
+	mode  DebugMode
+	added bool
+}
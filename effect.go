@@ -0,0 +1,750 @@
+package gfx
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/tonybillings/gfx/shaders"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+)
+
+const (
+	defaultBloomEffectName        = "BloomEffect"
+	defaultFxaaEffectName         = "FxaaEffect"
+	defaultColorGradingEffectName = "ColorGradingEffect"
+	defaultVignetteEffectName     = "VignetteEffect"
+	defaultToneMappingEffectName  = "ToneMappingEffect"
+)
+
+const (
+	defaultBloomThreshold  = 0.8
+	defaultBloomIntensity  = 1.0
+	defaultBloomBlurPasses = 4
+
+	defaultVignetteIntensity = 0.5
+	defaultVignetteRadius    = 1.0
+	defaultVignetteSoftness  = 0.5
+
+	defaultToneMappingExposure = 1.0
+	defaultToneMappingGamma    = 2.2
+)
+
+/******************************************************************************
+ BloomEffect
+******************************************************************************/
+
+// BloomEffect Makes the bright parts of the frame glow, by extracting the
+// parts brighter than the threshold (at half the resolution of the frame),
+// blurring them and adding them back onto the frame.  As the frame is
+// rendered with a floating-point color buffer, colors brighter than white
+// (e.g., from emissive materials) will glow more than white itself.
+type BloomEffect struct {
+	EffectBase
+
+	threshold  float32
+	intensity  float32
+	blurPasses int
+
+	extractShader Shader
+	blurShader    Shader
+	combineShader Shader
+
+	extractUniforms effectUniforms
+	combineUniforms effectUniforms
+	thresholdLoc    int32
+	blurMapLoc      int32
+	directionLoc    int32
+	bloomMapLoc     int32
+	intensityLoc    int32
+
+	frameBuffers [2]uint32
+	textures     [2]uint32
+	width        int32
+	height       int32
+
+	stateMutex sync.Mutex
+}
+
+func (e *BloomEffect) Init() (ok bool) {
+	if e.Initialized() {
+		return true
+	}
+
+	assets := e.window.Assets()
+	e.extractShader = assets.Get(BloomExtractShader).(Shader)
+	e.blurShader = assets.Get(BloomBlurShader).(Shader)
+	e.combineShader = assets.Get(BloomCombineShader).(Shader)
+
+	e.extractUniforms.init(e.extractShader)
+	e.thresholdLoc = e.extractShader.GetUniformLocation("u_Threshold")
+	e.blurMapLoc = e.blurShader.GetUniformLocation("u_SceneMap")
+	e.directionLoc = e.blurShader.GetUniformLocation("u_Direction")
+	e.combineUniforms.init(e.combineShader)
+	e.bloomMapLoc = e.combineShader.GetUniformLocation("u_BloomMap")
+	e.intensityLoc = e.combineShader.GetUniformLocation("u_Intensity")
+
+	return e.EffectBase.Init()
+}
+
+func (e *BloomEffect) Close() {
+	if !e.Initialized() {
+		return
+	}
+
+	e.closeFrameBuffers()
+	e.EffectBase.Close()
+}
+
+func (e *BloomEffect) apply(frame *effectFrame) {
+	e.stateMutex.Lock()
+	threshold := e.threshold
+	intensity := e.intensity
+	blurPasses := e.blurPasses
+	e.stateMutex.Unlock()
+
+	width, height := max(frame.width/2, 1), max(frame.height/2, 1)
+	if width != e.width || height != e.height {
+		e.closeFrameBuffers()
+		e.initFrameBuffers(width, height)
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, e.frameBuffers[0])
+	gl.Viewport(0, 0, width, height)
+	e.extractShader.Activate()
+	e.extractUniforms.set(frame)
+	gl.Uniform1f(e.thresholdLoc, threshold)
+	frame.draw()
+
+	e.blurShader.Activate()
+	gl.Uniform1i(e.blurMapLoc, effectSceneMapTextureUnit)
+	gl.ActiveTexture(gl.TEXTURE0 + effectSceneMapTextureUnit)
+	for i := 0; i < blurPasses; i++ {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, e.frameBuffers[1])
+		gl.BindTexture(gl.TEXTURE_2D, e.textures[0])
+		gl.Uniform2f(e.directionLoc, 1/float32(width), 0)
+		frame.draw()
+
+		gl.BindFramebuffer(gl.FRAMEBUFFER, e.frameBuffers[0])
+		gl.BindTexture(gl.TEXTURE_2D, e.textures[1])
+		gl.Uniform2f(e.directionLoc, 0, 1/float32(height))
+		frame.draw()
+	}
+
+	frame.bindOutput()
+	e.combineShader.Activate()
+	e.combineUniforms.set(frame)
+	gl.ActiveTexture(gl.TEXTURE0 + effectReservedTextureUnits)
+	gl.BindTexture(gl.TEXTURE_2D, e.textures[0])
+	gl.Uniform1i(e.bloomMapLoc, effectReservedTextureUnits)
+	gl.Uniform1f(e.intensityLoc, intensity)
+	frame.draw()
+}
+
+func (e *BloomEffect) initFrameBuffers(width, height int32) {
+	for i := range e.frameBuffers {
+		e.frameBuffers[i], e.textures[i] = newEffectFrameBuffer(width, height)
+	}
+	e.width = width
+	e.height = height
+}
+
+func (e *BloomEffect) closeFrameBuffers() {
+	if e.frameBuffers[0] == 0 {
+		return
+	}
+
+	gl.DeleteFramebuffers(2, &e.frameBuffers[0])
+	gl.DeleteTextures(2, &e.textures[0])
+	e.frameBuffers = [2]uint32{}
+	e.textures = [2]uint32{}
+	e.width = 0
+	e.height = 0
+}
+
+func (e *BloomEffect) Threshold() (threshold float32) {
+	e.stateMutex.Lock()
+	threshold = e.threshold
+	e.stateMutex.Unlock()
+	return
+}
+
+// SetThreshold sets the brightness (the maximum of the red, green and blue
+// channels) above which parts of the frame glow.
+func (e *BloomEffect) SetThreshold(threshold float32) *BloomEffect {
+	e.stateMutex.Lock()
+	e.threshold = threshold
+	e.stateMutex.Unlock()
+	return e
+}
+
+func (e *BloomEffect) Intensity() (intensity float32) {
+	e.stateMutex.Lock()
+	intensity = e.intensity
+	e.stateMutex.Unlock()
+	return
+}
+
+func (e *BloomEffect) SetIntensity(intensity float32) *BloomEffect {
+	e.stateMutex.Lock()
+	e.intensity = max(intensity, 0)
+	e.stateMutex.Unlock()
+	return e
+}
+
+func (e *BloomEffect) BlurPasses() (passes int) {
+	e.stateMutex.Lock()
+	passes = e.blurPasses
+	e.stateMutex.Unlock()
+	return
+}
+
+// SetBlurPasses sets how many times the bright parts of the frame are
+// blurred (horizontally, then vertically), with more passes spreading the
+// glow further.
+func (e *BloomEffect) SetBlurPasses(passes int) *BloomEffect {
+	e.stateMutex.Lock()
+	e.blurPasses = max(passes, 0)
+	e.stateMutex.Unlock()
+	return e
+}
+
+func NewBloomEffect() *BloomEffect {
+	e := &BloomEffect{
+		threshold:  defaultBloomThreshold,
+		intensity:  defaultBloomIntensity,
+		blurPasses: defaultBloomBlurPasses,
+	}
+
+	e.SetName(defaultBloomEffectName)
+	e.SetEnabled(true)
+	return e
+}
+
+/******************************************************************************
+ FxaaEffect
+******************************************************************************/
+
+// FxaaEffect Smooths the jagged edges of the frame using fast approximate
+// anti-aliasing, which is much cheaper than multisampling but also blurs
+// fine details (such as text) slightly; it should be applied after effects
+// that change the colors of the frame, such as ToneMappingEffect.
+type FxaaEffect struct {
+	EffectBase
+
+	shader   Shader
+	uniforms effectUniforms
+}
+
+func (e *FxaaEffect) Init() (ok bool) {
+	if e.Initialized() {
+		return true
+	}
+
+	e.shader = e.window.Assets().Get(FxaaShader).(Shader)
+	e.uniforms.init(e.shader)
+
+	return e.EffectBase.Init()
+}
+
+func (e *FxaaEffect) apply(frame *effectFrame) {
+	e.shader.Activate()
+	e.uniforms.set(frame)
+	frame.draw()
+}
+
+func NewFxaaEffect() *FxaaEffect {
+	e := &FxaaEffect{}
+	e.SetName(defaultFxaaEffectName)
+	e.SetEnabled(true)
+	return e
+}
+
+/******************************************************************************
+ ColorGradingEffect
+******************************************************************************/
+
+// ColorGradingEffect Remaps the colors of the frame using a lookup table
+// (LUT), given as a texture containing a horizontal strip of N slices, each
+// N by N pixels (e.g., 256x16 or 1024x32), where the blue channel of the
+// original color selects the slice, red increases to the right within each
+// slice and green increases toward the top.  A neutral LUT, which leaves the
+// colors unchanged and can be edited with any image editor to create a
+// grade, can be created with NewNeutralLut().  The texture should use linear
+// filtering without mipmaps (e.g., NewTextureConfig(LowQuality, Clamp)).
+type ColorGradingEffect struct {
+	EffectBase
+
+	lut       Texture
+	intensity float32
+
+	shader       Shader
+	uniforms     effectUniforms
+	lutMapLoc    int32
+	lutSizeLoc   int32
+	intensityLoc int32
+
+	stateMutex sync.Mutex
+}
+
+func (e *ColorGradingEffect) Init() (ok bool) {
+	if e.Initialized() {
+		return true
+	}
+
+	e.shader = e.window.Assets().Get(ColorGradingShader).(Shader)
+	e.uniforms.init(e.shader)
+	e.lutMapLoc = e.shader.GetUniformLocation("u_LutMap")
+	e.lutSizeLoc = e.shader.GetUniformLocation("u_LutSize")
+	e.intensityLoc = e.shader.GetUniformLocation("u_Intensity")
+
+	return e.EffectBase.Init()
+}
+
+func (e *ColorGradingEffect) apply(frame *effectFrame) {
+	e.stateMutex.Lock()
+	lut := e.lut
+	intensity := e.intensity
+	e.stateMutex.Unlock()
+
+	e.shader.Activate()
+	e.uniforms.set(frame)
+
+	if lut == nil {
+		intensity = 0
+	} else {
+		if !lut.Initialized() {
+			lut.Init()
+		}
+		gl.ActiveTexture(gl.TEXTURE0 + effectReservedTextureUnits)
+		gl.BindTexture(gl.TEXTURE_2D, lut.GlName())
+		gl.Uniform1f(e.lutSizeLoc, float32(lut.Height()))
+	}
+
+	gl.Uniform1i(e.lutMapLoc, effectReservedTextureUnits)
+	gl.Uniform1f(e.intensityLoc, intensity)
+	frame.draw()
+}
+
+func (e *ColorGradingEffect) Lut() (lut Texture) {
+	e.stateMutex.Lock()
+	lut = e.lut
+	e.stateMutex.Unlock()
+	return
+}
+
+// SetLut sets the lookup table used to remap the colors of the frame, which
+// will be initialized when first used if it has not been already.
+func (e *ColorGradingEffect) SetLut(lut Texture) *ColorGradingEffect {
+	e.stateMutex.Lock()
+	e.lut = lut
+	e.stateMutex.Unlock()
+	return e
+}
+
+func (e *ColorGradingEffect) Intensity() (intensity float32) {
+	e.stateMutex.Lock()
+	intensity = e.intensity
+	e.stateMutex.Unlock()
+	return
+}
+
+// SetIntensity sets how much of the graded color is used, from 0 (the
+// original color) to 1 (the graded color).
+func (e *ColorGradingEffect) SetIntensity(intensity float32) *ColorGradingEffect {
+	e.stateMutex.Lock()
+	e.intensity = mgl32.Clamp(intensity, 0, 1)
+	e.stateMutex.Unlock()
+	return e
+}
+
+func NewColorGradingEffect(lut Texture) *ColorGradingEffect {
+	e := &ColorGradingEffect{
+		lut:       lut,
+		intensity: 1,
+	}
+
+	e.SetName(defaultColorGradingEffectName)
+	e.SetEnabled(true)
+	return e
+}
+
+// NewNeutralLut creates the image of a lookup table, as expected by
+// ColorGradingEffect, that leaves colors unchanged, with the given number of
+// slices (e.g., 16 or 32).
+func NewNeutralLut(size int) *image.NRGBA {
+	size = max(size, 2)
+	lut := image.NewNRGBA(image.Rect(0, 0, size*size, size))
+	level := func(i int) uint8 {
+		return uint8(math.Round(float64(i) * 255 / float64(size-1)))
+	}
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size*size; x++ {
+			lut.SetNRGBA(x, y, color.NRGBA{
+				R: level(x % size),
+				G: level(size - 1 - y), // images are flipped when loaded as textures
+				B: level(x / size),
+				A: 255,
+			})
+		}
+	}
+
+	return lut
+}
+
+/******************************************************************************
+ VignetteEffect
+******************************************************************************/
+
+// VignetteEffect Darkens (or tints, with a color other than black) the
+// edges of the frame, blending from the frame's color at the given radius
+// minus the softness to the vignette's color at the radius, with the
+// distance from the center of the frame being 1.0 at its corners.
+type VignetteEffect struct {
+	EffectBase
+
+	color     mgl32.Vec4
+	intensity float32
+	radius    float32
+	softness  float32
+
+	shader       Shader
+	uniforms     effectUniforms
+	colorLoc     int32
+	intensityLoc int32
+	radiusLoc    int32
+	softnessLoc  int32
+
+	stateMutex sync.Mutex
+}
+
+func (e *VignetteEffect) Init() (ok bool) {
+	if e.Initialized() {
+		return true
+	}
+
+	e.shader = e.window.Assets().Get(VignetteShader).(Shader)
+	e.uniforms.init(e.shader)
+	e.colorLoc = e.shader.GetUniformLocation("u_Color")
+	e.intensityLoc = e.shader.GetUniformLocation("u_Intensity")
+	e.radiusLoc = e.shader.GetUniformLocation("u_Radius")
+	e.softnessLoc = e.shader.GetUniformLocation("u_Softness")
+
+	return e.EffectBase.Init()
+}
+
+func (e *VignetteEffect) apply(frame *effectFrame) {
+	e.shader.Activate()
+	e.uniforms.set(frame)
+
+	e.stateMutex.Lock()
+	gl.Uniform4fv(e.colorLoc, 1, &e.color[0])
+	gl.Uniform1f(e.intensityLoc, e.intensity)
+	gl.Uniform1f(e.radiusLoc, e.radius)
+	gl.Uniform1f(e.softnessLoc, e.softness)
+	e.stateMutex.Unlock()
+
+	frame.draw()
+}
+
+func (e *VignetteEffect) Color() (rgba color.RGBA) {
+	e.stateMutex.Lock()
+	rgba = FloatArrayToRgba(e.color)
+	e.stateMutex.Unlock()
+	return
+}
+
+func (e *VignetteEffect) SetColor(rgba color.RGBA) *VignetteEffect {
+	e.stateMutex.Lock()
+	e.color = RgbaToFloatArray(rgba)
+	e.stateMutex.Unlock()
+	return e
+}
+
+func (e *VignetteEffect) Intensity() (intensity float32) {
+	e.stateMutex.Lock()
+	intensity = e.intensity
+	e.stateMutex.Unlock()
+	return
+}
+
+// SetIntensity sets how much of the vignette's color is used outside the
+// radius, from 0 (none) to 1 (fully opaque).
+func (e *VignetteEffect) SetIntensity(intensity float32) *VignetteEffect {
+	e.stateMutex.Lock()
+	e.intensity = mgl32.Clamp(intensity, 0, 1)
+	e.stateMutex.Unlock()
+	return e
+}
+
+func (e *VignetteEffect) Radius() (radius float32) {
+	e.stateMutex.Lock()
+	radius = e.radius
+	e.stateMutex.Unlock()
+	return
+}
+
+func (e *VignetteEffect) SetRadius(radius float32) *VignetteEffect {
+	e.stateMutex.Lock()
+	e.radius = max(radius, 0)
+	e.stateMutex.Unlock()
+	return e
+}
+
+func (e *VignetteEffect) Softness() (softness float32) {
+	e.stateMutex.Lock()
+	softness = e.softness
+	e.stateMutex.Unlock()
+	return
+}
+
+func (e *VignetteEffect) SetSoftness(softness float32) *VignetteEffect {
+	e.stateMutex.Lock()
+	e.softness = max(softness, 0.001)
+	e.stateMutex.Unlock()
+	return e
+}
+
+func NewVignetteEffect() *VignetteEffect {
+	e := &VignetteEffect{
+		color:     RgbaToFloatArray(Black),
+		intensity: defaultVignetteIntensity,
+		radius:    defaultVignetteRadius,
+		softness:  defaultVignetteSoftness,
+	}
+
+	e.SetName(defaultVignetteEffectName)
+	e.SetEnabled(true)
+	return e
+}
+
+/******************************************************************************
+ ToneMappingOperator
+******************************************************************************/
+
+type ToneMappingOperator int32
+
+const (
+	// NoToneMapping Colors are only scaled by the exposure, with those
+	// brighter than white being clipped.
+	NoToneMapping ToneMappingOperator = iota
+
+	// ReinhardToneMapping Colors are mapped with the Reinhard operator,
+	// c / (c + 1), which never quite reaches white.
+	ReinhardToneMapping
+
+	// AcesToneMapping Colors are mapped with an approximation of the ACES
+	// filmic curve, which adds contrast and saturation.
+	AcesToneMapping
+)
+
+/******************************************************************************
+ ToneMappingEffect
+******************************************************************************/
+
+// ToneMappingEffect Maps the colors of the frame, which can be brighter than
+// white (e.g., after applying BloomEffect), into the displayable range, after
+// scaling them by the exposure, then applies gamma correction.  The frame is
+// assumed to have been encoded with a gamma of 2.2, as done by the default 3D
+// shaders, so it is decoded before being mapped; with a gamma of 2.2, no
+// tone mapping and an exposure of 1, the frame is unchanged.
+type ToneMappingEffect struct {
+	EffectBase
+
+	operator ToneMappingOperator
+	exposure float32
+	gamma    float32
+
+	shader      Shader
+	uniforms    effectUniforms
+	operatorLoc int32
+	exposureLoc int32
+	gammaLoc    int32
+
+	stateMutex sync.Mutex
+}
+
+func (e *ToneMappingEffect) Init() (ok bool) {
+	if e.Initialized() {
+		return true
+	}
+
+	e.shader = e.window.Assets().Get(ToneMappingShader).(Shader)
+	e.uniforms.init(e.shader)
+	e.operatorLoc = e.shader.GetUniformLocation("u_Operator")
+	e.exposureLoc = e.shader.GetUniformLocation("u_Exposure")
+	e.gammaLoc = e.shader.GetUniformLocation("u_Gamma")
+
+	return e.EffectBase.Init()
+}
+
+func (e *ToneMappingEffect) apply(frame *effectFrame) {
+	e.shader.Activate()
+	e.uniforms.set(frame)
+
+	e.stateMutex.Lock()
+	gl.Uniform1i(e.operatorLoc, int32(e.operator))
+	gl.Uniform1f(e.exposureLoc, e.exposure)
+	gl.Uniform1f(e.gammaLoc, e.gamma)
+	e.stateMutex.Unlock()
+
+	frame.draw()
+}
+
+func (e *ToneMappingEffect) Operator() (operator ToneMappingOperator) {
+	e.stateMutex.Lock()
+	operator = e.operator
+	e.stateMutex.Unlock()
+	return
+}
+
+func (e *ToneMappingEffect) SetOperator(operator ToneMappingOperator) *ToneMappingEffect {
+	e.stateMutex.Lock()
+	e.operator = operator
+	e.stateMutex.Unlock()
+	return e
+}
+
+func (e *ToneMappingEffect) Exposure() (exposure float32) {
+	e.stateMutex.Lock()
+	exposure = e.exposure
+	e.stateMutex.Unlock()
+	return
+}
+
+func (e *ToneMappingEffect) SetExposure(exposure float32) *ToneMappingEffect {
+	e.stateMutex.Lock()
+	e.exposure = max(exposure, 0)
+	e.stateMutex.Unlock()
+	return e
+}
+
+func (e *ToneMappingEffect) Gamma() (gamma float32) {
+	e.stateMutex.Lock()
+	gamma = e.gamma
+	e.stateMutex.Unlock()
+	return
+}
+
+// SetGamma sets the gamma used to encode the mapped colors, with values
+// above 2.2 brightening the frame and values below it darkening the frame.
+func (e *ToneMappingEffect) SetGamma(gamma float32) *ToneMappingEffect {
+	e.stateMutex.Lock()
+	e.gamma = max(gamma, 0.01)
+	e.stateMutex.Unlock()
+	return e
+}
+
+func NewToneMappingEffect(operator ToneMappingOperator) *ToneMappingEffect {
+	e := &ToneMappingEffect{
+		operator: operator,
+		exposure: defaultToneMappingExposure,
+		gamma:    defaultToneMappingGamma,
+	}
+
+	e.SetName(defaultToneMappingEffectName)
+	e.SetEnabled(true)
+	return e
+}
+
+/******************************************************************************
+ ShaderEffect
+******************************************************************************/
+
+// ShaderEffect A custom effect rendered with a user-defined fragment shader,
+// which is given the UV coordinates of the frame and the uniforms shared by
+// all effects:
+//
+//	#version 410 core
+//
+//	in vec2 UV;
+//	out vec4 FragColor;
+//
+//	uniform sampler2D u_SceneMap;    // output of the previous effect
+//	uniform sampler2D u_OriginalMap; // frame as rendered, before any effect
+//	uniform vec2 u_Resolution;       // size of the frame, in pixels
+//	uniform vec2 u_TexelSize;        // 1.0 / u_Resolution
+//	uniform float u_Time;            // seconds since effects were first applied
+//
+// Any of these can be omitted.  Additional uniforms can be set by giving
+// the effect a shader-bindable struct (see ShaderBinding), whose textures
+// are bound to the texture units following those used by the two maps.
+type ShaderEffect struct {
+	EffectBase
+
+	shader      *BasicShader
+	uniforms    effectUniforms
+	boundStruct any
+	binding     *ShaderBinding
+}
+
+func (e *ShaderEffect) Init() (ok bool) {
+	if e.Initialized() {
+		return true
+	}
+
+	e.shader.SetSourceLibrary(e.window.Assets())
+	if !e.shader.Init() {
+		return false
+	}
+	e.uniforms.init(e.shader)
+
+	if e.boundStruct != nil {
+		e.binding = NewShaderBinding(e.shader, e.boundStruct, nil)
+		e.binding.textureCount = effectReservedTextureUnits
+		e.binding.Init()
+	}
+
+	return e.EffectBase.Init()
+}
+
+func (e *ShaderEffect) Close() {
+	if !e.Initialized() {
+		return
+	}
+
+	if e.binding != nil {
+		e.binding.Close()
+		e.binding = nil
+	}
+	e.shader.Close()
+
+	e.EffectBase.Close()
+}
+
+func (e *ShaderEffect) apply(frame *effectFrame) {
+	e.shader.Activate()
+	e.uniforms.set(frame)
+	if e.binding != nil {
+		e.binding.Update(0)
+	}
+	frame.draw()
+}
+
+func (e *ShaderEffect) Shader() Shader {
+	return e.shader
+}
+
+// NewShaderEffect creates an effect from the given fragment shader source,
+// which can be the GLSL code itself or the name of a file containing it
+// (looked up in the window's asset library first), along with an optional
+// shader-bindable struct.
+func NewShaderEffect[T ShaderSource](name string, fragmentShaderSource T, boundStruct ...any) *ShaderEffect {
+	vsSource, err := shaders.Assets.ReadFile("texture_vert.glsl")
+	if err != nil {
+		panic(err)
+	}
+
+	e := &ShaderEffect{
+		shader: NewBasicShader(name, T(vsSource), fragmentShaderSource),
+	}
+	if len(boundStruct) > 0 {
+		e.boundStruct = boundStruct[0]
+	}
+
+	e.SetName(name)
+	e.SetEnabled(true)
+	return e
+}
@@ -36,6 +36,12 @@ func NewCubeView(window *gfx.Window) gfx.WindowObject {
 	// generate them automatically.
 	model.ComputeTangents(true)
 
+	// Materials that use the MTL PBR extension (Pr/Pm/map_Pr/map_Pm) are
+	// rendered with gfx.PbrShader as an obj.PbrMaterial.  The exporter used
+	// for cube.mtl writes (meaningless) Pr/Pm values, so we'll stick with
+	// obj.BasicMaterial for this example.
+	model.SetPbrEnabled(false)
+
 	// The default shader that the obj package uses is gfx.Shape3DShader.
 	// That shader fully supports obj.BasicMaterial. This is how you could
 	// change it to the "no lights" Shape3D shader:
@@ -0,0 +1,315 @@
+// Package gfxtest provides golden-image regression testing for scenes
+// rendered with gfx.  A scene is rendered to a Window, the full framebuffer
+// is read back and compared against a previously approved ("golden") PNG,
+// allowing for small per-channel differences and a maximum ratio of
+// differing pixels.  On failure, the actual image and a diff image are
+// written next to the golden images for inspection.
+//
+// Run tests with the -update flag (or set the GFX_UPDATE_GOLDEN environment
+// variable) to (re)generate the golden images:
+//
+//	go test -v shape_golden_test.go -update
+package gfxtest
+
+import (
+	"flag"
+	"fmt"
+	"github.com/tonybillings/gfx"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	defaultGoldenDir        = "testdata/golden"
+	defaultChannelTolerance = 2
+	defaultMaxDiffRatio     = 0.001
+	goldenFileExt           = ".png"
+	actualFileSuffix        = "_actual.png"
+	diffFileSuffix          = "_diff.png"
+	goldenDirPerm           = 0755
+)
+
+const (
+	// UpdateFlag is the name of the command-line flag that causes golden
+	// images to be (re)written instead of compared against.
+	UpdateFlag = "update"
+
+	// UpdateEnvVar is the environment variable that, when set to a true
+	// value (see strconv.ParseBool()), causes golden images to be
+	// (re)written instead of compared against.
+	UpdateEnvVar = "GFX_UPDATE_GOLDEN"
+)
+
+var (
+	update atomic.Bool
+)
+
+var (
+	diffColor  = color.RGBA{R: 255, A: 255}
+	matchAlpha = uint8(64)
+)
+
+func init() {
+	// A flag with the same name may have been registered by another
+	// package, in which case that flag is honored instead
+	if flag.Lookup(UpdateFlag) == nil {
+		flag.Bool(UpdateFlag, false, "regenerate golden images instead of comparing against them")
+	}
+}
+
+/******************************************************************************
+ Options
+******************************************************************************/
+
+type Options struct {
+	// GoldenDir is the directory containing the golden images, which
+	// is also where actual/diff images are written on failure.
+	GoldenDir string
+
+	// ChannelTolerance is the maximum absolute difference allowed for
+	// any color channel (R, G, B, or A) before a pixel is considered
+	// to be different.
+	ChannelTolerance uint8
+
+	// MaxDiffRatio is the maximum ratio of differing pixels to total
+	// pixels (0.0 - 1.0) for a comparison to pass.
+	MaxDiffRatio float64
+
+	// Update causes the golden image to be (re)written, regardless of
+	// SetUpdate(), the -update flag or the GFX_UPDATE_GOLDEN environment
+	// variable.
+	Update bool
+}
+
+func DefaultOptions() Options {
+	return Options{
+		GoldenDir:        defaultGoldenDir,
+		ChannelTolerance: defaultChannelTolerance,
+		MaxDiffRatio:     defaultMaxDiffRatio,
+	}
+}
+
+/******************************************************************************
+ Result
+******************************************************************************/
+
+type Result struct {
+	DiffPixels  int
+	TotalPixels int
+	DiffRatio   float64
+	MaxDiff     uint8
+	Passed      bool
+
+	// Diff is a faded copy of the expected image with the
+	// pixels that differ highlighted in red.
+	Diff *image.RGBA
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%d of %d pixels differ (ratio %.6f, max channel difference %d)",
+		r.DiffPixels, r.TotalPixels, r.DiffRatio, r.MaxDiff)
+}
+
+/******************************************************************************
+ Functions
+******************************************************************************/
+
+// SetUpdate sets whether golden images are (re)written instead of
+// compared against, for all subsequent matches.  Golden images are
+// also updated when the -update flag is given or the GFX_UPDATE_GOLDEN
+// environment variable is set.
+func SetUpdate(enabled bool) {
+	update.Store(enabled)
+}
+
+// Updating returns true if golden images are being (re)written instead
+// of compared against, via SetUpdate(), the -update flag or the
+// GFX_UPDATE_GOLDEN environment variable.
+func Updating() bool {
+	if update.Load() {
+		return true
+	}
+	if f := flag.Lookup(UpdateFlag); f != nil {
+		if enabled, err := strconv.ParseBool(f.Value.String()); err == nil && enabled {
+			return true
+		}
+	}
+	enabled, err := strconv.ParseBool(os.Getenv(UpdateEnvVar))
+	return err == nil && enabled
+}
+
+// Compare compares the actual image with the expected one, pixel by pixel,
+// using the tolerance and ratio specified in the given options.  Images of
+// different sizes never pass and produce no diff image.
+func Compare(actual, expected image.Image, opts Options) *Result {
+	result := &Result{}
+
+	if actual.Bounds().Size() != expected.Bounds().Size() {
+		return result
+	}
+
+	width := expected.Bounds().Dx()
+	height := expected.Bounds().Dy()
+	result.TotalPixels = width * height
+	result.Diff = image.NewRGBA(image.Rect(0, 0, width, height))
+
+	aMin := actual.Bounds().Min
+	eMin := expected.Bounds().Min
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			a := color.RGBAModel.Convert(actual.At(aMin.X+x, aMin.Y+y)).(color.RGBA)
+			e := color.RGBAModel.Convert(expected.At(eMin.X+x, eMin.Y+y)).(color.RGBA)
+
+			d := maxChannelDiff(a, e)
+			if d > result.MaxDiff {
+				result.MaxDiff = d
+			}
+
+			if d > opts.ChannelTolerance {
+				result.DiffPixels++
+				result.Diff.SetRGBA(x, y, diffColor)
+			} else {
+				result.Diff.SetRGBA(x, y, color.RGBA{R: e.R / 4, G: e.G / 4, B: e.B / 4, A: matchAlpha})
+			}
+		}
+	}
+
+	if result.TotalPixels > 0 {
+		result.DiffRatio = float64(result.DiffPixels) / float64(result.TotalPixels)
+	}
+	result.Passed = result.DiffRatio <= opts.MaxDiffRatio
+
+	return result
+}
+
+// AssertGolden reads back the next frame rendered by the window and
+// compares it against the golden image with the given name (see
+// MatchGolden()).  Must be called from a routine other than the main
+// routine, after the window has been initialized.
+func AssertGolden(t testing.TB, window *gfx.Window, name string, opts ...Options) bool {
+	t.Helper()
+
+	img := window.ToImage()
+	if img == nil {
+		t.Errorf("error reading frame for %s: window is not running", name)
+		return false
+	}
+
+	return MatchGolden(t, img, name, opts...)
+}
+
+// MatchGolden compares the image with the golden image with the given name,
+// failing the test if they differ by more than allowed by the options (or
+// DefaultOptions(), if not provided).  When updating, the golden image is
+// written instead and the test will pass.
+func MatchGolden(t testing.TB, actual image.Image, name string, opts ...Options) bool {
+	t.Helper()
+
+	o := DefaultOptions()
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	goldenFile := filepath.Join(o.GoldenDir, name+goldenFileExt)
+
+	if o.Update || Updating() {
+		if err := writePNG(goldenFile, actual); err != nil {
+			t.Errorf("error updating golden image: %v", err)
+			return false
+		}
+		return true
+	}
+
+	expected, err := readPNG(goldenFile)
+	if err != nil {
+		t.Errorf("error reading golden image (set %s to create it): %v", UpdateEnvVar, err)
+		return false
+	}
+
+	result := Compare(actual, expected, o)
+	if result.Passed {
+		return true
+	}
+
+	actualFile := filepath.Join(o.GoldenDir, name+actualFileSuffix)
+	if err = writePNG(actualFile, actual); err != nil {
+		t.Logf("error writing actual image: %v", err)
+	}
+
+	if result.Diff == nil {
+		t.Errorf("golden image mismatch for %s: expected size %v, got %v (actual image: %s)",
+			name, expected.Bounds().Size(), actual.Bounds().Size(), actualFile)
+		return false
+	}
+
+	diffFile := filepath.Join(o.GoldenDir, name+diffFileSuffix)
+	if err = writePNG(diffFile, result.Diff); err != nil {
+		t.Logf("error writing diff image: %v", err)
+	}
+
+	t.Errorf("golden image mismatch for %s: %s (actual image: %s, diff image: %s)",
+		name, result.String(), actualFile, diffFile)
+	return false
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func maxChannelDiff(a, b color.RGBA) (d uint8) {
+	for _, cd := range [4]uint8{
+		absDiff(a.R, b.R),
+		absDiff(a.G, b.G),
+		absDiff(a.B, b.B),
+		absDiff(a.A, b.A),
+	} {
+		if cd > d {
+			d = cd
+		}
+	}
+	return
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func readPNG(filename string) (image.Image, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	return png.Decode(file)
+}
+
+func writePNG(filename string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(filename), goldenDirPerm); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err = png.Encode(file, img); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -6,6 +6,7 @@ import (
 
 const (
 	sizeOfFloat32 = 4 // byte count
+	sizeOfUint32  = 4 // byte count
 )
 
 type VertexAttributeLayout int
@@ -27,7 +28,24 @@ const (
 	PositionNormalUvTangentsVaoLayout
 )
 
-func newVertexArrayObject(layout VertexAttributeLayout, shader Shader, vertices []float32) (glName uint32, closeFunc func()) {
+// vertexStride Returns the size, in bytes, of a single vertex
+// with the given layout.
+func vertexStride(layout VertexAttributeLayout) int32 {
+	switch layout {
+	case PositionColorVaoLayout:
+		return 6 * sizeOfFloat32
+	case PositionUvVaoLayout:
+		return 5 * sizeOfFloat32
+	case PositionNormalUvVaoLayout:
+		return 8 * sizeOfFloat32
+	case PositionNormalUvTangentsVaoLayout:
+		return 14 * sizeOfFloat32
+	default:
+		return 3 * sizeOfFloat32
+	}
+}
+
+func newVertexArrayObject(layout VertexAttributeLayout, shader Shader, vertices []float32) (glName, vboName uint32, closeFunc func()) {
 	if shader == nil || !shader.Initialized() {
 		panic("shader cannot be nil or uninitialized")
 	}
@@ -44,7 +62,97 @@ func newVertexArrayObject(layout VertexAttributeLayout, shader Shader, vertices
 	gl.BindVertexArray(vao)
 	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
 
-	stride := int32(0)
+	enableVertexAttributes(layout, shader)
+
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*sizeOfFloat32, gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+
+	glName = vao
+	vboName = vbo
+	closeFunc = func() {
+		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+		gl.DeleteBuffers(1, &vbo)
+
+		gl.BindVertexArray(0)
+		gl.DeleteVertexArrays(1, &vao)
+	}
+
+	return
+}
+
+// newVertexBufferObject Creates a vertex buffer object holding the given
+// (interleaved) vertices, which can then be shared by multiple vertex array
+// objects (see newIndexedVertexArrayObject()).
+func newVertexBufferObject(vertices []float32) (glName uint32, closeFunc func()) {
+	if vertices == nil || len(vertices) == 0 {
+		panic("vertices cannot be nil or zero-length")
+	}
+
+	vbo := uint32(0)
+	gl.GenBuffers(1, &vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*sizeOfFloat32, gl.Ptr(vertices), gl.STATIC_DRAW)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	glName = vbo
+	closeFunc = func() {
+		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+		gl.DeleteBuffers(1, &vbo)
+	}
+
+	return
+}
+
+// newIndexedVertexArrayObject Creates a vertex array object that reads the
+// vertices from an existing vertex buffer object, in the order given by the
+// indices, which are uploaded to a new element buffer object (deleted along
+// with the vertex array object).  Draw with glDrawElements.
+func newIndexedVertexArrayObject(layout VertexAttributeLayout, shader Shader, vbo uint32, indices []uint32) (glName, eboName uint32, closeFunc func()) {
+	if shader == nil || !shader.Initialized() {
+		panic("shader cannot be nil or uninitialized")
+	}
+
+	if indices == nil || len(indices) == 0 {
+		panic("indices cannot be nil or zero-length")
+	}
+
+	vao, ebo := uint32(0), uint32(0)
+
+	gl.GenVertexArrays(1, &vao)
+	gl.GenBuffers(1, &ebo)
+
+	gl.BindVertexArray(vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+
+	enableVertexAttributes(layout, shader)
+
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*sizeOfUint32, gl.Ptr(indices), gl.STATIC_DRAW)
+
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+
+	glName = vao
+	eboName = ebo
+	closeFunc = func() {
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
+		gl.DeleteBuffers(1, &ebo)
+
+		gl.BindVertexArray(0)
+		gl.DeleteVertexArrays(1, &vao)
+	}
+
+	return
+}
+
+// enableVertexAttributes Points the attributes expected by the given layout
+// to the vertex buffer object currently bound to GL_ARRAY_BUFFER, for the
+// vertex array object currently bound.
+func enableVertexAttributes(layout VertexAttributeLayout, shader Shader) {
+	stride := vertexStride(layout)
 
 	posLoc := uint32(shader.GetAttribLocation("a_Position"))
 	colorLoc := uint32(shader.GetAttribLocation("a_Color"))
@@ -58,19 +166,16 @@ func newVertexArrayObject(layout VertexAttributeLayout, shader Shader, vertices
 		gl.EnableVertexAttribArray(posLoc)
 		gl.VertexAttribPointerWithOffset(posLoc, 3, gl.FLOAT, false, stride, 0)
 	case PositionColorVaoLayout:
-		stride = 24
 		gl.EnableVertexAttribArray(posLoc)
 		gl.VertexAttribPointerWithOffset(posLoc, 3, gl.FLOAT, false, stride, 0)
 		gl.EnableVertexAttribArray(colorLoc)
 		gl.VertexAttribPointerWithOffset(colorLoc, 3, gl.FLOAT, false, stride, uintptr(3*sizeOfFloat32))
 	case PositionUvVaoLayout:
-		stride = 20
 		gl.EnableVertexAttribArray(posLoc)
 		gl.VertexAttribPointerWithOffset(posLoc, 3, gl.FLOAT, false, stride, 0)
 		gl.EnableVertexAttribArray(uvLoc)
 		gl.VertexAttribPointerWithOffset(uvLoc, 2, gl.FLOAT, false, stride, uintptr(3*sizeOfFloat32))
 	case PositionNormalUvVaoLayout:
-		stride = 32
 		gl.EnableVertexAttribArray(posLoc)
 		gl.VertexAttribPointerWithOffset(posLoc, 3, gl.FLOAT, false, stride, 0)
 		gl.EnableVertexAttribArray(normLoc)
@@ -78,7 +183,6 @@ func newVertexArrayObject(layout VertexAttributeLayout, shader Shader, vertices
 		gl.EnableVertexAttribArray(uvLoc)
 		gl.VertexAttribPointerWithOffset(uvLoc, 2, gl.FLOAT, false, stride, uintptr(6*sizeOfFloat32))
 	case PositionNormalUvTangentsVaoLayout:
-		stride = 56
 		gl.EnableVertexAttribArray(posLoc)
 		gl.VertexAttribPointerWithOffset(posLoc, 3, gl.FLOAT, false, stride, 0)
 		gl.EnableVertexAttribArray(normLoc)
@@ -90,20 +194,93 @@ func newVertexArrayObject(layout VertexAttributeLayout, shader Shader, vertices
 		gl.EnableVertexAttribArray(bitanLoc)
 		gl.VertexAttribPointerWithOffset(bitanLoc, 3, gl.FLOAT, false, stride, uintptr(11*sizeOfFloat32))
 	}
+}
 
-	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*sizeOfFloat32, gl.Ptr(vertices), gl.STATIC_DRAW)
+// newPositionOnlyVertexArrayObject Creates a vertex array object that reads
+// only the position of each vertex from an existing vertex buffer object,
+// which is useful for depth-only passes (e.g. shadow mapping) using a shader
+// that expects only a_Position.  If not zero, the given element buffer
+// object is also bound to the vertex array object.
+func newPositionOnlyVertexArrayObject(layout VertexAttributeLayout, shader Shader, vbo, ebo uint32) (glName uint32, closeFunc func()) {
+	vao := uint32(0)
+	gl.GenVertexArrays(1, &vao)
+	gl.BindVertexArray(vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	if ebo != 0 {
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
+	}
+
+	posLoc := uint32(shader.GetAttribLocation("a_Position"))
+	gl.EnableVertexAttribArray(posLoc)
+	gl.VertexAttribPointerWithOffset(posLoc, 3, gl.FLOAT, false, vertexStride(layout), 0)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
 	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, 0)
 
 	glName = vao
 	closeFunc = func() {
-		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
-		gl.DeleteBuffers(1, &vbo)
-
 		gl.BindVertexArray(0)
 		gl.DeleteVertexArrays(1, &vao)
 	}
 
 	return
 }
+
+// instanceStride The size, in bytes, of the data of a single instance, as
+// expected by the instanced 3D shaders: a world matrix (a_InstanceWorldMat)
+// followed by a color tint (a_InstanceTint).
+const instanceStride = 20 * sizeOfFloat32
+
+// enableInstanceAttributes Points the per-instance attributes of the given
+// vertex array object, a_InstanceWorldMat and a_InstanceTint, to the given
+// instance buffer object, advancing once per instance rather than once per
+// vertex.  Attributes not used by the shader are skipped.
+func enableInstanceAttributes(vao, vbo uint32, shader Shader) {
+	gl.BindVertexArray(vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+
+	if loc := shader.GetAttribLocation("a_InstanceWorldMat"); loc != -1 {
+		for i := uint32(0); i < 4; i++ { // one attribute per matrix column
+			gl.EnableVertexAttribArray(uint32(loc) + i)
+			gl.VertexAttribPointerWithOffset(uint32(loc)+i, 4, gl.FLOAT, false, instanceStride, uintptr(i*4*sizeOfFloat32))
+			gl.VertexAttribDivisor(uint32(loc)+i, 1)
+		}
+	}
+
+	if loc := shader.GetAttribLocation("a_InstanceTint"); loc != -1 {
+		gl.EnableVertexAttribArray(uint32(loc))
+		gl.VertexAttribPointerWithOffset(uint32(loc), 4, gl.FLOAT, false, instanceStride, uintptr(16*sizeOfFloat32))
+		gl.VertexAttribDivisor(uint32(loc), 1)
+	}
+
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
+
+// skinStride The size, in bytes, of the skinning data of a single vertex, as
+// expected by the skinning shaders: the indices of four joints (a_Joints)
+// followed by their weights (a_Weights).
+const skinStride = 8 * sizeOfFloat32
+
+// enableSkinAttributes Points the skinning attributes of the given vertex
+// array object, a_Joints and a_Weights, to the given buffer object, which
+// holds the skinning data of each vertex in the same order as the vertex
+// buffer object.  Attributes not used by the shader are skipped.
+func enableSkinAttributes(vao, vbo uint32, shader Shader) {
+	gl.BindVertexArray(vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+
+	if loc := shader.GetAttribLocation("a_Joints"); loc != -1 {
+		gl.EnableVertexAttribArray(uint32(loc))
+		gl.VertexAttribPointerWithOffset(uint32(loc), 4, gl.FLOAT, false, skinStride, 0)
+	}
+
+	if loc := shader.GetAttribLocation("a_Weights"); loc != -1 {
+		gl.EnableVertexAttribArray(uint32(loc))
+		gl.VertexAttribPointerWithOffset(uint32(loc), 4, gl.FLOAT, false, skinStride, uintptr(4*sizeOfFloat32))
+	}
+
+	gl.BindVertexArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
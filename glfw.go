@@ -11,7 +11,6 @@ import (
 	"runtime"
 	"sync"
 	"sync/atomic"
-	"time"
 )
 
 /******************************************************************************
@@ -34,6 +33,9 @@ const (
 var (
 	targetFramerate atomic.Uint32
 	vSyncEnabled    atomic.Bool
+
+	clock      Clock
+	clockMutex sync.Mutex
 )
 
 // TargetFramerate returns the target framerate in frames per second.
@@ -67,6 +69,38 @@ func SetVSyncEnabled(enabled bool) {
 	vSyncEnabled.Store(enabled)
 }
 
+// CurrentClock returns the clock used by the main processing loop
+// to determine when windows are updated and the delta time passed
+// to them.
+func CurrentClock() (c Clock) {
+	clockMutex.Lock()
+	c = clock
+	clockMutex.Unlock()
+	return
+}
+
+// SetClock changes the clock used by the main processing loop,
+// which must be done before calling Run().  By default, a
+// RealTimeClock is used.
+func SetClock(c Clock) {
+	clockMutex.Lock()
+	clock = c
+	clockMutex.Unlock()
+}
+
+// Step Advance all windows by exactly the given number of frames,
+// passing in the given delta time (in microseconds) for each one,
+// and block until those frames have been processed.  The current
+// clock must be a ManualClock (see SetClock()).  Can call from any
+// routine other than the main routine.
+func Step(frames int, deltaTime int64) {
+	if c, ok := CurrentClock().(*ManualClock); ok {
+		c.Step(frames, deltaTime)
+	} else {
+		panic(fmt.Errorf("cannot step: current clock is not a ManualClock"))
+	}
+}
+
 /******************************************************************************
  init Function
 ******************************************************************************/
@@ -85,6 +119,7 @@ func init() {
 	// Set default configuration
 	SetTargetFramerate(defaultTargetFramerate)
 	SetVSyncEnabled(defaultVSyncEnabled)
+	SetClock(NewRealTimeClock())
 }
 
 /******************************************************************************
@@ -146,6 +181,12 @@ type GlfwWindow interface {
 	// with the sample size set to 4.
 	MultiSamplingEnabled() bool
 
+	// Headless shall return true if the window should never be shown,
+	// rendering instead to an offscreen framebuffer.  Headless windows
+	// can be used where no display is available to the user, such as
+	// when generating images or running automated tests.
+	Headless() bool
+
 	// IsSecondary shall return true if the window has been designated
 	// as a secondary/tool/dialog window, which means closing it will
 	// not result in the application closing.
@@ -174,7 +215,7 @@ type GlfwWindow interface {
  gfx Functions
 ******************************************************************************/
 
-func gfxNewWindow(title string, width, height int, borderless, resizable, multisampling bool) (*glfw.Window, error) {
+func gfxNewWindow(title string, width, height int, borderless, resizable, multisampling, headless bool) (*glfw.Window, error) {
 	if !gfxInitialized {
 		return nil, fmt.Errorf("GLFW not initialized: must call gfx.Init() from the main thread first")
 	}
@@ -200,12 +241,18 @@ func gfxNewWindow(title string, width, height int, borderless, resizable, multis
 		glfw.WindowHint(glfw.Resizable, glfw.False)
 	}
 
-	if multisampling {
+	if multisampling && !headless {
 		glfw.WindowHint(glfw.Samples, 4)
 	} else {
 		glfw.WindowHint(glfw.Samples, 0)
 	}
 
+	if headless {
+		glfw.WindowHint(glfw.Visible, glfw.False)
+	} else {
+		glfw.WindowHint(glfw.Visible, glfw.True)
+	}
+
 	win, err := glfw.CreateWindow(w, h, title, nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating GLFW window: %w", err)
@@ -218,11 +265,13 @@ func gfxNewWindow(title string, width, height int, borderless, resizable, multis
 		glfw.SwapInterval(0)
 	}
 
-	monitor := glfw.GetPrimaryMonitor()
-	monMode := monitor.GetVideoMode()
-	xPos := (monMode.Width - w) / 2
-	yPos := (monMode.Height - h) / 2
-	win.SetPos(xPos, yPos)
+	if !headless {
+		monitor := glfw.GetPrimaryMonitor()
+		monMode := monitor.GetVideoMode()
+		xPos := (monMode.Width - w) / 2
+		yPos := (monMode.Height - h) / 2
+		win.SetPos(xPos, yPos)
+	}
 
 	if err = gl.Init(); err != nil {
 		win.Destroy()
@@ -239,7 +288,8 @@ func gfxProcessInitQueue() {
 			win.Title(),
 			win.Width(), win.Height(),
 			win.Borderless(), win.Resizable(),
-			win.MultiSamplingEnabled()); err != nil {
+			win.MultiSamplingEnabled(),
+			win.Headless()); err != nil {
 			panic(err)
 		} else {
 			win.Init(glwin, gfxContext)
@@ -353,7 +403,8 @@ func CloseWindowAsync(window GlfwWindow) {
 
 // Run Start the main processing loop, which will call Update() on
 // all windows at an interval based on the target framerate but
-// limited by system performance. Must call from the main routine.
+// limited by system performance (or, when using a ManualClock,
+// only when stepped via Step()). Must call from the main routine.
 func Run(ctx context.Context, cancelFunc context.CancelFunc) {
 	gfxStateMutex.Lock()
 
@@ -370,10 +421,8 @@ func Run(ctx context.Context, cancelFunc context.CancelFunc) {
 		}
 	}
 
-	now := time.Now().UnixMicro()
-	lastTick := now
-	deltaTime := int64(0)
-	drawInterval := int64(1000000 / targetFramerate.Load())
+	runClock := CurrentClock()
+	runClock.Start()
 	gfxRunning = true
 	doneChan := gfxContext.Done()
 	gfxStateMutex.Unlock()
@@ -381,6 +430,7 @@ func Run(ctx context.Context, cancelFunc context.CancelFunc) {
 	for {
 		select {
 		case <-doneChan:
+			runClock.Stop()
 			gfxStateMutex.Lock()
 			for _, w := range gfxWindows {
 				gfxWindowCloseQueue = append(gfxWindowCloseQueue, w)
@@ -391,16 +441,7 @@ func Run(ctx context.Context, cancelFunc context.CancelFunc) {
 		default:
 		}
 
-		deltaTime = time.Now().UnixMicro() - lastTick
-		if deltaTime < drawInterval {
-			// This is done to give more CPU time to other, higher
-			// priority tasks/processes.  Setting a lower target framerate
-			// will give them more time to work, while a higher value
-			// increases the potential rendering performance of the windows.
-			time.Sleep(time.Microsecond * time.Duration(drawInterval-deltaTime))
-			deltaTime = time.Now().UnixMicro() - lastTick
-		}
-		lastTick = time.Now().UnixMicro()
+		deltaTime, frameDue := runClock.Tick(doneChan)
 
 		gfxStateMutex.Lock()
 
@@ -409,13 +450,15 @@ func Run(ctx context.Context, cancelFunc context.CancelFunc) {
 
 		glfw.PollEvents()
 
-		for _, win := range gfxWindows {
-			if win != gfxWindow {
-				gfxWindow = win
-				gfxWindow.GLFW().MakeContextCurrent()
-				gfxWindow.Update(deltaTime)
-			} else {
-				gfxWindow.Update(deltaTime)
+		if frameDue {
+			for _, win := range gfxWindows {
+				if win != gfxWindow {
+					gfxWindow = win
+					gfxWindow.GLFW().MakeContextCurrent()
+					gfxWindow.Update(deltaTime)
+				} else {
+					gfxWindow.Update(deltaTime)
+				}
 			}
 		}
 
@@ -0,0 +1,59 @@
+package gltf
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/internal/importer"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	dataUriPrefix = "data:"
+)
+
+// readUri returns the data referenced by the given URI, which is either
+// a base64-encoded data URI or a path relative to the directory of the
+// glTF file (baseDir), resolved via the asset's source library if set.
+func readUri(asset gfx.Asset, baseDir, uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, dataUriPrefix) {
+		comma := strings.IndexByte(uri, ',')
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, fmt.Errorf("data URI must be base64 encoded")
+		}
+		data, err := base64.StdEncoding.DecodeString(uri[comma+1:])
+		if err != nil {
+			return nil, fmt.Errorf("data URI decode error: %w", err)
+		}
+		return data, nil
+	}
+
+	name, err := url.PathUnescape(uri)
+	if err != nil {
+		name = uri
+	}
+	if baseDir != "" && !path.IsAbs(name) {
+		name = path.Join(baseDir, name)
+	}
+
+	reader, closeFunc, err := importer.SourceReader(asset, name)
+	defer closeFunc()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if reader == nil {
+		return nil, fmt.Errorf("file not found: %s", name)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("file read error: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,440 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/tonybillings/gfx"
+	"math"
+	"strings"
+)
+
+const (
+	glbMagic         = 0x46546C67 // "glTF"
+	glbVersion       = 2
+	glbHeaderLength  = 12
+	glbChunkJson     = 0x4E4F534A // "JSON"
+	glbChunkBin      = 0x004E4942 // "BIN\0"
+	glbChunkHdrLen   = 8
+	gltfMajorVersion = "2"
+)
+
+const (
+	componentTypeByte          = 5120
+	componentTypeUnsignedByte  = 5121
+	componentTypeShort         = 5122
+	componentTypeUnsignedShort = 5123
+	componentTypeUnsignedInt   = 5125
+	componentTypeFloat         = 5126
+)
+
+var (
+	componentSizes = map[int]int{
+		componentTypeByte:          1,
+		componentTypeUnsignedByte:  1,
+		componentTypeShort:         2,
+		componentTypeUnsignedShort: 2,
+		componentTypeUnsignedInt:   4,
+		componentTypeFloat:         4,
+	}
+
+	accessorTypeSizes = map[string]int{
+		"SCALAR": 1,
+		"VEC2":   2,
+		"VEC3":   3,
+		"VEC4":   4,
+		"MAT2":   4,
+		"MAT3":   9,
+		"MAT4":   16,
+	}
+)
+
+/******************************************************************************
+ decoder
+******************************************************************************/
+
+// decoder Holds the parsed JSON document along with the binary buffers it
+// references and provides access to the data described by its accessors.
+type decoder struct {
+	asset   gfx.Asset
+	format  string
+	file    string
+	baseDir string
+
+	doc     *document
+	buffers [][]byte
+}
+
+func (d *decoder) errorf(property string, format string, args ...any) *ParseError {
+	return newParseError(d.format, d.file, property, fmt.Errorf(format, args...))
+}
+
+func (d *decoder) decode(data []byte) *ParseError {
+	var jsonChunk, binChunk []byte
+
+	if isGlb(data) {
+		d.format = "GLB"
+		var err *ParseError
+		if jsonChunk, binChunk, err = d.splitGlb(data); err != nil {
+			return err
+		}
+	} else {
+		d.format = "glTF"
+		jsonChunk = data
+	}
+
+	d.doc = &document{}
+	if err := json.Unmarshal(jsonChunk, d.doc); err != nil {
+		return d.errorf("", "JSON decode error: %w", err)
+	}
+
+	if !strings.HasPrefix(d.doc.Asset.Version, gltfMajorVersion+".") &&
+		d.doc.Asset.Version != gltfMajorVersion {
+		return d.errorf("asset.version", "unsupported version: %q", d.doc.Asset.Version)
+	}
+
+	return d.loadBuffers(binChunk)
+}
+
+func (d *decoder) splitGlb(data []byte) (jsonChunk, binChunk []byte, err *ParseError) {
+	if len(data) < glbHeaderLength {
+		return nil, nil, d.errorf("", "GLB header is truncated")
+	}
+
+	if version := binary.LittleEndian.Uint32(data[4:8]); version != glbVersion {
+		return nil, nil, d.errorf("", "unsupported GLB version: %d", version)
+	}
+
+	length := int(binary.LittleEndian.Uint32(data[8:12]))
+	if length > len(data) {
+		return nil, nil, d.errorf("", "GLB length (%d) exceeds data length (%d)", length, len(data))
+	}
+
+	offset := glbHeaderLength
+	for offset+glbChunkHdrLen <= length {
+		chunkLength := int(binary.LittleEndian.Uint32(data[offset : offset+4]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4 : offset+8])
+		offset += glbChunkHdrLen
+
+		if chunkLength < 0 || offset+chunkLength > length {
+			return nil, nil, d.errorf("", "GLB chunk exceeds data length")
+		}
+
+		chunk := data[offset : offset+chunkLength]
+		switch chunkType {
+		case glbChunkJson:
+			if jsonChunk == nil {
+				jsonChunk = chunk
+			}
+		case glbChunkBin:
+			if binChunk == nil {
+				binChunk = chunk
+			}
+		}
+
+		offset += chunkLength
+	}
+
+	if jsonChunk == nil {
+		return nil, nil, d.errorf("", "GLB is missing the JSON chunk")
+	}
+
+	return
+}
+
+func (d *decoder) loadBuffers(binChunk []byte) *ParseError {
+	d.buffers = make([][]byte, len(d.doc.Buffers))
+
+	for i, buffer := range d.doc.Buffers {
+		property := fmt.Sprintf("buffers[%d]", i)
+
+		var data []byte
+		if buffer.URI == "" {
+			if i != 0 || binChunk == nil {
+				return d.errorf(property, "buffer has no URI")
+			}
+			data = binChunk
+		} else {
+			var err error
+			if data, err = readUri(d.asset, d.baseDir, buffer.URI); err != nil {
+				return newParseError(d.format, d.file, property, err)
+			}
+		}
+
+		if len(data) < buffer.ByteLength {
+			return d.errorf(property, "buffer length (%d) is less than byteLength (%d)", len(data), buffer.ByteLength)
+		}
+
+		d.buffers[i] = data
+	}
+
+	return nil
+}
+
+func (d *decoder) bufferView(index int) (data []byte, stride int, err *ParseError) {
+	property := fmt.Sprintf("bufferViews[%d]", index)
+
+	if index < 0 || index >= len(d.doc.BufferViews) {
+		return nil, 0, d.errorf(property, "index out of range")
+	}
+
+	view := d.doc.BufferViews[index]
+	if view.Buffer < 0 || view.Buffer >= len(d.buffers) {
+		return nil, 0, d.errorf(property, "buffer index (%d) out of range", view.Buffer)
+	}
+
+	buffer := d.buffers[view.Buffer]
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset+view.ByteLength > len(buffer) {
+		return nil, 0, d.errorf(property, "byte range exceeds buffer length")
+	}
+
+	return buffer[view.ByteOffset : view.ByteOffset+view.ByteLength], view.ByteStride, nil
+}
+
+// readFloats returns the elements of the accessor as a flat array of
+// floats, along with the number of components per element.  Integer
+// components are converted to floats, normalizing them if required.
+func (d *decoder) readFloats(index int) (values []float32, components int, err *ParseError) {
+	accessor, components, err := d.accessor(index)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	values = make([]float32, accessor.Count*components)
+	err = d.readAccessor(index, accessor, components, func(i int, data []byte) {
+		values[i] = readFloat(data, accessor.ComponentType, accessor.Normalized)
+	})
+
+	return
+}
+
+// readInts returns the elements of the accessor, which must have an
+// integer component type, as a flat array of integers.
+func (d *decoder) readInts(index int) (values []int, components int, err *ParseError) {
+	accessor, components, err := d.accessor(index)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if accessor.ComponentType == componentTypeFloat {
+		return nil, 0, d.errorf(fmt.Sprintf("accessors[%d]", index), "expected an integer component type")
+	}
+
+	values = make([]int, accessor.Count*components)
+	err = d.readAccessor(index, accessor, components, func(i int, data []byte) {
+		values[i] = readInt(data, accessor.ComponentType)
+	})
+
+	return
+}
+
+func (d *decoder) accessor(index int) (accessor docAccessor, components int, err *ParseError) {
+	property := fmt.Sprintf("accessors[%d]", index)
+
+	if index < 0 || index >= len(d.doc.Accessors) {
+		return accessor, 0, d.errorf(property, "index out of range")
+	}
+
+	accessor = d.doc.Accessors[index]
+
+	var ok bool
+	if components, ok = accessorTypeSizes[accessor.Type]; !ok {
+		return accessor, 0, d.errorf(property, "unsupported type: %q", accessor.Type)
+	}
+
+	if _, ok = componentSizes[accessor.ComponentType]; !ok {
+		return accessor, 0, d.errorf(property, "unsupported component type: %d", accessor.ComponentType)
+	}
+
+	if accessor.Count < 0 {
+		return accessor, 0, d.errorf(property, "count cannot be negative")
+	}
+
+	err = d.checkAccessorRange(property, accessor, components)
+	return
+}
+
+// checkAccessorRange ensures the elements of the accessor fit within its
+// buffer view, so the count can be trusted when allocating.  Accessors
+// without a buffer view cannot describe more elements than there are
+// bytes in the loaded buffers.
+func (d *decoder) checkAccessorRange(property string, accessor docAccessor, components int) *ParseError {
+	if accessor.Count == 0 {
+		return nil
+	}
+
+	elementSize := componentSizes[accessor.ComponentType] * components
+
+	if accessor.BufferView == nil {
+		available := 0
+		for _, buffer := range d.buffers {
+			available += len(buffer)
+		}
+		if accessor.Count > available/elementSize {
+			return d.errorf(property, "count (%d) exceeds the size of the buffers", accessor.Count)
+		}
+		return nil
+	}
+
+	data, stride, err := d.bufferView(*accessor.BufferView)
+	if err != nil {
+		return err
+	}
+
+	if stride == 0 {
+		stride = elementSize
+	}
+
+	if accessor.ByteOffset < 0 || accessor.ByteOffset > len(data) || accessor.Count > len(data)/stride+1 {
+		return d.errorf(property, "byte range exceeds buffer view length")
+	}
+
+	if end := accessor.ByteOffset + stride*(accessor.Count-1) + elementSize; end > len(data) {
+		return d.errorf(property, "byte range exceeds buffer view length")
+	}
+
+	return nil
+}
+
+// readAccessor passes the components of each element to the given
+// function.  The accessor must have been validated by accessor().
+func (d *decoder) readAccessor(index int, accessor docAccessor, components int, set func(int, []byte)) *ParseError {
+	property := fmt.Sprintf("accessors[%d]", index)
+	componentSize := componentSizes[accessor.ComponentType]
+	elementSize := componentSize * components
+
+	if accessor.BufferView != nil {
+		data, stride, err := d.bufferView(*accessor.BufferView)
+		if err != nil {
+			return err
+		}
+
+		if stride == 0 {
+			stride = elementSize
+		}
+
+		for i := 0; i < accessor.Count; i++ {
+			offset := accessor.ByteOffset + i*stride
+			for c := 0; c < components; c++ {
+				set(i*components+c, data[offset+c*componentSize:])
+			}
+		}
+	} // else all values are zero unless replaced by sparse values
+
+	if accessor.Sparse != nil {
+		return d.readSparse(property, accessor, components, set)
+	}
+
+	return nil
+}
+
+func (d *decoder) readSparse(property string, accessor docAccessor, components int, set func(int, []byte)) *ParseError {
+	sparse := accessor.Sparse
+	property += ".sparse"
+
+	indexSize, ok := componentSizes[sparse.Indices.ComponentType]
+	if !ok || sparse.Indices.ComponentType == componentTypeFloat {
+		return d.errorf(property, "unsupported index component type: %d", sparse.Indices.ComponentType)
+	}
+
+	indexData, _, err := d.bufferView(sparse.Indices.BufferView)
+	if err != nil {
+		return err
+	}
+
+	valueData, _, err := d.bufferView(sparse.Values.BufferView)
+	if err != nil {
+		return err
+	}
+
+	if sparse.Count < 0 {
+		return d.errorf(property, "count cannot be negative")
+	}
+
+	if sparse.Indices.ByteOffset < 0 || sparse.Values.ByteOffset < 0 {
+		return d.errorf(property, "byte offset cannot be negative")
+	}
+
+	componentSize := componentSizes[accessor.ComponentType]
+	elementSize := componentSize * components
+
+	if sparse.Count > len(indexData)/indexSize || sparse.Count > len(valueData)/elementSize ||
+		sparse.Indices.ByteOffset+sparse.Count*indexSize > len(indexData) ||
+		sparse.Values.ByteOffset+sparse.Count*elementSize > len(valueData) {
+		return d.errorf(property, "byte range exceeds buffer view length")
+	}
+
+	for i := 0; i < sparse.Count; i++ {
+		target := readInt(indexData[sparse.Indices.ByteOffset+i*indexSize:], sparse.Indices.ComponentType)
+		if target < 0 || target >= accessor.Count {
+			return d.errorf(property, "index (%d) out of range", target)
+		}
+
+		offset := sparse.Values.ByteOffset + i*elementSize
+		for c := 0; c < components; c++ {
+			set(target*components+c, valueData[offset+c*componentSize:])
+		}
+	}
+
+	return nil
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func isGlb(data []byte) bool {
+	return len(data) >= 4 && binary.LittleEndian.Uint32(data[0:4]) == glbMagic
+}
+
+func readInt(data []byte, componentType int) int {
+	switch componentType {
+	case componentTypeByte:
+		return int(int8(data[0]))
+	case componentTypeUnsignedByte:
+		return int(data[0])
+	case componentTypeShort:
+		return int(int16(binary.LittleEndian.Uint16(data)))
+	case componentTypeUnsignedShort:
+		return int(binary.LittleEndian.Uint16(data))
+	case componentTypeUnsignedInt:
+		return int(binary.LittleEndian.Uint32(data))
+	case componentTypeFloat:
+		return int(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	}
+	return 0
+}
+
+func readFloat(data []byte, componentType int, normalized bool) float32 {
+	if componentType == componentTypeFloat {
+		return math.Float32frombits(binary.LittleEndian.Uint32(data))
+	}
+
+	value := float32(readInt(data, componentType))
+	if !normalized {
+		return value
+	}
+
+	switch componentType {
+	case componentTypeByte:
+		return float32(math.Max(float64(value)/127.0, -1.0))
+	case componentTypeUnsignedByte:
+		return value / 255.0
+	case componentTypeShort:
+		return float32(math.Max(float64(value)/32767.0, -1.0))
+	case componentTypeUnsignedShort:
+		return value / 65535.0
+	case componentTypeUnsignedInt:
+		return float32(float64(value) / 4294967295.0)
+	}
+
+	return value
+}
+
+func newDecoder(asset gfx.Asset, file, baseDir string) *decoder {
+	return &decoder{
+		asset:   asset,
+		file:    file,
+		baseDir: baseDir,
+	}
+}
@@ -0,0 +1,168 @@
+package gltf
+
+/******************************************************************************
+ glTF JSON Schema
+******************************************************************************/
+
+// These types mirror the subset of the glTF 2.0 JSON schema used by
+// this package; unsupported properties are simply ignored.  Optional
+// indices are pointers so that index 0 can be told apart from absent.
+
+type document struct {
+	Asset       docAsset        `json:"asset"`
+	Scene       *int            `json:"scene"`
+	Scenes      []docScene      `json:"scenes"`
+	Nodes       []docNode       `json:"nodes"`
+	Meshes      []docMesh       `json:"meshes"`
+	Accessors   []docAccessor   `json:"accessors"`
+	BufferViews []docBufferView `json:"bufferViews"`
+	Buffers     []docBuffer     `json:"buffers"`
+	Materials   []docMaterial   `json:"materials"`
+	Textures    []docTexture    `json:"textures"`
+	Images      []docImage      `json:"images"`
+	Samplers    []docSampler    `json:"samplers"`
+	Skins       []docSkin       `json:"skins"`
+	Animations  []docAnimation  `json:"animations"`
+}
+
+type docAsset struct {
+	Version    string `json:"version"`
+	MinVersion string `json:"minVersion"`
+}
+
+type docScene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+type docNode struct {
+	Name        string    `json:"name"`
+	Children    []int     `json:"children"`
+	Mesh        *int      `json:"mesh"`
+	Skin        *int      `json:"skin"`
+	Matrix      []float32 `json:"matrix"`
+	Translation []float32 `json:"translation"`
+	Rotation    []float32 `json:"rotation"`
+	Scale       []float32 `json:"scale"`
+}
+
+type docMesh struct {
+	Name       string         `json:"name"`
+	Primitives []docPrimitive `json:"primitives"`
+}
+
+type docPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type docAccessor struct {
+	BufferView    *int       `json:"bufferView"`
+	ByteOffset    int        `json:"byteOffset"`
+	ComponentType int        `json:"componentType"`
+	Normalized    bool       `json:"normalized"`
+	Count         int        `json:"count"`
+	Type          string     `json:"type"`
+	Sparse        *docSparse `json:"sparse"`
+}
+
+type docSparse struct {
+	Count   int              `json:"count"`
+	Indices docSparseIndices `json:"indices"`
+	Values  docSparseValues  `json:"values"`
+}
+
+type docSparseIndices struct {
+	BufferView    int `json:"bufferView"`
+	ByteOffset    int `json:"byteOffset"`
+	ComponentType int `json:"componentType"`
+}
+
+type docSparseValues struct {
+	BufferView int `json:"bufferView"`
+	ByteOffset int `json:"byteOffset"`
+}
+
+type docBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type docBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type docMaterial struct {
+	Name                 string          `json:"name"`
+	PbrMetallicRoughness *docPbr         `json:"pbrMetallicRoughness"`
+	NormalTexture        *docTextureInfo `json:"normalTexture"`
+	EmissiveFactor       []float32       `json:"emissiveFactor"`
+	AlphaMode            string          `json:"alphaMode"`
+}
+
+type docPbr struct {
+	BaseColorFactor          []float32       `json:"baseColorFactor"`
+	BaseColorTexture         *docTextureInfo `json:"baseColorTexture"`
+	MetallicFactor           *float32        `json:"metallicFactor"`
+	RoughnessFactor          *float32        `json:"roughnessFactor"`
+	MetallicRoughnessTexture *docTextureInfo `json:"metallicRoughnessTexture"`
+}
+
+type docTextureInfo struct {
+	Index    int `json:"index"`
+	TexCoord int `json:"texCoord"`
+}
+
+type docTexture struct {
+	Name    string `json:"name"`
+	Sampler *int   `json:"sampler"`
+	Source  *int   `json:"source"`
+}
+
+type docImage struct {
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	MimeType   string `json:"mimeType"`
+	BufferView *int   `json:"bufferView"`
+}
+
+type docSampler struct {
+	MagFilter int `json:"magFilter"`
+	MinFilter int `json:"minFilter"`
+	WrapS     int `json:"wrapS"`
+	WrapT     int `json:"wrapT"`
+}
+
+type docSkin struct {
+	Name                string `json:"name"`
+	InverseBindMatrices *int   `json:"inverseBindMatrices"`
+	Skeleton            *int   `json:"skeleton"`
+	Joints              []int  `json:"joints"`
+}
+
+type docAnimation struct {
+	Name     string                `json:"name"`
+	Channels []docAnimationChannel `json:"channels"`
+	Samplers []docAnimationSampler `json:"samplers"`
+}
+
+type docAnimationChannel struct {
+	Sampler int                       `json:"sampler"`
+	Target  docAnimationChannelTarget `json:"target"`
+}
+
+type docAnimationChannelTarget struct {
+	Node *int   `json:"node"`
+	Path string `json:"path"`
+}
+
+type docAnimationSampler struct {
+	Input         int    `json:"input"`
+	Output        int    `json:"output"`
+	Interpolation string `json:"interpolation"`
+}
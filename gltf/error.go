@@ -0,0 +1,54 @@
+package gltf
+
+import (
+	"fmt"
+)
+
+/******************************************************************************
+ ParseError
+******************************************************************************/
+
+// ParseError Describes a problem encountered while loading a glTF or GLB
+// file.  The first ParseError encountered stops the load and is returned
+// by Err(); problems that only cause part of the file to be skipped
+// (e.g., a primitive that is not made of triangles) are instead recorded
+// as warnings.
+type ParseError struct {
+	// Format is either "glTF" or "GLB".
+	Format string
+
+	// File is the name of the source file or, if the asset was
+	// not loaded from a file, the name of the asset.
+	File string
+
+	// Property identifies the offending element of the document
+	// (e.g. "accessors[3]", "meshes[0].primitives[1]"), if any.
+	Property string
+
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	if e.Property == "" {
+		return fmt.Sprintf("%s parse error: %s: %v", e.Format, e.File, e.Err)
+	}
+	return fmt.Sprintf("%s parse error: %s: %s: %v", e.Format, e.File, e.Property, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func newParseError(format, file, property string, err error) *ParseError {
+	return &ParseError{
+		Format:   format,
+		File:     file,
+		Property: property,
+		Err:      err,
+	}
+}
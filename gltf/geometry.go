@@ -0,0 +1,247 @@
+package gltf
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	epsilon = 1e-12
+)
+
+/******************************************************************************
+ primitive
+******************************************************************************/
+
+// primitive Holds the per-vertex attributes of a glTF mesh primitive while
+// any missing attributes are generated and the node transform is applied.
+type primitive struct {
+	positions []float32 // vec3
+	normals   []float32 // vec3
+	uvs       []float32 // vec2, with V flipped to match OpenGL/OBJ
+	tangents  []float32 // vec4, with W being the handedness (1 or -1)
+	joints    []float32 // vec4, indices into the joints of the model
+	weights   []float32 // vec4
+}
+
+func (p *primitive) vertexCount() int {
+	return len(p.positions) / 3
+}
+
+func (p *primitive) position(i int) mgl32.Vec3 {
+	return mgl32.Vec3{p.positions[i*3], p.positions[i*3+1], p.positions[i*3+2]}
+}
+
+func (p *primitive) normal(i int) mgl32.Vec3 {
+	return mgl32.Vec3{p.normals[i*3], p.normals[i*3+1], p.normals[i*3+2]}
+}
+
+func (p *primitive) uv(i int) mgl32.Vec2 {
+	return mgl32.Vec2{p.uvs[i*2], p.uvs[i*2+1]}
+}
+
+// unweld Gives each triangle its own vertices, which is required
+// to produce flat normals, returning the new triangle indices.
+func (p *primitive) unweld(triangles []int) []int {
+	positions := make([]float32, 0, len(triangles)*3)
+	uvs := make([]float32, 0, len(triangles)*2)
+	tangents := make([]float32, 0, len(triangles)*4)
+	joints := make([]float32, 0, len(triangles)*4)
+	weights := make([]float32, 0, len(triangles)*4)
+	unwelded := make([]int, len(triangles))
+
+	for i, index := range triangles {
+		positions = append(positions, p.positions[index*3:index*3+3]...)
+		if p.uvs != nil {
+			uvs = append(uvs, p.uvs[index*2:index*2+2]...)
+		}
+		if p.tangents != nil {
+			tangents = append(tangents, p.tangents[index*4:index*4+4]...)
+		}
+		if p.joints != nil {
+			joints = append(joints, p.joints[index*4:index*4+4]...)
+			weights = append(weights, p.weights[index*4:index*4+4]...)
+		}
+		unwelded[i] = i
+	}
+
+	p.positions = positions
+	if p.uvs != nil {
+		p.uvs = uvs
+	}
+	if p.tangents != nil {
+		p.tangents = tangents
+	}
+	if p.joints != nil {
+		p.joints = joints
+		p.weights = weights
+	}
+
+	return unwelded
+}
+
+// computeFlatNormals Sets the normal of every vertex to that of the
+// triangle it belongs to, which requires the primitive to be unwelded.
+func (p *primitive) computeFlatNormals() {
+	p.normals = make([]float32, len(p.positions))
+	for i := 0; i+2 < p.vertexCount(); i += 3 {
+		v0, v1, v2 := p.position(i), p.position(i+1), p.position(i+2)
+		n := normalizeOr(v1.Sub(v0).Cross(v2.Sub(v0)), mgl32.Vec3{0, 0, 1})
+		for j := 0; j < 3; j++ {
+			copy(p.normals[(i+j)*3:], n[:])
+		}
+	}
+}
+
+// computeTangents Generates per-vertex tangents by accumulating the
+// tangent/bitangent of each triangle sharing the vertex, which are then
+// orthogonalized against the normal.
+func (p *primitive) computeTangents(triangles []int) {
+	count := p.vertexCount()
+	tan := make([]mgl32.Vec3, count)
+	bitan := make([]mgl32.Vec3, count)
+
+	for i := 0; i+2 < len(triangles); i += 3 {
+		i0, i1, i2 := triangles[i], triangles[i+1], triangles[i+2]
+
+		deltaPos1 := p.position(i1).Sub(p.position(i0))
+		deltaPos2 := p.position(i2).Sub(p.position(i0))
+		deltaUV1 := p.uv(i1).Sub(p.uv(i0))
+		deltaUV2 := p.uv(i2).Sub(p.uv(i0))
+
+		d := deltaUV1.X()*deltaUV2.Y() - deltaUV1.Y()*deltaUV2.X()
+		if d == 0 {
+			continue
+		}
+
+		r := 1.0 / d
+		t := deltaPos1.Mul(deltaUV2.Y()).Sub(deltaPos2.Mul(deltaUV1.Y())).Mul(r)
+		b := deltaPos2.Mul(deltaUV1.X()).Sub(deltaPos1.Mul(deltaUV2.X())).Mul(r)
+
+		for _, index := range []int{i0, i1, i2} {
+			tan[index] = tan[index].Add(t)
+			bitan[index] = bitan[index].Add(b)
+		}
+	}
+
+	p.tangents = make([]float32, count*4)
+	for i := 0; i < count; i++ {
+		n := p.normal(i)
+		t := normalizeOr(tan[i].Sub(n.Mul(n.Dot(tan[i]))), perpendicular(n))
+
+		w := float32(1.0)
+		if n.Cross(t).Dot(bitan[i]) < 0 {
+			w = -1.0
+		}
+
+		p.tangents[i*4] = t[0]
+		p.tangents[i*4+1] = t[1]
+		p.tangents[i*4+2] = t[2]
+		p.tangents[i*4+3] = w
+	}
+}
+
+// transform Applies the world matrix of the node to the vertex attributes.
+func (p *primitive) transform(world mgl32.Mat4) {
+	normalMat := world.Mat3().Inv().Transpose()
+	tangentMat := world.Mat3()
+
+	handedness := float32(1.0)
+	if world.Det() < 0 {
+		handedness = -1.0
+	}
+
+	for i := 0; i < p.vertexCount(); i++ {
+		pos := mgl32.TransformCoordinate(p.position(i), world)
+		copy(p.positions[i*3:], pos[:])
+
+		n := normalizeOr(normalMat.Mul3x1(p.normal(i)), p.normal(i))
+		copy(p.normals[i*3:], n[:])
+
+		t := mgl32.Vec3{p.tangents[i*4], p.tangents[i*4+1], p.tangents[i*4+2]}
+		t = normalizeOr(tangentMat.Mul3x1(t), t)
+		copy(p.tangents[i*4:], t[:])
+		p.tangents[i*4+3] *= handedness
+	}
+}
+
+// bitangents Returns the bitangent of each vertex, derived from the
+// normal, tangent and handedness as defined by the glTF specification.
+func (p *primitive) bitangents() []float32 {
+	bitangents := make([]float32, 0, len(p.positions))
+	for i := 0; i < p.vertexCount(); i++ {
+		t := mgl32.Vec3{p.tangents[i*4], p.tangents[i*4+1], p.tangents[i*4+2]}
+		b := p.normal(i).Cross(t).Mul(p.tangents[i*4+3])
+		bitangents = append(bitangents, b[:]...)
+	}
+	return bitangents
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func normalizeOr(v, fallback mgl32.Vec3) mgl32.Vec3 {
+	if length := v.Len(); length > epsilon {
+		return v.Mul(1.0 / length)
+	}
+	return fallback
+}
+
+func perpendicular(n mgl32.Vec3) mgl32.Vec3 {
+	axis := mgl32.Vec3{1, 0, 0}
+	if n.X()*n.X() > 0.5 {
+		axis = mgl32.Vec3{0, 1, 0}
+	}
+	return normalizeOr(axis.Sub(n.Mul(n.Dot(axis))), mgl32.Vec3{1, 0, 0})
+}
+
+func triangulate(indices []int, mode int) []int {
+	switch mode {
+	case modeTriangleStrip:
+		triangles := make([]int, 0, len(indices)*3)
+		for i := 0; i+2 < len(indices); i++ {
+			if i%2 == 0 {
+				triangles = append(triangles, indices[i], indices[i+1], indices[i+2])
+			} else {
+				triangles = append(triangles, indices[i+1], indices[i], indices[i+2])
+			}
+		}
+		return triangles
+	case modeTriangleFan:
+		triangles := make([]int, 0, len(indices)*3)
+		for i := 1; i+1 < len(indices); i++ {
+			triangles = append(triangles, indices[0], indices[i], indices[i+1])
+		}
+		return triangles
+	default:
+		return indices[:len(indices)-len(indices)%3]
+	}
+}
+
+func nodeMatrix(node docNode) mgl32.Mat4 {
+	if len(node.Matrix) == 16 {
+		var mat mgl32.Mat4
+		copy(mat[:], node.Matrix) // column-major, as is mgl32
+		return mat
+	}
+
+	mat := mgl32.Ident4()
+
+	if len(node.Translation) == 3 {
+		mat = mat.Mul4(mgl32.Translate3D(node.Translation[0], node.Translation[1], node.Translation[2]))
+	}
+
+	if len(node.Rotation) == 4 {
+		quat := mgl32.Quat{
+			W: node.Rotation[3],
+			V: mgl32.Vec3{node.Rotation[0], node.Rotation[1], node.Rotation[2]},
+		}
+		mat = mat.Mul4(quat.Normalize().Mat4())
+	}
+
+	if len(node.Scale) == 3 {
+		mat = mat.Mul4(mgl32.Scale3D(node.Scale[0], node.Scale[1], node.Scale[2]))
+	}
+
+	return mat
+}
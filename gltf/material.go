@@ -0,0 +1,51 @@
+package gltf
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/tonybillings/gfx/internal/basic"
+)
+
+const (
+	defaultMaterialName = "default"
+	maxShininess        = 128.0
+	minShininess        = 1.0
+)
+
+/******************************************************************************
+ Material
+******************************************************************************/
+
+// BasicMaterial A glTF metallic-roughness material approximated with the
+// properties expected by the default 3D shaders (gfx.Shape3DShader, etc).
+// The base color (factor and texture) becomes the diffuse color/map, the
+// normal texture becomes the normal map and the emissive factor becomes
+// the emissive color; roughness is used to derive the specular intensity
+// and shininess.  The textures loaded from the glTF asset are closed with
+// the material.
+type BasicMaterial = basic.Material
+
+type BasicMaterialProperties = basic.MaterialProperties
+
+/******************************************************************************
+ Material Functions
+******************************************************************************/
+
+func setRoughness(material *BasicMaterial, roughness float32) {
+	smoothness := 1.0 - mgl32.Clamp(roughness, 0.0, 1.0)
+	material.Properties.Specular = mgl32.Vec4{smoothness, smoothness, smoothness, 1.0}
+	material.Properties.Shininess = mgl32.Clamp(maxShininess*smoothness*smoothness, minShininess, maxShininess)
+}
+
+/******************************************************************************
+ New Material Function
+******************************************************************************/
+
+// NewMaterial creates a material with the default values defined by the
+// glTF specification: a white, fully metallic and rough base color.
+func NewMaterial() *BasicMaterial {
+	m := basic.NewMaterial()
+	m.Properties.Ambient = mgl32.Vec4{0.2, 0.2, 0.2, 1.0}
+	m.Properties.Diffuse = mgl32.Vec4{1.0, 1.0, 1.0, 1.0}
+	setRoughness(m, 1.0)
+	return m
+}
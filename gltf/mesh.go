@@ -0,0 +1,44 @@
+package gltf
+
+import (
+	"github.com/tonybillings/gfx/internal/basic"
+)
+
+/******************************************************************************
+ Mesh
+******************************************************************************/
+
+// Mesh Contains the triangles of every primitive of a glTF mesh that is
+// referenced by a node in the scene.  Since the node's world transform is
+// baked into the model's vertex data, the mesh's own transform is left as
+// the identity and can be freely used to move the mesh relative to the
+// Shape3D object.  The vertices of skinned meshes are instead left in their
+// bind pose, to be transformed by the joints of the skin.
+type Mesh struct {
+	*basic.Mesh
+
+	node int
+}
+
+// Face A triangle.  Since every vertex attribute is stored per-vertex in
+// the model, the same indices are used for positions, normals, etc.
+type Face = basic.Face
+
+/******************************************************************************
+ Mesh Functions
+******************************************************************************/
+
+// Node returns the index of the glTF node from which the mesh was created.
+func (m *Mesh) Node() int {
+	return m.node
+}
+
+/******************************************************************************
+ New Mesh Function
+******************************************************************************/
+
+func NewMesh() *Mesh {
+	return &Mesh{
+		Mesh: basic.NewMesh(),
+	}
+}
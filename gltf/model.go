@@ -0,0 +1,747 @@
+// Package gltf provides an importer for glTF 2.0 models, supporting both
+// the JSON (.gltf) and binary (.glb) formats, with buffers and images
+// that are either embedded (data URIs, GLB binary chunk) or stored in
+// external files.  The node hierarchy of the scene is flattened, with
+// each node that references a mesh producing one gfx.Mesh whose vertex
+// data has the node's world transform baked in.  Skins and animations are
+// imported as the joints and clips of a gfx.SkinnedModel, with skinned
+// meshes left in their bind pose and meshes attached to animated nodes
+// bound rigidly to their joint, so they can be played by gfx.Shape3D.
+package gltf
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/internal/basic"
+	"github.com/tonybillings/gfx/internal/importer"
+	"io"
+	"path"
+	"sync/atomic"
+)
+
+const (
+	modeTriangles     = 4
+	modeTriangleStrip = 5
+	modeTriangleFan   = 6
+)
+
+const (
+	samplerNearest = 9728
+	maxNodeDepth   = 256
+)
+
+/******************************************************************************
+ Model
+******************************************************************************/
+
+type Model struct {
+	gfx.ModelBase
+
+	vertices   []float32
+	normals    []float32
+	uvs        []float32
+	tangents   []float32
+	bitangents []float32
+
+	joints       []gfx.Joint
+	jointIndices []float32
+	jointWeights []float32
+	clips        []*gfx.AnimationClip
+	skeleton     *skeleton
+
+	meshes    []*Mesh
+	materials []*BasicMaterial
+	images    map[int][]byte
+
+	defaultMaterial *BasicMaterial
+	defaultShader   gfx.Shader
+
+	scene int
+
+	err      error
+	warnings []*ParseError
+	loaded   atomic.Bool
+}
+
+/******************************************************************************
+ gfx.Asset Implementation
+******************************************************************************/
+
+func (m *Model) Init() bool {
+	if m.Initialized() {
+		return true
+	}
+
+	if err := m.Load(); err != nil {
+		return false
+	}
+
+	if ok := m.initMaterials(); !ok {
+		return false
+	}
+
+	if ok := m.initMeshes(); !ok {
+		return false
+	}
+
+	return m.AssetBase.Init()
+}
+
+func (m *Model) Close() {
+	if !m.Initialized() {
+		return
+	}
+
+	m.closeMeshes()
+	m.closeMaterials()
+
+	m.AssetBase.Close()
+}
+
+/******************************************************************************
+ gfx.Model Implementation
+******************************************************************************/
+
+func (m *Model) Vertices() []float32 {
+	return m.vertices
+}
+
+func (m *Model) Normals() []float32 {
+	return m.normals
+}
+
+func (m *Model) UVs() []float32 {
+	return m.uvs
+}
+
+func (m *Model) Tangents() []float32 {
+	return m.tangents
+}
+
+func (m *Model) Bitangents() []float32 {
+	return m.bitangents
+}
+
+func (m *Model) Meshes() []gfx.Mesh {
+	meshes := make([]gfx.Mesh, len(m.meshes))
+	for i, mesh := range m.meshes {
+		meshes[i] = mesh
+	}
+	return meshes
+}
+
+/******************************************************************************
+ gfx.IndexedModel Implementation
+******************************************************************************/
+
+func (m *Model) Indices() []uint32 {
+	return basic.Indices(m.Meshes())
+}
+
+/******************************************************************************
+ gfx.SkinnedModel Implementation
+******************************************************************************/
+
+func (m *Model) Joints() []gfx.Joint {
+	return m.joints
+}
+
+func (m *Model) JointIndices() []float32 {
+	return m.jointIndices
+}
+
+func (m *Model) JointWeights() []float32 {
+	return m.jointWeights
+}
+
+func (m *Model) AnimationClips() []*gfx.AnimationClip {
+	return m.clips
+}
+
+/******************************************************************************
+ Model Functions
+******************************************************************************/
+
+func (m *Model) loadFromSlice(slice []byte, filename string) {
+	d := newDecoder(m, filename, path.Dir(filename))
+	if err := d.decode(slice); err != nil {
+		m.err = err
+		return
+	}
+
+	m.loadDocument(d)
+}
+
+func (m *Model) loadFromFile(name string) (ok bool) {
+	reader, closeFunc, err := importer.SourceReader(m, name)
+	defer closeFunc()
+
+	if err != nil {
+		m.err = newParseError("glTF", name, "", err)
+		return true
+	}
+
+	if reader == nil {
+		return false
+	}
+
+	m.loadFromReader(reader, name)
+	return true
+}
+
+func (m *Model) loadFromString(gltf string) {
+	m.loadFromSlice([]byte(gltf), m.Name())
+}
+
+func (m *Model) loadFromReader(reader *bufio.Reader, filename string) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		m.err = newParseError("glTF", filename, "", fmt.Errorf("file read error: %w", err))
+		return
+	}
+
+	m.loadFromSlice(data, filename)
+}
+
+func (m *Model) loadDocument(d *decoder) {
+	m.images = make(map[int][]byte)
+	defer func() {
+		m.images = nil
+		m.skeleton = nil
+	}()
+
+	if m.loadMaterials(d); m.err != nil {
+		return
+	}
+
+	m.loadScene(d)
+}
+
+func (m *Model) loadMaterials(d *decoder) {
+	for i, mat := range d.doc.Materials {
+		material, err := m.loadMaterial(d, i, mat)
+		if err != nil {
+			m.err = err
+			return
+		}
+		m.materials = append(m.materials, material)
+	}
+
+	if m.defaultShader == nil {
+		srcLib := m.SourceLibrary()
+		if srcLib != nil {
+			shaderName := gfx.Shape3DShader
+			if len(d.doc.Skins) > 0 || len(d.doc.Animations) > 0 {
+				shaderName = gfx.Shape3DSkinnedShader
+			}
+			if defaultShader := srcLib.Get(shaderName); defaultShader != nil {
+				if shader, ok := defaultShader.(gfx.Shader); ok {
+					m.defaultShader = shader
+				}
+			}
+		}
+	}
+
+	if m.defaultMaterial == nil {
+		m.defaultMaterial = NewMaterial()
+		m.defaultMaterial.SetName(defaultMaterialName)
+		m.defaultMaterial.SetSourceLibrary(m.SourceLibrary())
+		m.defaultMaterial.LoadDefaultTextures()
+	}
+
+	for _, material := range append(m.materials, m.defaultMaterial) {
+		if material.AttachedShader() == nil && m.defaultShader != nil {
+			material.AttachShader(m.defaultShader)
+		}
+	}
+}
+
+func (m *Model) loadMaterial(d *decoder, index int, mat docMaterial) (*BasicMaterial, *ParseError) {
+	property := fmt.Sprintf("materials[%d]", index)
+
+	material := NewMaterial()
+	if mat.Name != "" {
+		material.SetName(mat.Name)
+	} else {
+		material.SetName(property)
+	}
+	material.SetSourceLibrary(m.SourceLibrary())
+
+	if pbr := mat.PbrMetallicRoughness; pbr != nil {
+		if len(pbr.BaseColorFactor) == 4 {
+			material.Properties.Diffuse = mgl32.Vec4{
+				pbr.BaseColorFactor[0], pbr.BaseColorFactor[1], pbr.BaseColorFactor[2], pbr.BaseColorFactor[3],
+			}
+		}
+
+		if pbr.RoughnessFactor != nil {
+			setRoughness(material, *pbr.RoughnessFactor)
+		}
+
+		if pbr.BaseColorTexture != nil {
+			texture, err := m.loadTexture(d, property+".pbrMetallicRoughness.baseColorTexture", pbr.BaseColorTexture.Index)
+			if err != nil {
+				return nil, err
+			}
+			material.DiffuseMap = material.AddTexture(texture)
+		}
+	}
+
+	if mat.NormalTexture != nil {
+		texture, err := m.loadTexture(d, property+".normalTexture", mat.NormalTexture.Index)
+		if err != nil {
+			return nil, err
+		}
+		material.NormalMap = material.AddTexture(texture)
+	}
+
+	if len(mat.EmissiveFactor) == 3 {
+		material.Properties.Emissive = mgl32.Vec4{mat.EmissiveFactor[0], mat.EmissiveFactor[1], mat.EmissiveFactor[2], 1.0}
+	}
+
+	if mat.AlphaMode == "BLEND" {
+		material.Properties.Transparency = 1.0 - material.Properties.Diffuse[3]
+	}
+
+	material.LoadDefaultTextures()
+	return material, nil
+}
+
+func (m *Model) loadTexture(d *decoder, property string, index int) (*gfx.Texture2D, *ParseError) {
+	if index < 0 || index >= len(d.doc.Textures) {
+		return nil, d.errorf(property, "texture index (%d) out of range", index)
+	}
+
+	tex := d.doc.Textures[index]
+	if tex.Source == nil {
+		return nil, d.errorf(fmt.Sprintf("textures[%d]", index), "texture has no source image")
+	}
+
+	data, name, err := m.loadImage(d, *tex.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	config := gfx.NewTextureConfig(gfx.HighestQuality)
+	if tex.Sampler != nil {
+		if *tex.Sampler < 0 || *tex.Sampler >= len(d.doc.Samplers) {
+			return nil, d.errorf(fmt.Sprintf("textures[%d]", index), "sampler index (%d) out of range", *tex.Sampler)
+		}
+
+		sampler := d.doc.Samplers[*tex.Sampler]
+		if sampler.MagFilter == samplerNearest {
+			config.FilterQuality = gfx.LowestQuality
+		}
+		if sampler.WrapS != 0 {
+			config.UWrapMode = gfx.TextureWrapMode(sampler.WrapS)
+		}
+		if sampler.WrapT != 0 {
+			config.VWrapMode = gfx.TextureWrapMode(sampler.WrapT)
+		}
+	}
+
+	texture := gfx.NewTexture2D(name, data, config)
+	texture.SetSourceLibrary(m.SourceLibrary())
+	return texture, nil
+}
+
+func (m *Model) loadImage(d *decoder, index int) (data []byte, name string, err *ParseError) {
+	property := fmt.Sprintf("images[%d]", index)
+
+	if index < 0 || index >= len(d.doc.Images) {
+		return nil, "", d.errorf(property, "index out of range")
+	}
+
+	img := d.doc.Images[index]
+	name = img.Name
+	if name == "" {
+		name = fmt.Sprintf("%s/%s", m.Name(), property)
+	}
+
+	if cached, ok := m.images[index]; ok {
+		return cached, name, nil
+	}
+
+	switch {
+	case img.BufferView != nil:
+		if data, _, err = d.bufferView(*img.BufferView); err != nil {
+			return nil, "", err
+		}
+	case img.URI != "":
+		var readErr error
+		if data, readErr = readUri(m, d.baseDir, img.URI); readErr != nil {
+			return nil, "", newParseError(d.format, d.file, property, readErr)
+		}
+	default:
+		return nil, "", d.errorf(property, "image has no URI or buffer view")
+	}
+
+	m.images[index] = data
+	return data, name, nil
+}
+
+func (m *Model) loadScene(d *decoder) {
+	var roots []int
+
+	sceneIndex := m.scene
+	if sceneIndex < 0 && d.doc.Scene != nil {
+		sceneIndex = *d.doc.Scene
+	}
+
+	switch {
+	case len(d.doc.Scenes) == 0:
+		// Without scenes, every node that is not a child is a root node
+		isChild := make(map[int]bool)
+		for _, node := range d.doc.Nodes {
+			for _, child := range node.Children {
+				isChild[child] = true
+			}
+		}
+		for i := range d.doc.Nodes {
+			if !isChild[i] {
+				roots = append(roots, i)
+			}
+		}
+	case sceneIndex < 0:
+		roots = d.doc.Scenes[0].Nodes
+	case sceneIndex < len(d.doc.Scenes):
+		roots = d.doc.Scenes[sceneIndex].Nodes
+	default:
+		m.err = d.errorf("scene", "scene index (%d) out of range", sceneIndex)
+		return
+	}
+
+	if m.loadSkeleton(d, roots); m.err != nil {
+		return
+	}
+
+	for _, root := range roots {
+		if m.loadNode(d, root, mgl32.Ident4(), 0); m.err != nil {
+			return
+		}
+	}
+}
+
+func (m *Model) loadNode(d *decoder, index int, parentMat mgl32.Mat4, depth int) {
+	property := fmt.Sprintf("nodes[%d]", index)
+
+	if index < 0 || index >= len(d.doc.Nodes) {
+		m.err = d.errorf(property, "index out of range")
+		return
+	}
+
+	if depth > maxNodeDepth {
+		m.err = d.errorf(property, "node hierarchy is too deep or contains a cycle")
+		return
+	}
+
+	node := d.doc.Nodes[index]
+	worldMat := parentMat.Mul4(nodeMatrix(node))
+
+	if node.Mesh != nil {
+		if m.loadMesh(d, index, *node.Mesh, worldMat); m.err != nil {
+			return
+		}
+	}
+
+	for _, child := range node.Children {
+		if m.loadNode(d, child, worldMat, depth+1); m.err != nil {
+			return
+		}
+	}
+}
+
+func (m *Model) loadMesh(d *decoder, nodeIndex, meshIndex int, worldMat mgl32.Mat4) {
+	property := fmt.Sprintf("meshes[%d]", meshIndex)
+
+	if meshIndex < 0 || meshIndex >= len(d.doc.Meshes) {
+		m.err = d.errorf(fmt.Sprintf("nodes[%d].mesh", nodeIndex), "mesh index (%d) out of range", meshIndex)
+		return
+	}
+
+	docMesh := d.doc.Meshes[meshIndex]
+
+	mesh := NewMesh()
+	mesh.node = nodeIndex
+	name := d.doc.Nodes[nodeIndex].Name
+	if name == "" {
+		name = docMesh.Name
+	}
+	if name == "" {
+		name = fmt.Sprintf("nodes[%d]", nodeIndex)
+	}
+	mesh.SetName(name)
+
+	for i, prim := range docMesh.Primitives {
+		if err := m.loadPrimitive(d, fmt.Sprintf("%s.primitives[%d]", property, i), nodeIndex, prim, worldMat, mesh); err != nil {
+			m.err = err
+			return
+		}
+	}
+
+	if len(mesh.Triangles()) > 0 {
+		m.meshes = append(m.meshes, mesh)
+	}
+}
+
+func (m *Model) loadPrimitive(d *decoder, property string, nodeIndex int, prim docPrimitive, worldMat mgl32.Mat4, mesh *Mesh) *ParseError {
+	mode := modeTriangles
+	if prim.Mode != nil {
+		mode = *prim.Mode
+	}
+
+	if mode != modeTriangles && mode != modeTriangleStrip && mode != modeTriangleFan {
+		m.warnings = append(m.warnings, d.errorf(property, "unsupported mode (%d), only triangles are supported", mode))
+		return nil
+	}
+
+	positionIndex, ok := prim.Attributes["POSITION"]
+	if !ok {
+		m.warnings = append(m.warnings, d.errorf(property, "primitive has no POSITION attribute"))
+		return nil
+	}
+
+	p := &primitive{}
+	var err *ParseError
+
+	if p.positions, err = m.readAttribute(d, property, "POSITION", positionIndex, 3, -1); err != nil {
+		return err
+	}
+	vertexCount := p.vertexCount()
+
+	if index, ok := prim.Attributes["NORMAL"]; ok {
+		if p.normals, err = m.readAttribute(d, property, "NORMAL", index, 3, vertexCount); err != nil {
+			return err
+		}
+	}
+
+	if index, ok := prim.Attributes["TEXCOORD_0"]; ok {
+		if p.uvs, err = m.readAttribute(d, property, "TEXCOORD_0", index, 2, vertexCount); err != nil {
+			return err
+		}
+		for i := 1; i < len(p.uvs); i += 2 {
+			p.uvs[i] = 1.0 - p.uvs[i]
+		}
+	}
+
+	if index, ok := prim.Attributes["TANGENT"]; ok {
+		if p.tangents, err = m.readAttribute(d, property, "TANGENT", index, 4, vertexCount); err != nil {
+			return err
+		}
+	}
+
+	// Skinned vertices are transformed by their joints rather than the node
+	if m.skeleton != nil {
+		skinned, err := m.loadJointAttributes(d, property, nodeIndex, prim, p)
+		if err != nil {
+			return err
+		}
+		if skinned {
+			worldMat = mgl32.Ident4()
+		}
+	}
+
+	var indices []int
+	if prim.Indices != nil {
+		var components int
+		if indices, components, err = d.readInts(*prim.Indices); err != nil {
+			return err
+		}
+		if components != 1 {
+			return d.errorf(property+".indices", "expected SCALAR accessor")
+		}
+		for _, index := range indices {
+			if index < 0 || index >= vertexCount {
+				return d.errorf(property+".indices", "index (%d) out of range", index)
+			}
+		}
+	} else {
+		indices = make([]int, vertexCount)
+		for i := range indices {
+			indices[i] = i
+		}
+	}
+
+	triangles := triangulate(indices, mode)
+	if len(triangles) == 0 {
+		return nil
+	}
+
+	if p.normals == nil {
+		triangles = p.unweld(triangles)
+		p.computeFlatNormals()
+	}
+
+	if p.uvs == nil {
+		p.uvs = make([]float32, p.vertexCount()*2)
+	}
+
+	if p.tangents == nil {
+		p.computeTangents(triangles)
+	}
+
+	p.transform(worldMat)
+
+	material := m.defaultMaterial
+	if prim.Material != nil {
+		if *prim.Material < 0 || *prim.Material >= len(m.materials) {
+			return d.errorf(property+".material", "material index (%d) out of range", *prim.Material)
+		}
+		material = m.materials[*prim.Material]
+	}
+
+	base := len(m.vertices) / 3
+	m.vertices = append(m.vertices, p.positions...)
+	m.normals = append(m.normals, p.normals...)
+	m.uvs = append(m.uvs, p.uvs...)
+	m.bitangents = append(m.bitangents, p.bitangents()...)
+	for i := 0; i < p.vertexCount(); i++ {
+		m.tangents = append(m.tangents, p.tangents[i*4:i*4+3]...)
+	}
+	if m.skeleton != nil {
+		m.jointIndices = append(m.jointIndices, p.joints...)
+		m.jointWeights = append(m.jointWeights, p.weights...)
+	}
+
+	mirrored := worldMat.Det() < 0
+	for i := 0; i+2 < len(triangles); i += 3 {
+		indices := []int{base + triangles[i], base + triangles[i+1], base + triangles[i+2]}
+		if mirrored {
+			indices[1], indices[2] = indices[2], indices[1]
+		}
+		mesh.AddFace(basic.NewIndexedFace(indices, material))
+	}
+
+	return nil
+}
+
+func (m *Model) readAttribute(d *decoder, property, attribute string, index, components, count int) ([]float32, *ParseError) {
+	values, actual, err := d.readFloats(index)
+	if err != nil {
+		return nil, err
+	}
+
+	property = fmt.Sprintf("%s.attributes.%s", property, attribute)
+
+	if actual != components {
+		return nil, d.errorf(property, "expected %d components, got %d", components, actual)
+	}
+
+	if count >= 0 && len(values)/components != count {
+		return nil, d.errorf(property, "expected %d elements, got %d", count, len(values)/components)
+	}
+
+	return values, nil
+}
+
+func (m *Model) initMaterials() bool {
+	ok := true
+	for _, mat := range m.materials {
+		ok = ok && mat.Init()
+	}
+	return ok && m.defaultMaterial.Init()
+}
+
+func (m *Model) closeMaterials() {
+	for _, mat := range m.materials {
+		mat.Close()
+	}
+
+	if m.defaultMaterial != nil {
+		m.defaultMaterial.Close()
+	}
+}
+
+func (m *Model) initMeshes() bool {
+	ok := true
+	for _, mesh := range m.meshes {
+		ok = ok && mesh.Init()
+	}
+	return ok
+}
+
+func (m *Model) closeMeshes() {
+	for _, mesh := range m.meshes {
+		mesh.Close()
+	}
+}
+
+func (m *Model) SetDefaultShader(shader gfx.Shader) *Model {
+	m.defaultShader = shader
+	return m
+}
+
+// Materials returns the materials defined by the document, in the same
+// order, which can be used to alter their properties after loading.
+func (m *Model) Materials() []*BasicMaterial {
+	return m.materials
+}
+
+func (m *Model) Scene() int {
+	return m.scene
+}
+
+// SetScene sets the index of the scene to load, which must be done before
+// the model is loaded.  By default (-1), the scene specified by the
+// document is used or, if not specified, the first scene.
+func (m *Model) SetScene(index int) *Model {
+	m.scene = index
+	return m
+}
+
+// Load parses the glTF/GLB source, along with any external buffers and
+// images it references, returning the error that stopped the load,
+// if any (see Err()).
+func (m *Model) Load() error {
+	if m.loaded.Load() {
+		return m.err
+	}
+
+	switch source := m.Source().(type) {
+	case []byte:
+		m.loadFromSlice(source, m.Name())
+	case string:
+		if ok := m.loadFromFile(source); !ok {
+			m.loadFromString(source)
+		}
+	default:
+		m.err = newParseError("glTF", m.Name(), "", fmt.Errorf("source type is not supported"))
+	}
+
+	if m.err == nil && len(m.meshes) == 0 {
+		m.err = newParseError("glTF", m.Name(), "", fmt.Errorf("scene contains no triangle meshes"))
+	}
+
+	m.loaded.Store(true)
+	return m.err
+}
+
+// Err returns the error that caused loading to fail, which will
+// be a *ParseError, or nil if the model loaded successfully.
+func (m *Model) Err() error {
+	return m.err
+}
+
+// Warnings returns the problems encountered while loading that caused
+// parts of the document to be skipped, such as non-triangle primitives.
+func (m *Model) Warnings() []*ParseError {
+	return m.warnings
+}
+
+/******************************************************************************
+ New Model Function
+******************************************************************************/
+
+// NewModel creates a model from the given source, which can be the name of
+// a .gltf/.glb file (resolved via the source library, if set), the contents
+// of such a file or a string containing the JSON document.
+func NewModel[T gfx.ModelSource](name string, source T) *Model {
+	return &Model{
+		ModelBase: gfx.ModelBase{
+			AssetBase: *gfx.NewAssetBase(name, source),
+		},
+		scene: -1,
+	}
+}
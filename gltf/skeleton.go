@@ -0,0 +1,434 @@
+package gltf
+
+import (
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/tonybillings/gfx"
+	"time"
+)
+
+const (
+	interpolationStep        = "STEP"
+	interpolationCubicSpline = "CUBICSPLINE"
+
+	pathTranslation = "translation"
+	pathRotation    = "rotation"
+	pathScale       = "scale"
+)
+
+/******************************************************************************
+ skeleton
+******************************************************************************/
+
+// skeleton Holds the node hierarchy of the scene while loading a document
+// with skins and/or animations, along with the joints created for the nodes
+// that are part of the skeleton: those that are skin joints or animated, and
+// their ancestors.  A node has more than one joint when skins bind to it with
+// different inverse bind matrices, the first joint being bound to the node's
+// rest pose, as used by meshes that are not skinned but attached to the node
+// (or its descendants), which are bound rigidly to the joint.
+type skeleton struct {
+	parents    map[int]int        // the parent of each node in the scene, or -1
+	globals    map[int]mgl32.Mat4 // the world matrix of each node, at rest
+	order      []int              // the nodes in the scene, parents first
+	nodeJoints map[int][]int      // the joints created for each node
+	skinJoints [][]int            // the joint of each joint of each skin
+}
+
+func (s *skeleton) walk(d *decoder, node, parent int, parentMat mgl32.Mat4, depth int) {
+	if node < 0 || node >= len(d.doc.Nodes) || depth > maxNodeDepth {
+		return // reported when loading the nodes
+	}
+	if _, visited := s.parents[node]; visited {
+		return
+	}
+
+	worldMat := parentMat.Mul4(nodeMatrix(d.doc.Nodes[node]))
+	s.parents[node] = parent
+	s.globals[node] = worldMat
+	s.order = append(s.order, node)
+
+	for _, child := range d.doc.Nodes[node].Children {
+		s.walk(d, child, node, worldMat, depth+1)
+	}
+}
+
+// nearestJoint Returns the joint bound to the rest pose of the given node or,
+// if the node is not part of the skeleton, of its nearest ancestor that is.
+func (s *skeleton) nearestJoint(node int) (joint int, ok bool) {
+	for node >= 0 {
+		if joints, exists := s.nodeJoints[node]; exists {
+			return joints[0], true
+		}
+
+		parent, inScene := s.parents[node]
+		if !inScene {
+			break
+		}
+		node = parent
+	}
+	return
+}
+
+func newSkeleton() *skeleton {
+	return &skeleton{
+		parents:    make(map[int]int),
+		globals:    make(map[int]mgl32.Mat4),
+		nodeJoints: make(map[int][]int),
+	}
+}
+
+/******************************************************************************
+ Model Functions
+******************************************************************************/
+
+// loadSkeleton Creates the joints of the skins and animated nodes of the
+// scene with the given root nodes, followed by the animation clips.
+func (m *Model) loadSkeleton(d *decoder, roots []int) {
+	if len(d.doc.Skins) == 0 && len(d.doc.Animations) == 0 {
+		return
+	}
+
+	s := newSkeleton()
+	for _, root := range roots {
+		s.walk(d, root, -1, mgl32.Ident4(), 0)
+	}
+
+	included := make(map[int]bool)
+	include := func(node int) {
+		for node >= 0 && !included[node] {
+			parent, inScene := s.parents[node]
+			if !inScene {
+				return
+			}
+			included[node] = true
+			node = parent
+		}
+	}
+
+	for _, skin := range d.doc.Skins {
+		for _, node := range skin.Joints {
+			include(node)
+		}
+	}
+	for _, animation := range d.doc.Animations {
+		for _, channel := range animation.Channels {
+			if channel.Target.Node != nil && isJointPath(channel.Target.Path) {
+				include(*channel.Target.Node)
+			}
+		}
+	}
+
+	for _, node := range s.order {
+		if !included[node] {
+			continue
+		}
+
+		joint := gfx.Joint{
+			Name:              nodeName(d, node),
+			Parent:            -1,
+			InverseBindMatrix: s.globals[node].Inv(),
+		}
+		if parent := s.parents[node]; parent >= 0 {
+			joint.Parent = s.nodeJoints[parent][0]
+		}
+		joint.Translation, joint.Rotation, joint.Scale = nodeTransform(d.doc.Nodes[node])
+
+		s.nodeJoints[node] = []int{len(m.joints)}
+		m.joints = append(m.joints, joint)
+	}
+
+	for i, skin := range d.doc.Skins {
+		if m.loadSkin(d, s, i, skin); m.err != nil {
+			return
+		}
+	}
+
+	if len(m.joints) > gfx.MaxJoints {
+		m.warnings = append(m.warnings, d.errorf("skins", "skeleton has %d joints, exceeding the maximum of %d, "+
+			"so the model will not be animated", len(m.joints), gfx.MaxJoints))
+		m.joints = nil
+		return
+	}
+
+	for i, animation := range d.doc.Animations {
+		if m.loadAnimation(d, s, i, animation); m.err != nil {
+			return
+		}
+	}
+
+	m.skeleton = s
+}
+
+func (m *Model) loadSkin(d *decoder, s *skeleton, index int, skin docSkin) {
+	property := fmt.Sprintf("skins[%d]", index)
+
+	var inverseBindMatrices []float32
+	if skin.InverseBindMatrices != nil {
+		values, components, err := d.readFloats(*skin.InverseBindMatrices)
+		if err != nil {
+			m.err = err
+			return
+		}
+		if components != 16 || len(values) < len(skin.Joints)*16 {
+			m.err = d.errorf(property+".inverseBindMatrices", "expected %d MAT4 elements", len(skin.Joints))
+			return
+		}
+		inverseBindMatrices = values
+	}
+
+	joints := make([]int, len(skin.Joints))
+	for i, node := range skin.Joints {
+		nodeJoints, ok := s.nodeJoints[node]
+		if !ok {
+			m.err = d.errorf(property+".joints", "joint node (%d) is not part of the scene", node)
+			return
+		}
+
+		// As per the specification, the inverse bind matrices default to
+		// the identity matrix
+		inverseBindMatrix := mgl32.Ident4()
+		if inverseBindMatrices != nil {
+			copy(inverseBindMatrix[:], inverseBindMatrices[i*16:i*16+16]) // column-major, as is mgl32
+		}
+
+		joints[i] = -1
+		for _, joint := range nodeJoints {
+			if m.joints[joint].InverseBindMatrix.ApproxEqualThreshold(inverseBindMatrix, 1e-5) {
+				joints[i] = joint
+				break
+			}
+		}
+
+		if joints[i] == -1 {
+			joint := m.joints[nodeJoints[0]]
+			joint.InverseBindMatrix = inverseBindMatrix
+			joints[i] = len(m.joints)
+			s.nodeJoints[node] = append(nodeJoints, joints[i])
+			m.joints = append(m.joints, joint)
+		}
+	}
+
+	s.skinJoints = append(s.skinJoints, joints)
+}
+
+func (m *Model) loadAnimation(d *decoder, s *skeleton, index int, animation docAnimation) {
+	property := fmt.Sprintf("animations[%d]", index)
+
+	clip := &gfx.AnimationClip{Name: animation.Name}
+	if clip.Name == "" {
+		clip.Name = property
+	}
+
+	channels := make(map[int]int) // joint -> index of its channel in the clip
+	for i, channel := range animation.Channels {
+		channelProperty := fmt.Sprintf("%s.channels[%d]", property, i)
+
+		if channel.Target.Node == nil {
+			continue
+		}
+
+		if !isJointPath(channel.Target.Path) {
+			m.warnings = append(m.warnings, d.errorf(channelProperty, "unsupported target path: %q", channel.Target.Path))
+			continue
+		}
+
+		nodeJoints, ok := s.nodeJoints[*channel.Target.Node]
+		if !ok {
+			continue // not part of the scene
+		}
+
+		if channel.Sampler < 0 || channel.Sampler >= len(animation.Samplers) {
+			m.err = d.errorf(channelProperty, "sampler index (%d) out of range", channel.Sampler)
+			return
+		}
+		sampler := animation.Samplers[channel.Sampler]
+		samplerProperty := fmt.Sprintf("%s.samplers[%d]", property, channel.Sampler)
+
+		times, components, err := d.readFloats(sampler.Input)
+		if err != nil {
+			m.err = err
+			return
+		}
+		if components != 1 {
+			m.err = d.errorf(samplerProperty+".input", "expected SCALAR accessor")
+			return
+		}
+
+		values, components, err := d.readFloats(sampler.Output)
+		if err != nil {
+			m.err = err
+			return
+		}
+
+		expected := 3
+		if channel.Target.Path == pathRotation {
+			expected = 4
+		}
+
+		// Cubic spline samplers store an in-tangent, value and out-tangent
+		// per keyframe, of which only the value is used
+		stride := 1
+		if sampler.Interpolation == interpolationCubicSpline {
+			stride = 3
+			m.warnings = append(m.warnings, d.errorf(samplerProperty, "cubic spline interpolation is approximated as linear"))
+		}
+
+		if components != expected || len(values) != len(times)*components*stride {
+			m.err = d.errorf(samplerProperty+".output", "expected %d elements with %d components", len(times)*stride, expected)
+			return
+		}
+
+		var easing gfx.EasingFunc
+		if sampler.Interpolation == interpolationStep {
+			easing = gfx.EaseStep
+		}
+
+		for _, joint := range nodeJoints {
+			channelIndex, exists := channels[joint]
+			if !exists {
+				channelIndex = len(clip.Channels)
+				channels[joint] = channelIndex
+				clip.Channels = append(clip.Channels, gfx.JointChannel{Joint: joint})
+			}
+			jointChannel := &clip.Channels[channelIndex]
+
+			switch channel.Target.Path {
+			case pathTranslation:
+				jointChannel.Translations = newKeyframes(times, values, stride, easing, vec3At)
+			case pathRotation:
+				jointChannel.Rotations = newKeyframes(times, values, stride, easing, quatAt)
+			case pathScale:
+				jointChannel.Scales = newKeyframes(times, values, stride, easing, vec3At)
+			}
+		}
+	}
+
+	if len(clip.Channels) > 0 {
+		m.clips = append(m.clips, clip)
+	}
+}
+
+// loadJointAttributes Reads the joints and weights of the primitive, if its
+// node has a skin, remapping the joints of the skin to those of the model,
+// in which case true is returned.  Otherwise, the vertices are bound rigidly
+// to the joint of the node, or of its nearest ancestor that has one, if any.
+func (m *Model) loadJointAttributes(d *decoder, property string, nodeIndex int, prim docPrimitive, p *primitive) (skinned bool, err *ParseError) {
+	vertexCount := p.vertexCount()
+	node := d.doc.Nodes[nodeIndex]
+
+	jointsIndex, hasJoints := prim.Attributes["JOINTS_0"]
+	weightsIndex, hasWeights := prim.Attributes["WEIGHTS_0"]
+
+	if node.Skin != nil && hasJoints && hasWeights {
+		skin := *node.Skin
+		if skin < 0 || skin >= len(m.skeleton.skinJoints) {
+			return false, d.errorf(fmt.Sprintf("nodes[%d].skin", nodeIndex), "skin index (%d) out of range", skin)
+		}
+
+		joints, components, err := d.readInts(jointsIndex)
+		if err != nil {
+			return false, err
+		}
+		if components != 4 || len(joints) != vertexCount*4 {
+			return false, d.errorf(property+".attributes.JOINTS_0", "expected %d elements with 4 components", vertexCount)
+		}
+
+		if p.weights, err = m.readAttribute(d, property, "WEIGHTS_0", weightsIndex, 4, vertexCount); err != nil {
+			return false, err
+		}
+
+		skinJoints := m.skeleton.skinJoints[skin]
+		p.joints = make([]float32, len(joints))
+		for i, joint := range joints {
+			if p.weights[i] == 0 {
+				continue // joints without influence can be anything
+			}
+			if joint < 0 || joint >= len(skinJoints) {
+				return false, d.errorf(property+".attributes.JOINTS_0", "joint index (%d) out of range", joint)
+			}
+			p.joints[i] = float32(skinJoints[joint])
+		}
+
+		return true, nil
+	}
+
+	p.joints = make([]float32, vertexCount*4)
+	p.weights = make([]float32, vertexCount*4)
+
+	if joint, ok := m.skeleton.nearestJoint(nodeIndex); ok {
+		for i := 0; i < vertexCount; i++ {
+			p.joints[i*4] = float32(joint)
+			p.weights[i*4] = 1.0
+		}
+	}
+
+	return false, nil
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func isJointPath(path string) bool {
+	return path == pathTranslation || path == pathRotation || path == pathScale
+}
+
+func nodeName(d *decoder, node int) string {
+	if name := d.doc.Nodes[node].Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("nodes[%d]", node)
+}
+
+// nodeTransform Returns the translation, rotation and scale of the node,
+// relative to its parent.
+func nodeTransform(node docNode) (translation mgl32.Vec3, rotation mgl32.Quat, scale mgl32.Vec3) {
+	if len(node.Matrix) == 16 {
+		return gfx.DecomposeMatrix(nodeMatrix(node))
+	}
+
+	rotation = mgl32.QuatIdent()
+	scale = mgl32.Vec3{1, 1, 1}
+
+	if len(node.Translation) == 3 {
+		translation = mgl32.Vec3{node.Translation[0], node.Translation[1], node.Translation[2]}
+	}
+
+	if len(node.Rotation) == 4 {
+		rotation = quatAt(node.Rotation, 0)
+	}
+
+	if len(node.Scale) == 3 {
+		scale = mgl32.Vec3{node.Scale[0], node.Scale[1], node.Scale[2]}
+	}
+
+	return
+}
+
+func vec3At(values []float32, index int) mgl32.Vec3 {
+	return mgl32.Vec3{values[index*3], values[index*3+1], values[index*3+2]}
+}
+
+// quatAt Returns the quaternion stored as XYZW at the given index.
+func quatAt(values []float32, index int) mgl32.Quat {
+	v := values[index*4 : index*4+4]
+	return mgl32.Quat{W: v[3], V: mgl32.Vec3{v[0], v[1], v[2]}}.Normalize()
+}
+
+// newKeyframes Creates a keyframe for each of the given times (in seconds),
+// taking the value of each from the given sampler output, which has stride
+// values per keyframe, of which the middle one is used.
+func newKeyframes[T gfx.Animatable](times, values []float32, stride int, easing gfx.EasingFunc,
+	valueAt func([]float32, int) T) []gfx.Keyframe[T] {
+
+	keyframes := make([]gfx.Keyframe[T], len(times))
+	for i, t := range times {
+		keyframes[i] = gfx.Keyframe[T]{
+			Time:   time.Duration(float64(t) * float64(time.Second)),
+			Value:  valueAt(values, i*stride+stride/2),
+			Easing: easing,
+		}
+	}
+	return keyframes
+}
@@ -32,10 +32,15 @@ func (h *KeyEventHandler) Event() uint64 {
  Mouse
 ******************************************************************************/
 
+// MouseState The position of the mouse (in normalized device coordinates),
+// the state of its buttons, and the accumulated offset of its scroll
+// wheel(s), which only ever changes by the amount scrolled; consumers
+// should compare it with the value they last observed.
 type MouseState struct {
 	X, Y                       float32
 	PrimaryDown, SecondaryDown bool
 	ButtonsSwapped             bool
+	ScrollX, ScrollY           float32
 }
 
 func (s *MouseState) Update(button glfw.MouseButton, action glfw.Action) {
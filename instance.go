@@ -0,0 +1,339 @@
+package gfx
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"image/color"
+	"sync"
+)
+
+const (
+	defaultInstancedShape3DName = "InstancedShape3D"
+)
+
+/******************************************************************************
+ Instance3D
+******************************************************************************/
+
+// Instance3D A single instance of the Model drawn by an InstancedShape3D,
+// with its own transform (relative to the shape), color tint and visibility,
+// any of which can be changed from any goroutine.
+type Instance3D struct {
+	ObjectTransform
+
+	tint    color.RGBA
+	visible bool
+
+	stateMutex sync.Mutex
+}
+
+func (i *Instance3D) Tint() (rgba color.RGBA) {
+	i.stateMutex.Lock()
+	rgba = i.tint
+	i.stateMutex.Unlock()
+	return
+}
+
+// SetTint sets the color multiplied with the diffuse color (and opacity) of
+// the instance's materials.  Defaults to White.
+func (i *Instance3D) SetTint(rgba color.RGBA) *Instance3D {
+	i.stateMutex.Lock()
+	i.tint = rgba
+	i.stateMutex.Unlock()
+	return i
+}
+
+func (i *Instance3D) Visible() (visible bool) {
+	i.stateMutex.Lock()
+	visible = i.visible
+	i.stateMutex.Unlock()
+	return
+}
+
+// SetVisibility determines whether the instance is drawn (and can be hit when
+// ray casting).  Defaults to true.
+func (i *Instance3D) SetVisibility(visible bool) *Instance3D {
+	i.stateMutex.Lock()
+	i.visible = visible
+	i.stateMutex.Unlock()
+	return i
+}
+
+/******************************************************************************
+ InstancedShape3D
+******************************************************************************/
+
+// InstancedShape3D Draws many instances of the same Model, each with its own
+// transform, color tint and visibility (see Instance3D), using a single draw
+// call per group of faces rather than one per shape.  The world matrices and
+// tints of the visible instances are uploaded to an instance buffer each
+// frame, to be read by the shader via the a_InstanceWorldMat and
+// a_InstanceTint vertex attributes.  Instances are positioned relative to
+// the shape itself, so that all of them can be moved at once.
+type InstancedShape3D struct {
+	Shape3D
+
+	shader Shader
+
+	instances    []*Instance3D
+	instanceData []float32
+
+	// instanceCount The number of instances in the instance buffer, which
+	// is uploaded at most once per frame (see updateInstanceBuffer()).
+	instanceCount int32
+	instanceFrame uint64
+
+	instanceVbo          uint32
+	instanceVboCloseFunc func()
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (s *InstancedShape3D) Init() (ok bool) {
+	if s.Initialized() {
+		return true
+	}
+
+	s.initViewport()
+	s.initShader()
+	s.initModel()
+	s.initInstanceBuffer()
+
+	return s.WindowObjectBase.Init()
+}
+
+func (s *InstancedShape3D) Close() {
+	if !s.Initialized() {
+		return
+	}
+
+	if s.instanceVboCloseFunc != nil {
+		s.instanceVboCloseFunc()
+	}
+
+	s.Shape3D.Close()
+}
+
+/******************************************************************************
+ DrawableObject Implementation
+******************************************************************************/
+
+func (s *InstancedShape3D) Draw(deltaTime int64) (ok bool) {
+	if !s.DrawableObjectBase.Draw(deltaTime) {
+		return false
+	}
+
+	if count := s.updateInstanceBuffer(); count > 0 {
+		s.beginDraw()
+		s.updateScene()
+		s.modelRenderer.setInstanceCount(count)
+		s.draw()
+		s.endDraw()
+	}
+
+	return s.WindowObjectBase.drawChildren(deltaTime)
+}
+
+/******************************************************************************
+ InstancedShape3D Functions
+******************************************************************************/
+
+func (s *InstancedShape3D) initShader() {
+	if s.shader == nil {
+		s.shader = s.window.Assets().Get(Shape3DInstancedShader).(Shader)
+	}
+}
+
+func (s *InstancedShape3D) initModel() {
+	s.initModelInstance(newModelInstance(s.modelAsset, nil, s.shader))
+}
+
+func (s *InstancedShape3D) initInstanceBuffer() {
+	vbo := uint32(0)
+	gl.GenBuffers(1, &vbo)
+
+	for _, mesh := range s.modelInstance.meshes {
+		for _, group := range mesh.faceGroups {
+			enableInstanceAttributes(group.vao, vbo, group.shader)
+		}
+	}
+
+	s.instanceVbo = vbo
+	s.instanceVboCloseFunc = func() {
+		gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+		gl.DeleteBuffers(1, &vbo)
+	}
+}
+
+// updateInstanceBuffer Uploads the world matrix and tint of each visible
+// instance to the instance buffer, returning the number of instances to
+// be drawn.  The buffer is only uploaded once per frame, as it is used both
+// when rendering the shadow maps and when drawing the shape.
+func (s *InstancedShape3D) updateInstanceBuffer() int32 {
+	frame := s.window.frameCount
+	if s.instanceFrame == frame {
+		return s.instanceCount
+	}
+	s.instanceFrame = frame
+
+	s.stateMutex.Lock()
+	s.instanceData = s.instanceData[:0]
+	for _, instance := range s.instances {
+		if !instance.Visible() {
+			continue
+		}
+		worldMat := instance.WorldMatrix()
+		tint := RgbaToFloatArray(instance.Tint())
+		s.instanceData = append(s.instanceData, worldMat[:]...)
+		s.instanceData = append(s.instanceData, tint[:]...)
+	}
+	s.instanceCount = int32(len(s.instanceData) * sizeOfFloat32 / instanceStride)
+	s.stateMutex.Unlock()
+
+	if s.instanceCount == 0 {
+		return 0
+	}
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.instanceVbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(s.instanceData)*sizeOfFloat32, gl.Ptr(s.instanceData), gl.STREAM_DRAW)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	return s.instanceCount
+}
+
+func (s *InstancedShape3D) Shader() Shader {
+	s.stateMutex.Lock()
+	shader := s.shader
+	s.stateMutex.Unlock()
+	return shader
+}
+
+// SetShader sets the shader used to render every face of the model, in
+// place of the shaders attached to its materials, which must be set before
+// the shape is initialized.  Besides the vertex attributes of the model,
+// the shader can read the a_InstanceWorldMat (mat4) and a_InstanceTint
+// (vec4) attributes.  Defaults to Shape3DInstancedShader.
+func (s *InstancedShape3D) SetShader(shader Shader) *InstancedShape3D {
+	s.stateMutex.Lock()
+	s.shader = shader
+	s.stateMutex.Unlock()
+	return s
+}
+
+// AddInstance adds a new, visible and untinted instance of the model, which
+// can then be positioned, rotated, etc, relative to the shape.
+func (s *InstancedShape3D) AddInstance() *Instance3D {
+	instance := &Instance3D{
+		ObjectTransform: *NewObjectTransform(),
+		tint:            White,
+		visible:         true,
+	}
+	instance.SetParentTransform(s)
+
+	s.stateMutex.Lock()
+	s.instances = append(s.instances, instance)
+	s.stateMutex.Unlock()
+	return instance
+}
+
+func (s *InstancedShape3D) RemoveInstance(instance *Instance3D) *InstancedShape3D {
+	s.stateMutex.Lock()
+	for i, inst := range s.instances {
+		if inst == instance {
+			s.instances = append(s.instances[:i], s.instances[i+1:]...)
+			break
+		}
+	}
+	s.stateMutex.Unlock()
+	return s
+}
+
+func (s *InstancedShape3D) ClearInstances() *InstancedShape3D {
+	s.stateMutex.Lock()
+	s.instances = nil
+	s.stateMutex.Unlock()
+	return s
+}
+
+func (s *InstancedShape3D) Instances() []*Instance3D {
+	s.stateMutex.Lock()
+	instances := make([]*Instance3D, len(s.instances))
+	copy(instances, s.instances)
+	s.stateMutex.Unlock()
+	return instances
+}
+
+func (s *InstancedShape3D) InstanceCount() (count int) {
+	s.stateMutex.Lock()
+	count = len(s.instances)
+	s.stateMutex.Unlock()
+	return
+}
+
+// Raycast returns the nearest intersection of the given world-space ray
+// with the faces of the visible instances, testing each mesh's bounding
+// box first.  The shape must be initialized.
+func (s *InstancedShape3D) Raycast(ray Ray) (hit RayHit, ok bool) {
+	meshes := s.Meshes()
+	for _, instance := range s.Instances() {
+		if !instance.Visible() {
+			continue
+		}
+
+		// The ray parameter (distance) is preserved by the transformation
+		instanceRay := ray.Transform(instance.WorldMatrix().Inv())
+		for i, mesh := range meshes {
+			if meshHit, meshOk := mesh.raycast(instanceRay); meshOk && (!ok || meshHit.Distance < hit.Distance) {
+				hit = meshHit
+				hit.MeshIndex = i
+				hit.Instance = instance
+				ok = true
+			}
+		}
+	}
+
+	if ok {
+		hit.Shape = &s.Shape3D
+		hit.Position = ray.At(hit.Distance)
+	}
+	return
+}
+
+// Pick returns the nearest intersection of the visible instances with the
+// ray cast from the given window position (see RayAt()).
+func (s *InstancedShape3D) Pick(x, y float32) (hit RayHit, ok bool) {
+	if !s.Initialized() || !s.Enabled() || !s.Visible() {
+		return
+	}
+
+	ray, ok := s.RayAt(x, y)
+	if !ok {
+		return
+	}
+
+	return s.Raycast(ray)
+}
+
+/******************************************************************************
+ shadowCaster Implementation
+******************************************************************************/
+
+func (s *InstancedShape3D) drawDepth(shader Shader, worldMatLoc int32) {
+	if count := s.updateInstanceBuffer(); count > 0 {
+		s.modelRenderer.renderDepthInstanced(shader, worldMatLoc, s.instanceVbo, count)
+	}
+}
+
+/******************************************************************************
+ New InstancedShape3D Function
+******************************************************************************/
+
+func NewInstancedShape3D() *InstancedShape3D {
+	s := &InstancedShape3D{
+		Shape3D: *NewShape3D(),
+	}
+
+	s.SetName(defaultInstancedShape3DName)
+	return s
+}
@@ -0,0 +1,102 @@
+package basic
+
+import "github.com/tonybillings/gfx"
+
+/******************************************************************************
+ Face
+******************************************************************************/
+
+// Face A triangle with separate indices for its positions, normals and
+// texture coordinates.  As the models store exactly one tangent (and
+// bitangent) for each of their normals, the normal indices are also used
+// for the tangents and bitangents.
+type Face struct {
+	gfx.FaceBase
+
+	vertices []int
+	normals  []int
+	uvs      []int
+
+	material gfx.Material
+}
+
+/******************************************************************************
+ gfx.Face Implementation
+******************************************************************************/
+
+func (f *Face) VertexIndices() []int {
+	return f.vertices
+}
+
+func (f *Face) NormalIndices() []int {
+	return f.normals
+}
+
+func (f *Face) UvIndices() []int {
+	return f.uvs
+}
+
+func (f *Face) TangentIndices() []int {
+	return f.normals
+}
+
+func (f *Face) BitangentIndices() []int {
+	return f.normals
+}
+
+func (f *Face) AttachedMaterial() gfx.Material {
+	return f.material
+}
+
+/******************************************************************************
+ gfx.Initer Implementation
+******************************************************************************/
+
+func (f *Face) Init() bool {
+	if f.material != nil {
+		return f.material.Init()
+	}
+
+	return true
+}
+
+/******************************************************************************
+ gfx.Closer Implementation
+******************************************************************************/
+
+func (f *Face) Close() {
+	if f.material != nil {
+		f.material.Close()
+	}
+}
+
+/******************************************************************************
+ Face Functions
+******************************************************************************/
+
+// SetNormalIndices sets the indices of the normals (and so the tangents
+// and bitangents) of the face, for models that compute their normals after
+// the faces have been created.
+func (f *Face) SetNormalIndices(indices []int) {
+	f.normals = indices
+}
+
+/******************************************************************************
+ New Face Functions
+******************************************************************************/
+
+func NewFace(vertices, normals, uvs []int, material gfx.Material) *Face {
+	return &Face{
+		vertices: vertices,
+		normals:  normals,
+		uvs:      uvs,
+		material: material,
+	}
+}
+
+// NewIndexedFace creates a face for a model in which every vertex has
+// exactly one position, normal, texture coordinate, tangent and bitangent,
+// so that the same indices are used for each of these attributes.
+func NewIndexedFace(indices []int, material gfx.Material) *Face {
+	return NewFace(indices, indices, indices, material)
+}
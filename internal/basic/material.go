@@ -0,0 +1,128 @@
+// Package basic contains the materials, meshes and faces shared by the
+// packages that build models with a fixed vertex layout (primitive, stl and
+// gltf).
+package basic
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/tonybillings/gfx"
+)
+
+/******************************************************************************
+ Material
+******************************************************************************/
+
+// Material Has the properties expected by the default 3D shaders
+// (gfx.Shape3DShader, etc).  The texture maps are solid colors unless
+// replaced prior to initialization.  Maps assigned by the caller (e.g., a
+// gfx.RenderTarget) are initialized along with the material but, unlike the
+// default maps and those added with AddTexture(), they are not closed with
+// it.
+type Material struct {
+	gfx.MaterialBase
+
+	name string
+
+	// textures The default maps and those added with AddTexture(), which
+	// are owned (i.e. closed) by the material.
+	textures []gfx.Texture
+
+	Properties  *MaterialProperties
+	DiffuseMap  gfx.Texture
+	SpecularMap gfx.Texture
+	NormalMap   gfx.Texture
+}
+
+type MaterialProperties struct {
+	Ambient      mgl32.Vec4
+	Diffuse      mgl32.Vec4
+	Specular     mgl32.Vec4
+	Emissive     mgl32.Vec4
+	Shininess    float32
+	Transparency float32
+}
+
+/******************************************************************************
+ Asset Implementation
+******************************************************************************/
+
+func (m *Material) Name() string {
+	return m.name
+}
+
+func (m *Material) Init() bool {
+	if m.Initialized() {
+		return true
+	}
+
+	m.LoadDefaultTextures()
+
+	for _, t := range []gfx.Texture{m.DiffuseMap, m.NormalMap, m.SpecularMap} {
+		t.Init()
+	}
+
+	return m.AssetBase.Init()
+}
+
+func (m *Material) Close() {
+	if !m.Initialized() {
+		return
+	}
+
+	for _, t := range m.textures {
+		t.Close()
+	}
+
+	m.AssetBase.Close()
+}
+
+/******************************************************************************
+ Material Functions
+******************************************************************************/
+
+func (m *Material) SetName(name string) {
+	m.name = name
+}
+
+// AddTexture adds a texture created for the material (e.g., by a model
+// loader), so that it is closed with the material, and returns it.
+func (m *Material) AddTexture(texture gfx.Texture) gfx.Texture {
+	m.textures = append(m.textures, texture)
+	return texture
+}
+
+// LoadDefaultTextures creates the solid color maps used in place of those
+// that have not been assigned, which is otherwise done on initialization.
+func (m *Material) LoadDefaultTextures() {
+	if m.DiffuseMap == nil {
+		m.DiffuseMap = m.AddTexture(gfx.NewTexture2D("", gfx.White))
+		m.DiffuseMap.SetSourceLibrary(m.SourceLibrary())
+	}
+
+	if m.NormalMap == nil {
+		m.NormalMap = m.AddTexture(gfx.NewTexture2D("", gfx.DefaultNormalMapColor))
+		m.NormalMap.SetSourceLibrary(m.SourceLibrary())
+	}
+
+	if m.SpecularMap == nil {
+		m.SpecularMap = m.AddTexture(gfx.NewTexture2D("", gfx.DefaultSpecularMapColor))
+		m.SpecularMap.SetSourceLibrary(m.SourceLibrary())
+	}
+}
+
+/******************************************************************************
+ New Material Function
+******************************************************************************/
+
+func NewMaterial() *Material {
+	return &Material{
+		Properties: &MaterialProperties{
+			Ambient:      mgl32.Vec4{0.2, 0.2, 0.2},
+			Diffuse:      mgl32.Vec4{0.5, 0.5, 0.5},
+			Specular:     mgl32.Vec4{1.0, 1.0, 1.0},
+			Emissive:     mgl32.Vec4{0.0, 0.0, 0.0},
+			Shininess:    32.0,
+			Transparency: 0.0,
+		},
+	}
+}
@@ -0,0 +1,107 @@
+package basic
+
+import (
+	"github.com/tonybillings/gfx"
+)
+
+/******************************************************************************
+ Mesh
+******************************************************************************/
+
+type Mesh struct {
+	gfx.MeshBase
+
+	name  string
+	faces []*Face
+}
+
+/******************************************************************************
+ gfx.Mesh Implementation
+******************************************************************************/
+
+func (m *Mesh) Name() string {
+	return m.name
+}
+
+func (m *Mesh) Faces() []gfx.Face {
+	faces := make([]gfx.Face, len(m.faces))
+	for i, f := range m.faces {
+		faces[i] = f
+	}
+	return faces
+}
+
+/******************************************************************************
+ gfx.Initer Implementation
+******************************************************************************/
+
+func (m *Mesh) Init() bool {
+	ok := true
+	for _, f := range m.faces {
+		ok = ok && f.Init()
+	}
+	return ok
+}
+
+/******************************************************************************
+ gfx.Closer Implementation
+******************************************************************************/
+
+func (m *Mesh) Close() {
+	for _, f := range m.faces {
+		f.Close()
+	}
+}
+
+/******************************************************************************
+ Mesh Functions
+******************************************************************************/
+
+func (m *Mesh) SetName(name string) {
+	m.name = name
+}
+
+// Triangles returns the faces of the mesh, unlike Faces() without
+// converting them to the gfx.Face interface.
+func (m *Mesh) Triangles() []*Face {
+	return m.faces
+}
+
+func (m *Mesh) AddFace(face *Face) {
+	m.faces = append(m.faces, face)
+}
+
+/******************************************************************************
+ Indices Function
+******************************************************************************/
+
+// Indices returns the vertex indices of every face of the given meshes, in
+// order, as expected of gfx.IndexedModel.  The faces must be triangles.
+func Indices(meshes []gfx.Mesh) []uint32 {
+	count := 0
+	for _, mesh := range meshes {
+		count += len(mesh.Faces()) * 3
+	}
+
+	indices := make([]uint32, 0, count)
+	for _, mesh := range meshes {
+		for _, face := range mesh.Faces() {
+			for _, index := range face.VertexIndices() {
+				indices = append(indices, uint32(index))
+			}
+		}
+	}
+	return indices
+}
+
+/******************************************************************************
+ New Mesh Function
+******************************************************************************/
+
+func NewMesh() *Mesh {
+	return &Mesh{
+		MeshBase: gfx.MeshBase{
+			ObjectTransform: *gfx.NewObjectTransform(),
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"fmt"
+)
+
+const (
+	defaultParseErrorUnit = "line"
+)
+
+/******************************************************************************
+ ParseError
+******************************************************************************/
+
+// ParseError Describes a problem encountered while parsing a file made of
+// lines or fixed-size records, which stops the load.
+type ParseError struct {
+	// Format identifies the file format (e.g. "ASCII STL", "PLY").
+	Format string
+
+	// File is the name of the source file or, if the asset was
+	// not loaded from a file, the name of the asset.
+	File string
+
+	// Line is the 1-based line (or record) number, or 0 if the error
+	// is not associated with a specific line (e.g., a read error).
+	Line int
+
+	// Unit is what Line counts, such as "triangle" for binary formats
+	// made of fixed-size records; "line" if empty.
+	Unit string
+
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s parse error: %s: %v", e.Format, e.File, e.Err)
+	}
+
+	unit := e.Unit
+	if unit == "" {
+		unit = defaultParseErrorUnit
+	}
+
+	return fmt.Sprintf("%s parse error: %s: %s %d: %v", e.Format, e.File, unit, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+/******************************************************************************
+ Functions
+******************************************************************************/
+
+func NewParseError(format, file string, line int, err error) *ParseError {
+	return &ParseError{
+		Format: format,
+		File:   file,
+		Line:   line,
+		Err:    err,
+	}
+}
@@ -0,0 +1,44 @@
+// Package importer contains the functionality shared by the packages that
+// import models and point clouds from files (obj, gltf, stl and ply).
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/tonybillings/gfx"
+	"os"
+)
+
+const (
+	maxSourceNameLength = 200
+)
+
+// SourceReader returns a reader for the named source, read via the asset's
+// source library if it has one, otherwise from the file system.  The
+// reader will be nil (without an error) if the name is too long to be a
+// file name or the file does not exist, as the source is then assumed to
+// be the data itself.  The returned close function is never nil.
+func SourceReader(asset gfx.Asset, sourceName string) (reader *bufio.Reader, closeFunc func(), err error) {
+	closeFunc = func() {}
+	if srcLib := asset.SourceLibrary(); srcLib == nil {
+		if len(sourceName) > maxSourceNameLength {
+			return
+		}
+		if _, statErr := os.Stat(sourceName); statErr != nil && os.IsNotExist(statErr) {
+			return
+		}
+		if file, openErr := os.Open(sourceName); openErr != nil {
+			err = fmt.Errorf("open file error: %w", openErr)
+			return
+		} else {
+			reader = bufio.NewReader(file)
+			closeFunc = func() {
+				_ = file.Close()
+			}
+			return
+		}
+	} else {
+		reader, closeFunc = srcLib.GetFileReader(sourceName)
+		return
+	}
+}
@@ -2,9 +2,31 @@ package gfx
 
 import (
 	"github.com/go-gl/mathgl/mgl32"
+	"math"
 	"sync"
 )
 
+const (
+	// MaxLightCount The maximum number of lights that can be evaluated by the
+	// default shaders, i.e., the size of the Lights array in the BasicLighting
+	// uniform block.
+	MaxLightCount = 32
+
+	// MaxShadowCount The maximum number of lights that can cast shadows at
+	// the same time, i.e., the number of layers in the shadow map array.
+	MaxShadowCount = 4
+
+	defaultLightRange = 0 // unlimited
+)
+
+type LightType int32
+
+const (
+	DirectionalLightType LightType = iota
+	PointLightType
+	SpotLightType
+)
+
 /******************************************************************************
  Light
 ******************************************************************************/
@@ -85,11 +107,16 @@ func (l *LightBase) Unlock() {
  DirectionalLight
 ******************************************************************************/
 
+// DirectionalLight A light infinitely far away, illuminating everything in
+// the scene from the same direction (like the sun).  If CastShadows is true
+// and a ShadowMapper has been created for the lighting object, objects will
+// cast shadows from this light.
 type DirectionalLight struct {
 	LightBase
 
-	Color     mgl32.Vec3
-	Direction mgl32.Vec3
+	Color       mgl32.Vec3
+	Direction   mgl32.Vec3
+	CastShadows bool
 }
 
 /******************************************************************************
@@ -98,22 +125,369 @@ type DirectionalLight struct {
 
 func NewDirectionalLight() *DirectionalLight {
 	return &DirectionalLight{
+		LightBase: LightBase{enabled: true},
 		Color:     mgl32.Vec3{1, 1, 1},
 		Direction: mgl32.Vec3{0, -1, 0},
 	}
 }
 
+/******************************************************************************
+ PointLight
+******************************************************************************/
+
+// PointLight A light emitted in all directions from a single point (like a
+// light bulb), with an intensity that falls off with distance according to
+// its Attenuation, which holds the constant, linear and quadratic factors
+// (in that order).  If Range is greater than zero, the light will smoothly
+// fade to nothing at that distance.
+type PointLight struct {
+	LightBase
+
+	Color       mgl32.Vec3
+	Position    mgl32.Vec3
+	Attenuation mgl32.Vec3
+	Range       float32
+}
+
+/******************************************************************************
+ New PointLight Function
+******************************************************************************/
+
+func NewPointLight() *PointLight {
+	return &PointLight{
+		LightBase:   LightBase{enabled: true},
+		Color:       mgl32.Vec3{1, 1, 1},
+		Attenuation: mgl32.Vec3{1, 0.09, 0.032},
+		Range:       defaultLightRange,
+	}
+}
+
+/******************************************************************************
+ SpotLight
+******************************************************************************/
+
+// SpotLight A point light restricted to a cone (like a flashlight), pointing
+// in the given Direction.  Surfaces within the inner cone angle receive the
+// full intensity of the light, which then fades to nothing at the outer cone
+// angle.  Cone angles are in degrees, measured from the direction of the light
+// to the edge of the cone.  As with DirectionalLight, shadows are cast from
+// this light if CastShadows is true.
+type SpotLight struct {
+	LightBase
+
+	Color          mgl32.Vec3
+	Position       mgl32.Vec3
+	Direction      mgl32.Vec3
+	Attenuation    mgl32.Vec3
+	Range          float32
+	InnerConeAngle float32
+	OuterConeAngle float32
+	CastShadows    bool
+}
+
+/******************************************************************************
+ New SpotLight Function
+******************************************************************************/
+
+func NewSpotLight() *SpotLight {
+	return &SpotLight{
+		LightBase:      LightBase{enabled: true},
+		Color:          mgl32.Vec3{1, 1, 1},
+		Direction:      mgl32.Vec3{0, -1, 0},
+		Attenuation:    mgl32.Vec3{1, 0.09, 0.032},
+		Range:          defaultLightRange,
+		InnerConeAngle: 15,
+		OuterConeAngle: 25,
+	}
+}
+
+/******************************************************************************
+ LightProperties
+******************************************************************************/
+
+// LightProperties The std140 layout of a single light within the
+// BasicLighting uniform block, which is able to describe any of the
+// supported light types:
+//
+//	Color:       rgb = color, w = type (see LightType)
+//	Position:    xyz = position, w = range (0 for unlimited)
+//	Direction:   xyz = direction, w = cosine of the inner cone angle
+//	Attenuation: xyz = constant/linear/quadratic factors, w = cosine of the outer cone angle
+//	Shadow:      x = shadow map layer (-1 if not casting shadows), y = depth bias, z = PCF radius (in texels)
+type LightProperties struct {
+	Color       mgl32.Vec4
+	Position    mgl32.Vec4
+	Direction   mgl32.Vec4
+	Attenuation mgl32.Vec4
+	Shadow      mgl32.Vec4
+}
+
+// BasicLightingProperties The std140 layout of the BasicLighting uniform
+// block used by the default shaders.  ShadowMats holds the view-projection
+// matrix of each shadow-casting light, indexed by shadow map layer.
+type BasicLightingProperties struct {
+	Lights     [MaxLightCount]LightProperties
+	ShadowMats [MaxShadowCount]mgl32.Mat4
+	LightCount int32
+	_          [3]int32
+}
+
+// setLight Packs the given light into the next available slot, returning
+// false if the light is of an unsupported type or no slots are available.
+// The caller is responsible for synchronizing access to the light.
+func (p *BasicLightingProperties) setLight(light Light) bool {
+	if p.LightCount >= MaxLightCount {
+		return false
+	}
+
+	props := &p.Lights[p.LightCount]
+	*props = LightProperties{Shadow: mgl32.Vec4{-1, 0, 0, 0}}
+	switch l := light.(type) {
+	case *DirectionalLight:
+		props.Color = l.Color.Vec4(float32(DirectionalLightType))
+		props.Direction = l.Direction.Vec4(0)
+	case *PointLight:
+		props.Color = l.Color.Vec4(float32(PointLightType))
+		props.Position = l.Position.Vec4(l.Range)
+		props.Attenuation = l.Attenuation.Vec4(0)
+	case *SpotLight:
+		cosInner, cosOuter := coneCosines(l.InnerConeAngle, l.OuterConeAngle)
+		props.Color = l.Color.Vec4(float32(SpotLightType))
+		props.Position = l.Position.Vec4(l.Range)
+		props.Direction = l.Direction.Vec4(cosInner)
+		props.Attenuation = l.Attenuation.Vec4(cosOuter)
+	default:
+		return false
+	}
+
+	p.LightCount++
+	return true
+}
+
+/******************************************************************************
+ BasicLighting
+******************************************************************************/
+
+// BasicLighting A shader-bindable lighting object supporting any mix of
+// directional, point and spot lights (up to MaxLightCount, or fewer if
+// limited via SetMaxLights()), for use with the default 3D shaders.  Only
+// enabled lights are sent to the shader.
+type BasicLighting struct {
+	stateMutex sync.Mutex
+
+	lights    []Light
+	maxLights int
+
+	shadowMapper *ShadowMapper
+	shadows      map[Light]lightShadow
+
+	Properties *BasicLightingProperties
+}
+
+type lightShadow struct {
+	layer     int
+	viewProj  mgl32.Mat4
+	bias      float32
+	pcfRadius int32
+}
+
+/******************************************************************************
+ ShaderBindingUpdater Implementation
+******************************************************************************/
+
+func (l *BasicLighting) UpdateShaderBinding() {
+	l.stateMutex.Lock()
+	defer l.stateMutex.Unlock()
+
+	if l.Properties == nil {
+		l.Properties = &BasicLightingProperties{}
+	}
+
+	l.Properties.LightCount = 0
+	for _, light := range l.lights {
+		if int(l.Properties.LightCount) >= l.maxLights {
+			break
+		}
+		if !light.Enabled() {
+			continue
+		}
+		light.Lock()
+		ok := l.Properties.setLight(light)
+		light.Unlock()
+
+		if shadow, hasShadow := l.shadows[light]; ok && hasShadow {
+			l.Properties.Lights[l.Properties.LightCount-1].Shadow = mgl32.Vec4{float32(shadow.layer), shadow.bias, float32(shadow.pcfRadius), 0}
+			l.Properties.ShadowMats[shadow.layer] = shadow.viewProj
+		}
+	}
+}
+
+/******************************************************************************
+ sync.Locker Implementation
+******************************************************************************/
+
+func (l *BasicLighting) Lock() {
+	l.stateMutex.Lock()
+}
+
+func (l *BasicLighting) Unlock() {
+	l.stateMutex.Unlock()
+}
+
+/******************************************************************************
+ BasicLighting Functions
+******************************************************************************/
+
+func (l *BasicLighting) setShadows(shadows map[Light]lightShadow) {
+	l.stateMutex.Lock()
+	l.shadows = shadows
+	l.stateMutex.Unlock()
+}
+
+func (l *BasicLighting) setShadowMapper(mapper *ShadowMapper) {
+	l.stateMutex.Lock()
+	l.shadowMapper = mapper
+	if mapper == nil {
+		l.shadows = nil
+	}
+	l.stateMutex.Unlock()
+}
+
+// shadowMap Returns the name of the shadow map texture array, or
+// 0 if shadows are not being rendered for this lighting object.
+func (l *BasicLighting) shadowMap() uint32 {
+	l.stateMutex.Lock()
+	mapper := l.shadowMapper
+	l.stateMutex.Unlock()
+
+	if mapper == nil {
+		return 0
+	}
+	return mapper.shadowMap()
+}
+
+// AddLight adds the light (a *DirectionalLight, *PointLight or *SpotLight)
+// to the scene.  Lights can be shared by multiple lighting objects.
+func (l *BasicLighting) AddLight(light Light) *BasicLighting {
+	l.stateMutex.Lock()
+	l.lights = append(l.lights, light)
+	l.stateMutex.Unlock()
+	return l
+}
+
+func (l *BasicLighting) AddLights(lights ...Light) *BasicLighting {
+	l.stateMutex.Lock()
+	l.lights = append(l.lights, lights...)
+	l.stateMutex.Unlock()
+	return l
+}
+
+func (l *BasicLighting) RemoveLight(light Light) *BasicLighting {
+	l.stateMutex.Lock()
+	for i, existing := range l.lights {
+		if existing == light {
+			l.lights = append(l.lights[:i], l.lights[i+1:]...)
+			break
+		}
+	}
+	l.stateMutex.Unlock()
+	return l
+}
+
+func (l *BasicLighting) ClearLights() *BasicLighting {
+	l.stateMutex.Lock()
+	l.lights = nil
+	l.stateMutex.Unlock()
+	return l
+}
+
+func (l *BasicLighting) Lights() []Light {
+	l.stateMutex.Lock()
+	lights := make([]Light, len(l.lights))
+	copy(lights, l.lights)
+	l.stateMutex.Unlock()
+	return lights
+}
+
+func (l *BasicLighting) MaxLights() int {
+	l.stateMutex.Lock()
+	maxLights := l.maxLights
+	l.stateMutex.Unlock()
+	return maxLights
+}
+
+// SetMaxLights limits the number of (enabled) lights sent to the shader,
+// which cannot exceed MaxLightCount.
+func (l *BasicLighting) SetMaxLights(count int) *BasicLighting {
+	if count < 0 {
+		count = 0
+	} else if count > MaxLightCount {
+		count = MaxLightCount
+	}
+	l.stateMutex.Lock()
+	l.maxLights = count
+	l.stateMutex.Unlock()
+	return l
+}
+
+/******************************************************************************
+ New BasicLighting Function
+******************************************************************************/
+
+func NewBasicLighting(lights ...Light) *BasicLighting {
+	return &BasicLighting{
+		lights:     lights,
+		maxLights:  MaxLightCount,
+		Properties: &BasicLightingProperties{},
+	}
+}
+
 /******************************************************************************
  QuadDirectionalLighting
 ******************************************************************************/
 
+// QuadDirectionalLighting A shader-bindable lighting object supporting up
+// to four directional lights.  Unlike BasicLighting, the first LightCount
+// lights are sent to the shader regardless of whether they are enabled.
 type QuadDirectionalLighting struct {
 	stateMutex sync.Mutex
 
 	Lights     [4]DirectionalLight
 	LightCount int32
+
+	// BasicLighting is kept in sync with Lights/LightCount so that these
+	// lights can be evaluated by the default shaders, which expect the
+	// BasicLighting uniform block.
+	BasicLighting *BasicLightingProperties
+}
+
+/******************************************************************************
+ ShaderBindingUpdater Implementation
+******************************************************************************/
+
+func (l *QuadDirectionalLighting) UpdateShaderBinding() {
+	l.stateMutex.Lock()
+	defer l.stateMutex.Unlock()
+
+	if l.BasicLighting == nil {
+		l.BasicLighting = &BasicLightingProperties{}
+	}
+
+	count := int(l.LightCount)
+	if count > len(l.Lights) {
+		count = len(l.Lights)
+	}
+
+	l.BasicLighting.LightCount = 0
+	for i := 0; i < count; i++ {
+		l.BasicLighting.setLight(&l.Lights[i])
+	}
 }
 
+/******************************************************************************
+ sync.Locker Implementation
+******************************************************************************/
+
 func (l *QuadDirectionalLighting) Lock() {
 	l.stateMutex.Lock()
 }
@@ -122,6 +496,28 @@ func (l *QuadDirectionalLighting) Unlock() {
 	l.stateMutex.Unlock()
 }
 
+/******************************************************************************
+ New QuadDirectionalLighting Function
+******************************************************************************/
+
 func NewQuadDirectionalLighting() *QuadDirectionalLighting {
-	return &QuadDirectionalLighting{}
+	return &QuadDirectionalLighting{
+		BasicLighting: &BasicLightingProperties{},
+	}
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func coneCosines(innerAngle, outerAngle float32) (cosInner, cosOuter float32) {
+	if outerAngle < innerAngle {
+		outerAngle = innerAngle
+	}
+	cosInner = float32(math.Cos(float64(mgl32.DegToRad(innerAngle))))
+	cosOuter = float32(math.Cos(float64(mgl32.DegToRad(outerAngle))))
+	if cosInner-cosOuter < 1e-4 { // avoid an undefined smoothstep() in the shader
+		cosInner = cosOuter + 1e-4
+	}
+	return
 }
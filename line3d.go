@@ -0,0 +1,797 @@
+package gfx
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"image/color"
+)
+
+const (
+	defaultLine3DName     = "Line3D"
+	defaultPolyline3DName = "Polyline3D"
+	defaultGridFloorName  = "GridFloor"
+	defaultAxesName       = "Axes"
+)
+
+const (
+	defaultLine3DThickness    = 2
+	defaultGridFloorThickness = 1
+	lineVertexSize            = 7 // position (3) + color (4), see initLineVertexAttribs()
+)
+
+var (
+	line3DWhite = mgl32.Vec4{1, 1, 1, 1}
+)
+
+/******************************************************************************
+ line3DStrip
+******************************************************************************/
+
+// line3DStrip A connected sequence of points, each with its own color.
+type line3DStrip struct {
+	points []mgl32.Vec3
+	colors []mgl32.Vec4
+	closed bool
+}
+
+func (s *line3DStrip) segmentCount() int {
+	count := len(s.points)
+	if count < 2 {
+		return 0
+	}
+	if s.closed && count > 2 {
+		return count
+	}
+	return count - 1
+}
+
+// appendVertices Appends four vertices for each segment of the strip,
+// starting with the given one, being the two points of the segment along
+// with the points before and after it (or the points of the segment itself,
+// at the ends of an open strip), as expected by the lines_adjacency input
+// of the geometry shader.
+func (s *line3DStrip) appendVertices(vertices []float32, firstSegment int) []float32 {
+	count := len(s.points)
+	index := func(i int) int {
+		if s.closed && count > 2 {
+			return (i + count) % count
+		}
+		return max(0, min(i, count-1))
+	}
+
+	for i := firstSegment; i < s.segmentCount(); i++ {
+		for _, j := range [4]int{index(i - 1), index(i), index(i + 1), index(i + 2)} {
+			p := s.points[j]
+			c := line3DWhite
+			if j < len(s.colors) {
+				c = s.colors[j]
+			}
+			vertices = append(vertices, p[0], p[1], p[2], c[0], c[1], c[2], c[3])
+		}
+	}
+
+	return vertices
+}
+
+func newLine3DStrip(closed bool, points []mgl32.Vec3, colors ...color.RGBA) line3DStrip {
+	strip := line3DStrip{
+		points: points,
+		colors: make([]mgl32.Vec4, len(points)),
+		closed: closed,
+	}
+	for i := range strip.colors {
+		strip.colors[i] = line3DWhite
+		if len(colors) == 1 {
+			strip.colors[i] = RgbaToFloatArray(colors[0])
+		} else if i < len(colors) {
+			strip.colors[i] = RgbaToFloatArray(colors[i])
+		}
+	}
+	return strip
+}
+
+/******************************************************************************
+ lineBuilder
+******************************************************************************/
+
+// lineBuilder Collects individual line segments, allowing the same shapes
+// (see buildGridLines() and buildAxesLines()) to be rendered by the Line3D
+// objects and by the debug modes of Shape3D.
+type lineBuilder interface {
+	line(from, to mgl32.Vec3, rgba color.RGBA)
+}
+
+// line3DStrips A lineBuilder adding each segment as its own strip.
+type line3DStrips []line3DStrip
+
+func (s *line3DStrips) line(from, to mgl32.Vec3, rgba color.RGBA) {
+	*s = append(*s, newLine3DStrip(false, []mgl32.Vec3{from, to}, rgba))
+}
+
+// buildGridLines Adds the lines of a square grid on the XZ plane, centered
+// on the origin, with the lines through the center (which only exist when
+// the number of divisions is even) given their own color.
+func buildGridLines(builder lineBuilder, size float32, divisions int, rgba, centerRgba color.RGBA) {
+	half := size / 2
+	step := size / float32(divisions)
+
+	for i := 0; i <= divisions; i++ {
+		offset := -half + float32(i)*step
+		c := rgba
+		if i*2 == divisions {
+			c = centerRgba
+		}
+		builder.line(mgl32.Vec3{offset, 0, -half}, mgl32.Vec3{offset, 0, half}, c)
+		builder.line(mgl32.Vec3{-half, 0, offset}, mgl32.Vec3{half, 0, offset}, c)
+	}
+}
+
+// buildAxesLines Adds the X, Y and Z axes, of the given length, starting at
+// the origin.
+func buildAxesLines(builder lineBuilder, length float32, x, y, z color.RGBA) {
+	builder.line(mgl32.Vec3{}, mgl32.Vec3{length, 0, 0}, x)
+	builder.line(mgl32.Vec3{}, mgl32.Vec3{0, length, 0}, y)
+	builder.line(mgl32.Vec3{}, mgl32.Vec3{0, 0, length}, z)
+}
+
+// initLineVertexAttribs Sets the layout of the bound vertex array for line
+// vertices (see lineVertexSize), as expected by the vertex shader shared by
+// DebugLineShader and Line3DShader.
+func initLineVertexAttribs(shader Shader) {
+	posLoc := uint32(shader.GetAttribLocation("a_Position"))
+	gl.EnableVertexAttribArray(posLoc)
+	gl.VertexAttribPointerWithOffset(posLoc, 3, gl.FLOAT, false, lineVertexSize*sizeOfFloat32, 0)
+
+	colorLoc := uint32(shader.GetAttribLocation("a_Color"))
+	gl.EnableVertexAttribArray(colorLoc)
+	gl.VertexAttribPointerWithOffset(colorLoc, 4, gl.FLOAT, false, lineVertexSize*sizeOfFloat32, 3*sizeOfFloat32)
+}
+
+/******************************************************************************
+ Line3DBase
+******************************************************************************/
+
+// Line3DBase The base for WindowObjects rendering lines in world space,
+// through the assigned Camera, such as Line3D, Polyline3D, GridFloor and
+// Axes.  Lines keep the same thickness, in pixels, regardless of their
+// distance from the camera, and are anti-aliased.  The points of the lines
+// are transformed by the position, rotation and scale of the object, and
+// their colors are tinted by the color of the object (white by default).
+type Line3DBase struct {
+	WindowObjectBase
+
+	viewport  *Viewport
+	camera    Camera
+	thickness float32
+	depthTest bool
+
+	strips   []line3DStrip
+	vertices []float32
+
+	// changedFrom The index of the first value of vertices that has not
+	// been uploaded yet, or -1 if the buffer is up-to-date.
+	changedFrom int
+
+	shader      Shader
+	vao         uint32
+	vbo         uint32
+	vboSize     int
+	vertexCount int32
+
+	worldMatUniformLoc     int32
+	viewProjMatUniformLoc  int32
+	viewportSizeUniformLoc int32
+	thicknessUniformLoc    int32
+	colorUniformLoc        int32
+
+	viewportBak [4]int32
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (l *Line3DBase) Init() (ok bool) {
+	if l.Initialized() {
+		return true
+	}
+
+	l.initViewport()
+	l.initVertexVao()
+
+	return l.WindowObjectBase.Init()
+}
+
+func (l *Line3DBase) Close() {
+	if !l.Initialized() {
+		return
+	}
+
+	l.closeVertexVao()
+
+	l.WindowObjectBase.Close()
+}
+
+/******************************************************************************
+ DrawableObject Implementation
+******************************************************************************/
+
+func (l *Line3DBase) Draw(deltaTime int64) (ok bool) {
+	if !l.DrawableObjectBase.Draw(deltaTime) {
+		return false
+	}
+
+	l.beginDraw()
+	l.draw()
+	l.endDraw()
+
+	return l.WindowObjectBase.drawChildren(deltaTime)
+}
+
+/******************************************************************************
+ Resizer Implementation
+******************************************************************************/
+
+func (l *Line3DBase) Resize(newWidth, newHeight int) {
+	if l.viewport != nil {
+		l.viewport.SetWindowSize(newWidth, newHeight)
+	}
+
+	l.WindowObjectBase.Resize(newWidth, newHeight)
+}
+
+/******************************************************************************
+ Line3DBase Functions
+******************************************************************************/
+
+func (l *Line3DBase) initViewport() {
+	if l.viewport == nil {
+		l.viewport = NewViewport(l.window.Width(), l.window.Height())
+	}
+}
+
+func (l *Line3DBase) initVertexVao() {
+	l.shader = l.window.Assets().Get(Line3DShader).(Shader)
+
+	l.worldMatUniformLoc = l.shader.GetUniformLocation("u_WorldMat")
+	l.viewProjMatUniformLoc = l.shader.GetUniformLocation("u_ViewProjMat")
+	l.viewportSizeUniformLoc = l.shader.GetUniformLocation("u_ViewportSize")
+	l.thicknessUniformLoc = l.shader.GetUniformLocation("u_Thickness")
+	l.colorUniformLoc = l.shader.GetUniformLocation("u_Color")
+
+	gl.GenVertexArrays(1, &l.vao)
+	gl.GenBuffers(1, &l.vbo)
+
+	gl.BindVertexArray(l.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, l.vbo)
+
+	initLineVertexAttribs(l.shader)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+
+	l.vboSize = 0
+	l.vertexCount = 0
+
+	l.stateMutex.Lock()
+	l.changedFrom = 0
+	l.stateMutex.Unlock()
+}
+
+func (l *Line3DBase) closeVertexVao() {
+	gl.BindVertexArray(0)
+	gl.DeleteVertexArrays(1, &l.vao)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.DeleteBuffers(1, &l.vbo)
+}
+
+// updateVertexVbo Uploads the given vertices, which have changed starting
+// at the given offset, out of the given total.  The buffer only grows when
+// needed, as the points of a Polyline3D may change every frame, in which
+// case the offset must be 0.
+func (l *Line3DBase) updateVertexVbo(vertices []float32, offset, total int) {
+	gl.BindBuffer(gl.ARRAY_BUFFER, l.vbo)
+	if total > l.vboSize {
+		l.vboSize = max(total, l.vboSize*2)
+		gl.BufferData(gl.ARRAY_BUFFER, l.vboSize*sizeOfFloat32, nil, gl.DYNAMIC_DRAW)
+	}
+	if len(vertices) > 0 {
+		gl.BufferSubData(gl.ARRAY_BUFFER, offset*sizeOfFloat32, len(vertices)*sizeOfFloat32, gl.Ptr(vertices))
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+
+	l.vertexCount = int32(total / lineVertexSize)
+}
+
+func (l *Line3DBase) beginDraw() {
+	l.stateMutex.Lock()
+	depthTest := l.depthTest
+	l.stateMutex.Unlock()
+
+	if depthTest {
+		gl.Enable(gl.DEPTH_TEST)
+	}
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.GetIntegerv(gl.VIEWPORT, &l.viewportBak[0])
+}
+
+func (l *Line3DBase) draw() {
+	worldMat := l.WorldMatrix()
+
+	l.stateMutex.Lock()
+	camera := l.camera
+	viewport := l.viewport
+	thickness := l.thickness
+	color := l.color
+	offset, total := l.changedFrom, len(l.vertices)
+	var vertices []float32
+	if offset >= 0 {
+		if total > l.vboSize {
+			offset = 0 // growing the buffer discards its contents
+		}
+		vertices = append(vertices, l.vertices[offset:]...)
+		l.changedFrom = -1
+	}
+	l.stateMutex.Unlock()
+
+	if offset >= 0 {
+		l.updateVertexVbo(vertices, offset, total)
+	}
+
+	if camera == nil || l.vertexCount == 0 {
+		return
+	}
+
+	viewProjMat := camera.ViewProjection()
+	x, y, width, height := viewport.Get()
+
+	gl.Viewport(x, y, width, height)
+
+	l.shader.Activate()
+	gl.UniformMatrix4fv(l.worldMatUniformLoc, 1, false, &worldMat[0])
+	gl.UniformMatrix4fv(l.viewProjMatUniformLoc, 1, false, &viewProjMat[0])
+	gl.Uniform2f(l.viewportSizeUniformLoc, float32(width), float32(height))
+	gl.Uniform1f(l.thicknessUniformLoc, thickness)
+	gl.Uniform4fv(l.colorUniformLoc, 1, &color[0])
+
+	gl.BindVertexArray(l.vao)
+	gl.DrawArrays(gl.LINES_ADJACENCY, 0, l.vertexCount)
+}
+
+func (l *Line3DBase) endDraw() {
+	gl.Viewport(l.viewportBak[0], l.viewportBak[1], l.viewportBak[2], l.viewportBak[3])
+
+	gl.Disable(gl.BLEND)
+	gl.Disable(gl.DEPTH_TEST)
+
+	gl.BindVertexArray(0)
+
+	gl.UseProgram(0)
+}
+
+// setStrips Replaces the strips rendered by the object.  The state mutex
+// must be locked.
+func (l *Line3DBase) setStrips(strips ...line3DStrip) {
+	l.strips = strips
+	l.vertices = l.vertices[:0]
+	for i := range l.strips {
+		l.vertices = l.strips[i].appendVertices(l.vertices, 0)
+	}
+	l.changedFrom = 0
+}
+
+// extendStrip Appends a point to the last strip, which must be open, only
+// updating the vertices of the segments affected: the last segment (whose
+// following point is now the new one) and the new segment.  The state mutex
+// must be locked.
+func (l *Line3DBase) extendStrip(point mgl32.Vec3, rgba color.RGBA) {
+	strip := &l.strips[len(l.strips)-1]
+	segmentCount := strip.segmentCount()
+	firstSegment := max(segmentCount-1, 0)
+	offset := len(l.vertices) - (segmentCount-firstSegment)*4*lineVertexSize
+
+	strip.points = append(strip.points, point)
+	strip.colors = append(strip.colors, RgbaToFloatArray(rgba))
+
+	l.vertices = strip.appendVertices(l.vertices[:offset], firstSegment)
+	if l.changedFrom < 0 || offset < l.changedFrom {
+		l.changedFrom = offset
+	}
+}
+
+func (l *Line3DBase) Viewport() *Viewport {
+	l.stateMutex.Lock()
+	vp := l.viewport
+	l.stateMutex.Unlock()
+	return vp
+}
+
+func (l *Line3DBase) SetViewport(viewport *Viewport) *Line3DBase {
+	l.stateMutex.Lock()
+	l.viewport = viewport
+	l.stateMutex.Unlock()
+	return l
+}
+
+func (l *Line3DBase) Camera() Camera {
+	l.stateMutex.Lock()
+	cam := l.camera
+	l.stateMutex.Unlock()
+	return cam
+}
+
+func (l *Line3DBase) SetCamera(camera Camera) *Line3DBase {
+	l.stateMutex.Lock()
+	l.camera = camera
+	l.stateMutex.Unlock()
+	return l
+}
+
+func (l *Line3DBase) Thickness() (thickness float32) {
+	l.stateMutex.Lock()
+	thickness = l.thickness
+	l.stateMutex.Unlock()
+	return
+}
+
+// SetThickness sets the thickness of the lines, in pixels.
+func (l *Line3DBase) SetThickness(thickness float32) *Line3DBase {
+	l.stateMutex.Lock()
+	l.thickness = max(thickness, 0.1)
+	l.stateMutex.Unlock()
+	return l
+}
+
+func (l *Line3DBase) DepthTest() (enabled bool) {
+	l.stateMutex.Lock()
+	enabled = l.depthTest
+	l.stateMutex.Unlock()
+	return
+}
+
+// SetDepthTest sets whether the lines can be hidden behind other objects
+// rendered with depth testing (e.g., Shape3D objects), which is the default,
+// or always drawn on top of what was drawn before them.
+func (l *Line3DBase) SetDepthTest(enabled bool) *Line3DBase {
+	l.stateMutex.Lock()
+	l.depthTest = enabled
+	l.stateMutex.Unlock()
+	return l
+}
+
+func newLine3DBase(thickness float32) Line3DBase {
+	return Line3DBase{
+		WindowObjectBase: *NewWindowObject(),
+		thickness:        thickness,
+		depthTest:        true,
+		changedFrom:      -1,
+	}
+}
+
+/******************************************************************************
+ Line3D
+******************************************************************************/
+
+// Line3D A WindowObject rendering a single line segment in world space,
+// such as a force vector, optionally with a different color at each end.
+type Line3D struct {
+	Line3DBase
+
+	from      mgl32.Vec3
+	to        mgl32.Vec3
+	fromColor color.RGBA
+	toColor   color.RGBA
+}
+
+func (l *Line3D) updateStrips() {
+	l.setStrips(newLine3DStrip(false, []mgl32.Vec3{l.from, l.to}, l.fromColor, l.toColor))
+}
+
+func (l *Line3D) From() (from mgl32.Vec3) {
+	l.stateMutex.Lock()
+	from = l.from
+	l.stateMutex.Unlock()
+	return
+}
+
+func (l *Line3D) To() (to mgl32.Vec3) {
+	l.stateMutex.Lock()
+	to = l.to
+	l.stateMutex.Unlock()
+	return
+}
+
+func (l *Line3D) SetEndpoints(from, to mgl32.Vec3) *Line3D {
+	l.stateMutex.Lock()
+	l.from = from
+	l.to = to
+	l.updateStrips()
+	l.stateMutex.Unlock()
+	return l
+}
+
+// SetGradient sets the colors at each end of the line, which are blended
+// along its length and tinted by the color of the line.
+func (l *Line3D) SetGradient(from, to color.RGBA) *Line3D {
+	l.stateMutex.Lock()
+	l.fromColor = from
+	l.toColor = to
+	l.updateStrips()
+	l.stateMutex.Unlock()
+	return l
+}
+
+func NewLine3D(from, to mgl32.Vec3) *Line3D {
+	l := &Line3D{
+		Line3DBase: newLine3DBase(defaultLine3DThickness),
+		from:       from,
+		to:         to,
+		fromColor:  White,
+		toColor:    White,
+	}
+
+	l.SetName(defaultLine3DName)
+	l.updateStrips()
+	return l
+}
+
+/******************************************************************************
+ Polyline3D
+******************************************************************************/
+
+// Polyline3D A WindowObject rendering a connected sequence of points in
+// world space, such as a trajectory, with the lines meeting without gaps
+// at each point.  Points can be appended over time, in which case the
+// oldest points can be discarded by setting the maximum number of points.
+type Polyline3D struct {
+	Line3DBase
+
+	points    []mgl32.Vec3
+	colors    []color.RGBA
+	closed    bool
+	maxPoints int
+}
+
+// updateStrips Discards the oldest points if there are more than allowed,
+// then updates the strip rendered.  The state mutex must be locked.
+func (l *Polyline3D) updateStrips() {
+	if l.maxPoints > 0 && len(l.points) > l.maxPoints {
+		excess := len(l.points) - l.maxPoints
+		l.points = append(l.points[:0], l.points[excess:]...)
+		l.colors = append(l.colors[:0], l.colors[excess:]...)
+	}
+
+	l.setStrips(newLine3DStrip(l.closed, append([]mgl32.Vec3{}, l.points...), l.colors...))
+}
+
+func (l *Polyline3D) Points() (points []mgl32.Vec3) {
+	l.stateMutex.Lock()
+	points = make([]mgl32.Vec3, len(l.points))
+	copy(points, l.points)
+	l.stateMutex.Unlock()
+	return
+}
+
+// SetPoints replaces the points of the line, optionally with a color for
+// each point (blended along the segments between them) or a single color
+// for all of them, which are tinted by the color of the line.
+func (l *Polyline3D) SetPoints(points []mgl32.Vec3, colors ...color.RGBA) *Polyline3D {
+	l.stateMutex.Lock()
+	l.points = append(l.points[:0], points...)
+	l.colors = l.colors[:0]
+	for i := range points {
+		c := White
+		if len(colors) == 1 {
+			c = colors[0]
+		} else if i < len(colors) {
+			c = colors[i]
+		}
+		l.colors = append(l.colors, c)
+	}
+	l.updateStrips()
+	l.stateMutex.Unlock()
+	return l
+}
+
+// AddPoint appends a point to the line, optionally with its own color.
+// Unless the line is closed or its oldest point must be discarded, only the
+// vertices of the last segment are updated, so that points can be added
+// every frame regardless of how many the line has.
+func (l *Polyline3D) AddPoint(point mgl32.Vec3, rgba ...color.RGBA) *Polyline3D {
+	c := White
+	if len(rgba) > 0 {
+		c = rgba[0]
+	}
+
+	l.stateMutex.Lock()
+	l.points = append(l.points, point)
+	l.colors = append(l.colors, c)
+	if l.closed || (l.maxPoints > 0 && len(l.points) > l.maxPoints) {
+		l.updateStrips()
+	} else {
+		l.extendStrip(point, c)
+	}
+	l.stateMutex.Unlock()
+	return l
+}
+
+func (l *Polyline3D) ClearPoints() *Polyline3D {
+	l.stateMutex.Lock()
+	l.points = l.points[:0]
+	l.colors = l.colors[:0]
+	l.updateStrips()
+	l.stateMutex.Unlock()
+	return l
+}
+
+func (l *Polyline3D) Closed() (closed bool) {
+	l.stateMutex.Lock()
+	closed = l.closed
+	l.stateMutex.Unlock()
+	return
+}
+
+// SetClosed sets whether the last point of the line is connected back to
+// the first one, forming a loop.
+func (l *Polyline3D) SetClosed(closed bool) *Polyline3D {
+	l.stateMutex.Lock()
+	l.closed = closed
+	l.updateStrips()
+	l.stateMutex.Unlock()
+	return l
+}
+
+func (l *Polyline3D) MaxPoints() (count int) {
+	l.stateMutex.Lock()
+	count = l.maxPoints
+	l.stateMutex.Unlock()
+	return
+}
+
+// SetMaxPoints sets the maximum number of points kept by the line, with
+// the oldest points being discarded as new ones are added.  When 0 (the
+// default), all points are kept.
+func (l *Polyline3D) SetMaxPoints(count int) *Polyline3D {
+	l.stateMutex.Lock()
+	l.maxPoints = max(count, 0)
+	l.updateStrips()
+	l.stateMutex.Unlock()
+	return l
+}
+
+func NewPolyline3D(points ...mgl32.Vec3) *Polyline3D {
+	l := &Polyline3D{
+		Line3DBase: newLine3DBase(defaultLine3DThickness),
+	}
+
+	l.SetName(defaultPolyline3DName)
+	l.SetPoints(points)
+	return l
+}
+
+/******************************************************************************
+ GridFloor
+******************************************************************************/
+
+// GridFloor A WindowObject rendering a square grid on the XZ plane, centered
+// on the position of the object, as a reference for the ground.  The lines
+// through the center of the grid can be given their own color (e.g., to
+// distinguish the X and Z axes).
+type GridFloor struct {
+	Line3DBase
+
+	size        float32
+	divisions   int
+	centerColor color.RGBA
+}
+
+func (g *GridFloor) updateStrips() {
+	strips := make(line3DStrips, 0, (g.divisions+1)*2)
+	buildGridLines(&strips, g.size, g.divisions, White, g.centerColor)
+	g.setStrips(strips...)
+}
+
+func (g *GridFloor) Size() (size float32) {
+	g.stateMutex.Lock()
+	size = g.size
+	g.stateMutex.Unlock()
+	return
+}
+
+// SetSize sets the length of the sides of the grid, in world units.
+func (g *GridFloor) SetSize(size float32) *GridFloor {
+	g.stateMutex.Lock()
+	g.size = max(size, 0)
+	g.updateStrips()
+	g.stateMutex.Unlock()
+	return g
+}
+
+func (g *GridFloor) Divisions() (divisions int) {
+	g.stateMutex.Lock()
+	divisions = g.divisions
+	g.stateMutex.Unlock()
+	return
+}
+
+// SetDivisions sets the number of cells along each side of the grid.
+func (g *GridFloor) SetDivisions(divisions int) *GridFloor {
+	g.stateMutex.Lock()
+	g.divisions = max(divisions, 1)
+	g.updateStrips()
+	g.stateMutex.Unlock()
+	return g
+}
+
+// SetCenterColor sets the color of the lines through the center of the
+// grid, which only exist when the number of divisions is even, tinted by
+// the color of the grid.
+func (g *GridFloor) SetCenterColor(rgba color.RGBA) *GridFloor {
+	g.stateMutex.Lock()
+	g.centerColor = rgba
+	g.updateStrips()
+	g.stateMutex.Unlock()
+	return g
+}
+
+func NewGridFloor(size float32, divisions int) *GridFloor {
+	g := &GridFloor{
+		Line3DBase:  newLine3DBase(defaultGridFloorThickness),
+		size:        max(size, 0),
+		divisions:   max(divisions, 1),
+		centerColor: White,
+	}
+
+	g.SetName(defaultGridFloorName)
+	g.SetColor(Gray)
+	g.updateStrips()
+	return g
+}
+
+/******************************************************************************
+ Axes
+******************************************************************************/
+
+// Axes A WindowObject rendering the X (red), Y (green) and Z (blue) axes of
+// a reference frame, starting at the position of the object and oriented by
+// its rotation, such that it can show the frame of another object (e.g., by
+// being given the same transform, or being made its child).
+type Axes struct {
+	Line3DBase
+
+	length float32
+}
+
+func (a *Axes) updateStrips() {
+	strips := make(line3DStrips, 0, 3)
+	buildAxesLines(&strips, a.length, Red, Green, Blue)
+	a.setStrips(strips...)
+}
+
+func (a *Axes) Length() (length float32) {
+	a.stateMutex.Lock()
+	length = a.length
+	a.stateMutex.Unlock()
+	return
+}
+
+func (a *Axes) SetLength(length float32) *Axes {
+	a.stateMutex.Lock()
+	a.length = max(length, 0)
+	a.updateStrips()
+	a.stateMutex.Unlock()
+	return a
+}
+
+func NewAxes(length float32) *Axes {
+	a := &Axes{
+		Line3DBase: newLine3DBase(defaultLine3DThickness),
+		length:     max(length, 0),
+	}
+
+	a.SetName(defaultAxesName)
+	a.updateStrips()
+	return a
+}
@@ -3,12 +3,17 @@ package gfx
 import (
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
+	"sync"
 )
 
 const (
 	cameraUboBindPoint   = 5
 	materialUboBindPoint = 6
 	lightingUboBindPoint = 7
+	skeletonUboBindPoint = 8
+
+	environmentMapTextureUnit = 14
+	shadowMapTextureUnit      = 15
 )
 
 /******************************************************************************
@@ -58,6 +63,26 @@ type Model interface {
 	Meshes() []Mesh
 }
 
+/******************************************************************************
+ IndexedModel
+******************************************************************************/
+
+// IndexedModel An optional extension of the Model interface, for models that
+// store their vertex attributes per vertex; i.e., the position, normal, etc,
+// of vertex i are all found at index i of their respective arrays, as with
+// glTF.  The vertices of such models are uploaded once, to a buffer shared
+// by all of their meshes, and drawn using the supplied indices, rather than
+// being deduplicated by the renderer.
+type IndexedModel interface {
+	Model
+
+	// Indices shall return the vertex indices of every face of the model,
+	// which must all be triangles, in the order of the meshes (as returned
+	// by Meshes()) and of their faces.  May optionally return nil, in which
+	// case the model is rendered like any other Model.
+	Indices() []uint32
+}
+
 /******************************************************************************
  Mesh
 ******************************************************************************/
@@ -200,6 +225,33 @@ type modelRenderer struct {
 
 	activeLightingBinder *ShaderBinder
 	lightingBinders      map[any]*ShaderBinder
+
+	uniformLocs map[uint32]*modelUniformLocs
+
+	shadowMap      uint32
+	receiveShadows bool
+
+	environmentMap    uint32
+	environmentMaxLod float32
+	reflectivity      float32
+
+	// instanceCount The number of instances drawn per face group, when
+	// rendering an InstancedShape3D, otherwise 0.
+	instanceCount int32
+
+	// jointMats The current joint matrices of a SkinnedModel, uploaded to
+	// the Skeleton uniform block of shaders that support skinning.
+	jointMats   []mgl32.Mat4
+	skeletonUbo uint32
+}
+
+// modelUniformLocs The locations of the uniforms set by the modelRenderer
+// for each draw, per shader, or -1 if not used by the shader.
+type modelUniformLocs struct {
+	receiveShadows    int32
+	reflectivity      int32
+	environmentMaxLod int32
+	jointCount        int32
 }
 
 func (r *modelRenderer) setCamera(camera Camera) {
@@ -226,13 +278,130 @@ func (r *modelRenderer) drawFaces() {
 	for _, mesh := range r.model.meshes {
 		mesh.updateBindings()
 		for _, group := range mesh.faceGroups {
+			locs := r.bindShader(group.shader)
 			group.materialBinding.Update(0)
-			gl.BindVertexArray(group.vao)
-			gl.DrawArrays(gl.TRIANGLES, 0, group.vertexCount)
+			r.updateDrawUniforms(locs)
+			if r.instanceCount > 0 {
+				group.drawInstanced(r.instanceCount)
+			} else {
+				group.draw()
+			}
 		}
 	}
 }
 
+// bindShader Points the shadow and environment map samplers of the shader
+// to their dedicated texture units (even when not receiving shadows or
+// reflections, as samplers of different types cannot share a unit) and
+// binds its Skeleton uniform block, if any, the first time the shader is
+// used by the renderer, returning the locations of the uniforms that are
+// set for each draw (see updateDrawUniforms()).
+func (r *modelRenderer) bindShader(shader Shader) *modelUniformLocs {
+	name := shader.GlName()
+	if locs, ok := r.uniformLocs[name]; ok {
+		return locs
+	}
+
+	locs := &modelUniformLocs{
+		receiveShadows:    shader.GetUniformLocation("u_ReceiveShadows"),
+		reflectivity:      shader.GetUniformLocation("u_Reflectivity"),
+		environmentMaxLod: shader.GetUniformLocation("u_EnvironmentMaxLod"),
+		jointCount:        shader.GetUniformLocation("u_JointCount"),
+	}
+	r.uniformLocs[name] = locs
+
+	shadowMapLoc := shader.GetUniformLocation("u_ShadowMap")
+	environmentMapLoc := shader.GetUniformLocation("u_EnvironmentMap")
+	if shadowMapLoc != -1 || environmentMapLoc != -1 {
+		gl.UseProgram(name)
+		if shadowMapLoc != -1 {
+			gl.Uniform1i(shadowMapLoc, shadowMapTextureUnit)
+		}
+		if environmentMapLoc != -1 {
+			gl.Uniform1i(environmentMapLoc, environmentMapTextureUnit)
+		}
+	}
+
+	if index := shader.GetUniformBlockIndex("Skeleton"); locs.jointCount != -1 && index != gl.INVALID_INDEX {
+		gl.UniformBlockBinding(name, index, skeletonUboBindPoint)
+	}
+
+	return locs
+}
+
+// updateDrawUniforms Sets whether the surface receives shadows, its
+// reflectivity (0 when there is no environment map), the mipmap level of
+// the environment map sampled for the roughest surfaces and the number of
+// joints used to skin its vertices (0 when the model is not skinned), for
+// the shaders that support them.  The shader must already be active.
+func (r *modelRenderer) updateDrawUniforms(locs *modelUniformLocs) {
+	if locs.receiveShadows != -1 {
+		receive := int32(0)
+		if r.receiveShadows && r.shadowMap != 0 {
+			receive = 1
+		}
+		gl.Uniform1i(locs.receiveShadows, receive)
+	}
+
+	if locs.reflectivity != -1 {
+		reflectivity := float32(0)
+		if r.environmentMap != 0 {
+			reflectivity = r.reflectivity
+		}
+		gl.Uniform1f(locs.reflectivity, reflectivity)
+	}
+
+	if locs.environmentMaxLod != -1 {
+		gl.Uniform1f(locs.environmentMaxLod, r.environmentMaxLod)
+	}
+
+	if locs.jointCount != -1 {
+		gl.Uniform1i(locs.jointCount, int32(len(r.jointMats)))
+	}
+}
+
+// bindSceneTextures Binds the shadow and environment maps, if any, to
+// their dedicated texture units.
+func (r *modelRenderer) bindSceneTextures() {
+	gl.ActiveTexture(gl.TEXTURE0 + shadowMapTextureUnit)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, r.shadowMap)
+	gl.ActiveTexture(gl.TEXTURE0 + environmentMapTextureUnit)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, r.environmentMap)
+}
+
+func (r *modelRenderer) setShadows(shadowMap uint32, receive bool) {
+	r.shadowMap = shadowMap
+	r.receiveShadows = receive
+}
+
+func (r *modelRenderer) setEnvironment(environmentMap uint32, maxLod, reflectivity float32) {
+	r.environmentMap = environmentMap
+	r.environmentMaxLod = maxLod
+	r.reflectivity = reflectivity
+}
+
+func (r *modelRenderer) setJointMatrices(jointMats []mgl32.Mat4) {
+	r.jointMats = jointMats
+}
+
+// uploadJointMatrices Copies the joint matrices, if any, to the uniform
+// buffer object bound to the Skeleton uniform block.
+func (r *modelRenderer) uploadJointMatrices() {
+	if len(r.jointMats) == 0 {
+		return
+	}
+
+	if r.skeletonUbo == 0 {
+		gl.GenBuffers(1, &r.skeletonUbo)
+		gl.BindBuffer(gl.UNIFORM_BUFFER, r.skeletonUbo)
+		gl.BufferData(gl.UNIFORM_BUFFER, MaxJoints*16*sizeOfFloat32, nil, gl.DYNAMIC_DRAW)
+	}
+
+	gl.BindBuffer(gl.UNIFORM_BUFFER, r.skeletonUbo)
+	gl.BufferSubData(gl.UNIFORM_BUFFER, 0, len(r.jointMats)*16*sizeOfFloat32, gl.Ptr(&r.jointMats[0][0]))
+	gl.BindBufferBase(gl.UNIFORM_BUFFER, skeletonUboBindPoint, r.skeletonUbo)
+}
+
 func (r *modelRenderer) render() {
 	if r.activeCameraBinder != nil {
 		r.activeCameraBinder.Update(0)
@@ -240,9 +409,44 @@ func (r *modelRenderer) render() {
 	if r.activeLightingBinder != nil {
 		r.activeLightingBinder.Update(0)
 	}
+	r.bindSceneTextures()
+	r.uploadJointMatrices()
 	r.drawFaces()
 }
 
+// renderDepth Renders only the depth of the model, as used when
+// generating shadow maps.  The shader must already be active.
+func (r *modelRenderer) renderDepth(shader Shader, worldMatLoc int32) {
+	r.uploadJointMatrices()
+	r.updateDrawUniforms(r.bindShader(shader))
+	for _, mesh := range r.model.meshes {
+		worldMat := mesh.WorldMatrix()
+		gl.UniformMatrix4fv(worldMatLoc, 1, false, &worldMat[0])
+		for _, group := range mesh.faceGroups {
+			group.drawDepth(shader)
+		}
+	}
+}
+
+// renderDepthInstanced Same as renderDepth(), but renders the given number
+// of instances of the model with a single draw call per face group, the
+// shader reading the world matrix of each instance from the instance buffer
+// (see enableInstanceAttributes()).
+func (r *modelRenderer) renderDepthInstanced(shader Shader, worldMatLoc int32, instanceVbo uint32, instanceCount int32) {
+	r.updateDrawUniforms(r.bindShader(shader))
+	for _, mesh := range r.model.meshes {
+		worldMat := mesh.WorldMatrix()
+		gl.UniformMatrix4fv(worldMatLoc, 1, false, &worldMat[0])
+		for _, group := range mesh.faceGroups {
+			group.drawDepthInstanced(shader, instanceVbo, instanceCount)
+		}
+	}
+}
+
+func (r *modelRenderer) setInstanceCount(count int32) {
+	r.instanceCount = count
+}
+
 func (r *modelRenderer) close() {
 	for _, b := range r.cameraBinders {
 		b.Close()
@@ -250,6 +454,9 @@ func (r *modelRenderer) close() {
 	for _, b := range r.lightingBinders {
 		b.Close()
 	}
+	if r.skeletonUbo != 0 {
+		gl.DeleteBuffers(1, &r.skeletonUbo)
+	}
 }
 
 func newModelRenderer(model *modelInstance) *modelRenderer {
@@ -257,6 +464,7 @@ func newModelRenderer(model *modelInstance) *modelRenderer {
 		model:           model,
 		cameraBinders:   make(map[Camera]*ShaderBinder),
 		lightingBinders: make(map[any]*ShaderBinder),
+		uniformLocs:     make(map[uint32]*modelUniformLocs),
 	}
 }
 
@@ -269,12 +477,39 @@ type modelInstance struct {
 	meshes       []*meshInstance
 	shaders      map[uint32]Shader
 	bindingPoint uint32
+
+	// shader When not nil, used in place of the shaders attached to the
+	// materials of the model, as done by InstancedShape3D.
+	shader Shader
+
+	// Used when the model supplies its own indices (see IndexedModel), in
+	// which case its vertices are held in a single, shared buffer.
+	indices      []uint32
+	nextIndex    int
+	vbo          uint32
+	vboCloseFunc func()
+
+	// Used when the model is a SkinnedModel with a valid skeleton, in which
+	// case the skinning data of its vertices is held in a separate buffer,
+	// shared by all face groups if the model is indexed.
+	jointIndices     []float32
+	jointWeights     []float32
+	skinVbo          uint32
+	skinVboCloseFunc func()
 }
 
 func (m *modelInstance) getBindingPoint() uint32 {
 	return m.bindingPoint
 }
 
+// shaderFor Returns the shader used to render faces with the given material.
+func (m *modelInstance) shaderFor(material Material) Shader {
+	if m.shader != nil {
+		return m.shader
+	}
+	return material.AttachedShader()
+}
+
 func (m *modelInstance) getLayout() VertexAttributeLayout {
 	if len(m.model.Bitangents()) > 0 && len(m.model.Tangents()) > 0 &&
 		len(m.model.UVs()) > 0 && len(m.model.Normals()) > 0 && len(m.model.Vertices()) > 0 {
@@ -291,17 +526,114 @@ func (m *modelInstance) getLayout() VertexAttributeLayout {
 	panic("unsupported vertex attribute layout")
 }
 
+// initIndices Takes the indices supplied by the model, if it implements
+// IndexedModel and the indices are valid for its vertices and faces.
+func (m *modelInstance) initIndices() {
+	indexed, ok := m.model.(IndexedModel)
+	if !ok {
+		return
+	}
+
+	indices := indexed.Indices()
+	if len(indices) == 0 {
+		return
+	}
+
+	faceCount := 0
+	for _, mesh := range m.model.Meshes() {
+		for _, face := range mesh.Faces() {
+			if len(face.VertexIndices()) != 3 {
+				return
+			}
+			faceCount++
+		}
+	}
+	if len(indices) != faceCount*3 {
+		return
+	}
+
+	vertexCount := uint32(len(m.model.Vertices()) / 3)
+	for _, index := range indices {
+		if index >= vertexCount {
+			return
+		}
+	}
+
+	m.indices = indices
+}
+
+// initSharedBuffer Uploads every vertex of an IndexedModel to a single
+// vertex buffer object, to be shared by all face groups.  Returns false,
+// without creating the buffer, if the model does not store the attributes
+// required by the layout per vertex.
+func (m *modelInstance) initSharedBuffer() bool {
+	attributes := newVertexAttributes(m.model, m.getLayout())
+	if !attributes.perVertex() {
+		return false
+	}
+	count := len(attributes.vertices) / 3
+
+	buffer := make([]float32, 0, count*int(vertexStride(attributes.layout)/sizeOfFloat32))
+	for i := 0; i < count; i++ {
+		buffer = attributes.appendVertex(buffer, vertexKey{i, i, i, i, i, i})
+	}
+
+	m.vbo, m.vboCloseFunc = newVertexBufferObject(buffer)
+	return true
+}
+
+// initSkin Takes the skinning data of the model, if it implements
+// SkinnedModel with a skeleton of no more than MaxJoints joints, unless the
+// model is rendered with a shader override (as done by InstancedShape3D).
+func (m *modelInstance) initSkin() {
+	skinned, ok := m.model.(SkinnedModel)
+	if !ok || m.shader != nil {
+		return
+	}
+
+	if joints := skinned.Joints(); len(joints) == 0 || len(joints) > MaxJoints {
+		return
+	}
+
+	count := len(m.model.Vertices()) / 3
+	jointIndices, jointWeights := skinned.JointIndices(), skinned.JointWeights()
+	if len(jointIndices) != count*4 || len(jointWeights) != count*4 {
+		return
+	}
+
+	m.jointIndices, m.jointWeights = jointIndices, jointWeights
+
+	if m.indices != nil {
+		attributes := &vertexAttributes{joints: jointIndices, weights: jointWeights}
+		buffer := make([]float32, 0, count*8)
+		for i := 0; i < count; i++ {
+			buffer = attributes.appendSkin(buffer, i)
+		}
+		m.skinVbo, m.skinVboCloseFunc = newVertexBufferObject(buffer)
+	}
+}
+
+func (m *modelInstance) skinned() bool {
+	return m.jointIndices != nil
+}
+
 func (m *modelInstance) close() {
 	for _, mesh := range m.meshes {
 		mesh.close()
 	}
+	if m.vboCloseFunc != nil {
+		m.vboCloseFunc()
+	}
+	if m.skinVboCloseFunc != nil {
+		m.skinVboCloseFunc()
+	}
 }
 
 func (m *modelInstance) Meshes() []*meshInstance {
 	return m.meshes
 }
 
-func newModelInstance(model Model, parentTransform Transform) *modelInstance {
+func newModelInstance(model Model, parentTransform Transform, shader Shader) *modelInstance {
 	if model == nil {
 		panic("model cannot be nil")
 	}
@@ -322,6 +654,13 @@ func newModelInstance(model Model, parentTransform Transform) *modelInstance {
 	instance.meshes = make([]*meshInstance, len(meshes))
 	instance.shaders = make(map[uint32]Shader)
 	instance.bindingPoint = 5 // allow other bindings to take 0-4
+	instance.shader = shader
+
+	instance.initIndices()
+	if instance.indices != nil && !instance.initSharedBuffer() {
+		instance.indices = nil
+	}
+	instance.initSkin()
 
 	for i, mesh := range meshes {
 		meshInst := newMeshInstance(mesh, parentTransform, instance)
@@ -351,6 +690,14 @@ type meshInstance struct {
 	shaders map[uint32]Shader
 	binder  *ShaderBinder
 
+	// pickTriangles Built from source on the first raycast, as most
+	// meshes are never picked.
+	source            Mesh
+	pickTriangles     []pickTriangle
+	pickTrianglesOnce sync.Once
+
+	boundsMin, boundsMax mgl32.Vec3
+
 	WorldMat mgl32.Mat4
 }
 
@@ -364,145 +711,39 @@ func (m *meshInstance) initFaces(mesh Mesh) {
 
 	m.faces = make([]*faceInstance, len(faces))
 	for j, face := range faces {
-		faceInst := newFaceInstance(face)
+		faceInst := newFaceInstance(face, m.parent.shader)
 		m.faces[j] = faceInst
-		m.shaders[faceInst.shaderName] = faceInst.material.AttachedShader()
-	}
-}
-
-func (m *meshInstance) appendToFaceGroupBuffer(model Model, face Face, group *faceRenderGroup,
-	layout VertexAttributeLayout, indicesLen int, indices []int) {
-	vertices := model.Vertices()
-	colors := model.Colors()
-	uvs := model.UVs()
-	normals := model.Normals()
-	tangents := model.Tangents()
-	bitangents := model.Bitangents()
-
-	switch layout {
-	case PositionOnlyVaoLayout:
-		vertIndices := face.VertexIndices()
-		for i := 0; i < indicesLen; i++ {
-			index := indices[i]
-
-			vertexIdx := vertIndices[index] * 3
-			group.buffer = append(group.buffer, vertices[vertexIdx:vertexIdx+3]...)
-		}
-	case PositionColorVaoLayout:
-		vertIndices := face.VertexIndices()
-		colIndices := face.ColorIndices()
-		for i := 0; i < indicesLen; i++ {
-			index := indices[i]
-
-			vertexIdx := vertIndices[index] * 3
-			group.buffer = append(group.buffer, vertices[vertexIdx:vertexIdx+3]...)
-
-			colIdx := colIndices[index] * 3
-			group.buffer = append(group.buffer, colors[colIdx:colIdx+3]...)
-		}
-	case PositionUvVaoLayout:
-		vertIndices := face.VertexIndices()
-		uvIndices := face.UvIndices()
-		for i := 0; i < indicesLen; i++ {
-			index := indices[i]
-
-			vertexIdx := vertIndices[index] * 3
-			group.buffer = append(group.buffer, vertices[vertexIdx:vertexIdx+3]...)
-
-			uvIdx := uvIndices[index] * 2
-			group.buffer = append(group.buffer, uvs[uvIdx:uvIdx+2]...)
-		}
-	case PositionNormalUvVaoLayout:
-		vertIndices := face.VertexIndices()
-		normIndices := face.NormalIndices()
-		uvIndices := face.UvIndices()
-		for i := 0; i < indicesLen; i++ {
-			index := indices[i]
-
-			vertexIdx := vertIndices[index] * 3
-			group.buffer = append(group.buffer, vertices[vertexIdx:vertexIdx+3]...)
-
-			normalIdx := normIndices[index] * 3
-			group.buffer = append(group.buffer, normals[normalIdx:normalIdx+3]...)
-
-			uvIdx := uvIndices[index] * 2
-			group.buffer = append(group.buffer, uvs[uvIdx:uvIdx+2]...)
-		}
-	case PositionNormalUvTangentsVaoLayout:
-		vertIndices := face.VertexIndices()
-		normIndices := face.NormalIndices()
-		uvIndices := face.UvIndices()
-		tanIndices := face.TangentIndices()
-		bitanIndices := face.BitangentIndices()
-		for i := 0; i < indicesLen; i++ {
-			index := indices[i]
-
-			vertexIdx := vertIndices[index] * 3
-			group.buffer = append(group.buffer, vertices[vertexIdx:vertexIdx+3]...)
-
-			normalIdx := normIndices[index] * 3
-			group.buffer = append(group.buffer, normals[normalIdx:normalIdx+3]...)
-
-			uvIdx := uvIndices[index] * 2
-			group.buffer = append(group.buffer, uvs[uvIdx:uvIdx+2]...)
-
-			tanIdx := tanIndices[index] * 3
-			group.buffer = append(group.buffer, tangents[tanIdx:tanIdx+3]...)
-
-			bitanIdx := bitanIndices[index] * 3
-			group.buffer = append(group.buffer, bitangents[bitanIdx:bitanIdx+3]...)
-		}
+		m.shaders[faceInst.shaderName] = m.parent.shaderFor(faceInst.material)
 	}
 }
 
+// createFaceGroups Divides the faces into groups of contiguous faces that
+// share the same material, each drawn with a single call.
 func (m *meshInstance) createFaceGroups(mesh Mesh) {
-	model := m.parent.model
-	faces := mesh.Faces()
 	layout := m.parent.getLayout()
+	attributes := newVertexAttributes(m.parent.model, layout)
+	attributes.joints, attributes.weights = m.parent.jointIndices, m.parent.jointWeights
 
-	vertexCountMultiplier := 0
-	var indices []int
-	indicesLen := 0
-
-	switch len(faces[0].VertexIndices()) {
-	case 3:
-		vertexCountMultiplier = 3
-		indices = []int{0, 1, 2}
-		indicesLen = 3
-	case 4:
-		vertexCountMultiplier = 6
-		indices = []int{0, 1, 2, 0, 2, 3}
-		indicesLen = 6
-	default:
-		panic("unsupported number of face vertices (expecting 3 or 4)")
-	}
-
-	group := &faceRenderGroup{
-		model:    m.parent,
-		layout:   layout,
-		material: m.faces[0].material,
-	}
-
-	for _, face := range faces {
+	var group *faceRenderGroup
+	for _, face := range mesh.Faces() {
 		material := face.AttachedMaterial()
-		if material != group.material {
-			group.vertexCount = int32(group.faceCount * vertexCountMultiplier)
-			m.faceGroups = append(m.faceGroups, group)
+		if group == nil || material != group.material {
 			group = &faceRenderGroup{
 				model:    m.parent,
 				layout:   layout,
 				material: material,
 			}
+			m.faceGroups = append(m.faceGroups, group)
 		}
 
-		m.appendToFaceGroupBuffer(model, face, group, layout, indicesLen, indices)
+		if m.parent.indices != nil {
+			group.indices = append(group.indices, m.parent.indices[m.parent.nextIndex:m.parent.nextIndex+3]...)
+			m.parent.nextIndex += 3
+		} else {
+			group.appendFace(attributes, face)
+		}
 		group.faceCount++
 	}
-
-	if group.faceCount > 0 {
-		group.vertexCount = int32(group.faceCount * vertexCountMultiplier)
-		m.faceGroups = append(m.faceGroups, group)
-	}
 }
 
 func (m *meshInstance) initFaceGroups() {
@@ -528,10 +769,64 @@ func (m *meshInstance) close() {
 	m.binder.Close()
 }
 
+// raycast Returns the nearest intersection of the given world-space ray
+// with the faces of the mesh, first testing the ray against the bounding
+// box of the mesh.
+func (m *meshInstance) raycast(ray Ray) (hit RayHit, ok bool) {
+	localRay := ray.Transform(m.WorldMatrix().Inv())
+
+	if _, boxOk := localRay.IntersectBox(m.boundsMin, m.boundsMax); !boxOk {
+		return
+	}
+
+	m.pickTrianglesOnce.Do(func() {
+		m.pickTriangles = newPickTriangles(m.parent.model, m.source)
+	})
+
+	for i := range m.pickTriangles {
+		triangle := &m.pickTriangles[i]
+		v := triangle.vertices
+		distance, barycentric, triOk := localRay.IntersectTriangle(v[0], v[1], v[2])
+		if !triOk || (ok && distance >= hit.Distance) {
+			continue
+		}
+
+		hit.Mesh = m
+		hit.FaceIndex = triangle.faceIndex
+		hit.FaceVertices = triangle.faceVertices
+		hit.Barycentric = barycentric
+		hit.Distance = distance
+		ok = true
+	}
+
+	if ok {
+		hit.Position = ray.At(hit.Distance)
+	}
+	return
+}
+
+// WorldMatrix Returns the world matrix of the mesh, which is its local
+// matrix applied on top of the world matrix of the scene node holding its
+// Shape3D, if there is one.
+func (m *meshInstance) WorldMatrix() mgl32.Mat4 {
+	if shape, ok := m.ParentTransform().(*Shape3D); ok {
+		if node := shape.SceneNode(); node != nil {
+			return node.WorldMatrix().Mul4(m.LocalMatrix())
+		}
+	}
+	return m.ObjectTransform.WorldMatrix()
+}
+
 func (m *meshInstance) Name() string {
 	return m.name
 }
 
+// LocalBounds returns the corners of the axis-aligned box bounding the
+// vertices of the mesh, in local space.
+func (m *meshInstance) LocalBounds() (boundsMin, boundsMax mgl32.Vec3) {
+	return m.boundsMin, m.boundsMax
+}
+
 func (m *meshInstance) Faces() []*faceInstance {
 	return m.faces
 }
@@ -540,6 +835,7 @@ func newMeshInstance(mesh Mesh, parentTransform Transform, parentModel *modelIns
 	instance := &meshInstance{
 		parent: parentModel,
 		name:   mesh.Name(),
+		source: mesh,
 	}
 
 	instance.SetParentTransform(parentTransform)
@@ -552,6 +848,7 @@ func newMeshInstance(mesh Mesh, parentTransform Transform, parentModel *modelIns
 	}
 
 	instance.initFaces(mesh)
+	instance.boundsMin, instance.boundsMax = newMeshBounds(parentModel.model, mesh)
 	instance.createFaceGroups(mesh)
 	instance.initFaceGroups()
 	instance.initBindings()
@@ -572,11 +869,17 @@ func (f *faceInstance) Material() Material {
 	return f.material
 }
 
-func newFaceInstance(face Face) *faceInstance {
+func newFaceInstance(face Face, shaderOverride Shader) *faceInstance {
 	material := face.AttachedMaterial()
 	if material == nil {
 		panic("face must have an attached material")
 	}
+	if shaderOverride != nil {
+		return &faceInstance{
+			material:   material,
+			shaderName: shaderOverride.GlName(),
+		}
+	}
 	if shader := material.AttachedShader(); shader == nil {
 		panic("material must have an attached shader")
 	} else {
@@ -587,33 +890,256 @@ func newFaceInstance(face Face) *faceInstance {
 	}
 }
 
+/******************************************************************************
+ vertexAttributes
+******************************************************************************/
+
+// vertexKey The indices of the attributes of a single vertex: position,
+// color, texture coordinates, normal, tangent and bitangent.
+type vertexKey [6]int
+
+// vertexAttributes Holds the attribute arrays of a model that are used by
+// the given layout, along with the skinning data of a SkinnedModel, if any.
+type vertexAttributes struct {
+	layout     VertexAttributeLayout
+	vertices   []float32
+	colors     []float32
+	uvs        []float32
+	normals    []float32
+	tangents   []float32
+	bitangents []float32
+	joints     []float32
+	weights    []float32
+}
+
+// key Returns the indices of the attributes of the given corner of the face,
+// with those not used by the layout left as zero.
+func (a *vertexAttributes) key(face Face, corner int) (key vertexKey) {
+	key[0] = face.VertexIndices()[corner]
+
+	switch a.layout {
+	case PositionColorVaoLayout:
+		key[1] = face.ColorIndices()[corner]
+	case PositionUvVaoLayout:
+		key[2] = face.UvIndices()[corner]
+	case PositionNormalUvVaoLayout:
+		key[2] = face.UvIndices()[corner]
+		key[3] = face.NormalIndices()[corner]
+	case PositionNormalUvTangentsVaoLayout:
+		key[2] = face.UvIndices()[corner]
+		key[3] = face.NormalIndices()[corner]
+		key[4] = face.TangentIndices()[corner]
+		key[5] = face.BitangentIndices()[corner]
+	}
+
+	return
+}
+
+// appendVertex Appends the attributes of the given vertex to the buffer,
+// interleaved in the order expected by the layout.
+func (a *vertexAttributes) appendVertex(buffer []float32, key vertexKey) []float32 {
+	buffer = append(buffer, a.vertices[key[0]*3:key[0]*3+3]...)
+
+	switch a.layout {
+	case PositionColorVaoLayout:
+		buffer = append(buffer, a.colors[key[1]*3:key[1]*3+3]...)
+	case PositionUvVaoLayout:
+		buffer = append(buffer, a.uvs[key[2]*2:key[2]*2+2]...)
+	case PositionNormalUvVaoLayout:
+		buffer = append(buffer, a.normals[key[3]*3:key[3]*3+3]...)
+		buffer = append(buffer, a.uvs[key[2]*2:key[2]*2+2]...)
+	case PositionNormalUvTangentsVaoLayout:
+		buffer = append(buffer, a.normals[key[3]*3:key[3]*3+3]...)
+		buffer = append(buffer, a.uvs[key[2]*2:key[2]*2+2]...)
+		buffer = append(buffer, a.tangents[key[4]*3:key[4]*3+3]...)
+		buffer = append(buffer, a.bitangents[key[5]*3:key[5]*3+3]...)
+	}
+
+	return buffer
+}
+
+// appendSkin Appends the joint indices and weights of the vertex with the
+// given position index to the buffer, as expected by enableSkinAttributes().
+func (a *vertexAttributes) appendSkin(buffer []float32, index int) []float32 {
+	buffer = append(buffer, a.joints[index*4:index*4+4]...)
+	return append(buffer, a.weights[index*4:index*4+4]...)
+}
+
+// perVertex Reports whether each attribute used by the layout has exactly
+// one value per vertex position, as required to share the indices of an
+// IndexedModel.
+func (a *vertexAttributes) perVertex() bool {
+	count := len(a.vertices) / 3
+	switch a.layout {
+	case PositionColorVaoLayout:
+		return len(a.colors) == count*3
+	case PositionUvVaoLayout:
+		return len(a.uvs) == count*2
+	case PositionNormalUvVaoLayout:
+		return len(a.normals) == count*3 && len(a.uvs) == count*2
+	case PositionNormalUvTangentsVaoLayout:
+		return len(a.normals) == count*3 && len(a.uvs) == count*2 &&
+			len(a.tangents) == count*3 && len(a.bitangents) == count*3
+	}
+	return true
+}
+
+func newVertexAttributes(model Model, layout VertexAttributeLayout) *vertexAttributes {
+	return &vertexAttributes{
+		layout:     layout,
+		vertices:   model.Vertices(),
+		colors:     model.Colors(),
+		uvs:        model.UVs(),
+		normals:    model.Normals(),
+		tangents:   model.Tangents(),
+		bitangents: model.Bitangents(),
+	}
+}
+
 /******************************************************************************
  faceRenderGroup
 ******************************************************************************/
 
+var (
+	triangleCorners = []int{0, 1, 2}
+	quadCorners     = []int{0, 1, 2, 0, 2, 3}
+)
+
 type faceRenderGroup struct {
 	model           *modelInstance
 	material        Material
 	shader          Shader
 	layout          VertexAttributeLayout
 	buffer          []float32
+	indices         []uint32
+	vertexLookup    map[vertexKey]uint32
 	faceCount       int
-	vertexCount     int32
+	indexCount      int32
 	materialBinding *ShaderBinding
 	vao             uint32
+	vbo             uint32
+	ebo             uint32
 	closeFunc       func()
+	vboCloseFunc    func()
+
+	skinBuffer       []float32
+	skinVbo          uint32
+	skinVboCloseFunc func()
+
+	shadowVao       uint32
+	shadowCloseFunc func()
+}
+
+// appendFace Appends the face, as one or two triangles, to the group's
+// indices, adding only the vertices (unique combinations of attribute
+// indices) not already in the group's buffer.
+func (g *faceRenderGroup) appendFace(attributes *vertexAttributes, face Face) {
+	var corners []int
+	switch len(face.VertexIndices()) {
+	case 3:
+		corners = triangleCorners
+	case 4:
+		corners = quadCorners
+	default:
+		panic("unsupported number of face vertices (expecting 3 or 4)")
+	}
+
+	if g.vertexLookup == nil {
+		g.vertexLookup = make(map[vertexKey]uint32)
+	}
+
+	for _, corner := range corners {
+		key := attributes.key(face, corner)
+		index, ok := g.vertexLookup[key]
+		if !ok {
+			index = uint32(len(g.vertexLookup))
+			g.vertexLookup[key] = index
+			g.buffer = attributes.appendVertex(g.buffer, key)
+			if attributes.joints != nil {
+				g.skinBuffer = attributes.appendSkin(g.skinBuffer, key[0])
+			}
+		}
+		g.indices = append(g.indices, index)
+	}
 }
 
 func (g *faceRenderGroup) init() {
-	g.shader = g.material.AttachedShader()
+	g.shader = g.model.shaderFor(g.material)
 	g.materialBinding = NewShaderBinding(g.shader, g.material, func() uint32 { return materialUboBindPoint })
 	g.materialBinding.Init()
-	g.vao, g.closeFunc = newVertexArrayObject(g.layout, g.shader, g.buffer)
+
+	if g.model.vbo != 0 {
+		g.vbo = g.model.vbo
+	} else {
+		g.vbo, g.vboCloseFunc = newVertexBufferObject(g.buffer)
+	}
+	g.vao, g.ebo, g.closeFunc = newIndexedVertexArrayObject(g.layout, g.shader, g.vbo, g.indices)
+	g.indexCount = int32(len(g.indices))
+
+	if g.model.skinVbo != 0 {
+		g.skinVbo = g.model.skinVbo
+	} else if len(g.skinBuffer) > 0 {
+		g.skinVbo, g.skinVboCloseFunc = newVertexBufferObject(g.skinBuffer)
+	}
+	if g.skinVbo != 0 {
+		enableSkinAttributes(g.vao, g.skinVbo, g.shader)
+	}
+
+	// The vertices now live in GPU memory
+	g.buffer = nil
+	g.indices = nil
+	g.vertexLookup = nil
+	g.skinBuffer = nil
+}
+
+func (g *faceRenderGroup) draw() {
+	gl.BindVertexArray(g.vao)
+	gl.DrawElementsWithOffset(gl.TRIANGLES, g.indexCount, gl.UNSIGNED_INT, 0)
+}
+
+func (g *faceRenderGroup) drawInstanced(instanceCount int32) {
+	gl.BindVertexArray(g.vao)
+	gl.DrawElementsInstanced(gl.TRIANGLES, g.indexCount, gl.UNSIGNED_INT, nil, instanceCount)
+}
+
+// drawDepth Renders only the depth of the faces using the given shader
+// (which expects only a_Position), creating the required vertex array
+// object on first use.
+func (g *faceRenderGroup) drawDepth(shader Shader) {
+	if g.shadowVao == 0 {
+		g.shadowVao, g.shadowCloseFunc = newPositionOnlyVertexArrayObject(g.layout, shader, g.vbo, g.ebo)
+		if g.skinVbo != 0 {
+			enableSkinAttributes(g.shadowVao, g.skinVbo, shader)
+		}
+	}
+	gl.BindVertexArray(g.shadowVao)
+	gl.DrawElementsWithOffset(gl.TRIANGLES, g.indexCount, gl.UNSIGNED_INT, 0)
+}
+
+// drawDepthInstanced Same as drawDepth(), but renders the given number of
+// instances, the shader also expecting a_InstanceWorldMat from the given
+// instance buffer object.
+func (g *faceRenderGroup) drawDepthInstanced(shader Shader, instanceVbo uint32, instanceCount int32) {
+	if g.shadowVao == 0 {
+		g.shadowVao, g.shadowCloseFunc = newPositionOnlyVertexArrayObject(g.layout, shader, g.vbo, g.ebo)
+		enableInstanceAttributes(g.shadowVao, instanceVbo, shader)
+	}
+	gl.BindVertexArray(g.shadowVao)
+	gl.DrawElementsInstanced(gl.TRIANGLES, g.indexCount, gl.UNSIGNED_INT, nil, instanceCount)
 }
 
 func (g *faceRenderGroup) close() {
+	if g.shadowCloseFunc != nil {
+		g.shadowCloseFunc()
+	}
 	if g.closeFunc != nil {
 		g.closeFunc()
 	}
+	if g.vboCloseFunc != nil {
+		g.vboCloseFunc()
+	}
+	if g.skinVboCloseFunc != nil {
+		g.skinVboCloseFunc()
+	}
 	g.materialBinding.Close()
 }
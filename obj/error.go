@@ -0,0 +1,62 @@
+package obj
+
+import (
+	"fmt"
+)
+
+/******************************************************************************
+ ParseError
+******************************************************************************/
+
+// ParseError Describes a problem encountered while parsing an OBJ or MTL
+// file.  In strict mode (the default), the first ParseError encountered
+// stops the load and is returned by Err(); in lenient mode, the offending
+// line is skipped and the error is instead recorded as a warning.
+type ParseError struct {
+	// Format is either "OBJ" or "MTL".
+	Format string
+
+	// File is the name of the source file or, if the asset was
+	// not loaded from a file, the name of the asset.
+	File string
+
+	// Line is the 1-based line number, or 0 if the error is not
+	// associated with a specific line (e.g., a read error).
+	Line int
+
+	// Directive is the keyword (e.g. "f", "usemtl", "Kd") at the start
+	// of the offending line, if any.
+	Directive string
+
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	switch {
+	case e.Line == 0:
+		return fmt.Sprintf("%s parse error: %s: %v", e.Format, e.File, e.Err)
+	case e.Directive == "":
+		return fmt.Sprintf("%s parse error: %s: line %d: %v", e.Format, e.File, e.Line, e.Err)
+	default:
+		return fmt.Sprintf("%s parse error: %s: line %d: %s: %v", e.Format, e.File, e.Line, e.Directive, e.Err)
+	}
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func newParseError(format, file string, line int, directive string, err error) *ParseError {
+	return &ParseError{
+		Format:    format,
+		File:      file,
+		Line:      line,
+		Directive: directive,
+		Err:       err,
+	}
+}
@@ -17,7 +17,7 @@ type Face struct {
 	tangents   []int
 	bitangents []int
 
-	material *BasicMaterial
+	material gfx.Material
 }
 
 /******************************************************************************
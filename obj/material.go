@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/internal/importer"
+	"image/color"
 	"io"
 	"strings"
 	"sync/atomic"
@@ -22,9 +24,14 @@ type BasicMaterial struct {
 	mapKd   string
 	mapKs   string
 	mapNorm string
+	mapKe   string
 
+	// textures The maps created by the material (rather than assigned by
+	// the caller), which are owned (i.e. closed) by it.
 	textures []gfx.Texture
 
+	pbr *PbrMaterial
+
 	Properties  *BasicMaterialProperties
 	DiffuseMap  gfx.Texture
 	SpecularMap gfx.Texture
@@ -89,6 +96,163 @@ func NewMaterial() *BasicMaterial {
 	}
 }
 
+/******************************************************************************
+ PbrMaterial
+******************************************************************************/
+
+// PbrMaterial A metallic-roughness material, as expected by the default
+// PBR shaders (gfx.PbrShader and gfx.PbrNoNormalMapShader).  The metallic
+// value is read from the blue channel of MetallicMap and the roughness from
+// the green channel of RoughnessMap (so grayscale maps and the combined
+// maps used by glTF both work), while the occlusion is read from the red
+// channel of OcclusionMap.  The base color, metallic, roughness and emissive
+// maps are multiplied by the corresponding property, whereas Occlusion is
+// the strength of the occlusion map: 0 ignores the map, 1 applies it fully.
+type PbrMaterial struct {
+	gfx.MaterialBase
+
+	name string
+
+	textures []gfx.Texture
+
+	// sharedTextures The maps shared with (and owned, i.e. closed, by)
+	// the basic version of the material.
+	sharedTextures []gfx.Texture
+
+	Properties   *PbrMaterialProperties
+	BaseColorMap gfx.Texture
+	NormalMap    gfx.Texture
+	MetallicMap  gfx.Texture
+	RoughnessMap gfx.Texture
+	OcclusionMap gfx.Texture
+	EmissiveMap  gfx.Texture
+}
+
+type PbrMaterialProperties struct {
+	BaseColor    mgl32.Vec4
+	Emissive     mgl32.Vec4
+	Metallic     float32
+	Roughness    float32
+	Occlusion    float32
+	Transparency float32
+}
+
+/******************************************************************************
+ Asset Implementation
+******************************************************************************/
+
+func (m *PbrMaterial) Name() string {
+	return m.name
+}
+
+func (m *PbrMaterial) Init() bool {
+	if m.Initialized() {
+		return true
+	}
+
+	for _, t := range m.textures {
+		t.Init()
+	}
+
+	for _, t := range m.sharedTextures {
+		t.Init()
+	}
+
+	return m.AssetBase.Init()
+}
+
+func (m *PbrMaterial) Close() {
+	if !m.Initialized() {
+		return
+	}
+
+	for _, t := range m.textures {
+		t.Close()
+	}
+
+	m.AssetBase.Close()
+}
+
+/******************************************************************************
+ PbrMaterial Functions
+******************************************************************************/
+
+func (m *PbrMaterial) addTexture(texture gfx.Texture) gfx.Texture {
+	m.textures = append(m.textures, texture)
+	return texture
+}
+
+func (m *PbrMaterial) addMap(filename string, srcLib *gfx.AssetLibrary) gfx.Texture {
+	texture := gfx.NewTexture2D(filename, filename)
+	texture.SetSourceLibrary(srcLib)
+	return m.addTexture(texture)
+}
+
+func (m *PbrMaterial) loadDefaultTextures(srcLib *gfx.AssetLibrary) {
+	defaultMap := func(texture *gfx.Texture, color color.RGBA) {
+		if *texture == nil {
+			*texture = m.addTexture(gfx.NewTexture2D("", color))
+			(*texture).SetSourceLibrary(srcLib)
+		}
+	}
+
+	defaultMap(&m.BaseColorMap, gfx.White)
+	defaultMap(&m.NormalMap, gfx.DefaultNormalMapColor)
+	defaultMap(&m.MetallicMap, gfx.White)
+	defaultMap(&m.RoughnessMap, gfx.White)
+	defaultMap(&m.OcclusionMap, gfx.White)
+	defaultMap(&m.EmissiveMap, gfx.White)
+}
+
+// build Completes the PBR material from the directives shared with the
+// basic material (Kd, Ke, Tr, map_Kd, map_Ke and norm), once all of the
+// material's directives have been parsed.
+func (m *PbrMaterial) build(basic *BasicMaterial, srcLib *gfx.AssetLibrary) {
+	m.name = basic.name
+	m.SetSourceLibrary(srcLib)
+
+	m.Properties.BaseColor = mgl32.Vec4{basic.Properties.Diffuse[0], basic.Properties.Diffuse[1], basic.Properties.Diffuse[2], 1.0}
+	m.Properties.Emissive = basic.Properties.Emissive
+	m.Properties.Transparency = basic.Properties.Transparency
+
+	if basic.DiffuseMap != nil {
+		m.BaseColorMap = basic.DiffuseMap
+		m.sharedTextures = append(m.sharedTextures, basic.DiffuseMap)
+	}
+
+	if basic.NormalMap != nil {
+		m.NormalMap = basic.NormalMap
+		m.sharedTextures = append(m.sharedTextures, basic.NormalMap)
+	}
+
+	if basic.mapKe != "" {
+		m.EmissiveMap = m.addMap(basic.mapKe, srcLib)
+		if m.Properties.Emissive.Vec3().Len() == 0 {
+			m.Properties.Emissive = mgl32.Vec4{1, 1, 1, 1}
+		}
+	}
+
+	m.loadDefaultTextures(srcLib)
+}
+
+/******************************************************************************
+ New PbrMaterial Function
+******************************************************************************/
+
+// NewPbrMaterial creates a white, non-metallic and fully rough material.
+func NewPbrMaterial() *PbrMaterial {
+	return &PbrMaterial{
+		Properties: &PbrMaterialProperties{
+			BaseColor:    mgl32.Vec4{1.0, 1.0, 1.0, 1.0},
+			Emissive:     mgl32.Vec4{0.0, 0.0, 0.0, 0.0},
+			Metallic:     0.0,
+			Roughness:    1.0,
+			Occlusion:    1.0,
+			Transparency: 0.0,
+		},
+	}
+}
+
 /******************************************************************************
  MaterialLibrary
 ******************************************************************************/
@@ -96,8 +260,13 @@ func NewMaterial() *BasicMaterial {
 type MaterialLibrary struct {
 	gfx.AssetBase
 
-	materials map[string]*BasicMaterial
-	loaded    atomic.Bool
+	materials    map[string]*BasicMaterial
+	pbrMaterials map[string]*PbrMaterial
+	lenient      bool
+
+	err      error
+	warnings []*ParseError
+	loaded   atomic.Bool
 }
 
 /******************************************************************************
@@ -109,7 +278,9 @@ func (l *MaterialLibrary) Init() bool {
 		return true
 	}
 
-	l.Load()
+	if err := l.Load(); err != nil {
+		return false
+	}
 
 	if ok := l.initMaterials(); !ok {
 		return false
@@ -132,150 +303,236 @@ func (l *MaterialLibrary) Close() {
  MaterialLibrary Functions
 ******************************************************************************/
 
-func (l *MaterialLibrary) parseFields(fields []string, currentMat *BasicMaterial, lineNumber int) *BasicMaterial {
+func (l *MaterialLibrary) parseFields(fields []string, currentMat *BasicMaterial) (*BasicMaterial, error) {
+	var err error
+
 	switch fields[0] {
 	case "newmtl":
-		currentMat = l.parseNewmtl(fields, currentMat, lineNumber)
+		currentMat, err = l.parseNewmtl(fields, currentMat)
 	case "Ka":
-		l.parseKa(fields, currentMat, lineNumber)
+		err = l.parseKa(fields, currentMat)
 	case "Kd":
-		l.parseKd(fields, currentMat, lineNumber)
+		err = l.parseKd(fields, currentMat)
 	case "Ks":
-		l.parseKs(fields, currentMat, lineNumber)
+		err = l.parseKs(fields, currentMat)
 	case "Ns":
-		l.parseNs(fields, currentMat, lineNumber)
+		err = l.parseNs(fields, currentMat)
 	case "Ke":
-		l.parseKe(fields, currentMat, lineNumber)
+		err = l.parseKe(fields, currentMat)
 	case "Tr":
-		l.parseTr(fields, currentMat, lineNumber)
+		err = l.parseTr(fields, currentMat)
 	case "map_Kd":
-		l.parseMapKd(fields, currentMat, lineNumber)
+		err = l.parseMapKd(fields, currentMat)
 	case "map_Ks":
-		l.parseMapKs(fields, currentMat, lineNumber)
+		err = l.parseMapKs(fields, currentMat)
 	case "norm", "map_Kn":
-		l.parseMapKn(fields, currentMat, lineNumber)
+		err = l.parseMapKn(fields, currentMat)
+	case "map_Ke":
+		err = l.parseMapKe(fields, currentMat)
+	case "Pr":
+		err = l.parsePr(fields, currentMat)
+	case "Pm":
+		err = l.parsePm(fields, currentMat)
+	case "map_Pr":
+		err = l.parseMapPr(fields, currentMat)
+	case "map_Pm":
+		err = l.parseMapPm(fields, currentMat)
+	default:
+		if l.lenient && !strings.HasPrefix(fields[0], "#") {
+			err = fmt.Errorf("unsupported directive")
+		}
 	}
 
-	return currentMat
+	return currentMat, err
 }
 
-func (l *MaterialLibrary) parseNewmtl(fields []string, currentMat *BasicMaterial, lineNumber int) *BasicMaterial {
+func (l *MaterialLibrary) parseNewmtl(fields []string, currentMat *BasicMaterial) (*BasicMaterial, error) {
+	name, err := parseString(fields[1:])
+	if err != nil {
+		return currentMat, err
+	}
+
 	if currentMat.name != "" {
 		l.materials[currentMat.name] = currentMat
 		currentMat = NewMaterial()
 		currentMat.SetSourceLibrary(l.SourceLibrary())
 	}
 
-	if name, err := parseString(fields[1:]); err != nil {
-		panic(fmt.Errorf("MTL parse newmtl error: line %d: %w", lineNumber, err))
-	} else {
-		currentMat.name = name
-	}
-
-	return currentMat
+	currentMat.name = name
+	return currentMat, nil
 }
 
-func (l *MaterialLibrary) parseKa(fields []string, currentMat *BasicMaterial, lineNumber int) {
+func (l *MaterialLibrary) parseKa(fields []string, currentMat *BasicMaterial) error {
 	if value, err := parseVec4(fields[1:]); err != nil {
-		panic(fmt.Errorf("MTL Ka parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		currentMat.Properties.Ambient = value
 	}
+	return nil
 }
 
-func (l *MaterialLibrary) parseKd(fields []string, currentMat *BasicMaterial, lineNumber int) {
+func (l *MaterialLibrary) parseKd(fields []string, currentMat *BasicMaterial) error {
 	if value, err := parseVec4(fields[1:]); err != nil {
-		panic(fmt.Errorf("MTL Kd parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		currentMat.Properties.Diffuse = value
 	}
+	return nil
 }
 
-func (l *MaterialLibrary) parseKs(fields []string, currentMat *BasicMaterial, lineNumber int) {
+func (l *MaterialLibrary) parseKs(fields []string, currentMat *BasicMaterial) error {
 	if value, err := parseVec4(fields[1:]); err != nil {
-		panic(fmt.Errorf("MTL Ks parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		currentMat.Properties.Specular = value
 	}
+	return nil
 }
 
-func (l *MaterialLibrary) parseNs(fields []string, currentMat *BasicMaterial, lineNumber int) {
+func (l *MaterialLibrary) parseNs(fields []string, currentMat *BasicMaterial) error {
 	if value, err := parseFloat(fields[1:]); err != nil {
-		panic(fmt.Errorf("MTL Ns parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		currentMat.Properties.Shininess = value
 	}
+	return nil
 }
 
-func (l *MaterialLibrary) parseKe(fields []string, currentMat *BasicMaterial, lineNumber int) {
+func (l *MaterialLibrary) parseKe(fields []string, currentMat *BasicMaterial) error {
 	if value, err := parseVec4(fields[1:]); err != nil {
-		panic(fmt.Errorf("MTL Ke parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		currentMat.Properties.Emissive = value
 	}
+	return nil
 }
 
-func (l *MaterialLibrary) parseTr(fields []string, currentMat *BasicMaterial, lineNumber int) {
+func (l *MaterialLibrary) parseTr(fields []string, currentMat *BasicMaterial) error {
 	if value, err := parseFloat(fields[1:]); err != nil {
-		panic(fmt.Errorf("MTL Tr parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		currentMat.Properties.Transparency = value
 	}
+	return nil
 }
 
-func (l *MaterialLibrary) parseMapKd(fields []string, currentMat *BasicMaterial, lineNumber int) {
+func (l *MaterialLibrary) parseMapKd(fields []string, currentMat *BasicMaterial) error {
 	if value, err := parseString(fields[1:]); err != nil {
-		panic(fmt.Errorf("MTL map_Kd parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		currentMat.mapKd = value
 		currentMat.DiffuseMap = gfx.NewTexture2D(value, value)
 		currentMat.DiffuseMap.SetSourceLibrary(l.SourceLibrary())
 		currentMat.textures = append(currentMat.textures, currentMat.DiffuseMap)
 	}
+	return nil
 }
 
-func (l *MaterialLibrary) parseMapKs(fields []string, currentMat *BasicMaterial, lineNumber int) {
+func (l *MaterialLibrary) parseMapKs(fields []string, currentMat *BasicMaterial) error {
 	if value, err := parseString(fields[1:]); err != nil {
-		panic(fmt.Errorf("MTL map_Ks parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		currentMat.mapKs = value
 		currentMat.SpecularMap = gfx.NewTexture2D(value, value)
 		currentMat.SpecularMap.SetSourceLibrary(l.SourceLibrary())
 		currentMat.textures = append(currentMat.textures, currentMat.SpecularMap)
 	}
+	return nil
 }
 
-func (l *MaterialLibrary) parseMapKn(fields []string, currentMat *BasicMaterial, lineNumber int) {
+func (l *MaterialLibrary) parseMapKn(fields []string, currentMat *BasicMaterial) error {
 	if value, err := parseString(fields[1:]); err != nil {
-		panic(fmt.Errorf("MTL norm/map_Kn parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		currentMat.mapNorm = value
 		currentMat.NormalMap = gfx.NewTexture2D(value, value)
 		currentMat.NormalMap.SetSourceLibrary(l.SourceLibrary())
 		currentMat.textures = append(currentMat.textures, currentMat.NormalMap)
 	}
+	return nil
+}
+
+func (l *MaterialLibrary) parseMapKe(fields []string, currentMat *BasicMaterial) error {
+	if value, err := parseString(fields[1:]); err != nil {
+		return err
+	} else {
+		currentMat.mapKe = value
+	}
+	return nil
+}
+
+// pbrMaterial Returns the PBR version of the given material, creating it
+// when the first directive of the MTL PBR extension is encountered.
+func (l *MaterialLibrary) pbrMaterial(currentMat *BasicMaterial) *PbrMaterial {
+	if currentMat.pbr == nil {
+		currentMat.pbr = NewPbrMaterial()
+	}
+	return currentMat.pbr
+}
+
+func (l *MaterialLibrary) parsePr(fields []string, currentMat *BasicMaterial) error {
+	if value, err := parseFloat(fields[1:]); err != nil {
+		return err
+	} else {
+		l.pbrMaterial(currentMat).Properties.Roughness = mgl32.Clamp(value, 0.0, 1.0)
+	}
+	return nil
+}
+
+func (l *MaterialLibrary) parsePm(fields []string, currentMat *BasicMaterial) error {
+	if value, err := parseFloat(fields[1:]); err != nil {
+		return err
+	} else {
+		l.pbrMaterial(currentMat).Properties.Metallic = mgl32.Clamp(value, 0.0, 1.0)
+	}
+	return nil
+}
+
+func (l *MaterialLibrary) parseMapPr(fields []string, currentMat *BasicMaterial) error {
+	if value, err := parseString(fields[1:]); err != nil {
+		return err
+	} else {
+		mat := l.pbrMaterial(currentMat)
+		mat.RoughnessMap = mat.addMap(value, l.SourceLibrary())
+	}
+	return nil
+}
+
+func (l *MaterialLibrary) parseMapPm(fields []string, currentMat *BasicMaterial) error {
+	if value, err := parseString(fields[1:]); err != nil {
+		return err
+	} else {
+		mat := l.pbrMaterial(currentMat)
+		mat.MetallicMap = mat.addMap(value, l.SourceLibrary())
+	}
+	return nil
 }
 
 func (l *MaterialLibrary) loadFromSlice(slice []byte) {
 	reader := bufio.NewReader(bytes.NewReader(slice))
-	l.loadFromReader(reader)
+	l.loadFromReader(reader, l.Name())
 }
 
 func (l *MaterialLibrary) loadFromFile(name string) (ok bool) {
-	reader, closeFunc := getSourceReader(l, name)
+	reader, closeFunc, err := importer.SourceReader(l, name)
 	defer closeFunc()
 
+	if err != nil {
+		l.err = newParseError("MTL", name, 0, "", err)
+		return true
+	}
+
 	if reader == nil {
 		return false
 	}
 
-	l.loadFromReader(reader)
+	l.loadFromReader(reader, name)
 	return true
 }
 
 func (l *MaterialLibrary) loadFromString(mtl string) {
 	reader := bufio.NewReader(strings.NewReader(mtl))
-	l.loadFromReader(reader)
+	l.loadFromReader(reader, l.Name())
 }
 
 func (l *MaterialLibrary) loadTextures() {
@@ -298,9 +555,16 @@ func (l *MaterialLibrary) loadTextures() {
 			mat.textures = append(mat.textures, mat.SpecularMap)
 		}
 	}
+
+	for _, mat := range l.materials {
+		if mat.pbr != nil {
+			mat.pbr.build(mat, l.SourceLibrary())
+			l.pbrMaterials[mat.name] = mat.pbr
+		}
+	}
 }
 
-func (l *MaterialLibrary) loadFromReader(reader *bufio.Reader) {
+func (l *MaterialLibrary) loadFromReader(reader *bufio.Reader, filename string) {
 	lineNumber := 0
 	currentMat := NewMaterial()
 	currentMat.SetSourceLibrary(l.SourceLibrary())
@@ -310,17 +574,26 @@ func (l *MaterialLibrary) loadFromReader(reader *bufio.Reader) {
 
 		line, readErr := reader.ReadString('\n')
 		if readErr != nil && readErr != io.EOF {
-			panic(fmt.Errorf("file read error: %w", readErr))
-		} else if readErr == io.EOF {
-			break
+			l.err = newParseError("MTL", filename, 0, "", fmt.Errorf("file read error: %w", readErr))
+			return
 		}
 
-		fields := strings.Fields(line)
-		if len(fields) == 0 {
-			continue
+		if fields := strings.Fields(line); len(fields) > 0 {
+			var err error
+			currentMat, err = l.parseFields(fields, currentMat)
+			if err != nil {
+				parseErr := newParseError("MTL", filename, lineNumber, fields[0], err)
+				if !l.lenient {
+					l.err = parseErr
+					return
+				}
+				l.warnings = append(l.warnings, parseErr)
+			}
 		}
 
-		currentMat = l.parseFields(fields, currentMat, lineNumber)
+		if readErr == io.EOF {
+			break
+		}
 	}
 
 	l.materials[currentMat.name] = currentMat
@@ -333,10 +606,16 @@ func (l *MaterialLibrary) initMaterials() bool {
 	for _, mat := range l.materials {
 		ok = ok && mat.Init()
 	}
+	for _, mat := range l.pbrMaterials {
+		ok = ok && mat.Init()
+	}
 	return ok
 }
 
 func (l *MaterialLibrary) closeMaterials() {
+	for _, mat := range l.pbrMaterials {
+		mat.Close()
+	}
 	for _, mat := range l.materials {
 		mat.Close()
 	}
@@ -346,6 +625,16 @@ func (l *MaterialLibrary) Get(name string) *BasicMaterial {
 	return l.materials[name]
 }
 
+// GetPbr returns the PBR version of the given material, which is only
+// available if the material uses the MTL PBR extension (i.e., it has a
+// Pr, Pm, map_Pr or map_Pm directive), otherwise nil is returned.  The
+// base color, emissive color/map, transparency and normal map of the
+// PBR version are taken from the Kd, Ke/map_Ke, Tr, map_Kd and norm
+// directives.
+func (l *MaterialLibrary) GetPbr(name string) *PbrMaterial {
+	return l.pbrMaterials[name]
+}
+
 func (l *MaterialLibrary) GetNames() []string {
 	names := make([]string, 0)
 	for _, m := range l.materials {
@@ -354,9 +643,11 @@ func (l *MaterialLibrary) GetNames() []string {
 	return names
 }
 
-func (l *MaterialLibrary) Load() {
+// Load parses the MTL source, returning the error that
+// stopped the load, if any (see Err()).
+func (l *MaterialLibrary) Load() error {
 	if l.loaded.Load() {
-		return
+		return l.err
 	}
 
 	switch source := l.Source().(type) {
@@ -367,10 +658,36 @@ func (l *MaterialLibrary) Load() {
 			l.loadFromString(source)
 		}
 	default:
-		panic("unexpected error: source type is not supported")
+		l.err = newParseError("MTL", l.Name(), 0, "", fmt.Errorf("source type is not supported"))
 	}
 
 	l.loaded.Store(true)
+	return l.err
+}
+
+// Err returns the error that caused loading to fail, which will
+// be a *ParseError, or nil if the library loaded successfully.
+func (l *MaterialLibrary) Err() error {
+	return l.err
+}
+
+// Warnings returns the problems encountered while loading in
+// lenient mode, each of which resulted in a line being skipped.
+func (l *MaterialLibrary) Warnings() []*ParseError {
+	return l.warnings
+}
+
+func (l *MaterialLibrary) Lenient() bool {
+	return l.lenient
+}
+
+// SetLenient enables/disables lenient mode, which must be done before
+// the library is loaded.  In lenient mode, unsupported directives and
+// malformed lines are skipped and recorded as warnings (see Warnings())
+// rather than causing the load to fail.
+func (l *MaterialLibrary) SetLenient(lenient bool) *MaterialLibrary {
+	l.lenient = lenient
+	return l
 }
 
 /******************************************************************************
@@ -379,7 +696,8 @@ func (l *MaterialLibrary) Load() {
 
 func NewMaterialLibrary[T gfx.MaterialLibrarySource](name string, source T) *MaterialLibrary {
 	return &MaterialLibrary{
-		AssetBase: *gfx.NewAssetBase(name, source),
-		materials: make(map[string]*BasicMaterial),
+		AssetBase:    *gfx.NewAssetBase(name, source),
+		materials:    make(map[string]*BasicMaterial),
+		pbrMaterials: make(map[string]*PbrMaterial),
 	}
 }
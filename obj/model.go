@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/internal/importer"
 	"io"
 	"strings"
 	"sync/atomic"
@@ -28,12 +29,17 @@ type Model struct {
 	meshes       []*Mesh
 	materialLibs []*MaterialLibrary
 
-	defaultMaterial *BasicMaterial
-	defaultShader   gfx.Shader
+	defaultMaterial  *BasicMaterial
+	defaultShader    gfx.Shader
+	defaultPbrShader gfx.Shader
 
 	computeTangentsOnLoad bool
+	lenient               bool
+	pbrEnabled            bool
 
-	loaded atomic.Bool
+	err      error
+	warnings []*ParseError
+	loaded   atomic.Bool
 }
 
 /******************************************************************************
@@ -45,7 +51,9 @@ func (m *Model) Init() bool {
 		return true
 	}
 
-	m.Load()
+	if err := m.Load(); err != nil {
+		return false
+	}
 
 	if ok := m.initMaterialLibraries(); !ok {
 		return false
@@ -105,115 +113,136 @@ func (m *Model) Meshes() []gfx.Mesh {
  Model Functions
 ******************************************************************************/
 
-func (m *Model) parseFields(fields []string, currentMesh *Mesh, currentMat string, lineNumber int) (*Mesh, string) {
+func (m *Model) parseFields(fields []string, currentMesh *Mesh, currentMat string) (*Mesh, string, error) {
+	var err error
+
 	switch fields[0] {
 	case "mtllib":
-		m.parseMtllib(fields, lineNumber)
+		err = m.parseMtllib(fields)
 	case "usemtl":
-		currentMat = m.parseUsemtl(fields, currentMat, lineNumber)
+		currentMat, err = m.parseUsemtl(fields, currentMat)
 	case "g":
-		currentMesh = m.parseGroup(fields, currentMesh, lineNumber)
+		currentMesh, err = m.parseGroup(fields, currentMesh)
 	case "v":
-		m.parseVertex(fields, lineNumber)
+		err = m.parseVertex(fields)
 	case "vn":
-		m.parseVertexNormal(fields, lineNumber)
+		err = m.parseVertexNormal(fields)
 	case "vt":
-		m.parseVertexTexture(fields, lineNumber)
+		err = m.parseVertexTexture(fields)
 	case "f":
-		m.parseFace(fields, currentMesh, currentMat, lineNumber)
+		err = m.parseFace(fields, currentMesh, currentMat)
+	default:
+		if m.lenient && !strings.HasPrefix(fields[0], "#") {
+			err = fmt.Errorf("unsupported directive")
+		}
 	}
 
-	return currentMesh, currentMat
+	return currentMesh, currentMat, err
 }
 
-func (m *Model) parseMtllib(fields []string, lineNumber int) {
+func (m *Model) parseMtllib(fields []string) error {
 	if mat, err := parseString(fields[1:]); err != nil {
-		panic(fmt.Errorf("OBJ parse mtllib error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		m.mtllibs = append(m.mtllibs, mat)
 	}
+	return nil
 }
 
-func (m *Model) parseUsemtl(fields []string, currentMat string, lineNumber int) string {
+func (m *Model) parseUsemtl(fields []string, currentMat string) (string, error) {
 	if mat, err := parseString(fields[1:]); err != nil {
-		panic(fmt.Errorf("OBJ parse usemtl error: line %d: %w", lineNumber, err))
+		return currentMat, err
 	} else {
 		currentMat = mat
 	}
-	return currentMat
+	return currentMat, nil
 }
 
-func (m *Model) parseGroup(fields []string, currentMesh *Mesh, lineNumber int) *Mesh {
+func (m *Model) parseGroup(fields []string, currentMesh *Mesh) (*Mesh, error) {
+	group, err := parseString(fields[1:])
+	if err != nil {
+		return currentMesh, err
+	}
+
 	if currentMesh.name != "" {
 		m.meshes = append(m.meshes, currentMesh)
 		currentMesh = NewMesh()
 	}
 
-	if group, err := parseString(fields[1:]); err != nil {
-		panic(fmt.Errorf("OBJ parse group error: line %d: %w", lineNumber, err))
-	} else {
-		currentMesh.name = group
-	}
-
-	return currentMesh
+	currentMesh.name = group
+	return currentMesh, nil
 }
 
-func (m *Model) parseVertex(fields []string, lineNumber int) {
+func (m *Model) parseVertex(fields []string) error {
 	if vertex, err := parseVec3(fields[1:]); err != nil {
-		panic(fmt.Errorf("OBJ vertex parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		m.vertices = append(m.vertices, vertex[:]...)
 	}
+	return nil
 }
 
-func (m *Model) parseVertexNormal(fields []string, lineNumber int) {
+func (m *Model) parseVertexNormal(fields []string) error {
 	if normal, err := parseVec3(fields[1:]); err != nil {
-		panic(fmt.Errorf("OBJ vertex normal parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		m.normals = append(m.normals, normal[:]...)
 	}
+	return nil
 }
 
-func (m *Model) parseVertexTexture(fields []string, lineNumber int) {
+func (m *Model) parseVertexTexture(fields []string) error {
 	if uv, err := parseVec2(fields[1:]); err != nil {
-		panic(fmt.Errorf("OBJ vertex texture parsing error: line %d: %w", lineNumber, err))
+		return err
 	} else {
 		m.uvs = append(m.uvs, uv[:]...)
 	}
+	return nil
 }
 
-func (m *Model) parseFace(fields []string, currentMesh *Mesh, currentMat string, lineNumber int) {
-	if face, err := parseFace(fields[1:]); err != nil {
-		panic(fmt.Errorf("OBJ face parsing error: line %d: %w", lineNumber, err))
-	} else {
-		face.usemtl = currentMat
-		currentMesh.faces = append(currentMesh.faces, face)
+func (m *Model) parseFace(fields []string, currentMesh *Mesh, currentMat string) error {
+	face, err := parseFace(fields[1:])
+	if err != nil {
+		return err
+	}
+
+	if err = resolveFaceIndices(face, len(m.vertices)/3, len(m.uvs)/2, len(m.normals)/3); err != nil {
+		return err
 	}
+
+	face.usemtl = currentMat
+	currentMesh.faces = append(currentMesh.faces, face)
+	return nil
 }
 
 func (m *Model) loadFromSlice(slice []byte) {
 	reader := bufio.NewReader(bytes.NewReader(slice))
-	m.loadFromReader(reader)
+	m.loadFromReader(reader, m.Name())
 }
 
 func (m *Model) loadFromFile(name string) (ok bool) {
-	reader, closeFunc := getSourceReader(m, name)
+	reader, closeFunc, err := importer.SourceReader(m, name)
 	defer closeFunc()
 
+	if err != nil {
+		m.err = newParseError("OBJ", name, 0, "", err)
+		return true
+	}
+
 	if reader == nil {
 		return false
 	}
 
-	m.loadFromReader(reader)
+	m.loadFromReader(reader, name)
 	return true
 }
 
 func (m *Model) loadFromString(obj string) {
 	reader := bufio.NewReader(strings.NewReader(obj))
-	m.loadFromReader(reader)
+	m.loadFromReader(reader, m.Name())
 }
 
-func (m *Model) loadFromReader(reader *bufio.Reader) {
+func (m *Model) loadFromReader(reader *bufio.Reader, filename string) {
 	currentMat := ""
 	lineNumber := 0
 	currentMesh := NewMesh()
@@ -223,17 +252,26 @@ func (m *Model) loadFromReader(reader *bufio.Reader) {
 
 		line, readErr := reader.ReadString('\n')
 		if readErr != nil && readErr != io.EOF {
-			panic(fmt.Errorf("file read error: %w", readErr))
-		} else if readErr == io.EOF {
-			break
+			m.err = newParseError("OBJ", filename, 0, "", fmt.Errorf("file read error: %w", readErr))
+			return
 		}
 
-		fields := strings.Fields(line)
-		if len(fields) == 0 {
-			continue
+		if fields := strings.Fields(line); len(fields) > 0 {
+			var err error
+			currentMesh, currentMat, err = m.parseFields(fields, currentMesh, currentMat)
+			if err != nil {
+				parseErr := newParseError("OBJ", filename, lineNumber, fields[0], err)
+				if !m.lenient {
+					m.err = parseErr
+					return
+				}
+				m.warnings = append(m.warnings, parseErr)
+			}
 		}
 
-		currentMesh, currentMat = m.parseFields(fields, currentMesh, currentMat, lineNumber)
+		if readErr == io.EOF {
+			break
+		}
 	}
 
 	m.meshes = append(m.meshes, currentMesh)
@@ -251,7 +289,12 @@ func (m *Model) loadMaterialLibraries() {
 	for _, mtllib := range m.mtllibs {
 		mtl := NewMaterialLibrary(mtllib, mtllib)
 		mtl.SetSourceLibrary(m.SourceLibrary())
-		mtl.Load()
+		mtl.SetLenient(m.lenient)
+		if err := mtl.Load(); err != nil {
+			m.err = err
+			return
+		}
+		m.warnings = append(m.warnings, mtl.Warnings()...)
 		m.materialLibs = append(m.materialLibs, mtl)
 	}
 
@@ -266,6 +309,24 @@ func (m *Model) loadMaterialLibraries() {
 		}
 	}
 
+	if m.defaultPbrShader == nil {
+		// Without tangents, the vertex buffer will not have the layout
+		// expected by the shader that supports normal maps
+		pbrShaderName := gfx.PbrNoNormalMapShader
+		if m.computeTangentsOnLoad {
+			pbrShaderName = gfx.PbrShader
+		}
+
+		srcLib := m.SourceLibrary()
+		if srcLib != nil {
+			if defaultShader := srcLib.Get(pbrShaderName); defaultShader != nil {
+				if shader, ok := defaultShader.(gfx.Shader); ok {
+					m.defaultPbrShader = shader
+				}
+			}
+		}
+	}
+
 	if m.defaultMaterial == nil {
 		m.defaultMaterial = NewMaterial()
 		m.defaultMaterial.AttachShader(m.defaultShader)
@@ -280,25 +341,30 @@ func (m *Model) initMaterialLibraries() bool {
 	return ok && m.defaultMaterial.Init()
 }
 
-func (m *Model) getMaterial(name string) *BasicMaterial {
+// getMaterial Returns the named material, preferring its PBR version (if
+// available and enabled), or the default material if not found.
+func (m *Model) getMaterial(name string) (material gfx.Material, shader gfx.Shader) {
 	for _, mtl := range m.materialLibs {
+		if m.pbrEnabled {
+			if mat := mtl.GetPbr(name); mat != nil {
+				return mat, m.defaultPbrShader
+			}
+		}
 		if mat := mtl.Get(name); mat != nil {
-			return mat
+			return mat, m.defaultShader
 		}
 	}
-	return nil
+	return m.defaultMaterial, m.defaultShader
 }
 
 func (m *Model) setMaterials() {
 	for _, mesh := range m.meshes {
 		for _, face := range mesh.faces {
-			face.material = m.getMaterial(face.usemtl)
-			if face.material == nil {
-				face.material = m.defaultMaterial
-			}
+			var shader gfx.Shader
+			face.material, shader = m.getMaterial(face.usemtl)
 
-			if face.material.AttachedShader() == nil && m.defaultShader != nil {
-				face.material.AttachShader(m.defaultShader)
+			if face.material.AttachedShader() == nil && shader != nil {
+				face.material.AttachShader(shader)
 			}
 		}
 	}
@@ -333,9 +399,19 @@ func (m *Model) SetDefaultShader(shader gfx.Shader) *Model {
 	return m
 }
 
-func (m *Model) Load() {
+// SetDefaultPbrShader sets the shader attached to PBR materials that do
+// not already have one, which otherwise defaults to gfx.PbrShader if
+// tangents are computed on load, or gfx.PbrNoNormalMapShader if not.
+func (m *Model) SetDefaultPbrShader(shader gfx.Shader) *Model {
+	m.defaultPbrShader = shader
+	return m
+}
+
+// Load parses the OBJ source (and any referenced material libraries),
+// returning the error that stopped the load, if any (see Err()).
+func (m *Model) Load() error {
 	if m.loaded.Load() {
-		return
+		return m.err
 	}
 
 	switch source := m.Source().(type) {
@@ -346,16 +422,61 @@ func (m *Model) Load() {
 			m.loadFromString(source)
 		}
 	default:
-		panic("unexpected error: source type is not supported")
+		m.err = newParseError("OBJ", m.Name(), 0, "", fmt.Errorf("source type is not supported"))
+	}
+
+	if m.err == nil {
+		if m.computeTangentsOnLoad {
+			m.computeTangents()
+		}
+
+		m.loadMaterialLibraries()
 	}
 
-	if m.computeTangentsOnLoad {
-		m.computeTangents()
+	if m.err == nil {
+		m.setMaterials()
 	}
 
-	m.loadMaterialLibraries()
-	m.setMaterials()
 	m.loaded.Store(true)
+	return m.err
+}
+
+// Err returns the error that caused loading to fail, which will
+// be a *ParseError, or nil if the model loaded successfully.
+func (m *Model) Err() error {
+	return m.err
+}
+
+// Warnings returns the problems encountered while loading in
+// lenient mode, each of which resulted in a line being skipped.
+func (m *Model) Warnings() []*ParseError {
+	return m.warnings
+}
+
+func (m *Model) Lenient() bool {
+	return m.lenient
+}
+
+// SetLenient enables/disables lenient mode, which must be done before
+// the model is loaded.  In lenient mode, unsupported directives and
+// malformed lines are skipped and recorded as warnings (see Warnings())
+// rather than causing the load to fail.
+func (m *Model) SetLenient(lenient bool) *Model {
+	m.lenient = lenient
+	return m
+}
+
+func (m *Model) PbrEnabled() bool {
+	return m.pbrEnabled
+}
+
+// SetPbrEnabled determines whether faces use the PBR version of their
+// material, if available (see MaterialLibrary.GetPbr()), which must be set
+// before the model is loaded.  Enabled by default, so that materials with
+// PBR parameters (Pr, Pm, map_Pr or map_Pm) are rendered with PBR.
+func (m *Model) SetPbrEnabled(enabled bool) *Model {
+	m.pbrEnabled = enabled
+	return m
 }
 
 func (m *Model) ComputeTangents(computeOnLoad bool) {
@@ -371,5 +492,6 @@ func NewModel[T gfx.ModelSource](name string, source T) *Model {
 		ModelBase: gfx.ModelBase{
 			AssetBase: *gfx.NewAssetBase(name, source),
 		},
+		pbrEnabled: true,
 	}
 }
@@ -115,7 +115,50 @@ func parseFace(components []string) (*Face, error) {
 	return &face, nil
 }
 
+// resolveFaceIndices converts relative (negative) indices to absolute
+// ones and ensures every index refers to previously defined data.
+func resolveFaceIndices(face *Face, vertexCount, uvCount, normalCount int) error {
+	if len(face.vertices) < 3 {
+		return fmt.Errorf("face must have at least 3 vertices, got %d", len(face.vertices))
+	}
+
+	if len(face.uvs) > 0 && len(face.uvs) != len(face.vertices) {
+		return fmt.Errorf("texture coordinate index count does not match vertex index count")
+	}
+
+	if len(face.normals) > 0 && len(face.normals) != len(face.vertices) {
+		return fmt.Errorf("normal index count does not match vertex index count")
+	}
+
+	if err := resolveIndices(face.vertices, vertexCount, "vertex"); err != nil {
+		return err
+	}
+
+	if err := resolveIndices(face.uvs, uvCount, "texture coordinate"); err != nil {
+		return err
+	}
+
+	return resolveIndices(face.normals, normalCount, "normal")
+}
+
+func resolveIndices(indices []int, count int, kind string) error {
+	for i, index := range indices {
+		if index < -1 {
+			index = count + index + 1 // relative to the end of the list (e.g. -1 is the last element)
+		}
+		if index < 0 || index >= count {
+			return fmt.Errorf("%s index out of range: %d", kind, indices[i]+1)
+		}
+		indices[i] = index
+	}
+	return nil
+}
+
 func setFaceTangents(model *Model, face *Face) {
+	if len(face.uvs) < 3 {
+		return
+	}
+
 	v0Idx := face.vertices[0] * 3
 	v1Idx := face.vertices[1] * 3
 	v2Idx := face.vertices[2] * 3
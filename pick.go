@@ -0,0 +1,400 @@
+package gfx
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+)
+
+const (
+	defaultBoundingShape3DName = "BoundingShape3D"
+
+	rayEpsilon = 1e-7
+)
+
+/******************************************************************************
+ Ray
+******************************************************************************/
+
+// Ray A half-line in 3D space, starting at Origin and extending along
+// Direction, which is expected to be normalized when the ray is in world
+// space so that the distance to a hit equals its ray parameter.
+type Ray struct {
+	Origin    mgl32.Vec3
+	Direction mgl32.Vec3
+}
+
+// At returns the point along the ray at the given (parametric) distance.
+func (r Ray) At(distance float32) mgl32.Vec3 {
+	return r.Origin.Add(r.Direction.Mul(distance))
+}
+
+// Transform returns the ray transformed by the given matrix.  The direction
+// is not re-normalized, so the ray parameter of any hit remains the same as
+// it would be for the original ray.
+func (r Ray) Transform(mat mgl32.Mat4) Ray {
+	return Ray{
+		Origin:    mat.Mul4x1(r.Origin.Vec4(1)).Vec3(),
+		Direction: mat.Mul4x1(r.Direction.Vec4(0)).Vec3(),
+	}
+}
+
+// IntersectBox returns the distance along the ray to the axis-aligned box
+// with the given corners, or zero if the ray starts inside of the box.
+func (r Ray) IntersectBox(boxMin, boxMax mgl32.Vec3) (distance float32, ok bool) {
+	tMin := float32(0)
+	tMax := float32(math.MaxFloat32)
+
+	for i := 0; i < 3; i++ {
+		if abs32(r.Direction[i]) < rayEpsilon {
+			if r.Origin[i] < boxMin[i] || r.Origin[i] > boxMax[i] {
+				return 0, false
+			}
+			continue
+		}
+
+		invDir := 1 / r.Direction[i]
+		t1 := (boxMin[i] - r.Origin[i]) * invDir
+		t2 := (boxMax[i] - r.Origin[i]) * invDir
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+
+		tMin = max32(tMin, t1)
+		tMax = min32(tMax, t2)
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+
+	return tMin, true
+}
+
+// IntersectTriangle returns the distance along the ray to the triangle
+// with the given vertices (using the Möller–Trumbore algorithm), along with
+// the barycentric weights of the vertices at the point of intersection.
+// Both sides of the triangle can be hit.
+func (r Ray) IntersectTriangle(a, b, c mgl32.Vec3) (distance float32, barycentric mgl32.Vec3, ok bool) {
+	edge1 := b.Sub(a)
+	edge2 := c.Sub(a)
+
+	p := r.Direction.Cross(edge2)
+	det := edge1.Dot(p)
+	if abs32(det) < rayEpsilon {
+		return 0, mgl32.Vec3{}, false // parallel to the triangle
+	}
+	invDet := 1 / det
+
+	s := r.Origin.Sub(a)
+	u := s.Dot(p) * invDet
+	if u < 0 || u > 1 {
+		return 0, mgl32.Vec3{}, false
+	}
+
+	q := s.Cross(edge1)
+	v := r.Direction.Dot(q) * invDet
+	if v < 0 || u+v > 1 {
+		return 0, mgl32.Vec3{}, false
+	}
+
+	distance = edge2.Dot(q) * invDet
+	if distance < 0 {
+		return 0, mgl32.Vec3{}, false
+	}
+
+	return distance, mgl32.Vec3{1 - u - v, u, v}, true
+}
+
+// UnprojectRay returns the world-space ray passing through the given point
+// on the screen, as defined by the given view-projection matrix and the
+// normalized device coordinates (-1 to 1) of the point.  The ray starts at
+// the near plane and works with both perspective and orthographic cameras.
+func UnprojectRay(viewProj mgl32.Mat4, x, y float32) Ray {
+	inv := viewProj.Inv()
+
+	near := inv.Mul4x1(mgl32.Vec4{x, y, -1, 1})
+	far := inv.Mul4x1(mgl32.Vec4{x, y, 1, 1})
+
+	nearPoint := near.Vec3().Mul(1 / near[3])
+	farPoint := far.Vec3().Mul(1 / far[3])
+
+	return Ray{
+		Origin:    nearPoint,
+		Direction: farPoint.Sub(nearPoint).Normalize(),
+	}
+}
+
+/******************************************************************************
+ RayHit
+******************************************************************************/
+
+// RayHit Describes the nearest intersection of a ray with a Shape3D.
+type RayHit struct {
+	// Shape is the object that was hit.
+	Shape *Shape3D
+
+	// Mesh is the instance of the model's mesh that was hit and MeshIndex
+	// its index in the slice returned by Shape.Meshes().
+	Mesh      *meshInstance
+	MeshIndex int
+
+	// Instance is the instance of the model that was hit, when the shape is
+	// drawn by an InstancedShape3D, otherwise nil.
+	Instance *Instance3D
+
+	// FaceIndex is the index of the face (in the slice returned by the
+	// mesh's Faces() function) that was hit.
+	FaceIndex int
+
+	// FaceVertices holds the positions, within the face's vertex indices,
+	// of the vertices of the triangle that was hit, as quads are split into
+	// two triangles: (0, 1, 2) and (0, 2, 3).
+	FaceVertices [3]int
+
+	// Barycentric holds the weights of the triangle's vertices (in the
+	// order given by FaceVertices) at the point of intersection, which can
+	// be used to interpolate any of the vertex attributes.
+	Barycentric mgl32.Vec3
+
+	// Position is the point of intersection, in world space.
+	Position mgl32.Vec3
+
+	// Distance is the distance from the origin of the ray to Position.
+	Distance float32
+}
+
+/******************************************************************************
+ pickTriangle
+******************************************************************************/
+
+// pickTriangle A triangle of a mesh in local space, as tested during
+// ray casting.
+type pickTriangle struct {
+	vertices     [3]mgl32.Vec3
+	faceIndex    int
+	faceVertices [3]int
+}
+
+// newPickTriangles Returns the triangles of the given mesh.
+func newPickTriangles(model Model, mesh Mesh) (triangles []pickTriangle) {
+	vertices := model.Vertices()
+
+	vertex := func(index int) mgl32.Vec3 {
+		return mgl32.Vec3{vertices[index*3], vertices[index*3+1], vertices[index*3+2]}
+	}
+
+	for faceIndex, face := range mesh.Faces() {
+		indices := face.VertexIndices()
+
+		var corners [][3]int
+		switch len(indices) {
+		case 3:
+			corners = [][3]int{{0, 1, 2}}
+		case 4:
+			corners = [][3]int{{0, 1, 2}, {0, 2, 3}}
+		default:
+			continue
+		}
+
+		for _, c := range corners {
+			triangles = append(triangles, pickTriangle{
+				vertices:     [3]mgl32.Vec3{vertex(indices[c[0]]), vertex(indices[c[1]]), vertex(indices[c[2]])},
+				faceIndex:    faceIndex,
+				faceVertices: c,
+			})
+		}
+	}
+
+	return
+}
+
+// newMeshBounds Returns the axis-aligned box bounding the triangles and
+// quads of the given mesh, which is tested before its triangles.
+func newMeshBounds(model Model, mesh Mesh) (boundsMin, boundsMax mgl32.Vec3) {
+	vertices := model.Vertices()
+
+	boundsMin = mgl32.Vec3{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+	boundsMax = mgl32.Vec3{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+
+	for _, face := range mesh.Faces() {
+		indices := face.VertexIndices()
+		if len(indices) != 3 && len(indices) != 4 {
+			continue
+		}
+
+		for _, index := range indices {
+			for i := 0; i < 3; i++ {
+				boundsMin[i] = min32(boundsMin[i], vertices[index*3+i])
+				boundsMax[i] = max32(boundsMax[i], vertices[index*3+i])
+			}
+		}
+	}
+
+	return
+}
+
+/******************************************************************************
+ BoundingShape3D
+******************************************************************************/
+
+// BoundingShape3D A BoundingObject raising mouse events for a Shape3D, to
+// which it should be added as a child.  The mouse is considered to be over
+// the shape when the ray cast from the mouse position through the shape's
+// camera and viewport hits one of its faces and, when occlusion is enabled,
+// when no other Shape3D in the window is hit first.  The local mouse
+// position is relative to the shape's viewport.
+type BoundingShape3D struct {
+	BoundingObjectBase
+
+	occlusion bool
+	hit       RayHit
+}
+
+func (b *BoundingShape3D) Update(_ int64) bool {
+	if !b.enabled.Load() {
+		return false
+	}
+
+	shape, ok := b.Parent().(*Shape3D)
+	if !ok {
+		return true
+	}
+
+	winMouse := b.mouseSurface.Mouse()
+	xLocal, yLocal := float32(untrackedMousePosition), float32(untrackedMousePosition)
+	mouseOver := false
+
+	if viewport := shape.Viewport(); viewport != nil && winMouse.X != untrackedMousePosition {
+		xLocal, yLocal = viewport.WindowToViewport(winMouse.X, winMouse.Y)
+
+		var hit RayHit
+		if b.occlusion && b.window != nil {
+			hit, mouseOver = b.window.pick3DOnce(winMouse.X, winMouse.Y)
+			mouseOver = mouseOver && hit.Shape == shape
+		} else {
+			hit, mouseOver = shape.Pick(winMouse.X, winMouse.Y)
+		}
+
+		if mouseOver {
+			b.mouseStateMutex.Lock()
+			b.hit = hit
+			b.mouseStateMutex.Unlock()
+		}
+	}
+
+	b.endUpdate(winMouse, mouseOver, xLocal, yLocal)
+
+	return true
+}
+
+// Hit returns the most recent intersection of the mouse ray with the
+// shape, which is only current while MouseOver() returns true.
+func (b *BoundingShape3D) Hit() (hit RayHit) {
+	b.mouseStateMutex.Lock()
+	hit = b.hit
+	b.mouseStateMutex.Unlock()
+	return
+}
+
+func (b *BoundingShape3D) Occlusion() (enabled bool) {
+	b.mouseStateMutex.Lock()
+	enabled = b.occlusion
+	b.mouseStateMutex.Unlock()
+	return
+}
+
+// SetOcclusion determines whether other Shape3D objects in the window,
+// when closer to the camera, prevent the mouse from being over the shape.
+// Defaults to true.
+func (b *BoundingShape3D) SetOcclusion(enabled bool) *BoundingShape3D {
+	b.mouseStateMutex.Lock()
+	b.occlusion = enabled
+	b.mouseStateMutex.Unlock()
+	return b
+}
+
+func NewBoundingShape3D() *BoundingShape3D {
+	bs := &BoundingShape3D{
+		BoundingObjectBase: *NewBoundingObject(),
+		occlusion:          true,
+	}
+
+	bs.visible.Store(false)
+	bs.SetName(defaultBoundingShape3DName)
+	return bs
+}
+
+/******************************************************************************
+ pick3DCache
+******************************************************************************/
+
+// pick3DCache The result of Window.pick3DOnce() for the given frame and
+// window position.  Frame 0 is never ticked, so the zero value is empty.
+type pick3DCache struct {
+	frame uint64
+	x, y  float32
+	hit   RayHit
+	ok    bool
+}
+
+/******************************************************************************
+ Functions
+******************************************************************************/
+
+// Pick3D returns the nearest intersection of the given shapes with the rays
+// cast from the given window position (normalized device coordinates, as
+// with MouseState) through the camera and viewport of each shape.
+func Pick3D(x, y float32, shapes ...*Shape3D) (hit RayHit, ok bool) {
+	for _, shape := range shapes {
+		if shapeHit, shapeOk := shape.Pick(x, y); shapeOk && (!ok || shapeHit.Distance < hit.Distance) {
+			hit = shapeHit
+			ok = true
+		}
+	}
+	return
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+// collectPickShapes Returns the enabled and visible Shape3D objects among
+// the given objects, their children and the shapes of Scene objects.
+func collectPickShapes(objects []WindowObject) (shapes []*Shape3D) {
+	var collect func(objects []WindowObject)
+	collect = func(objects []WindowObject) {
+		for _, o := range objects {
+			if !o.Enabled() || !o.Visible() {
+				continue
+			}
+			if shape, isShape := o.(*Shape3D); isShape {
+				shapes = append(shapes, shape)
+			} else if scene, isScene := o.(*Scene); isScene {
+				shapes = append(shapes, scene.shapes()...)
+			}
+			collect(o.Children())
+		}
+	}
+	collect(objects)
+	return
+}
+
+func abs32(value float32) float32 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,25 @@
+package ply
+
+import (
+	"github.com/tonybillings/gfx/internal/importer"
+)
+
+/******************************************************************************
+ ParseError
+******************************************************************************/
+
+// ParseError Describes a problem encountered while parsing a PLY file,
+// which stops the load and is returned by Err().  Format is "PLY" if the
+// error occurred in the header, otherwise the format of the data: "ASCII
+// PLY", "binary little-endian PLY" or "binary big-endian PLY".  Line is
+// the 1-based line number (header and ASCII data) or 0 if the error is
+// not associated with a specific line (e.g., binary data or a read error).
+type ParseError = importer.ParseError
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func newParseError(format, file string, line int, err error) *ParseError {
+	return importer.NewParseError(format, file, line, err)
+}
@@ -0,0 +1,415 @@
+package ply
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	plyFormat          = "PLY"
+	asciiFormat        = "ASCII PLY"
+	littleEndianFormat = "binary little-endian PLY"
+	bigEndianFormat    = "binary big-endian PLY"
+	vertexElement      = "vertex"
+)
+
+/******************************************************************************
+ scalarType
+******************************************************************************/
+
+type scalarType struct {
+	name     string
+	size     int
+	float    bool
+	signed   bool
+	maxValue float32 // used to normalize integer colors
+}
+
+var scalarTypes = map[string]scalarType{
+	"char":    {name: "char", size: 1, signed: true, maxValue: math.MaxInt8},
+	"uchar":   {name: "uchar", size: 1, maxValue: math.MaxUint8},
+	"short":   {name: "short", size: 2, signed: true, maxValue: math.MaxInt16},
+	"ushort":  {name: "ushort", size: 2, maxValue: math.MaxUint16},
+	"int":     {name: "int", size: 4, signed: true, maxValue: math.MaxInt32},
+	"uint":    {name: "uint", size: 4, maxValue: math.MaxUint32},
+	"float":   {name: "float", size: 4, float: true, maxValue: 1},
+	"double":  {name: "double", size: 8, float: true, maxValue: 1},
+	"int8":    {name: "int8", size: 1, signed: true, maxValue: math.MaxInt8},
+	"uint8":   {name: "uint8", size: 1, maxValue: math.MaxUint8},
+	"int16":   {name: "int16", size: 2, signed: true, maxValue: math.MaxInt16},
+	"uint16":  {name: "uint16", size: 2, maxValue: math.MaxUint16},
+	"int32":   {name: "int32", size: 4, signed: true, maxValue: math.MaxInt32},
+	"uint32":  {name: "uint32", size: 4, maxValue: math.MaxUint32},
+	"float32": {name: "float32", size: 4, float: true, maxValue: 1},
+	"float64": {name: "float64", size: 8, float: true, maxValue: 1},
+}
+
+/******************************************************************************
+ header
+******************************************************************************/
+
+type property struct {
+	name      string
+	dataType  scalarType
+	countType scalarType
+	isList    bool
+}
+
+type element struct {
+	name       string
+	count      int
+	properties []*property
+}
+
+type header struct {
+	format    string
+	byteOrder binary.ByteOrder
+	elements  []*element
+	comments  []string
+	lineCount int
+	length    int
+}
+
+/******************************************************************************
+ vertexData
+******************************************************************************/
+
+// vertexData Holds the value of every scalar property of the vertex
+// element, along with the type of each property as declared in the header.
+type vertexData struct {
+	count  int
+	values map[string][]float32
+	types  map[string]scalarType
+	order  []string
+}
+
+/******************************************************************************
+ Functions
+******************************************************************************/
+
+func parseHeader(data []byte, filename string) (*header, *ParseError) {
+	h := &header{}
+	lineNumber := 0
+	offset := 0
+
+	fail := func(format string, args ...any) (*header, *ParseError) {
+		return nil, newParseError(plyFormat, filename, lineNumber, fmt.Errorf(format, args...))
+	}
+
+	var current *element
+	for {
+		if offset >= len(data) {
+			return fail("unexpected end of file (missing end_header)")
+		}
+
+		lineNumber++
+
+		end := bytes.IndexByte(data[offset:], '\n')
+		var line string
+		if end < 0 {
+			line = string(data[offset:])
+			offset = len(data)
+		} else {
+			line = string(data[offset : offset+end])
+			offset += end + 1
+		}
+
+		fields := strings.Fields(line)
+		if lineNumber == 1 {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return fail("missing 'ply' magic number")
+			}
+			continue
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "format":
+			if len(fields) != 3 {
+				return fail("expected format and version")
+			}
+			switch fields[1] {
+			case "ascii":
+				h.format = asciiFormat
+			case "binary_little_endian":
+				h.format = littleEndianFormat
+				h.byteOrder = binary.LittleEndian
+			case "binary_big_endian":
+				h.format = bigEndianFormat
+				h.byteOrder = binary.BigEndian
+			default:
+				return fail("unsupported format '%s'", fields[1])
+			}
+			if fields[2] != "1.0" {
+				return fail("unsupported version '%s'", fields[2])
+			}
+		case "comment", "obj_info":
+			h.comments = append(h.comments, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fields[0])))
+		case "element":
+			if len(fields) != 3 {
+				return fail("expected element name and count")
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return fail("invalid element count '%s'", fields[2])
+			}
+			current = &element{name: fields[1], count: count}
+			h.elements = append(h.elements, current)
+		case "property":
+			if current == nil {
+				return fail("property outside of element")
+			}
+			prop, err := parseProperty(fields[1:])
+			if err != nil {
+				return fail("%v", err)
+			}
+			current.properties = append(current.properties, prop)
+		case "end_header":
+			if h.format == "" {
+				return fail("missing format")
+			}
+			h.lineCount = lineNumber
+			h.length = offset
+			return h, nil
+		default:
+			return fail("unexpected keyword '%s'", fields[0])
+		}
+	}
+}
+
+func parseProperty(fields []string) (*property, error) {
+	if len(fields) > 0 && fields[0] == "list" {
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("expected list count type, item type and name")
+		}
+		countType, ok := scalarTypes[fields[1]]
+		if !ok || countType.float {
+			return nil, fmt.Errorf("invalid list count type '%s'", fields[1])
+		}
+		dataType, ok := scalarTypes[fields[2]]
+		if !ok {
+			return nil, fmt.Errorf("unknown type '%s'", fields[2])
+		}
+		return &property{name: fields[3], dataType: dataType, countType: countType, isList: true}, nil
+	}
+
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("expected property type and name")
+	}
+	dataType, ok := scalarTypes[fields[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown type '%s'", fields[0])
+	}
+	return &property{name: fields[1], dataType: dataType}, nil
+}
+
+// newVertexData Allocates the values of the vertex element, after
+// ensuring the given body could hold as many vertices as declared in the
+// header, so that the count can be trusted.
+func newVertexData(h *header, body []byte) (*vertexData, error) {
+	vd := &vertexData{
+		values: make(map[string][]float32),
+		types:  make(map[string]scalarType),
+	}
+
+	for _, e := range h.elements {
+		if e.name != vertexElement {
+			continue
+		}
+		if size := e.minSize(h.format == asciiFormat); size > 0 && e.count > len(body)/size+1 {
+			return nil, fmt.Errorf("%s count (%d) exceeds the size of the data", e.name, e.count)
+		}
+		vd.count = e.count
+		for _, p := range e.properties {
+			if p.isList {
+				continue
+			}
+			vd.values[p.name] = make([]float32, e.count)
+			vd.types[p.name] = p.dataType
+			vd.order = append(vd.order, p.name)
+		}
+		break
+	}
+
+	return vd, nil
+}
+
+// minSize Returns the minimum number of bytes needed to store one
+// instance of the element, assuming empty lists and, for ASCII data,
+// single-character values separated by a single character.
+func (e *element) minSize(ascii bool) (size int) {
+	for _, p := range e.properties {
+		switch {
+		case ascii:
+			size += 2
+		case p.isList:
+			size += p.countType.size
+		default:
+			size += p.dataType.size
+		}
+	}
+	return
+}
+
+// parseBinary Reads every element from the binary body, retaining
+// only the scalar properties of the vertex element.
+func parseBinary(h *header, body []byte, filename string) (*vertexData, *ParseError) {
+	vd, err := newVertexData(h, body)
+	if err != nil {
+		return nil, newParseError(h.format, filename, 0, err)
+	}
+	offset := 0
+
+	read := func(t scalarType) (float64, bool) {
+		if offset+t.size > len(body) {
+			return 0, false
+		}
+		value := readScalar(body[offset:], t, h.byteOrder)
+		offset += t.size
+		return value, true
+	}
+
+	for _, e := range h.elements {
+		for i := 0; i < e.count; i++ {
+			for _, p := range e.properties {
+				if p.isList {
+					count, ok := read(p.countType)
+					if !ok || count < 0 {
+						return nil, newParseError(h.format, filename, 0, fmt.Errorf("%s %d: unexpected end of data", e.name, i))
+					}
+					skip := int(count) * p.dataType.size
+					if offset+skip > len(body) {
+						return nil, newParseError(h.format, filename, 0, fmt.Errorf("%s %d: unexpected end of data", e.name, i))
+					}
+					offset += skip
+					continue
+				}
+
+				value, ok := read(p.dataType)
+				if !ok {
+					return nil, newParseError(h.format, filename, 0, fmt.Errorf("%s %d: unexpected end of data", e.name, i))
+				}
+				if e.name == vertexElement {
+					vd.values[p.name][i] = float32(value)
+				}
+			}
+		}
+		if e.name == vertexElement {
+			break // remaining elements (faces, etc) are not needed
+		}
+	}
+
+	return vd, nil
+}
+
+// parseASCII Reads every element from the ASCII body, retaining only the
+// scalar properties of the vertex element.  Although each element is
+// typically written on its own line, values are read as a stream of
+// whitespace-separated tokens.
+func parseASCII(h *header, body []byte, filename string) (*vertexData, *ParseError) {
+	vd, err := newVertexData(h, body)
+	if err != nil {
+		return nil, newParseError(asciiFormat, filename, 0, err)
+	}
+	lines := bytes.Split(body, []byte{'\n'})
+	lineIdx := 0
+	var fields []string
+
+	next := func() (string, bool) {
+		for len(fields) == 0 {
+			if lineIdx >= len(lines) {
+				return "", false
+			}
+			fields = strings.Fields(string(lines[lineIdx]))
+			lineIdx++
+		}
+		token := fields[0]
+		fields = fields[1:]
+		return token, true
+	}
+
+	fail := func(format string, args ...any) (*vertexData, *ParseError) {
+		return nil, newParseError(asciiFormat, filename, h.lineCount+lineIdx, fmt.Errorf(format, args...))
+	}
+
+	readValue := func(t scalarType) (float64, error) {
+		token, ok := next()
+		if !ok {
+			return 0, fmt.Errorf("unexpected end of file")
+		}
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s value '%s'", t.name, token)
+		}
+		return value, nil
+	}
+
+	for _, e := range h.elements {
+		for i := 0; i < e.count; i++ {
+			for _, p := range e.properties {
+				if p.isList {
+					count, err := readValue(p.countType)
+					if err != nil {
+						return fail("%v", err)
+					}
+					for j := 0; j < int(count); j++ {
+						if _, err = readValue(p.dataType); err != nil {
+							return fail("%v", err)
+						}
+					}
+					continue
+				}
+
+				value, err := readValue(p.dataType)
+				if err != nil {
+					return fail("%v", err)
+				}
+				if e.name == vertexElement {
+					vd.values[p.name][i] = float32(value)
+				}
+			}
+		}
+		if e.name == vertexElement {
+			break
+		}
+	}
+
+	return vd, nil
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func readScalar(data []byte, t scalarType, order binary.ByteOrder) float64 {
+	switch t.size {
+	case 1:
+		if t.signed {
+			return float64(int8(data[0]))
+		}
+		return float64(data[0])
+	case 2:
+		if t.signed {
+			return float64(int16(order.Uint16(data)))
+		}
+		return float64(order.Uint16(data))
+	case 4:
+		switch {
+		case t.float:
+			return float64(math.Float32frombits(order.Uint32(data)))
+		case t.signed:
+			return float64(int32(order.Uint32(data)))
+		default:
+			return float64(order.Uint32(data))
+		}
+	default:
+		return math.Float64frombits(order.Uint64(data))
+	}
+}
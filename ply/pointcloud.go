@@ -0,0 +1,296 @@
+// Package ply provides an importer for point clouds stored in the PLY
+// (Polygon File Format / Stanford Triangle Format) format, supporting the
+// ASCII and binary (little/big-endian) encodings.  The positions, normals,
+// colors and any other scalar properties of the vertex element are loaded,
+// while other elements (such as faces) are ignored.  The points can then be
+// rendered with gfx.PointCloud:
+//
+//	cloud := ply.NewPointCloud("scan", "scan.ply")
+//	if err := cloud.Load(); err != nil {
+//		...
+//	}
+//	obj := gfx.NewPointCloud(cloud.Count())
+//	obj.AddPoints(cloud.Points())
+package ply
+
+import (
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/internal/importer"
+	"io"
+	"sync/atomic"
+)
+
+const (
+	defaultScalarProperty  = "intensity"
+	fallbackScalarProperty = "z"
+)
+
+/******************************************************************************
+ PointCloud
+******************************************************************************/
+
+// PointCloud An asset holding the vertices of a PLY file.
+type PointCloud struct {
+	gfx.AssetBase
+
+	positions []float32
+	normals   []float32
+	colors    []float32
+	vertices  *vertexData
+	comments  []string
+
+	scalarProperty string
+
+	err    error
+	loaded atomic.Bool
+}
+
+/******************************************************************************
+ gfx.Asset Implementation
+******************************************************************************/
+
+func (c *PointCloud) Init() bool {
+	if c.Initialized() {
+		return true
+	}
+
+	if err := c.Load(); err != nil {
+		return false
+	}
+
+	return c.AssetBase.Init()
+}
+
+/******************************************************************************
+ PointCloud Functions
+******************************************************************************/
+
+func (c *PointCloud) loadFromSlice(slice []byte, filename string) {
+	h, err := parseHeader(slice, filename)
+	if err != nil {
+		c.err = err
+		return
+	}
+
+	var vd *vertexData
+	if h.byteOrder == nil {
+		vd, err = parseASCII(h, slice[h.length:], filename)
+	} else {
+		vd, err = parseBinary(h, slice[h.length:], filename)
+	}
+	if err != nil {
+		c.err = err
+		return
+	}
+
+	c.comments = h.comments
+	c.build(h, vd, filename)
+}
+
+func (c *PointCloud) loadFromFile(name string) (ok bool) {
+	reader, closeFunc, err := importer.SourceReader(c, name)
+	defer closeFunc()
+
+	if err != nil {
+		c.err = newParseError(plyFormat, name, 0, err)
+		return true
+	}
+
+	if reader == nil {
+		return false
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		c.err = newParseError(plyFormat, name, 0, fmt.Errorf("file read error: %w", err))
+		return true
+	}
+
+	c.loadFromSlice(data, name)
+	return true
+}
+
+func (c *PointCloud) build(h *header, vd *vertexData, filename string) {
+	x, y, z := vd.values["x"], vd.values["y"], vd.values["z"]
+	if x == nil || y == nil || z == nil {
+		c.err = newParseError(h.format, filename, 0, fmt.Errorf("vertex element must have x, y and z properties"))
+		return
+	}
+
+	c.vertices = vd
+	c.positions = interleave(vd.count, 1, x, y, z)
+
+	if nx, ny, nz := vd.values["nx"], vd.values["ny"], vd.values["nz"]; nx != nil && ny != nil && nz != nil {
+		c.normals = interleave(vd.count, 1, nx, ny, nz)
+	}
+
+	for _, prefix := range []string{"", "diffuse_"} {
+		r, g, b := vd.values[prefix+"red"], vd.values[prefix+"green"], vd.values[prefix+"blue"]
+		if r == nil || g == nil || b == nil {
+			continue
+		}
+
+		// Integer colors are normalized using the range of their type (e.g.
+		// 0-255 for uchar), while floating-point colors are expected to
+		// already be in the range 0-1.
+		maxValue := vd.types[prefix+"red"].maxValue
+		a := vd.values[prefix+"alpha"]
+		if a == nil {
+			a = make([]float32, vd.count)
+			for i := range a {
+				a[i] = maxValue
+			}
+		}
+
+		c.colors = interleave(vd.count, 1/maxValue, r, g, b, a)
+		break
+	}
+}
+
+// Load parses the PLY source, returning the error that stopped
+// the load, if any (see Err()).
+func (c *PointCloud) Load() error {
+	if c.loaded.Load() {
+		return c.err
+	}
+
+	switch source := c.Source().(type) {
+	case []byte:
+		c.loadFromSlice(source, c.Name())
+	case string:
+		if ok := c.loadFromFile(source); !ok {
+			c.loadFromSlice([]byte(source), c.Name())
+		}
+	default:
+		c.err = newParseError(plyFormat, c.Name(), 0, fmt.Errorf("source type is not supported"))
+	}
+
+	c.loaded.Store(true)
+	return c.err
+}
+
+// Err returns the error that caused loading to fail, which will
+// be a *ParseError, or nil if the point cloud loaded successfully.
+func (c *PointCloud) Err() error {
+	return c.err
+}
+
+// Count returns the number of points in the cloud.
+func (c *PointCloud) Count() int {
+	if c.vertices == nil {
+		return 0
+	}
+	return c.vertices.count
+}
+
+// Positions returns the x/y/z coordinates of each point.
+func (c *PointCloud) Positions() []float32 {
+	return c.positions
+}
+
+// Normals returns the x/y/z normal of each point, or nil if the
+// file does not contain normals.
+func (c *PointCloud) Normals() []float32 {
+	return c.normals
+}
+
+// Colors returns the normalized (0-1) RGBA color of each point, or nil
+// if the file does not contain colors.
+func (c *PointCloud) Colors() []float32 {
+	return c.colors
+}
+
+// Comments returns the comments (and obj_info lines) found in the header.
+func (c *PointCloud) Comments() []string {
+	return c.comments
+}
+
+// PropertyNames returns the names of the scalar properties of the vertex
+// element, in the order in which they are declared.
+func (c *PointCloud) PropertyNames() []string {
+	if c.vertices == nil {
+		return nil
+	}
+	return c.vertices.order
+}
+
+// Property returns the value of the given vertex property for each
+// point, or nil if there is no such property.
+func (c *PointCloud) Property(name string) []float32 {
+	if c.vertices == nil {
+		return nil
+	}
+	return c.vertices.values[name]
+}
+
+func (c *PointCloud) ScalarProperty() string {
+	return c.scalarProperty
+}
+
+// SetScalarProperty sets the vertex property used as the scalar value of
+// the points returned by Points(), which can then be mapped onto a colormap.
+// Defaults to "intensity" if present, otherwise "z".
+func (c *PointCloud) SetScalarProperty(name string) *PointCloud {
+	c.scalarProperty = name
+	return c
+}
+
+// Points returns the points of the cloud, ready to be added to a
+// gfx.PointCloud.  Points are white if the file does not contain colors.
+func (c *PointCloud) Points() []gfx.Point {
+	count := c.Count()
+	points := make([]gfx.Point, count)
+
+	scalars := c.Property(c.scalarProperty)
+	if c.scalarProperty == "" {
+		if scalars = c.Property(defaultScalarProperty); scalars == nil {
+			scalars = c.Property(fallbackScalarProperty)
+		}
+	}
+
+	for i := 0; i < count; i++ {
+		p := &points[i]
+		p.Position = mgl32.Vec3{c.positions[i*3], c.positions[i*3+1], c.positions[i*3+2]}
+
+		if c.colors != nil {
+			p.Color = gfx.FloatArrayToRgba([4]float32{c.colors[i*4], c.colors[i*4+1], c.colors[i*4+2], c.colors[i*4+3]})
+		} else {
+			p.Color = gfx.White
+		}
+
+		if scalars != nil {
+			p.Scalar = scalars[i]
+		}
+	}
+
+	return points
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func interleave(count int, scale float32, components ...[]float32) []float32 {
+	result := make([]float32, 0, count*len(components))
+	for i := 0; i < count; i++ {
+		for _, component := range components {
+			result = append(result, component[i]*scale)
+		}
+	}
+	return result
+}
+
+/******************************************************************************
+ New PointCloud Function
+******************************************************************************/
+
+// NewPointCloud creates a point cloud from the given source, which can be
+// the name of a PLY file (resolved via the source library, if set) or the
+// contents of such a file.
+func NewPointCloud[T gfx.ModelSource](name string, source T) *PointCloud {
+	return &PointCloud{
+		AssetBase: *gfx.NewAssetBase(name, source),
+	}
+}
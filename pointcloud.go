@@ -0,0 +1,602 @@
+package gfx
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"image/color"
+	"math"
+	"sync"
+	"unsafe"
+)
+
+const (
+	defaultPointCloudName     = "PointCloud"
+	defaultPointCloudCapacity = 1_000_000
+	defaultPointSize          = 2.0
+	maxColormapSize           = 16
+	sizeOfPointVertex         = int(unsafe.Sizeof(pointVertex{}))
+)
+
+/******************************************************************************
+ Point
+******************************************************************************/
+
+// Point A single point of a PointCloud.  Depending on the color mode of the
+// cloud, the point is rendered using its own color, the color produced by
+// mapping its scalar value (e.g. intensity, depth) onto the colormap, or the
+// color of the cloud itself.
+type Point struct {
+	Position mgl32.Vec3
+	Color    color.RGBA
+	Scalar   float32
+}
+
+type pointVertex struct {
+	x, y, z float32
+	color   color.RGBA
+	scalar  float32
+}
+
+/******************************************************************************
+ PointColorMode
+******************************************************************************/
+
+type PointColorMode int32
+
+const (
+	// SingleColorPoints All points are rendered using the color of
+	// the PointCloud (see SetColor()).
+	SingleColorPoints PointColorMode = iota
+
+	// PerPointColor Each point is rendered using its own color.
+	PerPointColor
+
+	// ColormapPointColor Each point is rendered using the color produced
+	// by mapping its scalar value onto the colormap of the PointCloud.
+	ColormapPointColor
+)
+
+/******************************************************************************
+ Colormap
+******************************************************************************/
+
+// Colormap An ordered list of (up to 16) colors, evenly spaced across the
+// scalar range, between which colors are linearly interpolated.
+type Colormap []color.RGBA
+
+var (
+	GrayscaleColormap = Colormap{Black, White}
+	JetColormap       = Colormap{
+		{R: 0, G: 0, B: 143, A: 255},
+		{R: 0, G: 0, B: 255, A: 255},
+		{R: 0, G: 255, B: 255, A: 255},
+		{R: 255, G: 255, B: 0, A: 255},
+		{R: 255, G: 0, B: 0, A: 255},
+		{R: 128, G: 0, B: 0, A: 255},
+	}
+	ViridisColormap = Colormap{
+		{R: 68, G: 1, B: 84, A: 255},
+		{R: 59, G: 82, B: 139, A: 255},
+		{R: 33, G: 145, B: 140, A: 255},
+		{R: 94, G: 201, B: 98, A: 255},
+		{R: 253, G: 231, B: 37, A: 255},
+	}
+)
+
+/******************************************************************************
+ PointCloud
+******************************************************************************/
+
+// PointCloud A WindowObject that renders (potentially millions of) points
+// in 3D space, as seen through the assigned Camera.  Points are held in a
+// ring buffer; once the capacity of the cloud has been reached, adding
+// points will overwrite the oldest ones, making the cloud suitable for
+// streaming sensor (LiDAR, depth camera, etc) data via AddPoints(), which
+// can be called from any routine.
+type PointCloud struct {
+	WindowObjectBase
+
+	viewport *Viewport
+	camera   Camera
+
+	pointSize   float32
+	colorMode   PointColorMode
+	colormap    Colormap
+	scalarMin   float32
+	scalarMax   float32
+	scalarAuto  bool
+	roundPoints bool
+
+	points     []pointVertex
+	pointIdx   int
+	pointCount int
+	dirtyIdx   int
+	dirtyCount int
+	minScalar  float32
+	maxScalar  float32
+	resized    bool
+	dataMutex  sync.Mutex
+
+	shader Shader
+	vao    uint32
+	vbo    uint32
+
+	worldMatUniformLoc    int32
+	viewProjMatUniformLoc int32
+	pointSizeUniformLoc   int32
+	colorModeUniformLoc   int32
+	colorUniformLoc       int32
+	colormapUniformLoc    int32
+	colormapSizeLoc       int32
+	scalarRangeLoc        int32
+	roundPointsLoc        int32
+
+	viewportBak [4]int32
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (c *PointCloud) Init() (ok bool) {
+	if c.Initialized() {
+		return true
+	}
+
+	c.initViewport()
+	c.initVertexVao()
+
+	return c.WindowObjectBase.Init()
+}
+
+func (c *PointCloud) Close() {
+	if !c.Initialized() {
+		return
+	}
+
+	c.closeVertexVao()
+
+	c.WindowObjectBase.Close()
+}
+
+/******************************************************************************
+ DrawableObject Implementation
+******************************************************************************/
+
+func (c *PointCloud) Draw(deltaTime int64) (ok bool) {
+	if !c.DrawableObjectBase.Draw(deltaTime) {
+		return false
+	}
+
+	c.beginDraw()
+	c.updateVertices()
+	c.draw()
+	c.endDraw()
+
+	return c.WindowObjectBase.drawChildren(deltaTime)
+}
+
+/******************************************************************************
+ Resizer Implementation
+******************************************************************************/
+
+func (c *PointCloud) Resize(newWidth, newHeight int) {
+	if c.viewport != nil {
+		c.viewport.SetWindowSize(newWidth, newHeight)
+	}
+
+	c.WindowObjectBase.Resize(newWidth, newHeight)
+}
+
+/******************************************************************************
+ PointCloud Functions
+******************************************************************************/
+
+func (c *PointCloud) initViewport() {
+	if c.viewport == nil {
+		c.viewport = NewViewport(c.window.Width(), c.window.Height())
+	}
+}
+
+func (c *PointCloud) initVertexVao() {
+	c.shader = c.window.Assets().Get(PointCloudShader).(Shader)
+
+	c.worldMatUniformLoc = c.shader.GetUniformLocation("u_WorldMat")
+	c.viewProjMatUniformLoc = c.shader.GetUniformLocation("u_ViewProjMat")
+	c.pointSizeUniformLoc = c.shader.GetUniformLocation("u_PointSize")
+	c.colorModeUniformLoc = c.shader.GetUniformLocation("u_ColorMode")
+	c.colorUniformLoc = c.shader.GetUniformLocation("u_Color")
+	c.colormapUniformLoc = c.shader.GetUniformLocation("u_Colormap")
+	c.colormapSizeLoc = c.shader.GetUniformLocation("u_ColormapSize")
+	c.scalarRangeLoc = c.shader.GetUniformLocation("u_ScalarRange")
+	c.roundPointsLoc = c.shader.GetUniformLocation("u_RoundPoints")
+
+	gl.GenVertexArrays(1, &c.vao)
+	gl.GenBuffers(1, &c.vbo)
+
+	gl.BindVertexArray(c.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, c.vbo)
+
+	stride := int32(sizeOfPointVertex)
+
+	posLoc := uint32(c.shader.GetAttribLocation("a_Position"))
+	gl.EnableVertexAttribArray(posLoc)
+	gl.VertexAttribPointerWithOffset(posLoc, 3, gl.FLOAT, false, stride, 0)
+
+	colorLoc := uint32(c.shader.GetAttribLocation("a_Color"))
+	gl.EnableVertexAttribArray(colorLoc)
+	gl.VertexAttribPointerWithOffset(colorLoc, 4, gl.UNSIGNED_BYTE, true, stride, uintptr(3*sizeOfFloat32))
+
+	scalarLoc := uint32(c.shader.GetAttribLocation("a_Scalar"))
+	gl.EnableVertexAttribArray(scalarLoc)
+	gl.VertexAttribPointerWithOffset(scalarLoc, 1, gl.FLOAT, false, stride, uintptr(4*sizeOfFloat32))
+
+	c.dataMutex.Lock()
+	gl.BufferData(gl.ARRAY_BUFFER, len(c.points)*sizeOfPointVertex, gl.Ptr(c.points), gl.DYNAMIC_DRAW)
+	c.dirtyCount = 0
+	c.resized = false
+	c.dataMutex.Unlock()
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+}
+
+func (c *PointCloud) closeVertexVao() {
+	gl.BindVertexArray(0)
+	gl.DeleteVertexArrays(1, &c.vao)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.DeleteBuffers(1, &c.vbo)
+}
+
+// updateVertices Uploads the points added since the last frame, which may
+// wrap around the end of the ring buffer, or the entire buffer if the
+// capacity has changed.
+func (c *PointCloud) updateVertices() {
+	c.dataMutex.Lock()
+	defer c.dataMutex.Unlock()
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, c.vbo)
+
+	if c.resized {
+		c.resized = false
+		c.dirtyCount = 0
+		gl.BufferData(gl.ARRAY_BUFFER, len(c.points)*sizeOfPointVertex, gl.Ptr(c.points), gl.DYNAMIC_DRAW)
+	}
+
+	if c.dirtyCount > 0 {
+		capacity := len(c.points)
+		first := c.dirtyCount
+		if c.dirtyIdx+first > capacity {
+			first = capacity - c.dirtyIdx
+		}
+
+		gl.BufferSubData(gl.ARRAY_BUFFER, c.dirtyIdx*sizeOfPointVertex, first*sizeOfPointVertex, gl.Ptr(c.points[c.dirtyIdx:]))
+		if remaining := c.dirtyCount - first; remaining > 0 {
+			gl.BufferSubData(gl.ARRAY_BUFFER, 0, remaining*sizeOfPointVertex, gl.Ptr(c.points))
+		}
+
+		c.dirtyCount = 0
+	}
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
+
+func (c *PointCloud) beginDraw() {
+	gl.Enable(gl.DEPTH_TEST)
+	gl.Enable(gl.BLEND)
+	gl.Enable(gl.PROGRAM_POINT_SIZE)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.GetIntegerv(gl.VIEWPORT, &c.viewportBak[0])
+}
+
+func (c *PointCloud) draw() {
+	c.shader.Activate()
+
+	worldMat := c.WorldMatrix()
+	viewProjMat := mgl32.Ident4()
+
+	c.stateMutex.Lock()
+	gl.Viewport(c.viewport.Get())
+
+	if c.camera != nil {
+		viewProjMat = c.camera.ViewProjection()
+	}
+
+	colormap := make([]float32, 0, maxColormapSize*4)
+	for _, rgba := range c.colormap {
+		rgbaFloats := RgbaToFloatArray(rgba)
+		colormap = append(colormap, rgbaFloats[:]...)
+	}
+
+	gl.UniformMatrix4fv(c.worldMatUniformLoc, 1, false, &worldMat[0])
+	gl.UniformMatrix4fv(c.viewProjMatUniformLoc, 1, false, &viewProjMat[0])
+	gl.Uniform1f(c.pointSizeUniformLoc, c.pointSize)
+	gl.Uniform1i(c.colorModeUniformLoc, int32(c.colorMode))
+	gl.Uniform4fv(c.colorUniformLoc, 1, &c.color[0])
+	if len(colormap) > 0 {
+		gl.Uniform4fv(c.colormapUniformLoc, int32(len(c.colormap)), &colormap[0])
+	}
+	gl.Uniform1i(c.colormapSizeLoc, int32(len(c.colormap)))
+	if c.roundPoints {
+		gl.Uniform1i(c.roundPointsLoc, 1)
+	} else {
+		gl.Uniform1i(c.roundPointsLoc, 0)
+	}
+	scalarAuto := c.scalarAuto
+	scalarMin, scalarMax := c.scalarMin, c.scalarMax
+	c.stateMutex.Unlock()
+
+	c.dataMutex.Lock()
+	count := int32(c.pointCount)
+	if scalarAuto {
+		scalarMin, scalarMax = c.minScalar, c.maxScalar
+	}
+	c.dataMutex.Unlock()
+
+	gl.Uniform2f(c.scalarRangeLoc, scalarMin, scalarMax)
+
+	gl.BindVertexArray(c.vao)
+	gl.DrawArrays(gl.POINTS, 0, count)
+}
+
+func (c *PointCloud) endDraw() {
+	gl.Viewport(c.viewportBak[0], c.viewportBak[1], c.viewportBak[2], c.viewportBak[3])
+
+	gl.Disable(gl.PROGRAM_POINT_SIZE)
+	gl.Disable(gl.BLEND)
+	gl.Disable(gl.DEPTH_TEST)
+
+	gl.BindVertexArray(0)
+
+	gl.UseProgram(0)
+}
+
+func (c *PointCloud) resetScalarRange() {
+	c.minScalar = math.MaxFloat32
+	c.maxScalar = -math.MaxFloat32
+}
+
+// AddPoints Append the points to the cloud, overwriting the oldest
+// points once its capacity has been reached.  Can be called from any
+// routine.
+func (c *PointCloud) AddPoints(points []Point) {
+	c.dataMutex.Lock()
+
+	capacity := len(c.points)
+	if len(points) > capacity { // only the newest points would survive
+		points = points[len(points)-capacity:]
+	}
+
+	if c.dirtyCount == 0 {
+		c.dirtyIdx = c.pointIdx
+	}
+
+	for _, p := range points {
+		c.points[c.pointIdx] = pointVertex{
+			x:      p.Position[0],
+			y:      p.Position[1],
+			z:      p.Position[2],
+			color:  p.Color,
+			scalar: p.Scalar,
+		}
+		c.pointIdx = (c.pointIdx + 1) % capacity
+
+		if p.Scalar < c.minScalar {
+			c.minScalar = p.Scalar
+		}
+		if p.Scalar > c.maxScalar {
+			c.maxScalar = p.Scalar
+		}
+	}
+
+	c.pointCount = int(math.Min(float64(c.pointCount+len(points)), float64(capacity)))
+	c.dirtyCount = int(math.Min(float64(c.dirtyCount+len(points)), float64(capacity)))
+	if c.dirtyCount == capacity {
+		c.dirtyIdx = 0
+	}
+
+	c.dataMutex.Unlock()
+}
+
+// SetPoints Replace all points in the cloud with the given ones.
+func (c *PointCloud) SetPoints(points []Point) {
+	c.ClearPoints()
+	c.AddPoints(points)
+}
+
+// ClearPoints Remove all points from the cloud.
+func (c *PointCloud) ClearPoints() {
+	c.dataMutex.Lock()
+	c.pointIdx = 0
+	c.pointCount = 0
+	c.dirtyIdx = 0
+	c.dirtyCount = 0
+	c.resetScalarRange()
+	c.dataMutex.Unlock()
+}
+
+func (c *PointCloud) PointCount() (count int) {
+	c.dataMutex.Lock()
+	count = c.pointCount
+	c.dataMutex.Unlock()
+	return
+}
+
+func (c *PointCloud) Capacity() (capacity int) {
+	c.dataMutex.Lock()
+	capacity = len(c.points)
+	c.dataMutex.Unlock()
+	return
+}
+
+// SetCapacity sets the maximum number of points held by the cloud.
+// Changing the capacity will clear the cloud.
+func (c *PointCloud) SetCapacity(capacity int) *PointCloud {
+	if capacity < 1 {
+		capacity = 1
+	}
+	c.dataMutex.Lock()
+	c.points = make([]pointVertex, capacity)
+	c.resized = true
+	c.dataMutex.Unlock()
+	c.ClearPoints()
+	return c
+}
+
+func (c *PointCloud) Viewport() *Viewport {
+	c.stateMutex.Lock()
+	vp := c.viewport
+	c.stateMutex.Unlock()
+	return vp
+}
+
+func (c *PointCloud) SetViewport(viewport *Viewport) *PointCloud {
+	c.stateMutex.Lock()
+	c.viewport = viewport
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *PointCloud) Camera() Camera {
+	c.stateMutex.Lock()
+	cam := c.camera
+	c.stateMutex.Unlock()
+	return cam
+}
+
+func (c *PointCloud) SetCamera(camera Camera) *PointCloud {
+	c.stateMutex.Lock()
+	c.camera = camera
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *PointCloud) PointSize() (size float32) {
+	c.stateMutex.Lock()
+	size = c.pointSize
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetPointSize sets the diameter of the rendered points, in pixels.
+func (c *PointCloud) SetPointSize(size float32) *PointCloud {
+	if size < 1 {
+		size = 1
+	}
+	c.stateMutex.Lock()
+	c.pointSize = size
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *PointCloud) RoundPoints() (round bool) {
+	c.stateMutex.Lock()
+	round = c.roundPoints
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetRoundPoints determines whether points are rendered as
+// circles rather than squares.
+func (c *PointCloud) SetRoundPoints(round bool) *PointCloud {
+	c.stateMutex.Lock()
+	c.roundPoints = round
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *PointCloud) ColorMode() (mode PointColorMode) {
+	c.stateMutex.Lock()
+	mode = c.colorMode
+	c.stateMutex.Unlock()
+	return
+}
+
+func (c *PointCloud) SetColorMode(mode PointColorMode) *PointCloud {
+	c.stateMutex.Lock()
+	c.colorMode = mode
+	c.stateMutex.Unlock()
+	return c
+}
+
+func (c *PointCloud) Colormap() (colormap Colormap) {
+	c.stateMutex.Lock()
+	colormap = c.colormap
+	c.stateMutex.Unlock()
+	return
+}
+
+// SetColormap sets the colors used when the color mode is ColormapPointColor;
+// only the first 16 colors are used.
+func (c *PointCloud) SetColormap(colormap Colormap) *PointCloud {
+	if len(colormap) > maxColormapSize {
+		colormap = colormap[:maxColormapSize]
+	}
+	c.stateMutex.Lock()
+	c.colormap = colormap
+	c.stateMutex.Unlock()
+	return c
+}
+
+// ScalarRange returns the range of scalar values mapped onto the colormap,
+// which is the range of values added thus far unless set explicitly.
+func (c *PointCloud) ScalarRange() (minValue, maxValue float32) {
+	c.stateMutex.Lock()
+	auto := c.scalarAuto
+	minValue, maxValue = c.scalarMin, c.scalarMax
+	c.stateMutex.Unlock()
+
+	if auto {
+		c.dataMutex.Lock()
+		minValue, maxValue = c.minScalar, c.maxScalar
+		c.dataMutex.Unlock()
+	}
+	return
+}
+
+// SetScalarRange sets the range of scalar values mapped onto the colormap;
+// values outside this range are clamped.
+func (c *PointCloud) SetScalarRange(minValue, maxValue float32) *PointCloud {
+	c.stateMutex.Lock()
+	c.scalarMin = minValue
+	c.scalarMax = maxValue
+	c.scalarAuto = false
+	c.stateMutex.Unlock()
+	return c
+}
+
+// SetScalarRangeAuto causes the range of scalar values mapped onto the
+// colormap to be the range of values added to the cloud.
+func (c *PointCloud) SetScalarRangeAuto() *PointCloud {
+	c.stateMutex.Lock()
+	c.scalarAuto = true
+	c.stateMutex.Unlock()
+	return c
+}
+
+/******************************************************************************
+ New PointCloud Function
+******************************************************************************/
+
+// NewPointCloud creates a point cloud with the given capacity, i.e., the
+// maximum number of points it will hold (1,000,000 if not provided).
+func NewPointCloud(capacity ...int) *PointCloud {
+	c := &PointCloud{
+		WindowObjectBase: *NewWindowObject(),
+		pointSize:        defaultPointSize,
+		colorMode:        PerPointColor,
+		colormap:         ViridisColormap,
+		scalarAuto:       true,
+	}
+
+	size := defaultPointCloudCapacity
+	if len(capacity) > 0 && capacity[0] > 0 {
+		size = capacity[0]
+	}
+	c.points = make([]pointVertex, size)
+	c.resetScalarRange()
+
+	c.SetName(defaultPointCloudName)
+	return c
+}
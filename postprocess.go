@@ -0,0 +1,466 @@
+package gfx
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"sync"
+)
+
+const (
+	postProcessorSamples = 4
+)
+
+const (
+	effectSceneMapTextureUnit    = 0
+	effectOriginalMapTextureUnit = 1
+	effectReservedTextureUnits   = 2
+)
+
+var effectQuadVertices = []float32{
+	-1, -1, 0, 0,
+	1, -1, 1, 0,
+	1, 1, 1, 1,
+	1, 1, 1, 1,
+	-1, 1, 0, 1,
+	-1, -1, 0, 0,
+}
+
+/******************************************************************************
+ Effect
+******************************************************************************/
+
+// Effect Post-processing effects are applied to the frame rendered by a
+// Window, after all of its objects have been drawn, in the order in which
+// they were added to the window (see Window.AddEffect()).  Each effect reads
+// the output of the previous one (the rendered frame, for the first) and
+// the last one writes to the window itself.  Effects can be enabled and
+// disabled at runtime, from any goroutine, with disabled effects being
+// skipped; when no effect is enabled, the frame is rendered directly to the
+// window, as if none were added.  Custom effects can be created from user
+// GLSL with NewShaderEffect().
+type Effect interface {
+	Object
+	windowSetter
+
+	// Window shall return the Window to which this effect was added.
+	Window() *Window
+
+	// apply shall render the effect into the framebuffer given by the frame,
+	// which is bound when called, reading from the frame's input texture.
+	apply(frame *effectFrame)
+}
+
+/******************************************************************************
+ EffectBase
+******************************************************************************/
+
+type EffectBase struct {
+	ObjectBase
+	window *Window
+}
+
+func (e *EffectBase) Window() *Window {
+	return e.window
+}
+
+func (e *EffectBase) setWindow(window *Window) {
+	e.window = window
+}
+
+/******************************************************************************
+ effectFrame
+******************************************************************************/
+
+// effectFrame The state given to an effect when it is applied.
+type effectFrame struct {
+	input    uint32
+	original uint32
+	output   uint32
+	width    int32
+	height   int32
+	time     float32
+	quadVao  uint32
+}
+
+// bindOutput binds the framebuffer the effect must write to, for effects
+// that first render into their own framebuffers.
+func (f *effectFrame) bindOutput() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.output)
+	gl.Viewport(0, 0, f.width, f.height)
+}
+
+// draw renders a quad covering the whole framebuffer.
+func (f *effectFrame) draw() {
+	gl.BindVertexArray(f.quadVao)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(effectQuadVertices)/4))
+}
+
+/******************************************************************************
+ effectUniforms
+******************************************************************************/
+
+// effectUniforms The locations of the uniforms shared by all effect shaders,
+// whose values are set from the effectFrame:
+//
+//	uniform sampler2D u_SceneMap;    // output of the previous effect
+//	uniform sampler2D u_OriginalMap; // frame as rendered, before any effect
+//	uniform vec2 u_Resolution;       // size of the frame, in pixels
+//	uniform vec2 u_TexelSize;        // 1.0 / u_Resolution
+//	uniform float u_Time;            // seconds since effects were first applied
+type effectUniforms struct {
+	sceneMap    int32
+	originalMap int32
+	resolution  int32
+	texelSize   int32
+	time        int32
+}
+
+func (u *effectUniforms) init(shader Shader) {
+	u.sceneMap = shader.GetUniformLocation("u_SceneMap")
+	u.originalMap = shader.GetUniformLocation("u_OriginalMap")
+	u.resolution = shader.GetUniformLocation("u_Resolution")
+	u.texelSize = shader.GetUniformLocation("u_TexelSize")
+	u.time = shader.GetUniformLocation("u_Time")
+}
+
+// set binds the frame's textures and sets the uniforms of the shader, which
+// must already be active.
+func (u *effectUniforms) set(frame *effectFrame) {
+	gl.ActiveTexture(gl.TEXTURE0 + effectOriginalMapTextureUnit)
+	gl.BindTexture(gl.TEXTURE_2D, frame.original)
+	gl.ActiveTexture(gl.TEXTURE0 + effectSceneMapTextureUnit)
+	gl.BindTexture(gl.TEXTURE_2D, frame.input)
+
+	gl.Uniform1i(u.sceneMap, effectSceneMapTextureUnit)
+	gl.Uniform1i(u.originalMap, effectOriginalMapTextureUnit)
+	gl.Uniform2f(u.resolution, float32(frame.width), float32(frame.height))
+	gl.Uniform2f(u.texelSize, 1/float32(frame.width), 1/float32(frame.height))
+	gl.Uniform1f(u.time, frame.time)
+}
+
+/******************************************************************************
+ postProcessor
+******************************************************************************/
+
+// postProcessor Renders the frame of a Window into an offscreen (and, if the
+// window has multisampling enabled, multisampled) framebuffer with a
+// floating-point color buffer, so that values above 1.0 are retained for
+// effects like bloom and tone mapping, then applies the enabled effects,
+// alternating between two more framebuffers, with the last effect writing
+// to the framebuffer that was bound before the frame was rendered.
+type postProcessor struct {
+	effects   []Effect
+	initQueue []Effect
+	enabled   []Effect
+
+	width   int32
+	height  int32
+	samples int32
+
+	sceneFrameBuffer uint32
+	sceneTexture     uint32
+	sceneDepthBuffer uint32
+	msaaFrameBuffer  uint32
+	msaaColorBuffer  uint32
+	msaaDepthBuffer  uint32
+
+	pingPongFrameBuffers [2]uint32
+	pingPongTextures     [2]uint32
+
+	quadVao uint32
+	quadVbo uint32
+
+	time float32
+
+	framebufferBak int32
+	viewportBak    [4]int32
+
+	stateMutex sync.Mutex
+}
+
+func (p *postProcessor) add(window *Window, effects ...Effect) {
+	p.stateMutex.Lock()
+	for _, e := range effects {
+		if e == nil {
+			continue
+		}
+		e.setWindow(window)
+		p.effects = append(p.effects, e)
+		p.initQueue = append(p.initQueue, e)
+	}
+	p.stateMutex.Unlock()
+}
+
+func (p *postProcessor) remove(effect Effect) {
+	p.stateMutex.Lock()
+	for i, e := range p.effects {
+		if e == effect {
+			p.effects = append(p.effects[:i], p.effects[i+1:]...)
+			break
+		}
+	}
+	for i, e := range p.initQueue {
+		if e == effect {
+			p.initQueue = append(p.initQueue[:i], p.initQueue[i+1:]...)
+			break
+		}
+	}
+	p.stateMutex.Unlock()
+}
+
+func (p *postProcessor) list() []Effect {
+	p.stateMutex.Lock()
+	defer p.stateMutex.Unlock()
+	effects := make([]Effect, len(p.effects))
+	copy(effects, p.effects)
+	return effects
+}
+
+// begin binds the offscreen framebuffer, if any effect is enabled, so that
+// the objects of the window are drawn into it.
+func (p *postProcessor) begin(window *Window, deltaTime int64) {
+	p.stateMutex.Lock()
+	for _, e := range p.initQueue {
+		e.Init()
+	}
+	p.initQueue = p.initQueue[:0]
+
+	p.enabled = p.enabled[:0]
+	for _, e := range p.effects {
+		if e.Enabled() && e.Initialized() {
+			p.enabled = append(p.enabled, e)
+		}
+	}
+	p.stateMutex.Unlock()
+
+	if len(p.enabled) == 0 {
+		return
+	}
+
+	p.time += float32(deltaTime) / 1000000.0
+
+	width, height := int32(window.Width()), int32(window.Height())
+	samples := int32(0)
+	if window.MultiSamplingEnabled() {
+		samples = postProcessorSamples
+	}
+	if p.quadVao == 0 {
+		p.initQuadVao(window.Assets().Get(TextureShader).(Shader))
+	}
+	if width != p.width || height != p.height || samples != p.samples {
+		p.closeFrameBuffers()
+		p.initFrameBuffers(width, height, samples)
+	}
+
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &p.framebufferBak)
+	gl.GetIntegerv(gl.VIEWPORT, &p.viewportBak[0])
+
+	if p.samples > 0 {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, p.msaaFrameBuffer)
+	} else {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, p.sceneFrameBuffer)
+	}
+
+	clearColor := window.clearColorVec
+	gl.ClearColor(clearColor[0], clearColor[1], clearColor[2], clearColor[3])
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+}
+
+// end applies the enabled effects to the frame drawn since begin was called.
+func (p *postProcessor) end() {
+	if len(p.enabled) == 0 {
+		return
+	}
+
+	if p.samples > 0 {
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, p.msaaFrameBuffer)
+		gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, p.sceneFrameBuffer)
+		gl.BlitFramebuffer(0, 0, p.width, p.height, 0, 0, p.width, p.height, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	}
+
+	gl.Disable(gl.BLEND)
+	gl.Disable(gl.DEPTH_TEST)
+
+	frame := &effectFrame{
+		input:    p.sceneTexture,
+		original: p.sceneTexture,
+		width:    p.width,
+		height:   p.height,
+		time:     p.time,
+		quadVao:  p.quadVao,
+	}
+
+	for i, e := range p.enabled {
+		if i == len(p.enabled)-1 {
+			frame.output = uint32(p.framebufferBak)
+		} else {
+			frame.output = p.pingPongFrameBuffers[i%2]
+		}
+
+		frame.bindOutput()
+		e.apply(frame)
+		frame.input = p.pingPongTextures[i%2]
+	}
+
+	gl.BindVertexArray(0)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.UseProgram(0)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(p.framebufferBak))
+	gl.Viewport(p.viewportBak[0], p.viewportBak[1], p.viewportBak[2], p.viewportBak[3])
+}
+
+func (p *postProcessor) close() {
+	p.stateMutex.Lock()
+	for _, e := range p.effects {
+		e.Close()
+	}
+	p.initQueue = append(p.initQueue[:0], p.effects...)
+	p.stateMutex.Unlock()
+
+	p.closeFrameBuffers()
+	p.closeQuadVao()
+}
+
+// initQuadVao Creates the quad drawn by the effects, whose shaders all share
+// the vertex shader of the given TextureShader.
+func (p *postProcessor) initQuadVao(shader Shader) {
+	posAttribLoc := uint32(shader.GetAttribLocation("a_Position"))
+	uvAttribLoc := uint32(shader.GetAttribLocation("a_UV"))
+
+	gl.GenVertexArrays(1, &p.quadVao)
+	gl.GenBuffers(1, &p.quadVbo)
+
+	gl.BindVertexArray(p.quadVao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.quadVbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(effectQuadVertices)*sizeOfFloat32, gl.Ptr(effectQuadVertices), gl.STATIC_DRAW)
+
+	stride := int32(4 * sizeOfFloat32)
+	gl.EnableVertexAttribArray(posAttribLoc)
+	gl.VertexAttribPointerWithOffset(posAttribLoc, 2, gl.FLOAT, false, stride, 0)
+	gl.EnableVertexAttribArray(uvAttribLoc)
+	gl.VertexAttribPointerWithOffset(uvAttribLoc, 2, gl.FLOAT, false, stride, uintptr(2*sizeOfFloat32))
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+}
+
+func (p *postProcessor) closeQuadVao() {
+	if p.quadVao == 0 {
+		return
+	}
+
+	gl.DeleteVertexArrays(1, &p.quadVao)
+	gl.DeleteBuffers(1, &p.quadVbo)
+	p.quadVao = 0
+	p.quadVbo = 0
+}
+
+func (p *postProcessor) initFrameBuffers(width, height, samples int32) {
+	p.width = width
+	p.height = height
+	p.samples = samples
+
+	var frameBufferBak int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &frameBufferBak)
+
+	p.sceneFrameBuffer, p.sceneTexture = newEffectFrameBuffer(width, height)
+
+	gl.GenRenderbuffers(1, &p.sceneDepthBuffer)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, p.sceneDepthBuffer)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, width, height)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, p.sceneDepthBuffer)
+
+	// Multisampled renderbuffers cannot be sampled directly, so they
+	// are resolved (blitted) into the scene texture first
+	if samples > 0 {
+		gl.GenFramebuffers(1, &p.msaaFrameBuffer)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, p.msaaFrameBuffer)
+
+		gl.GenRenderbuffers(1, &p.msaaColorBuffer)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, p.msaaColorBuffer)
+		gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, samples, gl.RGBA16F, width, height)
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, p.msaaColorBuffer)
+
+		gl.GenRenderbuffers(1, &p.msaaDepthBuffer)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, p.msaaDepthBuffer)
+		gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, samples, gl.DEPTH24_STENCIL8, width, height)
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, p.msaaDepthBuffer)
+
+		if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+			panic(fmt.Errorf("post-processing multisample framebuffer incomplete: status 0x%x", status))
+		}
+	}
+
+	for i := range p.pingPongFrameBuffers {
+		p.pingPongFrameBuffers[i], p.pingPongTextures[i] = newEffectFrameBuffer(width, height)
+	}
+
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(frameBufferBak))
+}
+
+func (p *postProcessor) closeFrameBuffers() {
+	if p.sceneFrameBuffer == 0 {
+		return
+	}
+
+	gl.DeleteFramebuffers(1, &p.sceneFrameBuffer)
+	gl.DeleteTextures(1, &p.sceneTexture)
+	gl.DeleteRenderbuffers(1, &p.sceneDepthBuffer)
+	p.sceneFrameBuffer = 0
+	p.sceneTexture = 0
+	p.sceneDepthBuffer = 0
+
+	if p.msaaFrameBuffer != 0 {
+		gl.DeleteFramebuffers(1, &p.msaaFrameBuffer)
+		gl.DeleteRenderbuffers(1, &p.msaaColorBuffer)
+		gl.DeleteRenderbuffers(1, &p.msaaDepthBuffer)
+		p.msaaFrameBuffer = 0
+		p.msaaColorBuffer = 0
+		p.msaaDepthBuffer = 0
+	}
+
+	gl.DeleteFramebuffers(2, &p.pingPongFrameBuffers[0])
+	gl.DeleteTextures(2, &p.pingPongTextures[0])
+	p.pingPongFrameBuffers = [2]uint32{}
+	p.pingPongTextures = [2]uint32{}
+
+	p.width = 0
+	p.height = 0
+}
+
+/******************************************************************************
+ New postProcessor Function
+******************************************************************************/
+
+func newPostProcessor() *postProcessor {
+	return &postProcessor{}
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+// newEffectFrameBuffer creates a framebuffer with a floating-point color
+// texture of the given size, leaving the framebuffer bound.
+func newEffectFrameBuffer(width, height int32) (frameBuffer, texture uint32) {
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA16F, width, height, 0, gl.RGBA, gl.FLOAT, nil)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.GenFramebuffers(1, &frameBuffer)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, frameBuffer)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, texture, 0)
+
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		panic(fmt.Errorf("post-processing framebuffer incomplete: status 0x%x", status))
+	}
+
+	return
+}
@@ -0,0 +1,23 @@
+package primitive
+
+import "github.com/tonybillings/gfx/internal/basic"
+
+/******************************************************************************
+ Material
+******************************************************************************/
+
+// BasicMaterial Every face of a generated model shares a single instance of
+// this material, which has the properties expected by the default 3D shaders
+// (gfx.Shape3DShader, etc).  The texture maps are solid colors unless
+// replaced prior to initialization.
+type BasicMaterial = basic.Material
+
+type BasicMaterialProperties = basic.MaterialProperties
+
+/******************************************************************************
+ New Material Function
+******************************************************************************/
+
+func NewMaterial() *BasicMaterial {
+	return basic.NewMaterial()
+}
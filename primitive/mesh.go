@@ -0,0 +1,23 @@
+package primitive
+
+import "github.com/tonybillings/gfx/internal/basic"
+
+/******************************************************************************
+ Mesh
+******************************************************************************/
+
+// Mesh Contains every triangle of a generated model.
+type Mesh = basic.Mesh
+
+// Face A triangle of a generated model.  As every vertex of the model has
+// exactly one position, normal, texture coordinate, tangent and bitangent,
+// the same indices are used for each of these attributes.
+type Face = basic.Face
+
+/******************************************************************************
+ New Mesh Function
+******************************************************************************/
+
+func NewMesh() *Mesh {
+	return basic.NewMesh()
+}
@@ -0,0 +1,322 @@
+// Package primitive provides models generated from a few parameters, such as
+// boxes, spheres, cylinders and planes, which can be assigned to a Shape3D
+// without authoring a model file.  Each model is centered on the origin,
+// using a Y-up coordinate system, with counter-clockwise winding and
+// vertices that carry positions, normals, texture coordinates, tangents and
+// bitangents, so that the default 3D shaders (including normal mapping) can
+// be used:
+//
+//	sphere := primitive.NewUVSphere("ball", 1, 32, 16)
+//	win.Assets().Add(sphere)
+//	shape := gfx.NewShape3D().SetModel(sphere)
+package primitive
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/internal/basic"
+)
+
+const (
+	epsilon = 1e-12
+)
+
+/******************************************************************************
+ Model
+******************************************************************************/
+
+type Model struct {
+	gfx.ModelBase
+
+	vertices   []float32
+	normals    []float32
+	uvs        []float32
+	tangents   []float32
+	bitangents []float32
+
+	meshes []*Mesh
+
+	material      *BasicMaterial
+	defaultShader gfx.Shader
+}
+
+/******************************************************************************
+ gfx.Asset Implementation
+******************************************************************************/
+
+func (m *Model) Init() bool {
+	if m.Initialized() {
+		return true
+	}
+
+	m.attachDefaultShader()
+
+	if ok := m.material.Init(); !ok {
+		return false
+	}
+
+	if ok := m.initMeshes(); !ok {
+		return false
+	}
+
+	return m.AssetBase.Init()
+}
+
+func (m *Model) Close() {
+	if !m.Initialized() {
+		return
+	}
+
+	m.closeMeshes()
+	m.material.Close()
+
+	m.AssetBase.Close()
+}
+
+/******************************************************************************
+ gfx.Model Implementation
+******************************************************************************/
+
+func (m *Model) Vertices() []float32 {
+	return m.vertices
+}
+
+func (m *Model) Normals() []float32 {
+	return m.normals
+}
+
+func (m *Model) UVs() []float32 {
+	return m.uvs
+}
+
+func (m *Model) Tangents() []float32 {
+	return m.tangents
+}
+
+func (m *Model) Bitangents() []float32 {
+	return m.bitangents
+}
+
+func (m *Model) Meshes() []gfx.Mesh {
+	meshes := make([]gfx.Mesh, len(m.meshes))
+	for i, mesh := range m.meshes {
+		meshes[i] = mesh
+	}
+	return meshes
+}
+
+/******************************************************************************
+ gfx.IndexedModel Implementation
+******************************************************************************/
+
+func (m *Model) Indices() []uint32 {
+	return basic.Indices(m.Meshes())
+}
+
+/******************************************************************************
+ Model Functions
+******************************************************************************/
+
+// build Copies the vertices of the given geometry into the model, as a
+// single mesh, generating the tangents and bitangents from the texture
+// coordinates.
+func (m *Model) build(g *geometry) {
+	tangents, bitangents := g.computeTangents()
+
+	m.vertices = make([]float32, 0, len(g.positions)*3)
+	m.normals = make([]float32, 0, len(g.normals)*3)
+	m.uvs = make([]float32, 0, len(g.uvs)*2)
+	m.tangents = make([]float32, 0, len(tangents)*3)
+	m.bitangents = make([]float32, 0, len(bitangents)*3)
+
+	for i := range g.positions {
+		m.vertices = append(m.vertices, g.positions[i][:]...)
+		m.normals = append(m.normals, g.normals[i][:]...)
+		m.uvs = append(m.uvs, g.uvs[i][:]...)
+		m.tangents = append(m.tangents, tangents[i][:]...)
+		m.bitangents = append(m.bitangents, bitangents[i][:]...)
+	}
+
+	mesh := NewMesh()
+	mesh.SetName(m.Name())
+	for _, triangle := range g.triangles {
+		mesh.AddFace(basic.NewIndexedFace([]int{triangle[0], triangle[1], triangle[2]}, m.material))
+	}
+	m.meshes = []*Mesh{mesh}
+}
+
+func (m *Model) attachDefaultShader() {
+	if m.defaultShader == nil {
+		srcLib := m.SourceLibrary()
+		if srcLib != nil {
+			if defaultShader := srcLib.Get(gfx.Shape3DShader); defaultShader != nil {
+				if shader, ok := defaultShader.(gfx.Shader); ok {
+					m.defaultShader = shader
+				}
+			}
+		}
+	}
+
+	m.material.SetSourceLibrary(m.SourceLibrary())
+	if m.material.AttachedShader() == nil && m.defaultShader != nil {
+		m.material.AttachShader(m.defaultShader)
+	}
+}
+
+func (m *Model) initMeshes() bool {
+	ok := true
+	for _, mesh := range m.meshes {
+		ok = ok && mesh.Init()
+	}
+	return ok
+}
+
+func (m *Model) closeMeshes() {
+	for _, mesh := range m.meshes {
+		mesh.Close()
+	}
+}
+
+// SetDefaultShader sets the shader attached to the model's material when
+// initialized, if no shader has been attached to it already.  Defaults to
+// gfx.Shape3DShader, taken from the source library of the model.
+func (m *Model) SetDefaultShader(shader gfx.Shader) *Model {
+	m.defaultShader = shader
+	return m
+}
+
+// Material returns the material shared by every face of the model,
+// which can be used to set its color, etc.
+func (m *Model) Material() *BasicMaterial {
+	return m.material
+}
+
+// VertexCount returns the number of (unique) vertices of the model.
+func (m *Model) VertexCount() int {
+	return len(m.vertices) / 3
+}
+
+// FaceCount returns the number of triangles of the model.
+func (m *Model) FaceCount() int {
+	count := 0
+	for _, mesh := range m.meshes {
+		count += len(mesh.Triangles())
+	}
+	return count
+}
+
+/******************************************************************************
+ geometry
+******************************************************************************/
+
+// geometry Holds the vertices and triangles of a model as it is generated.
+type geometry struct {
+	positions []mgl32.Vec3
+	normals   []mgl32.Vec3
+	uvs       []mgl32.Vec2
+	triangles [][3]int
+}
+
+func (g *geometry) addVertex(position, normal mgl32.Vec3, uv mgl32.Vec2) int {
+	g.positions = append(g.positions, position)
+	g.normals = append(g.normals, normal)
+	g.uvs = append(g.uvs, uv)
+	return len(g.positions) - 1
+}
+
+// addTriangle Adds the triangle with the given (counter-clockwise) vertices,
+// unless it is degenerate, as happens at the poles of spheres, etc.
+func (g *geometry) addTriangle(a, b, c int) {
+	edge1 := g.positions[b].Sub(g.positions[a])
+	edge2 := g.positions[c].Sub(g.positions[a])
+	if edge1.Cross(edge2).Len() <= epsilon {
+		return
+	}
+	g.triangles = append(g.triangles, [3]int{a, b, c})
+}
+
+// addQuad Adds the quad with the given (counter-clockwise) vertices as two
+// triangles.
+func (g *geometry) addQuad(a, b, c, d int) {
+	g.addTriangle(a, b, c)
+	g.addTriangle(a, c, d)
+}
+
+// computeTangents Returns the tangent and bitangent of each vertex, aligned
+// with the U and V texture coordinate axes and orthogonalized with respect
+// to the vertex normal.
+func (g *geometry) computeTangents() (tangents, bitangents []mgl32.Vec3) {
+	uTangents := make([]mgl32.Vec3, len(g.positions))
+	vTangents := make([]mgl32.Vec3, len(g.positions))
+
+	for _, triangle := range g.triangles {
+		p0, p1, p2 := g.positions[triangle[0]], g.positions[triangle[1]], g.positions[triangle[2]]
+		uv0, uv1, uv2 := g.uvs[triangle[0]], g.uvs[triangle[1]], g.uvs[triangle[2]]
+
+		edge1, edge2 := p1.Sub(p0), p2.Sub(p0)
+		deltaUv1, deltaUv2 := uv1.Sub(uv0), uv2.Sub(uv0)
+
+		det := deltaUv1[0]*deltaUv2[1] - deltaUv2[0]*deltaUv1[1]
+		if det*det <= epsilon {
+			continue
+		}
+		r := 1 / det
+
+		u := edge1.Mul(deltaUv2[1]).Sub(edge2.Mul(deltaUv1[1])).Mul(r)
+		v := edge2.Mul(deltaUv1[0]).Sub(edge1.Mul(deltaUv2[0])).Mul(r)
+
+		for _, index := range triangle {
+			uTangents[index] = uTangents[index].Add(u)
+			vTangents[index] = vTangents[index].Add(v)
+		}
+	}
+
+	tangents = make([]mgl32.Vec3, len(g.positions))
+	bitangents = make([]mgl32.Vec3, len(g.positions))
+	for i, n := range g.normals {
+		t := normalizeOr(uTangents[i].Sub(n.Mul(n.Dot(uTangents[i]))), perpendicular(n))
+		b := n.Cross(t)
+		if b.Dot(vTangents[i]) < 0 {
+			b = b.Mul(-1) // mirrored texture coordinates
+		}
+		tangents[i] = t
+		bitangents[i] = b
+	}
+
+	return
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func normalizeOr(v, fallback mgl32.Vec3) mgl32.Vec3 {
+	if length := v.Len(); length*length > epsilon {
+		return v.Mul(1.0 / length)
+	}
+	return fallback
+}
+
+func perpendicular(n mgl32.Vec3) mgl32.Vec3 {
+	axis := mgl32.Vec3{1, 0, 0}
+	if n.X()*n.X() > 0.5 {
+		axis = mgl32.Vec3{0, 1, 0}
+	}
+	return normalizeOr(axis.Sub(n.Mul(n.Dot(axis))), mgl32.Vec3{1, 0, 0})
+}
+
+/******************************************************************************
+ New Model Function
+******************************************************************************/
+
+func newModel(name string, g *geometry) *Model {
+	m := &Model{
+		ModelBase: gfx.ModelBase{
+			AssetBase: *gfx.NewAssetBase(name, nil),
+		},
+		material: NewMaterial(),
+	}
+
+	m.build(g)
+	return m
+}
@@ -0,0 +1,366 @@
+package primitive
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+)
+
+const (
+	minSegments        = 3
+	minRings           = 2
+	maxIcoSubdivisions = 8
+)
+
+/******************************************************************************
+ profilePoint
+******************************************************************************/
+
+// profilePoint A point of the profile revolved around the Y axis to generate
+// a surface of revolution, with the normal given in the same (radial, Y)
+// plane and v being the texture coordinate along the profile.
+type profilePoint struct {
+	radius, y float32
+	normal    mgl32.Vec2
+	v         float32
+}
+
+/******************************************************************************
+ geometry Functions
+******************************************************************************/
+
+// addGrid Adds a flat, subdivided rectangle centered on the given point,
+// spanning the given (half-extent) axes, with its front face (and normal)
+// pointing along uAxis x vAxis.  The U/V texture coordinates run along the
+// respective axes.
+func (g *geometry) addGrid(center, uAxis, vAxis mgl32.Vec3, uSegments, vSegments int) {
+	normal := normalizeOr(uAxis.Cross(vAxis), mgl32.Vec3{0, 1, 0})
+	base := len(g.positions)
+
+	for j := 0; j <= vSegments; j++ {
+		v := float32(j) / float32(vSegments)
+		for i := 0; i <= uSegments; i++ {
+			u := float32(i) / float32(uSegments)
+			position := center.Add(uAxis.Mul(u*2 - 1)).Add(vAxis.Mul(v*2 - 1))
+			g.addVertex(position, normal, mgl32.Vec2{u, v})
+		}
+	}
+
+	row := uSegments + 1
+	for j := 0; j < vSegments; j++ {
+		for i := 0; i < uSegments; i++ {
+			a := base + j*row + i
+			g.addQuad(a, a+1, a+row+1, a+row)
+		}
+	}
+}
+
+// addLathe Adds the surface generated by revolving the given profile (ordered
+// from top to bottom, on the side facing away from the axis) around the Y
+// axis.  The U texture coordinate runs around the axis, starting at +Z.
+func (g *geometry) addLathe(profile []profilePoint, segments int) {
+	base := len(g.positions)
+
+	for _, p := range profile {
+		for s := 0; s <= segments; s++ {
+			u := float32(s) / float32(segments)
+			theta := float64(u) * 2 * math.Pi
+			sin, cos := float32(math.Sin(theta)), float32(math.Cos(theta))
+
+			position := mgl32.Vec3{p.radius * sin, p.y, p.radius * cos}
+			normal := normalizeOr(mgl32.Vec3{p.normal[0] * sin, p.normal[1], p.normal[0] * cos}, mgl32.Vec3{0, 1, 0})
+			g.addVertex(position, normal, mgl32.Vec2{u, p.v})
+		}
+	}
+
+	row := segments + 1
+	for i := 0; i < len(profile)-1; i++ {
+		for s := 0; s < segments; s++ {
+			a := base + i*row + s
+			g.addQuad(a, a+row, a+row+1, a+1)
+		}
+	}
+}
+
+// addDisc Adds a flat disc, parallel to the XZ plane, facing up (+Y) or down.
+// The texture coordinates map the disc onto the unit square.
+func (g *geometry) addDisc(y, radius float32, segments int, up bool) {
+	normal := mgl32.Vec3{0, -1, 0}
+	vSign := float32(1)
+	if up {
+		normal = mgl32.Vec3{0, 1, 0}
+		vSign = -1
+	}
+
+	center := g.addVertex(mgl32.Vec3{0, y, 0}, normal, mgl32.Vec2{0.5, 0.5})
+	for s := 0; s < segments; s++ {
+		theta := float64(s) / float64(segments) * 2 * math.Pi
+		sin, cos := float32(math.Sin(theta)), float32(math.Cos(theta))
+		g.addVertex(mgl32.Vec3{radius * sin, y, radius * cos}, normal, mgl32.Vec2{0.5 + sin*0.5, 0.5 + vSign*cos*0.5})
+	}
+
+	for s := 0; s < segments; s++ {
+		current := center + 1 + s
+		next := center + 1 + (s+1)%segments
+		if up {
+			g.addTriangle(center, current, next)
+		} else {
+			g.addTriangle(center, next, current)
+		}
+	}
+}
+
+/******************************************************************************
+ New Model Functions
+******************************************************************************/
+
+// NewBox creates a box with the given dimensions (along the X, Y and Z axes),
+// with each side divided into segments x segments quads and mapped onto the
+// full texture.
+func NewBox(name string, width, height, depth float32, segments int) *Model {
+	segments = max(segments, 1)
+	w, h, d := width*0.5, height*0.5, depth*0.5
+
+	g := &geometry{}
+	g.addGrid(mgl32.Vec3{0, 0, d}, mgl32.Vec3{w, 0, 0}, mgl32.Vec3{0, h, 0}, segments, segments)   // front
+	g.addGrid(mgl32.Vec3{0, 0, -d}, mgl32.Vec3{-w, 0, 0}, mgl32.Vec3{0, h, 0}, segments, segments) // back
+	g.addGrid(mgl32.Vec3{w, 0, 0}, mgl32.Vec3{0, 0, -d}, mgl32.Vec3{0, h, 0}, segments, segments)  // right
+	g.addGrid(mgl32.Vec3{-w, 0, 0}, mgl32.Vec3{0, 0, d}, mgl32.Vec3{0, h, 0}, segments, segments)  // left
+	g.addGrid(mgl32.Vec3{0, h, 0}, mgl32.Vec3{w, 0, 0}, mgl32.Vec3{0, 0, -d}, segments, segments)  // top
+	g.addGrid(mgl32.Vec3{0, -h, 0}, mgl32.Vec3{w, 0, 0}, mgl32.Vec3{0, 0, d}, segments, segments)  // bottom
+
+	return newModel(name, g)
+}
+
+// NewPlane creates a flat grid in the XZ plane, facing up (+Y), divided into
+// the given number of quads along the X and Z axes.  The top of the texture
+// is mapped to the -Z edge.
+func NewPlane(name string, width, depth float32, xSegments, zSegments int) *Model {
+	g := &geometry{}
+	g.addGrid(mgl32.Vec3{}, mgl32.Vec3{width * 0.5, 0, 0}, mgl32.Vec3{0, 0, -depth * 0.5}, max(xSegments, 1), max(zSegments, 1))
+	return newModel(name, g)
+}
+
+// NewUVSphere creates a sphere made of the given number of segments (around
+// the Y axis) and rings (from pole to pole), with the texture mapped using an
+// equirectangular projection.
+func NewUVSphere(name string, radius float32, segments, rings int) *Model {
+	rings = max(rings, minRings)
+
+	profile := make([]profilePoint, rings+1)
+	for r := 0; r <= rings; r++ {
+		phi := float64(r) / float64(rings) * math.Pi
+		sin, cos := float32(math.Sin(phi)), float32(math.Cos(phi))
+		profile[r] = profilePoint{radius: radius * sin, y: radius * cos, normal: mgl32.Vec2{sin, cos}, v: 1 - float32(r)/float32(rings)}
+	}
+
+	g := &geometry{}
+	g.addLathe(profile, max(segments, minSegments))
+	return newModel(name, g)
+}
+
+// NewIcosphere creates a sphere by subdividing the faces of an icosahedron
+// the given number of times (each subdivision quadrupling the number of
+// faces), resulting in evenly sized triangles.  The texture is mapped using
+// an equirectangular projection, as with NewUVSphere().
+func NewIcosphere(name string, radius float32, subdivisions int) *Model {
+	subdivisions = min(max(subdivisions, 0), maxIcoSubdivisions)
+
+	t := float32((1 + math.Sqrt(5)) / 2)
+	points := []mgl32.Vec3{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+	}
+	for i := range points {
+		points[i] = points[i].Normalize()
+	}
+
+	triangles := [][3]int{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+
+	for i := 0; i < subdivisions; i++ {
+		midpoints := make(map[[2]int]int)
+		midpoint := func(a, b int) int {
+			key := [2]int{min(a, b), max(a, b)}
+			if index, ok := midpoints[key]; ok {
+				return index
+			}
+			points = append(points, points[a].Add(points[b]).Normalize())
+			midpoints[key] = len(points) - 1
+			return len(points) - 1
+		}
+
+		subdivided := make([][3]int, 0, len(triangles)*4)
+		for _, tri := range triangles {
+			ab, bc, ca := midpoint(tri[0], tri[1]), midpoint(tri[1], tri[2]), midpoint(tri[2], tri[0])
+			subdivided = append(subdivided,
+				[3]int{tri[0], ab, ca}, [3]int{tri[1], bc, ab}, [3]int{tri[2], ca, bc}, [3]int{ab, bc, ca})
+		}
+		triangles = subdivided
+	}
+
+	// Each triangle gets its own vertices, so that those on the seam of the
+	// texture (and at the poles) can be given the texture coordinates that
+	// suit the triangle.
+	g := &geometry{}
+	for _, tri := range triangles {
+		p := [3]mgl32.Vec3{points[tri[0]], points[tri[1]], points[tri[2]]}
+		if p[1].Sub(p[0]).Cross(p[2].Sub(p[0])).Dot(p[0]) < 0 {
+			p[1], p[2] = p[2], p[1]
+		}
+
+		var uvs [3]mgl32.Vec2
+		for i := range p {
+			uvs[i] = sphereUv(p[i])
+		}
+		fixSphereUvs(p, &uvs)
+
+		a := g.addVertex(p[0].Mul(radius), p[0], uvs[0])
+		b := g.addVertex(p[1].Mul(radius), p[1], uvs[1])
+		c := g.addVertex(p[2].Mul(radius), p[2], uvs[2])
+		g.addTriangle(a, b, c)
+	}
+
+	return newModel(name, g)
+}
+
+// NewCylinder creates a capped cylinder, aligned with the Y axis, made of the
+// given number of segments around the axis.
+func NewCylinder(name string, radius, height float32, segments int) *Model {
+	segments = max(segments, minSegments)
+	h := height * 0.5
+
+	g := &geometry{}
+	g.addLathe([]profilePoint{
+		{radius: radius, y: h, normal: mgl32.Vec2{1, 0}, v: 1},
+		{radius: radius, y: -h, normal: mgl32.Vec2{1, 0}, v: 0},
+	}, segments)
+	g.addDisc(h, radius, segments, true)
+	g.addDisc(-h, radius, segments, false)
+
+	return newModel(name, g)
+}
+
+// NewCone creates a cone, aligned with the Y axis and pointing up, with a
+// capped base made of the given number of segments around the axis.
+func NewCone(name string, radius, height float32, segments int) *Model {
+	segments = max(segments, minSegments)
+	h := height * 0.5
+
+	normal := mgl32.Vec2{height, radius}.Normalize()
+
+	g := &geometry{}
+	g.addLathe([]profilePoint{
+		{radius: 0, y: h, normal: normal, v: 1},
+		{radius: radius, y: -h, normal: normal, v: 0},
+	}, segments)
+	g.addDisc(-h, radius, segments, false)
+
+	return newModel(name, g)
+}
+
+// NewTorus creates a torus lying in the XZ plane, where majorRadius is the
+// distance from the center to the middle of the tube and minorRadius is the
+// radius of the tube.  The segment counts determine the subdivision around
+// the Y axis and around the tube, respectively.
+func NewTorus(name string, majorRadius, minorRadius float32, majorSegments, minorSegments int) *Model {
+	minorSegments = max(minorSegments, minSegments)
+
+	profile := make([]profilePoint, minorSegments+1)
+	for j := 0; j <= minorSegments; j++ {
+		phi := float64(j) / float64(minorSegments) * 2 * math.Pi
+		sin, cos := float32(math.Sin(phi)), float32(math.Cos(phi))
+		profile[j] = profilePoint{
+			radius: majorRadius + minorRadius*sin,
+			y:      minorRadius * cos,
+			normal: mgl32.Vec2{sin, cos},
+			v:      1 - float32(j)/float32(minorSegments),
+		}
+	}
+
+	g := &geometry{}
+	g.addLathe(profile, max(majorSegments, minSegments))
+	return newModel(name, g)
+}
+
+// NewCapsule creates a capsule aligned with the Y axis, with the given total
+// height (including the hemispherical caps, so no less than twice the
+// radius), made of the given number of segments around the axis and rings
+// in each hemisphere.
+func NewCapsule(name string, radius, height float32, segments, rings int) *Model {
+	rings = max(rings, 1)
+	halfLength := max(height*0.5-radius, 0) // half the length of the cylindrical part
+
+	// The texture coordinate along the profile is proportional to the
+	// distance travelled along the surface.
+	quarter := radius * math.Pi * 0.5
+	total := 2*quarter + 2*halfLength
+
+	profile := make([]profilePoint, 0, 2*(rings+1))
+	for k := 0; k <= rings; k++ {
+		phi := float64(k) / float64(rings) * math.Pi * 0.5
+		sin, cos := float32(math.Sin(phi)), float32(math.Cos(phi))
+		distance := radius * float32(phi)
+		profile = append(profile, profilePoint{radius: radius * sin, y: halfLength + radius*cos, normal: mgl32.Vec2{sin, cos}, v: 1 - distance/total})
+	}
+	for k := 0; k <= rings; k++ {
+		phi := math.Pi*0.5 + float64(k)/float64(rings)*math.Pi*0.5
+		sin, cos := float32(math.Sin(phi)), float32(math.Cos(phi))
+		distance := quarter + 2*halfLength + radius*float32(phi-math.Pi*0.5)
+		profile = append(profile, profilePoint{radius: radius * sin, y: -halfLength + radius*cos, normal: mgl32.Vec2{sin, cos}, v: 1 - distance/total})
+	}
+
+	g := &geometry{}
+	g.addLathe(profile, max(segments, minSegments))
+	return newModel(name, g)
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+// sphereUv Returns the equirectangular texture coordinates of the given
+// point on the unit sphere, with U starting at +Z (as with NewUVSphere()).
+func sphereUv(p mgl32.Vec3) mgl32.Vec2 {
+	u := float32(math.Atan2(float64(p.X()), float64(p.Z())) / (2 * math.Pi))
+	if u < 0 {
+		u += 1
+	}
+	v := 0.5 + float32(math.Asin(float64(mgl32.Clamp(p.Y(), -1, 1)))/math.Pi)
+	return mgl32.Vec2{u, v}
+}
+
+// fixSphereUvs Adjusts the texture coordinates of a triangle that crosses the
+// seam of the texture (so that U does not wrap around within the triangle)
+// or that touches a pole (where U is undefined).
+func fixSphereUvs(p [3]mgl32.Vec3, uvs *[3]mgl32.Vec2) {
+	isPole := func(i int) bool {
+		return p[i].X()*p[i].X()+p[i].Z()*p[i].Z() < 1e-10
+	}
+
+	minU, maxU := float32(1), float32(0)
+	for i := range p {
+		if !isPole(i) {
+			minU = min(minU, uvs[i][0])
+			maxU = max(maxU, uvs[i][0])
+		}
+	}
+
+	if maxU-minU > 0.5 {
+		for i := range uvs {
+			if uvs[i][0] < 0.5 {
+				uvs[i][0] += 1
+			}
+		}
+	}
+
+	for i := range p {
+		if isPole(i) {
+			uvs[i][0] = (uvs[(i+1)%3][0] + uvs[(i+2)%3][0]) * 0.5
+		}
+	}
+}
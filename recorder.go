@@ -0,0 +1,451 @@
+package gfx
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultFrameRecorderName          = "FrameRecorder"
+	defaultFrameRecorderInterval      = 1
+	defaultFrameRecorderQueueSize     = 60
+	defaultFrameRecorderGifDelay      = 2 // hundredths of a second
+	defaultFrameRecorderGifMaxFrames  = 1500
+	defaultFrameRecorderFilenameFmt   = "frame_%06d.png"
+	defaultFrameRecorderGifFilename   = "recording.gif"
+	defaultFrameRecorderDirectoryPerm = 0755
+)
+
+/******************************************************************************
+ frameCapturer
+******************************************************************************/
+
+// frameCapturer services are given the opportunity to read the framebuffer
+// after all objects have been drawn but before the buffers are swapped.
+type frameCapturer interface {
+	captureFrame()
+}
+
+/******************************************************************************
+ RecordingFormat
+******************************************************************************/
+
+type RecordingFormat int
+
+const (
+	// PngSequenceRecording Frames are saved as individual, sequentially
+	// numbered PNG files in the output directory.
+	PngSequenceRecording RecordingFormat = iota
+
+	// AnimatedGifRecording Frames are encoded as a single animated GIF,
+	// written to the output path when recording is stopped.  As the frames
+	// are kept in memory until then, at most 1500 frames are recorded by
+	// default (see FrameRecorder.SetGifMaxFrames()) and any captured after
+	// that are dropped.
+	AnimatedGifRecording
+)
+
+/******************************************************************************
+ FrameRecorder
+******************************************************************************/
+
+// FrameRecorder A Service that, once started, captures every Nth frame
+// rendered by the Window to which it was added.  Captured frames are
+// placed in a bounded queue and encoded by a separate routine, so the
+// render thread is never blocked by encoding/disk I/O; if the queue is
+// full, frames are dropped (see DroppedFrames()).
+type FrameRecorder struct {
+	ServiceBase
+
+	format     RecordingFormat
+	outputPath string
+	interval   int
+	queueSize  int
+	gifDelay   int
+	gifMax     int
+
+	recording   atomic.Bool
+	frameIndex  int
+	savedFrames atomic.Int64
+	dropped     atomic.Int64
+
+	frameQueue  chan *image.RGBA
+	encoderDone chan struct{}
+	err         error
+
+	stateMutex sync.Mutex
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (r *FrameRecorder) Init() (ok bool) {
+	if r.Initialized() {
+		return true
+	}
+
+	r.initialized.Store(true)
+	return true
+}
+
+func (r *FrameRecorder) Close() {
+	if !r.Initialized() {
+		return
+	}
+
+	r.Stop()
+	r.initialized.Store(false)
+}
+
+/******************************************************************************
+ frameCapturer Implementation
+******************************************************************************/
+
+func (r *FrameRecorder) captureFrame() {
+	if !r.recording.Load() || !r.Enabled() {
+		return
+	}
+
+	r.stateMutex.Lock()
+	defer r.stateMutex.Unlock()
+
+	if r.frameQueue == nil {
+		return
+	}
+
+	r.frameIndex++
+	if r.frameIndex%r.interval != 0 {
+		return
+	}
+
+	if len(r.frameQueue) == cap(r.frameQueue) {
+		r.dropped.Add(1)
+		return
+	}
+
+	r.frameQueue <- r.window.readPixels()
+}
+
+/******************************************************************************
+ FrameRecorder Functions
+******************************************************************************/
+
+func (r *FrameRecorder) encodePngSequence(queue <-chan *image.RGBA, outputDir string) {
+	frameNumber := 0
+	for img := range queue {
+		if r.Err() != nil {
+			continue // drain the queue
+		}
+
+		filename := filepath.Join(outputDir, fmt.Sprintf(defaultFrameRecorderFilenameFmt, frameNumber))
+		frameNumber++
+
+		file, err := os.Create(filename)
+		if err != nil {
+			r.setErr(fmt.Errorf("error creating frame file: %w", err))
+			continue
+		}
+
+		if err = png.Encode(file, img); err != nil {
+			_ = file.Close()
+			r.setErr(fmt.Errorf("error encoding frame: %w", err))
+			continue
+		}
+
+		if err = file.Close(); err != nil {
+			r.setErr(fmt.Errorf("error closing frame file: %w", err))
+			continue
+		}
+
+		r.savedFrames.Add(1)
+	}
+}
+
+func (r *FrameRecorder) encodeAnimatedGif(queue <-chan *image.RGBA, outputFile string, delay, maxFrames int) {
+	anim := &gif.GIF{}
+	for img := range queue {
+		// The queue is still drained, so that capturing is not blocked
+		if len(anim.Image) >= maxFrames {
+			r.dropped.Add(1)
+			continue
+		}
+
+		paletted := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, img.Bounds(), img, image.Point{})
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	if len(anim.Image) == 0 {
+		return
+	}
+
+	file, err := os.Create(outputFile)
+	if err != nil {
+		r.setErr(fmt.Errorf("error creating GIF file: %w", err))
+		return
+	}
+
+	if err = gif.EncodeAll(file, anim); err != nil {
+		_ = file.Close()
+		r.setErr(fmt.Errorf("error encoding GIF: %w", err))
+		return
+	}
+
+	if err = file.Close(); err != nil {
+		r.setErr(fmt.Errorf("error closing GIF file: %w", err))
+		return
+	}
+
+	r.savedFrames.Store(int64(len(anim.Image)))
+}
+
+func (r *FrameRecorder) setErr(err error) {
+	r.stateMutex.Lock()
+	if r.err == nil {
+		r.err = err
+	}
+	r.stateMutex.Unlock()
+}
+
+// Start Begin capturing frames, discarding the state of any previous
+// recording.  Can be called from any routine.
+func (r *FrameRecorder) Start() error {
+	r.stateMutex.Lock()
+
+	if r.recording.Load() {
+		r.stateMutex.Unlock()
+		return nil
+	}
+
+	switch r.format {
+	case PngSequenceRecording:
+		if err := os.MkdirAll(r.outputPath, defaultFrameRecorderDirectoryPerm); err != nil {
+			r.stateMutex.Unlock()
+			return fmt.Errorf("error creating recording directory: %w", err)
+		}
+	case AnimatedGifRecording:
+		if dir := filepath.Dir(r.outputPath); dir != "." {
+			if err := os.MkdirAll(dir, defaultFrameRecorderDirectoryPerm); err != nil {
+				r.stateMutex.Unlock()
+				return fmt.Errorf("error creating recording directory: %w", err)
+			}
+		}
+	default:
+		r.stateMutex.Unlock()
+		return fmt.Errorf("unsupported recording format: %d", r.format)
+	}
+
+	r.err = nil
+	r.frameIndex = 0
+	r.savedFrames.Store(0)
+	r.dropped.Store(0)
+
+	queue := make(chan *image.RGBA, r.queueSize)
+	done := make(chan struct{})
+	r.frameQueue = queue
+	r.encoderDone = done
+
+	format := r.format
+	outputPath := r.outputPath
+	delay := r.gifDelay
+	maxFrames := r.gifMax
+	go func() {
+		switch format {
+		case PngSequenceRecording:
+			r.encodePngSequence(queue, outputPath)
+		case AnimatedGifRecording:
+			r.encodeAnimatedGif(queue, outputPath, delay, maxFrames)
+		}
+		close(done)
+	}()
+
+	r.recording.Store(true)
+	r.stateMutex.Unlock()
+	return nil
+}
+
+// Stop Stop capturing frames and block until all frames captured thus far
+// have been encoded/written, returning the first error encountered while
+// doing so (if any).  Can be called from any routine.
+func (r *FrameRecorder) Stop() error {
+	r.stateMutex.Lock()
+
+	if !r.recording.Load() {
+		err := r.err
+		r.stateMutex.Unlock()
+		return err
+	}
+
+	r.recording.Store(false)
+	close(r.frameQueue)
+	r.frameQueue = nil
+	done := r.encoderDone
+	r.stateMutex.Unlock()
+
+	<-done
+
+	return r.Err()
+}
+
+func (r *FrameRecorder) Recording() bool {
+	return r.recording.Load()
+}
+
+func (r *FrameRecorder) Err() (err error) {
+	r.stateMutex.Lock()
+	err = r.err
+	r.stateMutex.Unlock()
+	return
+}
+
+// SavedFrames returns the number of frames written by the current/last
+// recording.  For animated GIFs, this value is only updated once the
+// recording has been stopped.
+func (r *FrameRecorder) SavedFrames() int {
+	return int(r.savedFrames.Load())
+}
+
+// DroppedFrames returns the number of frames that could not be captured
+// by the current/last recording because the capture queue was full or,
+// for animated GIFs, because the maximum frame count was reached.
+func (r *FrameRecorder) DroppedFrames() int {
+	return int(r.dropped.Load())
+}
+
+func (r *FrameRecorder) Format() (format RecordingFormat) {
+	r.stateMutex.Lock()
+	format = r.format
+	r.stateMutex.Unlock()
+	return
+}
+
+func (r *FrameRecorder) OutputPath() (outputPath string) {
+	r.stateMutex.Lock()
+	outputPath = r.outputPath
+	r.stateMutex.Unlock()
+	return
+}
+
+// SetOutputPath sets the directory to which PNG frames will be written
+// or, when recording an animated GIF, the path of the GIF file.  Takes
+// effect the next time recording is started.
+func (r *FrameRecorder) SetOutputPath(outputPath string) *FrameRecorder {
+	r.stateMutex.Lock()
+	r.outputPath = outputPath
+	r.stateMutex.Unlock()
+	return r
+}
+
+func (r *FrameRecorder) FrameInterval() (interval int) {
+	r.stateMutex.Lock()
+	interval = r.interval
+	r.stateMutex.Unlock()
+	return
+}
+
+// SetFrameInterval sets N, where every Nth frame will be captured.
+func (r *FrameRecorder) SetFrameInterval(interval int) *FrameRecorder {
+	if interval < 1 {
+		interval = 1
+	}
+	r.stateMutex.Lock()
+	r.interval = interval
+	r.stateMutex.Unlock()
+	return r
+}
+
+func (r *FrameRecorder) QueueSize() (size int) {
+	r.stateMutex.Lock()
+	size = r.queueSize
+	r.stateMutex.Unlock()
+	return
+}
+
+// SetQueueSize sets the maximum number of captured frames waiting to be
+// encoded.  Takes effect the next time recording is started.
+func (r *FrameRecorder) SetQueueSize(size int) *FrameRecorder {
+	if size < 1 {
+		size = 1
+	}
+	r.stateMutex.Lock()
+	r.queueSize = size
+	r.stateMutex.Unlock()
+	return r
+}
+
+func (r *FrameRecorder) GifFrameDelay() (delay int) {
+	r.stateMutex.Lock()
+	delay = r.gifDelay
+	r.stateMutex.Unlock()
+	return
+}
+
+// SetGifFrameDelay sets the delay between frames of an animated GIF,
+// in hundredths of a second.
+func (r *FrameRecorder) SetGifFrameDelay(delay int) *FrameRecorder {
+	r.stateMutex.Lock()
+	r.gifDelay = delay
+	r.stateMutex.Unlock()
+	return r
+}
+
+func (r *FrameRecorder) GifMaxFrames() (maxFrames int) {
+	r.stateMutex.Lock()
+	maxFrames = r.gifMax
+	r.stateMutex.Unlock()
+	return
+}
+
+// SetGifMaxFrames sets the maximum number of frames in an animated GIF,
+// which are held in memory until the recording is stopped, after which
+// frames are dropped (see DroppedFrames()).  Takes effect the next time
+// recording is started.
+func (r *FrameRecorder) SetGifMaxFrames(maxFrames int) *FrameRecorder {
+	if maxFrames < 1 {
+		maxFrames = 1
+	}
+	r.stateMutex.Lock()
+	r.gifMax = maxFrames
+	r.stateMutex.Unlock()
+	return r
+}
+
+/******************************************************************************
+ New FrameRecorder Function
+******************************************************************************/
+
+// NewFrameRecorder creates a recorder that will write frames in the given
+// format.  For PNG sequences, outputPath is the directory to which frames
+// will be written; for animated GIFs it is the path of the file itself.
+// If not provided, the current directory (or "recording.gif") is used.
+func NewFrameRecorder(format RecordingFormat, outputPath ...string) *FrameRecorder {
+	r := &FrameRecorder{
+		format:    format,
+		interval:  defaultFrameRecorderInterval,
+		queueSize: defaultFrameRecorderQueueSize,
+		gifDelay:  defaultFrameRecorderGifDelay,
+		gifMax:    defaultFrameRecorderGifMaxFrames,
+	}
+
+	if len(outputPath) > 0 {
+		r.outputPath = outputPath[0]
+	} else if format == AnimatedGifRecording {
+		r.outputPath = defaultFrameRecorderGifFilename
+	} else {
+		r.outputPath = "."
+	}
+
+	r.SetName(defaultFrameRecorderName)
+	r.enabled.Store(true)
+	return r
+}
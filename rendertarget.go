@@ -0,0 +1,502 @@
+package gfx
+
+import (
+	"fmt"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"image/color"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultRenderTargetSamples = 4
+)
+
+/******************************************************************************
+ windowSetter
+******************************************************************************/
+
+// windowSetter objects are not WindowObjects but still need a reference to
+// the window to which they are added, which is given to them by the window.
+type windowSetter interface {
+	setWindow(window *Window)
+}
+
+/******************************************************************************
+ RenderTarget
+******************************************************************************/
+
+// RenderTarget An Object that draws its own set of WindowObjects into an
+// offscreen color/depth buffer, at its own resolution, and that implements
+// the Texture interface so that the result can be used like any other
+// texture, such as with Shape2D.SetTexture(), View.SetTexture() or as a map
+// of a 3D material.  Rendering happens when the target is updated, which is
+// before any object is drawn, so the order in which targets and the objects
+// using them are added to the window does not matter.  If multisampling is
+// enabled for the window, the target is multisampled as well.
+//
+// The objects added to a target are sized for the target's resolution, but
+// objects that maintain their aspect ratio still do so based on the size of
+// the window, so either give the target the same aspect ratio as the window
+// or disable MaintainAspectRatio on those objects.
+type RenderTarget struct {
+	ObjectBase
+
+	window *Window
+
+	objects   []WindowObject
+	initQueue []WindowObject
+
+	width  int
+	height int
+
+	clearColor mgl32.Vec4
+
+	frameBuffer     uint32
+	colorTexture    uint32
+	depthBuffer     uint32
+	msaaFrameBuffer uint32
+	msaaColorBuffer uint32
+	msaaDepthBuffer uint32
+	samples         int32
+
+	uWrapMode     int32
+	vWrapMode     int32
+	minFilterMode int32
+	magFilterMode int32
+	useMipMaps    bool
+
+	framebufferBak int32
+	viewportBak    [4]int32
+
+	srcLibrary *AssetLibrary
+	protected  atomic.Bool
+
+	sizeChanged bool
+	stateMutex  sync.Mutex
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (t *RenderTarget) Init() (ok bool) {
+	if t.Initialized() {
+		return true
+	}
+
+	// As a Texture, the target may be initialized by an asset (e.g., a
+	// material) before it has been added to a window
+	if t.window == nil {
+		return false
+	}
+
+	t.stateMutex.Lock()
+	t.initFrameBuffer()
+	window, width, height := t.window, t.width, t.height
+	initQueue := t.takeInitQueue()
+	t.stateMutex.Unlock()
+
+	if ok = t.initObjects(initQueue, window, width, height); !ok {
+		return false
+	}
+
+	return t.ObjectBase.Init()
+}
+
+func (t *RenderTarget) Update(deltaTime int64) (ok bool) {
+	if !t.ObjectBase.Update(deltaTime) {
+		return false
+	}
+
+	// Only the state of the target is read under the lock, as its objects
+	// may call back into it (e.g., to add objects or to get its size)
+	t.stateMutex.Lock()
+	resized := t.sizeChanged
+	if resized {
+		t.sizeChanged = false
+		t.closeFrameBuffer()
+		t.initFrameBuffer()
+	}
+	window, width, height := t.window, t.width, t.height
+	clearColor := t.clearColor
+	initQueue := t.takeInitQueue()
+	objects := t.copyObjects()
+	t.stateMutex.Unlock()
+
+	if resized {
+		t.resizeObjects(objects, width, height)
+	}
+
+	ok = t.initObjects(initQueue, window, width, height)
+
+	for _, o := range objects {
+		o.Update(deltaTime)
+	}
+
+	t.beginRender(width, height, clearColor)
+	for _, o := range objects {
+		o.Draw(deltaTime)
+	}
+	t.endRender(width, height)
+
+	return ok
+}
+
+func (t *RenderTarget) Close() {
+	if !t.Initialized() {
+		return
+	}
+
+	t.stateMutex.Lock()
+	objects := t.copyObjects()
+	t.initQueue = append(t.initQueue[:0], t.objects...)
+	t.closeFrameBuffer()
+	t.stateMutex.Unlock()
+
+	for _, o := range objects {
+		o.Close()
+	}
+
+	t.ObjectBase.Close()
+}
+
+/******************************************************************************
+ Resizer Implementation
+******************************************************************************/
+
+// Resize is called when the window is resized and, since the size of the
+// target is independent of the window's size, only refreshes the layout of
+// the objects added to the target.
+func (t *RenderTarget) Resize(_, _ int) {
+	if !t.Initialized() {
+		return
+	}
+
+	t.stateMutex.Lock()
+	objects := t.copyObjects()
+	width, height := t.width, t.height
+	t.stateMutex.Unlock()
+
+	t.resizeObjects(objects, width, height)
+}
+
+/******************************************************************************
+ Asset Implementation
+******************************************************************************/
+
+func (t *RenderTarget) Source() any {
+	return nil
+}
+
+func (t *RenderTarget) SourceLibrary() *AssetLibrary {
+	return t.srcLibrary
+}
+
+func (t *RenderTarget) SetSourceLibrary(library *AssetLibrary) Asset {
+	t.srcLibrary = library
+	return t
+}
+
+func (t *RenderTarget) Protected() bool {
+	return t.protected.Load()
+}
+
+func (t *RenderTarget) SetProtected(protected bool) Asset {
+	t.protected.Store(protected)
+	return t
+}
+
+/******************************************************************************
+ Texture Implementation
+******************************************************************************/
+
+func (t *RenderTarget) GlName() uint32 {
+	return t.colorTexture
+}
+
+func (t *RenderTarget) Width() int {
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	return t.width
+}
+
+func (t *RenderTarget) Height() int {
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	return t.height
+}
+
+/******************************************************************************
+ windowSetter Implementation
+******************************************************************************/
+
+func (t *RenderTarget) setWindow(window *Window) {
+	t.stateMutex.Lock()
+	t.window = window
+	for _, o := range t.objects {
+		o.SetWindow(window)
+	}
+	t.stateMutex.Unlock()
+}
+
+/******************************************************************************
+ RenderTarget Functions
+******************************************************************************/
+
+func (t *RenderTarget) initFrameBuffer() {
+	width := int32(t.width)
+	height := int32(t.height)
+
+	t.samples = 0
+	if t.window.MultiSamplingEnabled() {
+		t.samples = defaultRenderTargetSamples
+	}
+
+	var frameBufferBak int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &frameBufferBak)
+
+	gl.GenTextures(1, &t.colorTexture)
+	gl.BindTexture(gl.TEXTURE_2D, t.colorTexture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, t.uWrapMode)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, t.vWrapMode)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, t.minFilterMode)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, t.magFilterMode)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	if t.useMipMaps {
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+	}
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.GenFramebuffers(1, &t.frameBuffer)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, t.frameBuffer)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, t.colorTexture, 0)
+
+	gl.GenRenderbuffers(1, &t.depthBuffer)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, t.depthBuffer)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, width, height)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, t.depthBuffer)
+
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		panic(fmt.Errorf("render target framebuffer incomplete: status 0x%x", status))
+	}
+
+	// Multisampled renderbuffers cannot be sampled directly, so they
+	// are resolved (blitted) into the texture after each render
+	if t.samples > 0 {
+		gl.GenFramebuffers(1, &t.msaaFrameBuffer)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, t.msaaFrameBuffer)
+
+		gl.GenRenderbuffers(1, &t.msaaColorBuffer)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, t.msaaColorBuffer)
+		gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, t.samples, gl.RGBA8, width, height)
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, t.msaaColorBuffer)
+
+		gl.GenRenderbuffers(1, &t.msaaDepthBuffer)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, t.msaaDepthBuffer)
+		gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, t.samples, gl.DEPTH24_STENCIL8, width, height)
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, t.msaaDepthBuffer)
+
+		if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+			panic(fmt.Errorf("render target multisample framebuffer incomplete: status 0x%x", status))
+		}
+	}
+
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(frameBufferBak))
+}
+
+func (t *RenderTarget) closeFrameBuffer() {
+	gl.DeleteFramebuffers(1, &t.frameBuffer)
+	gl.DeleteTextures(1, &t.colorTexture)
+	gl.DeleteRenderbuffers(1, &t.depthBuffer)
+	t.frameBuffer = 0
+	t.colorTexture = 0
+	t.depthBuffer = 0
+
+	if t.msaaFrameBuffer != 0 {
+		gl.DeleteFramebuffers(1, &t.msaaFrameBuffer)
+		gl.DeleteRenderbuffers(1, &t.msaaColorBuffer)
+		gl.DeleteRenderbuffers(1, &t.msaaDepthBuffer)
+		t.msaaFrameBuffer = 0
+		t.msaaColorBuffer = 0
+		t.msaaDepthBuffer = 0
+	}
+}
+
+// takeInitQueue Returns the objects waiting to be initialized and empties
+// the queue, which must be done while holding the state mutex.
+func (t *RenderTarget) takeInitQueue() []WindowObject {
+	initQueue := t.initQueue
+	t.initQueue = nil
+	return initQueue
+}
+
+// copyObjects Returns a copy of the objects added to the target, which must
+// be done while holding the state mutex.
+func (t *RenderTarget) copyObjects() []WindowObject {
+	objects := make([]WindowObject, len(t.objects))
+	copy(objects, t.objects)
+	return objects
+}
+
+func (t *RenderTarget) initObjects(objects []WindowObject, window *Window, width, height int) (ok bool) {
+	ok = true
+	for _, o := range objects {
+		o.SetWindow(window)
+		initOk := o.Init()
+		ok = ok && initOk
+	}
+	t.resizeObjects(objects, width, height)
+	return
+}
+
+func (t *RenderTarget) resizeObjects(objects []WindowObject, width, height int) {
+	for _, o := range objects {
+		o.Resize(width, height)
+	}
+}
+
+func (t *RenderTarget) beginRender(width, height int, clearColor mgl32.Vec4) {
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &t.framebufferBak)
+	gl.GetIntegerv(gl.VIEWPORT, &t.viewportBak[0])
+
+	if t.samples > 0 {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, t.msaaFrameBuffer)
+	} else {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, t.frameBuffer)
+	}
+
+	gl.Viewport(0, 0, int32(width), int32(height))
+	gl.ClearColor(clearColor[0], clearColor[1], clearColor[2], clearColor[3])
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+}
+
+func (t *RenderTarget) endRender(width, height int) {
+	if t.samples > 0 {
+		w, h := int32(width), int32(height)
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, t.msaaFrameBuffer)
+		gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, t.frameBuffer)
+		gl.BlitFramebuffer(0, 0, w, h, 0, 0, w, h, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	}
+
+	if t.useMipMaps {
+		gl.BindTexture(gl.TEXTURE_2D, t.colorTexture)
+		gl.GenerateMipmap(gl.TEXTURE_2D)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(t.framebufferBak))
+	gl.Viewport(t.viewportBak[0], t.viewportBak[1], t.viewportBak[2], t.viewportBak[3])
+}
+
+func (t *RenderTarget) Window() *Window {
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	return t.window
+}
+
+// SetSize sets the resolution of the target, in pixels, recreating its
+// buffers the next time it is updated.
+func (t *RenderTarget) SetSize(width, height int) *RenderTarget {
+	t.stateMutex.Lock()
+	t.width = max(width, 1)
+	t.height = max(height, 1)
+	t.sizeChanged = t.Initialized()
+	t.stateMutex.Unlock()
+	return t
+}
+
+func (t *RenderTarget) ClearColor() color.RGBA {
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	return FloatArrayToRgba(t.clearColor)
+}
+
+// SetClearColor sets the color the target is cleared to before its objects
+// are drawn, which is transparent by default.
+func (t *RenderTarget) SetClearColor(rgba color.RGBA) *RenderTarget {
+	t.stateMutex.Lock()
+	t.clearColor = RgbaToFloatArray(rgba)
+	t.stateMutex.Unlock()
+	return t
+}
+
+// AddObject adds an object to be drawn into the target.  Objects can be
+// added at any time, from any goroutine, and are initialized (if they have
+// not been already) the next time the target is updated.
+func (t *RenderTarget) AddObject(object WindowObject) *RenderTarget {
+	return t.AddObjects(object)
+}
+
+func (t *RenderTarget) AddObjects(objects ...WindowObject) *RenderTarget {
+	t.stateMutex.Lock()
+	for _, o := range objects {
+		if o == nil {
+			continue
+		}
+		o.SetWindow(t.window)
+		t.objects = append(t.objects, o)
+		t.initQueue = append(t.initQueue, o)
+	}
+	t.stateMutex.Unlock()
+	return t
+}
+
+// RemoveObject removes the object from the target, without closing it.
+func (t *RenderTarget) RemoveObject(object WindowObject) {
+	t.stateMutex.Lock()
+	for i, o := range t.objects {
+		if o == object {
+			t.objects = append(t.objects[:i], t.objects[i+1:]...)
+			break
+		}
+	}
+	for i, o := range t.initQueue {
+		if o == object {
+			t.initQueue = append(t.initQueue[:i], t.initQueue[i+1:]...)
+			break
+		}
+	}
+	t.stateMutex.Unlock()
+}
+
+func (t *RenderTarget) Objects() []WindowObject {
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	return t.copyObjects()
+}
+
+/******************************************************************************
+ New RenderTarget Function
+******************************************************************************/
+
+// NewRenderTarget creates a target with the given resolution, in pixels,
+// and (optionally) the texture configuration used when sampling it, which
+// by default uses linear filtering (without mipmaps) and clamps to edges.
+// The target must be added to a window, like any other object, to be
+// rendered.
+func NewRenderTarget(name string, width, height int, config ...*TextureConfig) *RenderTarget {
+	if len(config) == 0 {
+		config = append(config, NewTextureConfig(LowQuality, Clamp))
+	}
+
+	cfg := config[0]
+	useMipMaps, minFilterMode, magFilterMode := cfg.GetFilterConfig()
+
+	t := &RenderTarget{
+		width:         max(width, 1),
+		height:        max(height, 1),
+		uWrapMode:     int32(cfg.UWrapMode),
+		vWrapMode:     int32(cfg.VWrapMode),
+		minFilterMode: minFilterMode,
+		magFilterMode: magFilterMode,
+		useMipMaps:    useMipMaps,
+	}
+
+	t.SetName(name)
+	t.enabled.Store(true)
+
+	return t
+}
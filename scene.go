@@ -0,0 +1,687 @@
+package gfx
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultSceneName     = "Scene"
+	defaultSceneRootName = "root"
+	scenePathSeparator   = "/"
+)
+
+/******************************************************************************
+ SceneNode
+******************************************************************************/
+
+// SceneNode A node of the tree held by a Scene, which is either an empty
+// group or holds a model (drawn by a Shape3D), a light or a camera.  The
+// transform of a node is relative to its parent node: its world matrix is
+// the product of its parent's world matrix and its own local matrix, so that
+// rotating a node rotates all of its descendants about its position, as
+// with the joints of a robot arm.  Disabling or hiding a node also disables
+// or hides its descendants.  Nodes can be modified from any goroutine.
+type SceneNode struct {
+	ObjectTransform
+
+	name    string
+	enabled bool
+	visible bool
+
+	parent   *SceneNode
+	children []*SceneNode
+
+	shape  *Shape3D
+	light  Light
+	camera LookAtCamera
+
+	// lightEnabled Whether the light was last enabled or disabled by the
+	// node, which only does so when that changes (see update()), so that
+	// the light can still be toggled directly.  Only used by the scene.
+	lightEnabled      bool
+	lightEnabledKnown bool
+
+	stateMutex sync.Mutex
+}
+
+/******************************************************************************
+ Transform Implementation
+******************************************************************************/
+
+func (n *SceneNode) WorldMatrix() mgl32.Mat4 {
+	local := n.LocalMatrix()
+	if parent := n.Parent(); parent != nil {
+		return parent.WorldMatrix().Mul4(local)
+	}
+	return local
+}
+
+func (n *SceneNode) WorldPosition() mgl32.Vec3 {
+	return n.WorldMatrix().Col(3).Vec3()
+}
+
+// WorldRotation returns the rotation of the node in world space, as an
+// axis-angle rotation vector (see Transform.SetRotation()).
+func (n *SceneNode) WorldRotation() mgl32.Vec3 {
+	worldMat := n.WorldMatrix()
+	scale := matrixScale(worldMat)
+	if scale.X() == 0 || scale.Y() == 0 || scale.Z() == 0 {
+		return mgl32.Vec3{}
+	}
+
+	rotMat := mgl32.Mat3FromCols(
+		worldMat.Col(0).Vec3().Mul(1/scale.X()),
+		worldMat.Col(1).Vec3().Mul(1/scale.Y()),
+		worldMat.Col(2).Vec3().Mul(1/scale.Z()))
+	rot := mgl32.Mat4ToQuat(rotMat.Mat4()).Normalize()
+	if rot.W < 0 {
+		rot = rot.Scale(-1)
+	}
+
+	sinHalfAngle := rot.V.Len()
+	if sinHalfAngle < 0.0001 {
+		return mgl32.Vec3{}
+	}
+	angle := 2 * float32(math.Atan2(float64(sinHalfAngle), float64(rot.W)))
+	return rot.V.Mul(angle / sinHalfAngle)
+}
+
+func (n *SceneNode) WorldScale() mgl32.Vec3 {
+	return matrixScale(n.WorldMatrix())
+}
+
+/******************************************************************************
+ SceneNode Functions
+******************************************************************************/
+
+func (n *SceneNode) Name() (name string) {
+	n.stateMutex.Lock()
+	name = n.name
+	n.stateMutex.Unlock()
+	return
+}
+
+// SetName sets the name of the node, which is used to find it by path (see
+// Find()) and so should not contain the path separator ("/").
+func (n *SceneNode) SetName(name string) *SceneNode {
+	n.stateMutex.Lock()
+	n.name = name
+	n.stateMutex.Unlock()
+	return n
+}
+
+func (n *SceneNode) Enabled() (enabled bool) {
+	n.stateMutex.Lock()
+	enabled = n.enabled
+	n.stateMutex.Unlock()
+	return
+}
+
+// SetEnabled determines whether the node, and its descendants, take part in
+// the scene: shapes are updated and drawn, and lights are enabled, only if
+// their node and all of its ancestors are enabled.  Defaults to true.
+func (n *SceneNode) SetEnabled(enabled bool) *SceneNode {
+	n.stateMutex.Lock()
+	n.enabled = enabled
+	n.stateMutex.Unlock()
+	return n
+}
+
+func (n *SceneNode) Visible() (visible bool) {
+	n.stateMutex.Lock()
+	visible = n.visible
+	n.stateMutex.Unlock()
+	return
+}
+
+// SetVisibility determines whether the shapes of the node and its
+// descendants are drawn (while still being updated).  Defaults to true.
+func (n *SceneNode) SetVisibility(visible bool) *SceneNode {
+	n.stateMutex.Lock()
+	n.visible = visible
+	n.stateMutex.Unlock()
+	return n
+}
+
+// WorldEnabled returns true if the node and all of its ancestors are enabled.
+func (n *SceneNode) WorldEnabled() bool {
+	for node := n; node != nil; node = node.Parent() {
+		if !node.Enabled() {
+			return false
+		}
+	}
+	return true
+}
+
+// WorldVisible returns true if the node and all of its ancestors are visible.
+func (n *SceneNode) WorldVisible() bool {
+	for node := n; node != nil; node = node.Parent() {
+		if !node.Visible() {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *SceneNode) Parent() (parent *SceneNode) {
+	n.stateMutex.Lock()
+	parent = n.parent
+	n.stateMutex.Unlock()
+	return
+}
+
+func (n *SceneNode) Children() []*SceneNode {
+	n.stateMutex.Lock()
+	children := make([]*SceneNode, len(n.children))
+	copy(children, n.children)
+	n.stateMutex.Unlock()
+	return children
+}
+
+// AddChild adds the given node as a child of this one, removing it from its
+// previous parent, if any.
+func (n *SceneNode) AddChild(child *SceneNode) *SceneNode {
+	if child == nil || child == n {
+		return n
+	}
+
+	if parent := child.Parent(); parent != nil {
+		parent.RemoveChild(child)
+	}
+
+	n.stateMutex.Lock()
+	n.children = append(n.children, child)
+	n.stateMutex.Unlock()
+
+	child.stateMutex.Lock()
+	child.parent = n
+	child.stateMutex.Unlock()
+	return n
+}
+
+func (n *SceneNode) AddChildren(children ...*SceneNode) *SceneNode {
+	for _, child := range children {
+		n.AddChild(child)
+	}
+	return n
+}
+
+func (n *SceneNode) RemoveChild(child *SceneNode) *SceneNode {
+	if child == nil {
+		return n
+	}
+
+	removed := false
+	n.stateMutex.Lock()
+	for i, c := range n.children {
+		if c == child {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			removed = true
+			break
+		}
+	}
+	n.stateMutex.Unlock()
+
+	if removed {
+		child.stateMutex.Lock()
+		child.parent = nil
+		child.stateMutex.Unlock()
+	}
+	return n
+}
+
+// Child returns the first child of the node with the given name, or nil if
+// there is none.
+func (n *SceneNode) Child(name string) *SceneNode {
+	for _, child := range n.Children() {
+		if child.Name() == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Find returns the descendant of the node found by following the given
+// path of node names, separated by "/" (e.g., "base/shoulder/elbow"), or nil
+// if there is no such node.
+func (n *SceneNode) Find(path string) *SceneNode {
+	node := n
+	for _, name := range strings.Split(path, scenePathSeparator) {
+		if name == "" {
+			continue
+		}
+		if node = node.Child(name); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// Path returns the names of the ancestors of the node (excluding the root
+// of the tree), and the node itself, separated by "/", which can be used
+// to find the node (see Scene.Node()).
+func (n *SceneNode) Path() string {
+	var names []string
+	for node := n; node.Parent() != nil; node = node.Parent() {
+		names = append([]string{node.Name()}, names...)
+	}
+	return strings.Join(names, scenePathSeparator)
+}
+
+// Shape returns the shape drawing the model held by the node, if any.
+func (n *SceneNode) Shape() *Shape3D {
+	return n.shape
+}
+
+// Light returns the light held by the node, if any, which is positioned at
+// the origin of the node and pointed along its downward (-Y) axis, which
+// is the default direction of lights, each time the scene is updated.  The
+// light is also enabled or disabled whenever the node is (taking its
+// ancestors into account).
+func (n *SceneNode) Light() Light {
+	return n.light
+}
+
+// Camera returns the camera held by the node, if any, which is positioned
+// at the origin of the node, looking along its forward (-Z) axis with its
+// up (+Y) axis as the up vector, each time the scene is updated.
+func (n *SceneNode) Camera() LookAtCamera {
+	return n.camera
+}
+
+// update Positions the light or camera of the node, if any, according to
+// its world matrix.
+func (n *SceneNode) update(enabled bool) {
+	if n.light == nil && n.camera == nil {
+		return
+	}
+
+	worldMat := n.WorldMatrix()
+	position := worldMat.Col(3).Vec3()
+	down := worldMat.Mul4x1(mgl32.Vec4{0, -1, 0, 0}).Vec3().Normalize()
+	forward := worldMat.Mul4x1(mgl32.Vec4{0, 0, -1, 0}).Vec3().Normalize()
+	up := worldMat.Mul4x1(mgl32.Vec4{0, 1, 0, 0}).Vec3().Normalize()
+
+	if n.light != nil {
+		n.light.Lock()
+		switch light := n.light.(type) {
+		case *DirectionalLight:
+			light.Direction = down
+		case *PointLight:
+			light.Position = position
+		case *SpotLight:
+			light.Position = position
+			light.Direction = down
+		}
+		n.light.Unlock()
+
+		if !n.lightEnabledKnown || n.lightEnabled != enabled {
+			n.light.SetEnabled(enabled)
+			n.lightEnabled, n.lightEnabledKnown = enabled, true
+		}
+	}
+
+	if n.camera != nil {
+		n.camera.SetLookAt(position, position.Add(forward), up)
+	}
+}
+
+// walk Calls the given function for the node and each of its descendants,
+// depth first, along with whether they are enabled/visible, taking their
+// ancestors into account.
+func (n *SceneNode) walk(enabled, visible bool, f func(node *SceneNode, enabled, visible bool)) {
+	enabled = enabled && n.Enabled()
+	visible = visible && n.Visible()
+	f(n, enabled, visible)
+	for _, child := range n.Children() {
+		child.walk(enabled, visible, f)
+	}
+}
+
+/******************************************************************************
+ New SceneNode Functions
+******************************************************************************/
+
+// NewSceneNode Creates an empty node, used to group other nodes.
+func NewSceneNode(name string) *SceneNode {
+	return &SceneNode{
+		ObjectTransform: *NewObjectTransform(),
+		name:            name,
+		enabled:         true,
+		visible:         true,
+	}
+}
+
+// NewModelNode Creates a node holding the given model, drawn by a new
+// Shape3D (see Shape()).
+func NewModelNode(name string, model Model) *SceneNode {
+	shape := NewShape3D().SetModel(model)
+	shape.SetName(name)
+	return NewShapeNode(name, shape)
+}
+
+// NewShapeNode Creates a node holding the given shape, which should not also
+// be added to the window or to another node.
+func NewShapeNode(name string, shape *Shape3D) *SceneNode {
+	n := NewSceneNode(name)
+	n.shape = shape
+
+	shape.stateMutex.Lock()
+	shape.sceneNode = n
+	shape.stateMutex.Unlock()
+	return n
+}
+
+// NewLightNode Creates a node holding the given light, which must still be
+// added to the lighting object of the scene.
+func NewLightNode(name string, light Light) *SceneNode {
+	n := NewSceneNode(name)
+	n.light = light
+	return n
+}
+
+// NewCameraNode Creates a node holding the given camera, which can then be
+// used as the camera of the scene, as when attaching it to a moving object.
+func NewCameraNode(name string, camera LookAtCamera) *SceneNode {
+	n := NewSceneNode(name)
+	n.camera = camera
+	return n
+}
+
+/******************************************************************************
+ Scene
+******************************************************************************/
+
+// Scene A WindowObject holding a tree of SceneNode objects, whose shapes are
+// all drawn using the camera, lighting and viewport of the scene.
+type Scene struct {
+	WindowObjectBase
+
+	viewport *Viewport
+	camera   Camera
+	lighting any
+
+	root *SceneNode
+
+	// liveShapes The shapes found in the tree when the scene was last
+	// initialized or updated, so that those of removed nodes can be closed.
+	liveShapes map[*Shape3D]bool
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (s *Scene) Init() (ok bool) {
+	if s.Initialized() {
+		return true
+	}
+
+	s.initViewport()
+
+	ok = true
+	s.liveShapes = make(map[*Shape3D]bool)
+	s.root.walk(true, true, func(node *SceneNode, _, _ bool) {
+		if node.shape != nil {
+			ok = s.initShape(node.shape) && ok
+			s.liveShapes[node.shape] = true
+		}
+	})
+	if !ok {
+		return false
+	}
+
+	return s.WindowObjectBase.Init()
+}
+
+func (s *Scene) Update(deltaTime int64) (ok bool) {
+	if !s.WindowObjectBase.Update(deltaTime) {
+		return false
+	}
+
+	live := make(map[*Shape3D]bool, len(s.liveShapes))
+	s.root.walk(true, true, func(node *SceneNode, enabled, _ bool) {
+		node.update(enabled)
+		if node.shape == nil {
+			return
+		}
+		live[node.shape] = true
+		if enabled && node.shape.Initialized() {
+			node.shape.Update(deltaTime)
+		}
+	})
+	s.closeRemovedShapes(live)
+
+	return true
+}
+
+func (s *Scene) Close() {
+	if !s.Initialized() {
+		return
+	}
+
+	s.root.walk(true, true, func(node *SceneNode, _, _ bool) {
+		if node.shape != nil {
+			node.shape.Close()
+		}
+	})
+	s.closeRemovedShapes(nil)
+
+	s.WindowObjectBase.Close()
+}
+
+/******************************************************************************
+ DrawableObject Implementation
+******************************************************************************/
+
+func (s *Scene) Draw(deltaTime int64) (ok bool) {
+	if !s.DrawableObjectBase.Draw(deltaTime) {
+		return false
+	}
+
+	s.root.walk(true, true, func(node *SceneNode, enabled, visible bool) {
+		if node.shape == nil {
+			return
+		}
+		if !node.shape.Initialized() {
+			s.initShape(node.shape)
+			s.liveShapes[node.shape] = true
+		}
+		if enabled && visible {
+			node.shape.Draw(deltaTime)
+		}
+	})
+
+	return s.WindowObjectBase.drawChildren(deltaTime)
+}
+
+/******************************************************************************
+ Resizer Implementation
+******************************************************************************/
+
+func (s *Scene) Resize(newWidth, newHeight int) {
+	if vp := s.Viewport(); vp != nil {
+		vp.SetWindowSize(newWidth, newHeight)
+	}
+
+	s.WindowObjectBase.Resize(newWidth, newHeight)
+}
+
+/******************************************************************************
+ Scene Functions
+******************************************************************************/
+
+func (s *Scene) initViewport() {
+	s.stateMutex.Lock()
+	if s.viewport == nil {
+		s.viewport = NewViewport(s.window.Width(), s.window.Height())
+	}
+	s.stateMutex.Unlock()
+}
+
+// initShape Initializes a shape added to the scene, which shares the
+// scene's viewport, camera and lighting.
+func (s *Scene) initShape(shape *Shape3D) bool {
+	s.stateMutex.Lock()
+	viewport, camera, lighting := s.viewport, s.camera, s.lighting
+	s.stateMutex.Unlock()
+
+	shape.SetWindow(s.window)
+	shape.SetViewport(viewport)
+	if camera != nil {
+		shape.SetCamera(camera)
+	}
+	if lighting != nil {
+		shape.SetLighting(lighting)
+	}
+
+	return shape.Init()
+}
+
+// closeRemovedShapes Closes the shapes found in the tree when the scene was
+// last updated that are not among the given shapes, as their nodes have
+// since been removed.  They are initialized again if added back.
+func (s *Scene) closeRemovedShapes(live map[*Shape3D]bool) {
+	for shape := range s.liveShapes {
+		if !live[shape] {
+			shape.Close()
+		}
+	}
+	s.liveShapes = live
+}
+
+// shapes Returns the initialized shapes of the enabled and visible nodes.
+func (s *Scene) shapes() (shapes []*Shape3D) {
+	s.root.walk(true, true, func(node *SceneNode, enabled, visible bool) {
+		if enabled && visible && node.shape != nil && node.shape.Initialized() {
+			shapes = append(shapes, node.shape)
+		}
+	})
+	return
+}
+
+// Root returns the root node of the scene, to which other nodes are added.
+func (s *Scene) Root() *SceneNode {
+	return s.root
+}
+
+// AddNode adds the given node as a child of the root node.
+func (s *Scene) AddNode(node *SceneNode) *Scene {
+	s.root.AddChild(node)
+	return s
+}
+
+func (s *Scene) AddNodes(nodes ...*SceneNode) *Scene {
+	s.root.AddChildren(nodes...)
+	return s
+}
+
+// RemoveNode removes the given node from its parent, wherever it is in the
+// tree.  Its shapes (and those of its descendants) are closed when the scene
+// is next updated, but are initialized again if the node is added back.
+func (s *Scene) RemoveNode(node *SceneNode) *Scene {
+	if parent := node.Parent(); parent != nil {
+		parent.RemoveChild(node)
+	}
+	return s
+}
+
+// Node returns the node found by following the given path of node names
+// from the root of the tree (e.g., "base/shoulder/elbow"), or nil if there
+// is no such node.
+func (s *Scene) Node(path string) *SceneNode {
+	return s.root.Find(path)
+}
+
+func (s *Scene) Viewport() *Viewport {
+	s.stateMutex.Lock()
+	vp := s.viewport
+	s.stateMutex.Unlock()
+	return vp
+}
+
+func (s *Scene) SetViewport(viewport *Viewport) *Scene {
+	s.stateMutex.Lock()
+	s.viewport = viewport
+	s.stateMutex.Unlock()
+
+	s.forEachShape(func(shape *Shape3D) { shape.SetViewport(viewport) })
+	return s
+}
+
+func (s *Scene) Camera() Camera {
+	s.stateMutex.Lock()
+	cam := s.camera
+	s.stateMutex.Unlock()
+	return cam
+}
+
+func (s *Scene) SetCamera(camera Camera) *Scene {
+	s.stateMutex.Lock()
+	s.camera = camera
+	s.stateMutex.Unlock()
+
+	s.forEachShape(func(shape *Shape3D) { shape.SetCamera(camera) })
+	return s
+}
+
+func (s *Scene) Lighting() any {
+	s.stateMutex.Lock()
+	lighting := s.lighting
+	s.stateMutex.Unlock()
+	return lighting
+}
+
+func (s *Scene) SetLighting(lighting any) *Scene {
+	s.stateMutex.Lock()
+	s.lighting = lighting
+	s.stateMutex.Unlock()
+
+	s.forEachShape(func(shape *Shape3D) { shape.SetLighting(lighting) })
+	return s
+}
+
+// forEachShape Calls the given function for the initialized shapes of all
+// nodes, as those not yet initialized are configured when they are.
+func (s *Scene) forEachShape(f func(shape *Shape3D)) {
+	s.root.walk(true, true, func(node *SceneNode, _, _ bool) {
+		if node.shape != nil && node.shape.Initialized() {
+			f(node.shape)
+		}
+	})
+}
+
+// Pick returns the nearest intersection of the shapes of the enabled and
+// visible nodes with the ray cast from the given window position (see
+// Shape3D.RayAt()).  The node that was hit is returned by the SceneNode()
+// function of the hit shape.
+func (s *Scene) Pick(x, y float32) (hit RayHit, ok bool) {
+	return Pick3D(x, y, s.shapes()...)
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+// matrixScale Returns the scale applied by the given (affine) matrix, i.e.,
+// the length of each of its basis vectors.
+func matrixScale(mat mgl32.Mat4) mgl32.Vec3 {
+	return mgl32.Vec3{mat.Col(0).Vec3().Len(), mat.Col(1).Vec3().Len(), mat.Col(2).Vec3().Len()}
+}
+
+/******************************************************************************
+ New Scene Function
+******************************************************************************/
+
+func NewScene() *Scene {
+	s := &Scene{
+		WindowObjectBase: *NewWindowObject(),
+		root:             NewSceneNode(defaultSceneRootName),
+	}
+
+	s.SetName(defaultSceneName)
+	return s
+}
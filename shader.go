@@ -43,14 +43,33 @@ const (
 
 	// Shape3DShader Can be used by Shape3D to render a textured Model with
 	// support for: ambient/diffuse/specular/emissive/transparent lighting,
-	// directional lights, and diffuse/normal/specular maps.  Expects the Model
+	// directional/point/spot lights (see BasicLighting), shadows (see
+	// ShadowMapper), and diffuse/normal/specular maps.  Expects the Model
 	// vertex buffer to have the PositionNormalUvTangentsVaoLayout.
 	Shape3DShader = "_shader_shape3d"
 
+	// Shape3DInstancedShader Used by InstancedShape3D to render many instances
+	// of a Model, each with its own world matrix and color tint, with the same
+	// support for lighting, shadows and maps as Shape3DShader.  Expects the
+	// Model vertex buffer to have the PositionNormalUvTangentsVaoLayout, as
+	// well as the per-instance attributes a_InstanceWorldMat (mat4) and
+	// a_InstanceTint (vec4).
+	Shape3DInstancedShader = "_shader_shape3d_instanced"
+
+	// Shape3DSkinnedShader Can be used by Shape3D to render a SkinnedModel,
+	// deforming its vertices using the joint matrices of its current pose,
+	// with the same support for lighting, shadows and maps as Shape3DShader.
+	// Expects the Model vertex buffer to have the
+	// PositionNormalUvTangentsVaoLayout, as well as the attributes a_Joints
+	// (vec4) and a_Weights (vec4), the uniform u_JointCount (int) and the
+	// Skeleton uniform block (an array of MaxJoints mat4).  Models that are
+	// not skinned are rendered as with Shape3DShader.
+	Shape3DSkinnedShader = "_shader_shape3d_skinned"
+
 	// Shape3DNoNormalSpecularMapsShader Can be used by Shape3D to render a
 	// textured Model with support for: ambient/diffuse/specular/emissive/transparent
-	// lighting, directional lights, and diffuse maps.  Expects the Model vertex
-	// buffer to have the PositionNormalUvVaoLayout.
+	// lighting, directional/point/spot lights, shadows, and diffuse maps.
+	// Expects the Model vertex buffer to have the PositionNormalUvVaoLayout.
 	Shape3DNoNormalSpecularMapsShader = "_shader_shape3d_no_norm_spec"
 
 	// Shape3DNoLightsShader Can be used by Shape3D to render a
@@ -58,6 +77,74 @@ const (
 	// lighting and diffuse maps.  Expects the Model vertex buffer to have
 	// the PositionUvVaoLayout.
 	Shape3DNoLightsShader = "_shader_shape3d_no_lights"
+
+	// PbrShader Can be used by Shape3D to render a textured Model with a
+	// physically based, metallic-roughness material (see obj.PbrMaterial),
+	// with support for: base color/normal/metallic/roughness/occlusion/emissive
+	// maps, directional/point/spot lights (see BasicLighting), and shadows.
+	// Expects the Model vertex buffer to have the PositionNormalUvTangentsVaoLayout.
+	PbrShader = "_shader_pbr"
+
+	// PbrNoNormalMapShader Same as PbrShader, but without normal map support.
+	// Expects the Model vertex buffer to have the PositionNormalUvVaoLayout.
+	PbrNoNormalMapShader = "_shader_pbr_no_norm"
+
+	// PointCloudShader Used by PointCloud to render its points, colored
+	// either by the cloud, the points themselves, or by mapping their
+	// scalar values onto a colormap.
+	PointCloudShader = "_shader_point_cloud"
+
+	// SkyboxShader Used by Skybox to render its TextureCube around the camera,
+	// at the far plane.
+	SkyboxShader = "_shader_skybox"
+
+	// BloomExtractShader Used by BloomEffect to extract the parts of the frame
+	// brighter than its threshold.
+	BloomExtractShader = "_shader_bloom_extract"
+
+	// BloomBlurShader Used by BloomEffect to blur the extracted parts of the
+	// frame, in the direction given by the u_Direction uniform.
+	BloomBlurShader = "_shader_bloom_blur"
+
+	// BloomCombineShader Used by BloomEffect to add the blurred parts of the
+	// frame back onto the frame.
+	BloomCombineShader = "_shader_bloom_combine"
+
+	// FxaaShader Used by FxaaEffect to smooth the jagged edges of the frame
+	// (fast approximate anti-aliasing).
+	FxaaShader = "_shader_fxaa"
+
+	// ColorGradingShader Used by ColorGradingEffect to remap the colors of
+	// the frame using a lookup table.
+	ColorGradingShader = "_shader_color_grading"
+
+	// VignetteShader Used by VignetteEffect to darken (or tint) the edges of
+	// the frame.
+	VignetteShader = "_shader_vignette"
+
+	// ToneMappingShader Used by ToneMappingEffect to apply exposure, tone
+	// mapping and gamma correction to the frame.
+	ToneMappingShader = "_shader_tone_mapping"
+
+	// DebugLineShader Used by Shape3D to render the lines of its debug modes
+	// (wireframe, normals, bounding boxes, etc), each with its own color.
+	DebugLineShader = "_shader_debug_line"
+
+	// Line3DShader Used by Line3D, Polyline3D, GridFloor and Axes to render
+	// thick, anti-aliased lines in world space, expanding each segment into
+	// a screen-aligned quad in the geometry shader (as done by SignalShader).
+	// Shares its vertex shader (and vertex format) with DebugLineShader.
+	Line3DShader = "_shader_line3d"
+
+	// ShadowDepthShader Used by ShadowMapper to render the depth of Shape3D
+	// objects from the perspective of each shadow-casting light, skinning
+	// the vertices of a SkinnedModel as done by Shape3DSkinnedShader.
+	ShadowDepthShader = "_shader_shadow_depth"
+
+	// ShadowDepthInstancedShader Used by ShadowMapper to render the depth of
+	// every instance of an InstancedShape3D with a single draw call per group
+	// of faces, reading the world matrices from the instance buffer.
+	ShadowDepthInstancedShader = "_shader_shadow_depth_instanced"
 )
 
 /******************************************************************************
@@ -330,8 +417,25 @@ func addDefaultShaders(lib *AssetLibrary) {
 	lib.Add(newDefaultShader(Shape2DShader, Shape2DShader[pfxLen:]))
 	lib.Add(newDefaultShader(Shape2DNoTextureShader, Shape2DNoTextureShader[pfxLen:]))
 	lib.Add(newDefaultShader(Shape3DShader, Shape3DShader[pfxLen:]))
+	lib.Add(newDefaultShader(Shape3DInstancedShader, Shape3DInstancedShader[pfxLen:], Shape3DShader[pfxLen:]))
+	lib.Add(newDefaultShader(Shape3DSkinnedShader, Shape3DSkinnedShader[pfxLen:], Shape3DShader[pfxLen:]))
 	lib.Add(newDefaultShader(Shape3DNoNormalSpecularMapsShader, Shape3DNoNormalSpecularMapsShader[pfxLen:]))
 	lib.Add(newDefaultShader(Shape3DNoLightsShader, Shape3DNoLightsShader[pfxLen:]))
+	lib.Add(newDefaultShader(PbrShader, Shape3DShader[pfxLen:], PbrShader[pfxLen:]))
+	lib.Add(newDefaultShader(PbrNoNormalMapShader, Shape3DNoNormalSpecularMapsShader[pfxLen:], PbrNoNormalMapShader[pfxLen:]))
+	lib.Add(newDefaultShader(PointCloudShader, PointCloudShader[pfxLen:]))
+	lib.Add(newDefaultShader(SkyboxShader, SkyboxShader[pfxLen:]))
+	lib.Add(newDefaultShader(BloomExtractShader, TextureShader[pfxLen:], BloomExtractShader[pfxLen:]))
+	lib.Add(newDefaultShader(BloomBlurShader, TextureShader[pfxLen:], BloomBlurShader[pfxLen:]))
+	lib.Add(newDefaultShader(BloomCombineShader, TextureShader[pfxLen:], BloomCombineShader[pfxLen:]))
+	lib.Add(newDefaultShader(FxaaShader, TextureShader[pfxLen:], FxaaShader[pfxLen:]))
+	lib.Add(newDefaultShader(ColorGradingShader, TextureShader[pfxLen:], ColorGradingShader[pfxLen:]))
+	lib.Add(newDefaultShader(VignetteShader, TextureShader[pfxLen:], VignetteShader[pfxLen:]))
+	lib.Add(newDefaultShader(ToneMappingShader, TextureShader[pfxLen:], ToneMappingShader[pfxLen:]))
+	lib.Add(newDefaultShader(DebugLineShader, DebugLineShader[pfxLen:]))
+	lib.Add(newDefaultShader(Line3DShader, DebugLineShader[pfxLen:], Line3DShader[pfxLen:], Line3DShader[pfxLen:]))
+	lib.Add(newDefaultShader(ShadowDepthShader, ShadowDepthShader[pfxLen:]))
+	lib.Add(newDefaultShader(ShadowDepthInstancedShader, ShadowDepthInstancedShader[pfxLen:], ShadowDepthShader[pfxLen:]))
 }
 
 /******************************************************************************
@@ -368,6 +472,20 @@ func checkProgramError(program uint32) error {
 	return nil
 }
 
+/******************************************************************************
+ ShaderBindingUpdater
+******************************************************************************/
+
+// ShaderBindingUpdater can be implemented by shader-bindable structs whose
+// bindable fields are derived from other state (e.g., BasicLighting, which
+// packs its lights into a uniform block).  UpdateShaderBinding will be called
+// before the struct is bound and each time before its data is sent to the
+// shader; if the struct also implements sync.Locker, it is called before the
+// struct is locked.
+type ShaderBindingUpdater interface {
+	UpdateShaderBinding()
+}
+
 /******************************************************************************
  ShaderBinding
 ******************************************************************************/
@@ -642,11 +760,16 @@ func (b *ShaderBinding) bindInterface(field reflect.Value, uniformLoc int32, nam
 	}
 	switch fieldAsType := field.Interface().(type) {
 	case Texture:
-		glName := fieldAsType.GlName()
 		unit := b.textureCount
+		target := uint32(gl.TEXTURE_2D)
+		if _, isCube := fieldAsType.(*TextureCube); isCube {
+			target = gl.TEXTURE_CUBE_MAP
+		}
+		// The name is read on each update, as it changes when the texture
+		// is recreated (e.g., when a RenderTarget is resized)
 		b.updateFuncs = append(b.updateFuncs, func() {
 			gl.ActiveTexture(gl.TEXTURE0 + unit)
-			gl.BindTexture(gl.TEXTURE_2D, glName)
+			gl.BindTexture(target, fieldAsType.GlName())
 			gl.Uniform1i(uniformLoc, int32(unit))
 		})
 		b.textureCount++
@@ -691,6 +814,10 @@ func (b *ShaderBinding) Init() (ok bool) {
 	}
 	b.shaderName = b.shader.GlName()
 
+	if updater, isUpdater := b.boundStruct.(ShaderBindingUpdater); isUpdater {
+		updater.UpdateShaderBinding()
+	}
+
 	b.bindStructFields(reflect.Indirect(reflect.ValueOf(b.boundStruct)), "")
 	b.initFuncs()
 
@@ -698,6 +825,9 @@ func (b *ShaderBinding) Init() (ok bool) {
 }
 
 func (b *ShaderBinding) Update(_ int64) (ok bool) {
+	if updater, isUpdater := b.boundStruct.(ShaderBindingUpdater); isUpdater {
+		updater.UpdateShaderBinding()
+	}
 	b.activate()
 	b.updateFunc()
 	return true
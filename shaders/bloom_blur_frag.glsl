@@ -0,0 +1,22 @@
+#version 410 core
+
+in vec2 UV;
+
+out vec4 FragColor;
+
+uniform sampler2D u_SceneMap;
+uniform vec2 u_Direction;
+
+// 9-tap Gaussian kernel, sampled between texels to use linear filtering
+const float OFFSETS[3] = float[](0.0, 1.3846153846, 3.2307692308);
+const float WEIGHTS[3] = float[](0.2270270270, 0.3162162162, 0.0702702703);
+
+void main()
+{
+    vec3 result = texture(u_SceneMap, UV).rgb * WEIGHTS[0];
+    for (int i = 1; i < 3; i++) {
+        result += texture(u_SceneMap, UV + u_Direction * OFFSETS[i]).rgb * WEIGHTS[i];
+        result += texture(u_SceneMap, UV - u_Direction * OFFSETS[i]).rgb * WEIGHTS[i];
+    }
+    FragColor = vec4(result, 1.0);
+}
@@ -0,0 +1,16 @@
+#version 410 core
+
+in vec2 UV;
+
+out vec4 FragColor;
+
+uniform sampler2D u_SceneMap;
+uniform sampler2D u_BloomMap;
+uniform float u_Intensity;
+
+void main()
+{
+    vec4 scene = texture(u_SceneMap, UV);
+    vec3 bloom = texture(u_BloomMap, UV).rgb;
+    FragColor = vec4(scene.rgb + bloom * u_Intensity, scene.a);
+}
@@ -0,0 +1,16 @@
+#version 410 core
+
+in vec2 UV;
+
+out vec4 FragColor;
+
+uniform sampler2D u_SceneMap;
+uniform float u_Threshold;
+
+void main()
+{
+    vec3 color = texture(u_SceneMap, UV).rgb;
+    float brightness = max(color.r, max(color.g, color.b));
+    float contribution = max(brightness - u_Threshold, 0.0) / max(brightness, 0.0001);
+    FragColor = vec4(color * contribution, 1.0);
+}
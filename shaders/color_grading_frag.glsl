@@ -0,0 +1,35 @@
+#version 410 core
+
+in vec2 UV;
+
+out vec4 FragColor;
+
+uniform sampler2D u_SceneMap;
+uniform sampler2D u_LutMap;
+uniform float u_LutSize;
+uniform float u_Intensity;
+
+// The LUT is a horizontal strip of u_LutSize slices, each u_LutSize texels
+// square, with blue selecting the slice, red increasing to the right within
+// the slice and green increasing upward
+vec3 lookup(vec3 color)
+{
+    float n = u_LutSize;
+    float blue = color.b * (n - 1.0);
+    float slice0 = floor(blue);
+    float slice1 = min(slice0 + 1.0, n - 1.0);
+
+    float x = (color.r * (n - 1.0) + 0.5) / (n * n);
+    float y = (color.g * (n - 1.0) + 0.5) / n;
+
+    vec3 color0 = texture(u_LutMap, vec2(x + slice0 / n, y)).rgb;
+    vec3 color1 = texture(u_LutMap, vec2(x + slice1 / n, y)).rgb;
+    return mix(color0, color1, blue - slice0);
+}
+
+void main()
+{
+    vec4 scene = texture(u_SceneMap, UV);
+    vec3 graded = lookup(clamp(scene.rgb, 0.0, 1.0));
+    FragColor = vec4(mix(scene.rgb, graded, u_Intensity), scene.a);
+}
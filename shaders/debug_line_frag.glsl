@@ -0,0 +1,9 @@
+#version 410 core
+
+in vec4 VertColor;
+
+out vec4 FragColor;
+
+void main() {
+    FragColor = VertColor;
+}
@@ -0,0 +1,16 @@
+#version 410 core
+
+in vec3 a_Position;
+in vec4 a_Color;
+
+out vec4 VertColor;
+
+uniform mat4 u_WorldMat;
+uniform mat4 u_ViewProjMat;
+uniform float u_DepthBias;
+
+void main() {
+    VertColor = a_Color;
+    gl_Position = u_ViewProjMat * u_WorldMat * vec4(a_Position, 1.0);
+    gl_Position.z -= u_DepthBias * gl_Position.w; // pull lines in front of coincident faces
+}
@@ -0,0 +1,45 @@
+#version 410 core
+
+in vec2 UV;
+
+out vec4 FragColor;
+
+uniform sampler2D u_SceneMap;
+uniform vec2 u_TexelSize;
+
+const float FXAA_SPAN_MAX = 8.0;
+const float FXAA_REDUCE_MUL = 1.0 / 8.0;
+const float FXAA_REDUCE_MIN = 1.0 / 128.0;
+const vec3 LUMA = vec3(0.299, 0.587, 0.114);
+
+void main()
+{
+    vec4 scene = texture(u_SceneMap, UV);
+    vec3 rgbNW = texture(u_SceneMap, UV + vec2(-1.0, -1.0) * u_TexelSize).rgb;
+    vec3 rgbNE = texture(u_SceneMap, UV + vec2(1.0, -1.0) * u_TexelSize).rgb;
+    vec3 rgbSW = texture(u_SceneMap, UV + vec2(-1.0, 1.0) * u_TexelSize).rgb;
+    vec3 rgbSE = texture(u_SceneMap, UV + vec2(1.0, 1.0) * u_TexelSize).rgb;
+
+    float lumaNW = dot(rgbNW, LUMA);
+    float lumaNE = dot(rgbNE, LUMA);
+    float lumaSW = dot(rgbSW, LUMA);
+    float lumaSE = dot(rgbSE, LUMA);
+    float lumaM = dot(scene.rgb, LUMA);
+    float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+    float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+    vec2 dir = vec2(-((lumaNW + lumaNE) - (lumaSW + lumaSE)), (lumaNW + lumaSW) - (lumaNE + lumaSE));
+    float dirReduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * (0.25 * FXAA_REDUCE_MUL), FXAA_REDUCE_MIN);
+    float rcpDirMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + dirReduce);
+    dir = clamp(dir * rcpDirMin, vec2(-FXAA_SPAN_MAX), vec2(FXAA_SPAN_MAX)) * u_TexelSize;
+
+    vec3 rgbA = 0.5 * (
+        texture(u_SceneMap, UV + dir * (1.0 / 3.0 - 0.5)).rgb +
+        texture(u_SceneMap, UV + dir * (2.0 / 3.0 - 0.5)).rgb);
+    vec3 rgbB = rgbA * 0.5 + 0.25 * (
+        texture(u_SceneMap, UV + dir * -0.5).rgb +
+        texture(u_SceneMap, UV + dir * 0.5).rgb);
+
+    float lumaB = dot(rgbB, LUMA);
+    FragColor = vec4((lumaB < lumaMin || lumaB > lumaMax) ? rgbA : rgbB, scene.a);
+}
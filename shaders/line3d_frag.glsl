@@ -0,0 +1,23 @@
+#version 410 core
+
+in vec4 Color;
+in float Edge;
+
+out vec4 FragColor;
+
+uniform vec4 u_Color;
+uniform float u_Thickness;
+
+const float FEATHER = 1.0;
+
+void main() {
+    // Fade out over the last pixel on each side of the line
+    float distance = abs(Edge) * (u_Thickness * 0.5 + FEATHER);
+    float alpha = clamp(u_Thickness * 0.5 + 0.5 - distance, 0.0, 1.0);
+    if (alpha <= 0.0) {
+        discard;
+    }
+
+    FragColor = Color * u_Color;
+    FragColor.a *= alpha;
+}
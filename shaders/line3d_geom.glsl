@@ -0,0 +1,92 @@
+#version 410 core
+
+layout(lines_adjacency) in;
+layout(triangle_strip, max_vertices = 4) out;
+
+in vec4 VertColor[];
+
+out vec4 Color;
+out float Edge;
+
+uniform vec2 u_ViewportSize;
+uniform float u_Thickness;
+
+const float NEAR_W = 0.0001;
+const float FEATHER = 1.0; // pixels added on each side for anti-aliasing
+const float MIN_MITER = 0.5;
+
+vec2 toScreen(vec4 clip) {
+    return clip.xy / clip.w * u_ViewportSize * 0.5;
+}
+
+vec2 direction(vec2 from, vec2 to, vec2 fallback) {
+    vec2 d = to - from;
+    return dot(d, d) > 1e-8 ? normalize(d) : fallback;
+}
+
+// miter Returns the offset, in pixels, of the edges of the line at a joint
+// between two segments, such that the segments meet without a gap.
+vec2 miter(vec2 dirIn, vec2 dirOut, vec2 normal, float halfWidth) {
+    vec2 tangent = dirIn + dirOut;
+    tangent = dot(tangent, tangent) > 1e-8 ? normalize(tangent) : dirOut;
+    vec2 miterNormal = vec2(-tangent.y, tangent.x);
+    return miterNormal * halfWidth / max(dot(miterNormal, normal), MIN_MITER);
+}
+
+void emit(vec4 clip, vec2 offset, float edge, vec4 color) {
+    gl_Position = clip + vec4(offset / (u_ViewportSize * 0.5) * clip.w, 0.0, 0.0);
+    Color = color;
+    Edge = edge;
+    EmitVertex();
+}
+
+void main() {
+    vec4 p0 = gl_in[0].gl_Position;
+    vec4 p1 = gl_in[1].gl_Position;
+    vec4 p2 = gl_in[2].gl_Position;
+    vec4 p3 = gl_in[3].gl_Position;
+    vec4 c1 = VertColor[1];
+    vec4 c2 = VertColor[2];
+
+    // Clip the segment against the near plane, as points behind the camera
+    // cannot be projected onto the screen
+    if (p1.w < NEAR_W && p2.w < NEAR_W) {
+        return;
+    }
+    if (p1.w < NEAR_W) {
+        float t = (NEAR_W - p1.w) / (p2.w - p1.w);
+        p1 = mix(p1, p2, t);
+        c1 = mix(c1, c2, t);
+        p0 = p1;
+    } else if (p2.w < NEAR_W) {
+        float t = (NEAR_W - p2.w) / (p1.w - p2.w);
+        p2 = mix(p2, p1, t);
+        c2 = mix(c2, c1, t);
+        p3 = p2;
+    }
+    if (p0.w < NEAR_W) {
+        p0 = p1;
+    }
+    if (p3.w < NEAR_W) {
+        p3 = p2;
+    }
+
+    vec2 s0 = toScreen(p0);
+    vec2 s1 = toScreen(p1);
+    vec2 s2 = toScreen(p2);
+    vec2 s3 = toScreen(p3);
+
+    vec2 dir = direction(s1, s2, vec2(1.0, 0.0));
+    vec2 normal = vec2(-dir.y, dir.x);
+    float halfWidth = u_Thickness * 0.5 + FEATHER;
+
+    vec2 offset1 = miter(direction(s0, s1, dir), dir, normal, halfWidth);
+    vec2 offset2 = miter(dir, direction(s2, s3, dir), normal, halfWidth);
+
+    emit(p1, offset1, 1.0, c1);
+    emit(p1, -offset1, -1.0, c1);
+    emit(p2, offset2, 1.0, c2);
+    emit(p2, -offset2, -1.0, c2);
+
+    EndPrimitive();
+}
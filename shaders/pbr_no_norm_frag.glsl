@@ -0,0 +1,173 @@
+#version 410 core
+
+const int MAX_LIGHT_COUNT = 32;
+const int MAX_SHADOW_COUNT = 4;
+const int DIRECTIONAL_LIGHT = 0;
+const int POINT_LIGHT = 1;
+const int SPOT_LIGHT = 2;
+const float PI = 3.14159265359;
+const float AMBIENT = 0.03;
+const float GAMMA = 2.2;
+
+in vec3 FragPos;
+in vec3 Normal;
+in vec2 UV;
+in vec3 CameraPos;
+
+out vec4 FragColor;
+
+uniform sampler2D u_BaseColorMap;
+uniform sampler2D u_MetallicMap;
+uniform sampler2D u_RoughnessMap;
+uniform sampler2D u_OcclusionMap;
+uniform sampler2D u_EmissiveMap;
+
+layout (std140) uniform PbrMaterial {
+    vec4    BaseColor;
+    vec4    Emissive;
+    float   Metallic;
+    float   Roughness;
+    float   Occlusion;
+    float   Transparency;
+} u_Material;
+
+struct Light {
+    vec4 Color;         // rgb = color, a = type
+    vec4 Position;      // xyz = position, w = range (0 for unlimited)
+    vec4 Direction;     // xyz = direction, w = cosine of inner cone angle
+    vec4 Attenuation;   // xyz = constant/linear/quadratic, w = cosine of outer cone angle
+    vec4 Shadow;        // x = shadow map layer (-1 for none), y = depth bias, z = PCF radius
+};
+
+layout (std140) uniform BasicLighting {
+    Light   Lights[MAX_LIGHT_COUNT];
+    mat4    ShadowMats[MAX_SHADOW_COUNT];
+    int     LightCount;
+} u_Lighting;
+
+uniform sampler2DArrayShadow u_ShadowMap;
+uniform int u_ReceiveShadows;
+
+uniform samplerCube u_EnvironmentMap;
+uniform float u_Reflectivity;
+uniform float u_EnvironmentMaxLod; // mipmap level of the 1x1 faces
+
+float calcShadow(Light light, vec3 norm, vec3 lightDir) {
+    int layer = int(light.Shadow.x + 0.5);
+    if (u_ReceiveShadows == 0 || light.Shadow.x < 0.0) {
+        return 1.0;
+    }
+
+    vec4 lightSpacePos = u_Lighting.ShadowMats[layer] * vec4(FragPos, 1.0);
+    vec3 coords = lightSpacePos.xyz / lightSpacePos.w * 0.5 + 0.5;
+    if (coords.z > 1.0) {
+        return 1.0;
+    }
+
+    float bias = max(light.Shadow.y * (1.0 - dot(norm, lightDir)), light.Shadow.y * 0.1);
+    int radius = int(light.Shadow.z + 0.5);
+    vec2 texelSize = 1.0 / vec2(textureSize(u_ShadowMap, 0).xy);
+    float lit = 0.0;
+    for (int x = -radius; x <= radius; x++) {
+        for (int y = -radius; y <= radius; y++) {
+            vec2 uv = coords.xy + vec2(x, y) * texelSize;
+            lit += texture(u_ShadowMap, vec4(uv, layer, coords.z - bias));
+        }
+    }
+    float taps = float((radius * 2 + 1) * (radius * 2 + 1));
+    return lit / taps;
+}
+
+float distributionGGX(float nDotH, float roughness) {
+    float a = roughness * roughness;
+    float a2 = a * a;
+    float denom = nDotH * nDotH * (a2 - 1.0) + 1.0;
+    return a2 / max(PI * denom * denom, 0.0001);
+}
+
+float geometrySchlickGGX(float nDotX, float roughness) {
+    float r = roughness + 1.0;
+    float k = (r * r) / 8.0;
+    return nDotX / (nDotX * (1.0 - k) + k);
+}
+
+vec3 fresnelSchlick(float cosTheta, vec3 f0) {
+    return f0 + (1.0 - f0) * pow(clamp(1.0 - cosTheta, 0.0, 1.0), 5.0);
+}
+
+vec3 calcLight(Light light, vec3 norm, vec3 viewDir, vec3 albedo, float metallic, float roughness, vec3 f0) {
+    int lightType = int(light.Color.a + 0.5);
+    vec3 lightDir;
+    float intensity = 1.0;
+
+    if (lightType == DIRECTIONAL_LIGHT) {
+        lightDir = normalize(-light.Direction.xyz);
+    } else {
+        vec3 toLight = light.Position.xyz - FragPos;
+        float dist = length(toLight);
+        lightDir = toLight / max(dist, 0.0001);
+
+        vec3 att = light.Attenuation.xyz;
+        intensity = 1.0 / max(att.x + att.y * dist + att.z * dist * dist, 0.0001);
+
+        float range = light.Position.w;
+        if (range > 0.0) {
+            float ratio = dist / range;
+            float falloff = clamp(1.0 - ratio * ratio * ratio * ratio, 0.0, 1.0);
+            intensity *= falloff * falloff;
+        }
+
+        if (lightType == SPOT_LIGHT) {
+            float theta = dot(lightDir, normalize(-light.Direction.xyz));
+            intensity *= smoothstep(light.Attenuation.w, light.Direction.w, theta);
+        }
+    }
+
+    float nDotL = max(dot(norm, lightDir), 0.0);
+    if (nDotL <= 0.0) {
+        return vec3(0.0);
+    }
+
+    vec3 halfway = normalize(viewDir + lightDir);
+    float nDotV = max(dot(norm, viewDir), 0.0001);
+    float nDotH = max(dot(norm, halfway), 0.0);
+
+    float ndf = distributionGGX(nDotH, roughness);
+    float geometry = geometrySchlickGGX(nDotV, roughness) * geometrySchlickGGX(nDotL, roughness);
+    vec3 fresnel = fresnelSchlick(max(dot(halfway, viewDir), 0.0), f0);
+
+    vec3 specular = (ndf * geometry * fresnel) / (4.0 * nDotV * nDotL + 0.0001);
+    vec3 kD = (vec3(1.0) - fresnel) * (1.0 - metallic);
+    vec3 radiance = light.Color.rgb * intensity;
+    return (kD * albedo / PI + specular) * radiance * nDotL * calcShadow(light, norm, lightDir);
+}
+
+void main() {
+    vec3 norm = normalize(Normal);
+    vec3 viewDir = normalize(CameraPos - FragPos);
+
+    vec4 baseColorMap = texture(u_BaseColorMap, UV);
+    vec3 albedo = pow(baseColorMap.rgb, vec3(GAMMA)) * u_Material.BaseColor.rgb;
+    float metallic = clamp(texture(u_MetallicMap, UV).b * u_Material.Metallic, 0.0, 1.0);
+    float roughness = clamp(texture(u_RoughnessMap, UV).g * u_Material.Roughness, 0.04, 1.0);
+    float occlusion = mix(1.0, texture(u_OcclusionMap, UV).r, u_Material.Occlusion);
+    vec3 emissive = pow(texture(u_EmissiveMap, UV).rgb, vec3(GAMMA)) * u_Material.Emissive.rgb;
+
+    vec3 f0 = mix(vec3(0.04), albedo, metallic);
+
+    vec3 result = vec3(0.0);
+    int lightCount = min(u_Lighting.LightCount, MAX_LIGHT_COUNT);
+    for (int i = 0; i < lightCount; i++) {
+        result += calcLight(u_Lighting.Lights[i], norm, viewDir, albedo, metallic, roughness, f0);
+    }
+    result += AMBIENT * albedo * occlusion + emissive;
+
+    if (u_Reflectivity > 0.0) {
+        vec3 reflectDir = reflect(-viewDir, norm);
+        vec3 reflected = textureLod(u_EnvironmentMap, reflectDir, roughness * u_EnvironmentMaxLod).rgb;
+        vec3 fresnel = fresnelSchlick(max(dot(norm, viewDir), 0.0), f0);
+        result += reflected * fresnel * occlusion * u_Reflectivity;
+    }
+
+    FragColor = vec4(pow(result, vec3(1.0 / GAMMA)), baseColorMap.a * u_Material.BaseColor.a * (1.0 - u_Material.Transparency));
+}
@@ -0,0 +1,18 @@
+#version 410 core
+
+in vec4 Color;
+
+out vec4 FragColor;
+
+uniform int u_RoundPoints;
+
+void main() {
+    if (u_RoundPoints != 0) {
+        vec2 coord = gl_PointCoord * 2.0 - 1.0;
+        if (dot(coord, coord) > 1.0) {
+            discard;
+        }
+    }
+
+    FragColor = Color;
+}
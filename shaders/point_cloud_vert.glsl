@@ -0,0 +1,41 @@
+#version 410 core
+
+in vec3 a_Position;
+in vec4 a_Color;
+in float a_Scalar;
+
+out vec4 Color;
+
+uniform mat4 u_WorldMat;
+uniform mat4 u_ViewProjMat;
+uniform float u_PointSize;
+uniform int u_ColorMode;
+uniform vec4 u_Color;
+uniform vec4 u_Colormap[16];
+uniform int u_ColormapSize;
+uniform vec2 u_ScalarRange;
+
+vec4 mapScalar(float scalar) {
+    if (u_ColormapSize < 2) {
+        return u_ColormapSize == 1 ? u_Colormap[0] : u_Color;
+    }
+
+    float range = u_ScalarRange.y - u_ScalarRange.x;
+    float t = range > 0.0 ? clamp((scalar - u_ScalarRange.x) / range, 0.0, 1.0) : 0.0;
+    float pos = t * float(u_ColormapSize - 1);
+    int idx = min(int(floor(pos)), u_ColormapSize - 2);
+    return mix(u_Colormap[idx], u_Colormap[idx + 1], pos - float(idx));
+}
+
+void main() {
+    if (u_ColorMode == 1) {
+        Color = a_Color;
+    } else if (u_ColorMode == 2) {
+        Color = mapScalar(a_Scalar);
+    } else {
+        Color = u_Color;
+    }
+
+    gl_PointSize = u_PointSize;
+    gl_Position = u_ViewProjMat * u_WorldMat * vec4(a_Position, 1.0);
+}
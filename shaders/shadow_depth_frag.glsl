@@ -0,0 +1,5 @@
+#version 410 core
+
+void main() {
+    // Only the depth is written
+}
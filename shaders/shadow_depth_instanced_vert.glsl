@@ -0,0 +1,11 @@
+#version 410 core
+
+in vec3 a_Position;
+in mat4 a_InstanceWorldMat;
+
+uniform mat4 u_WorldMat;
+uniform mat4 u_LightViewProjMat;
+
+void main() {
+    gl_Position = u_LightViewProjMat * a_InstanceWorldMat * u_WorldMat * vec4(a_Position, 1.0);
+}
@@ -0,0 +1,32 @@
+#version 410 core
+
+const int MAX_JOINTS = 128;
+
+in vec3 a_Position;
+in vec4 a_Joints;
+in vec4 a_Weights;
+
+uniform mat4 u_WorldMat;
+uniform mat4 u_LightViewProjMat;
+uniform int u_JointCount;
+
+layout (std140) uniform Skeleton {
+    mat4 JointMats[MAX_JOINTS];
+} u_Skeleton;
+
+mat4 skinMatrix() {
+    float totalWeight = a_Weights.x + a_Weights.y + a_Weights.z + a_Weights.w;
+    if (u_JointCount == 0 || totalWeight <= 0.0) {
+        return mat4(1.0);
+    }
+
+    ivec4 joints = clamp(ivec4(a_Joints + 0.5), 0, u_JointCount - 1);
+    return (a_Weights.x * u_Skeleton.JointMats[joints.x] +
+            a_Weights.y * u_Skeleton.JointMats[joints.y] +
+            a_Weights.z * u_Skeleton.JointMats[joints.z] +
+            a_Weights.w * u_Skeleton.JointMats[joints.w]) / totalWeight;
+}
+
+void main() {
+    gl_Position = u_LightViewProjMat * u_WorldMat * skinMatrix() * vec4(a_Position, 1.0);
+}
@@ -1,6 +1,10 @@
 #version 410 core
 
-const int MAX_LIGHT_COUNT = 4;
+const int MAX_LIGHT_COUNT = 32;
+const int MAX_SHADOW_COUNT = 4;
+const int DIRECTIONAL_LIGHT = 0;
+const int POINT_LIGHT = 1;
+const int SPOT_LIGHT = 2;
 
 in vec3 FragPos;
 in vec3 Normal;
@@ -21,11 +25,86 @@ layout (std140) uniform BasicMaterial {
 } u_Material;
 
 struct Light {
-    vec3 Color;
-    vec3 Direction;
+    vec4 Color;         // rgb = color, a = type
+    vec4 Position;      // xyz = position, w = range (0 for unlimited)
+    vec4 Direction;     // xyz = direction, w = cosine of inner cone angle
+    vec4 Attenuation;   // xyz = constant/linear/quadratic, w = cosine of outer cone angle
+    vec4 Shadow;        // x = shadow map layer (-1 for none), y = depth bias, z = PCF radius
 };
-uniform int     u_LightCount;
-uniform Light   u_Lights[MAX_LIGHT_COUNT];
+
+layout (std140) uniform BasicLighting {
+    Light   Lights[MAX_LIGHT_COUNT];
+    mat4    ShadowMats[MAX_SHADOW_COUNT];
+    int     LightCount;
+} u_Lighting;
+
+uniform sampler2DArrayShadow u_ShadowMap;
+uniform int u_ReceiveShadows;
+
+uniform samplerCube u_EnvironmentMap;
+uniform float u_Reflectivity;
+
+float calcShadow(Light light, vec3 norm, vec3 lightDir) {
+    int layer = int(light.Shadow.x + 0.5);
+    if (u_ReceiveShadows == 0 || light.Shadow.x < 0.0) {
+        return 1.0;
+    }
+
+    vec4 lightSpacePos = u_Lighting.ShadowMats[layer] * vec4(FragPos, 1.0);
+    vec3 coords = lightSpacePos.xyz / lightSpacePos.w * 0.5 + 0.5;
+    if (coords.z > 1.0) {
+        return 1.0;
+    }
+
+    float bias = max(light.Shadow.y * (1.0 - dot(norm, lightDir)), light.Shadow.y * 0.1);
+    int radius = int(light.Shadow.z + 0.5);
+    vec2 texelSize = 1.0 / vec2(textureSize(u_ShadowMap, 0).xy);
+    float lit = 0.0;
+    for (int x = -radius; x <= radius; x++) {
+        for (int y = -radius; y <= radius; y++) {
+            vec2 uv = coords.xy + vec2(x, y) * texelSize;
+            lit += texture(u_ShadowMap, vec4(uv, layer, coords.z - bias));
+        }
+    }
+    float taps = float((radius * 2 + 1) * (radius * 2 + 1));
+    return lit / taps;
+}
+
+vec3 calcLight(Light light, vec3 norm, vec3 viewDir, vec3 diffuse, vec3 specular) {
+    int lightType = int(light.Color.a + 0.5);
+    vec3 lightDir;
+    float intensity = 1.0;
+
+    if (lightType == DIRECTIONAL_LIGHT) {
+        lightDir = normalize(-light.Direction.xyz);
+    } else {
+        vec3 toLight = light.Position.xyz - FragPos;
+        float dist = length(toLight);
+        lightDir = toLight / max(dist, 0.0001);
+
+        vec3 att = light.Attenuation.xyz;
+        intensity = 1.0 / max(att.x + att.y * dist + att.z * dist * dist, 0.0001);
+
+        float range = light.Position.w;
+        if (range > 0.0) {
+            float ratio = dist / range;
+            float falloff = clamp(1.0 - ratio * ratio * ratio * ratio, 0.0, 1.0);
+            intensity *= falloff * falloff;
+        }
+
+        if (lightType == SPOT_LIGHT) {
+            float theta = dot(lightDir, normalize(-light.Direction.xyz));
+            intensity *= smoothstep(light.Attenuation.w, light.Direction.w, theta);
+        }
+    }
+
+    float diffPower = max(dot(norm, lightDir), 0.0);
+    vec3 litDiffuse = diffuse * light.Color.rgb * diffPower;
+    vec3 reflectDir = reflect(-lightDir, norm);
+    float specPower = pow(max(dot(viewDir, reflectDir), 0.0), u_Material.Shininess);
+    vec3 litSpecular = specular * specPower * light.Color.rgb;
+    return (litDiffuse + litSpecular) * intensity * calcShadow(light, norm, lightDir);
+}
 
 void main() {
     vec3 norm = normalize(Normal);
@@ -34,14 +113,15 @@ void main() {
     vec3 tintDiffuse = u_Material.Diffuse.rgb * mapDiffuse;
 
     vec3 result = u_Material.Ambient.rgb * tintDiffuse + u_Material.Emissive.rgb;
-    for(int i = 0; i < u_LightCount; i++) {
-        vec3 lightDir = normalize(-u_Lights[i].Direction);
-        float diffPower = max(dot(norm, lightDir), 0.0);
-        vec3 litDiffuse = tintDiffuse * u_Lights[i].Color * diffPower;
-        vec3 reflectDir = reflect(-lightDir, norm);
-        float specPower = pow(max(dot(viewDir, reflectDir), 0.0), u_Material.Shininess);
-        vec3 litSpecular = u_Material.Specular.rgb * specPower * u_Lights[i].Color;
-        result += litDiffuse + litSpecular;
+    vec3 specular = u_Material.Specular.rgb;
+    int lightCount = min(u_Lighting.LightCount, MAX_LIGHT_COUNT);
+    for (int i = 0; i < lightCount; i++) {
+        result += calcLight(u_Lighting.Lights[i], norm, viewDir, tintDiffuse, specular);
+    }
+
+    if (u_Reflectivity > 0.0) {
+        vec3 reflected = texture(u_EnvironmentMap, reflect(-viewDir, norm)).rgb;
+        result = mix(result, reflected, u_Reflectivity);
     }
 
     FragColor = vec4(result, 1.0 - u_Material.Transparency);
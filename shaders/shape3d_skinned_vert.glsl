@@ -0,0 +1,57 @@
+#version 410 core
+
+const int MAX_JOINTS = 128;
+
+in vec3 a_Position;
+in vec3 a_Normal;
+in vec2 a_UV;
+in vec3 a_Tangent;
+in vec3 a_Bitangent;
+in vec4 a_Joints;
+in vec4 a_Weights;
+
+out vec3 FragPos;
+out mat3 TBN;
+out vec2 UV;
+out vec3 CameraPos;
+out vec4 Tint;
+
+uniform mat4 u_WorldMat;
+uniform int u_JointCount;
+
+layout (std140) uniform BasicCamera {
+    vec4 Position;
+    vec4 Target;
+    vec4 Up;
+    mat4 ViewProjMat;
+} u_Camera;
+
+layout (std140) uniform Skeleton {
+    mat4 JointMats[MAX_JOINTS];
+} u_Skeleton;
+
+mat4 skinMatrix() {
+    float totalWeight = a_Weights.x + a_Weights.y + a_Weights.z + a_Weights.w;
+    if (u_JointCount == 0 || totalWeight <= 0.0) {
+        return mat4(1.0);
+    }
+
+    ivec4 joints = clamp(ivec4(a_Joints + 0.5), 0, u_JointCount - 1);
+    return (a_Weights.x * u_Skeleton.JointMats[joints.x] +
+            a_Weights.y * u_Skeleton.JointMats[joints.y] +
+            a_Weights.z * u_Skeleton.JointMats[joints.z] +
+            a_Weights.w * u_Skeleton.JointMats[joints.w]) / totalWeight;
+}
+
+void main() {
+    mat4 worldMat = u_WorldMat * skinMatrix();
+    FragPos = vec3(worldMat * vec4(a_Position, 1.0));
+    vec3 T = normalize(mat3(worldMat) * a_Tangent);
+    vec3 B = normalize(mat3(worldMat) * a_Bitangent);
+    vec3 N = normalize(mat3(worldMat) * a_Normal);
+    TBN = mat3(T, B, N);
+    UV = a_UV;
+    CameraPos = u_Camera.Position.xyz;
+    Tint = vec4(1.0);
+    gl_Position = u_Camera.ViewProjMat * vec4(FragPos, 1.0);
+}
@@ -10,6 +10,7 @@ out vec3 FragPos;
 out mat3 TBN;
 out vec2 UV;
 out vec3 CameraPos;
+out vec4 Tint;
 
 uniform mat4 u_WorldMat;
 
@@ -28,5 +29,6 @@ void main() {
     TBN = mat3(T, B, N);
     UV = a_UV;
     CameraPos = u_Camera.Position.xyz;
+    Tint = vec4(1.0);
     gl_Position = u_Camera.ViewProjMat * vec4(FragPos, 1.0);
 }
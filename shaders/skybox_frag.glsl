@@ -0,0 +1,12 @@
+#version 410 core
+
+in vec3 Direction;
+
+out vec4 FragColor;
+
+uniform samplerCube u_SkyboxMap;
+uniform vec4 u_Color;
+
+void main() {
+    FragColor = texture(u_SkyboxMap, Direction) * u_Color;
+}
@@ -0,0 +1,13 @@
+#version 410 core
+
+in vec3 a_Position;
+
+out vec3 Direction;
+
+uniform mat4 u_ViewProjMat;
+
+void main() {
+    Direction = a_Position;
+    vec4 pos = u_ViewProjMat * vec4(a_Position, 1.0);
+    gl_Position = pos.xyww; // always at the far plane
+}
@@ -0,0 +1,36 @@
+#version 410 core
+
+in vec2 UV;
+
+out vec4 FragColor;
+
+uniform sampler2D u_SceneMap;
+uniform int u_Operator;
+uniform float u_Exposure;
+uniform float u_Gamma;
+
+// The gamma with which the default 3D shaders encode their output
+const float GAMMA = 2.2;
+
+const int REINHARD_TONE_MAPPING = 1;
+const int ACES_TONE_MAPPING = 2;
+
+// Krzysztof Narkowicz's fit of the ACES filmic curve
+vec3 aces(vec3 x)
+{
+    return clamp((x * (2.51 * x + 0.03)) / (x * (2.43 * x + 0.59) + 0.14), 0.0, 1.0);
+}
+
+void main()
+{
+    vec4 scene = texture(u_SceneMap, UV);
+    vec3 color = pow(max(scene.rgb, vec3(0.0)), vec3(GAMMA)) * u_Exposure;
+
+    if (u_Operator == REINHARD_TONE_MAPPING) {
+        color = color / (color + vec3(1.0));
+    } else if (u_Operator == ACES_TONE_MAPPING) {
+        color = aces(color);
+    }
+
+    FragColor = vec4(pow(color, vec3(1.0 / u_Gamma)), scene.a);
+}
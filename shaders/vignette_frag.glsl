@@ -0,0 +1,20 @@
+#version 410 core
+
+in vec2 UV;
+
+out vec4 FragColor;
+
+uniform sampler2D u_SceneMap;
+uniform vec4 u_Color;
+uniform float u_Intensity;
+uniform float u_Radius;
+uniform float u_Softness;
+
+void main()
+{
+    vec4 scene = texture(u_SceneMap, UV);
+    float dist = distance(UV, vec2(0.5)) * 1.41421356; // 1.0 at the corners
+    float vignette = smoothstep(u_Radius - u_Softness, u_Radius, dist);
+    vec3 color = mix(scene.rgb, u_Color.rgb, vignette * u_Intensity * u_Color.a);
+    FragColor = vec4(color, scene.a);
+}
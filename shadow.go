@@ -0,0 +1,467 @@
+package gfx
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+	"sync"
+)
+
+const (
+	defaultShadowMapperName = "ShadowMapper"
+	defaultShadowBias       = 0.005
+	defaultShadowRadius     = 10
+	defaultSpotShadowFar    = 100
+	spotShadowNear          = 0.1
+)
+
+/******************************************************************************
+ shadowCaster
+******************************************************************************/
+
+// shadowCaster objects are rendered into the shadow maps of the ShadowMapper
+// assigned to the lighting object they use.
+type shadowCaster interface {
+	WindowObject
+
+	// castsShadows shall return true if the object should be rendered into
+	// the shadow maps generated for the given lighting object.
+	castsShadows(lighting *BasicLighting) bool
+
+	// drawDepth shall render the depth of the object using the given
+	// (already activated) shader, setting its world matrix uniform.  The
+	// shader is ShadowDepthInstancedShader for InstancedShape3D objects and
+	// ShadowDepthShader otherwise.
+	drawDepth(shader Shader, worldMatLoc int32)
+}
+
+/******************************************************************************
+ framePreparer
+******************************************************************************/
+
+// framePreparer services are given the opportunity to render (e.g., into
+// their own framebuffers) after all objects have been updated but before
+// any objects are drawn, so that what they render reflects the current frame.
+type framePreparer interface {
+	prepareFrame()
+}
+
+/******************************************************************************
+ ShadowMapper
+******************************************************************************/
+
+// ShadowMapper A Service that renders a depth map (shadow map) from the
+// perspective of each enabled DirectionalLight/SpotLight of a BasicLighting
+// object with CastShadows set to true, up to MaxShadowCount lights.  The
+// maps are rendered after objects are updated but before any are drawn,
+// from the Shape3D objects that use the lighting object and have shadow
+// casting enabled, then sampled
+// (using percentage-closer filtering) by the default 3D shaders when drawing
+// Shape3D objects that have shadow receiving enabled.  The resolution of the
+// maps and the size of the PCF kernel are determined by the QualityLevel.
+//
+// As directional lights have no position, their shadows are rendered for the
+// region of the scene within the bounding sphere given to SetBounds().
+type ShadowMapper struct {
+	ServiceBase
+
+	lighting *BasicLighting
+
+	quality      QualityLevel
+	bias         float32
+	boundsCenter mgl32.Vec3
+	boundsRadius float32
+
+	resolution int32
+	texture    uint32
+	fbo        uint32
+
+	shader           Shader
+	worldMatLoc      int32
+	lightViewProjLoc int32
+
+	instancedShader           Shader
+	instancedWorldMatLoc      int32
+	instancedLightViewProjLoc int32
+
+	framebufferBak int32
+	viewportBak    [4]int32
+
+	qualityChanged bool
+	stateMutex     sync.Mutex
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (m *ShadowMapper) Init() (ok bool) {
+	if m.Initialized() {
+		return true
+	}
+
+	m.shader = m.window.Assets().Get(ShadowDepthShader).(Shader)
+	m.worldMatLoc = m.shader.GetUniformLocation("u_WorldMat")
+	m.lightViewProjLoc = m.shader.GetUniformLocation("u_LightViewProjMat")
+
+	m.instancedShader = m.window.Assets().Get(ShadowDepthInstancedShader).(Shader)
+	m.instancedWorldMatLoc = m.instancedShader.GetUniformLocation("u_WorldMat")
+	m.instancedLightViewProjLoc = m.instancedShader.GetUniformLocation("u_LightViewProjMat")
+
+	gl.GenFramebuffers(1, &m.fbo)
+	m.initTexture()
+
+	m.lighting.setShadowMapper(m)
+
+	return m.ServiceBase.Init()
+}
+
+func (m *ShadowMapper) Update(deltaTime int64) (ok bool) {
+	if !m.ServiceBase.Update(deltaTime) {
+		m.lighting.setShadows(nil)
+		return false
+	}
+
+	m.stateMutex.Lock()
+	if m.qualityChanged {
+		m.qualityChanged = false
+		m.closeTexture()
+		m.initTexture()
+	}
+	m.stateMutex.Unlock()
+
+	return true
+}
+
+func (m *ShadowMapper) Close() {
+	if !m.Initialized() {
+		return
+	}
+
+	m.lighting.setShadowMapper(nil)
+
+	m.closeTexture()
+	gl.DeleteFramebuffers(1, &m.fbo)
+	m.fbo = 0
+
+	m.ServiceBase.Close()
+}
+
+/******************************************************************************
+ ShadowMapper Functions
+******************************************************************************/
+
+// prepareFrame Renders the shadow maps once all objects have been updated,
+// so that shadows do not lag behind the objects casting them.
+func (m *ShadowMapper) prepareFrame() {
+	if !m.Initialized() || !m.Enabled() {
+		return
+	}
+	m.renderShadowMaps()
+}
+
+func (m *ShadowMapper) initTexture() {
+	m.resolution = shadowMapResolution(m.quality)
+
+	gl.GenTextures(1, &m.texture)
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, m.texture)
+	gl.TexImage3D(gl.TEXTURE_2D_ARRAY, 0, gl.DEPTH_COMPONENT24, m.resolution, m.resolution, MaxShadowCount,
+		0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+	gl.TexParameteri(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+	borderColor := [4]float32{1, 1, 1, 1} // outside the map is never in shadow
+	gl.TexParameterfv(gl.TEXTURE_2D_ARRAY, gl.TEXTURE_BORDER_COLOR, &borderColor[0])
+	gl.BindTexture(gl.TEXTURE_2D_ARRAY, 0)
+}
+
+func (m *ShadowMapper) closeTexture() {
+	gl.DeleteTextures(1, &m.texture)
+	m.texture = 0
+}
+
+func (m *ShadowMapper) shadowMap() uint32 {
+	if !m.Initialized() || !m.Enabled() {
+		return 0
+	}
+	return m.texture
+}
+
+// collectCasters Returns the objects, from anywhere in the window's object
+// hierarchy (including the shapes of Scene objects), to be rendered into the
+// shadow maps.
+func (m *ShadowMapper) collectCasters() (casters []shadowCaster) {
+	var collect func(objects []WindowObject)
+	collect = func(objects []WindowObject) {
+		for _, o := range objects {
+			if !o.Enabled() || !o.Visible() {
+				continue
+			}
+			if caster, ok := o.(shadowCaster); ok && caster.castsShadows(m.lighting) {
+				casters = append(casters, caster)
+			} else if scene, ok := o.(*Scene); ok {
+				for _, shape := range scene.shapes() {
+					if shape.castsShadows(m.lighting) {
+						casters = append(casters, shape)
+					}
+				}
+			}
+			collect(o.Children())
+		}
+	}
+
+	for _, o := range m.window.objects {
+		if wo, ok := o.(WindowObject); ok {
+			collect([]WindowObject{wo})
+		}
+	}
+	return
+}
+
+// lightViewProjection Returns the view-projection matrix used to render the
+// shadow map of the given light, or false if the light cannot cast shadows.
+func (m *ShadowMapper) lightViewProjection(light Light) (viewProj mgl32.Mat4, ok bool) {
+	light.Lock()
+	defer light.Unlock()
+
+	switch l := light.(type) {
+	case *DirectionalLight:
+		if !l.CastShadows {
+			return
+		}
+		dir := normalizeOrDefault(l.Direction, mgl32.Vec3{0, -1, 0})
+		radius := m.boundsRadius
+		eye := m.boundsCenter.Sub(dir.Mul(radius * 2))
+		view := mgl32.LookAtV(eye, m.boundsCenter, shadowUpVector(dir))
+		proj := mgl32.Ortho(-radius, radius, -radius, radius, 0, radius*4)
+		return proj.Mul4(view), true
+	case *SpotLight:
+		if !l.CastShadows {
+			return
+		}
+		dir := normalizeOrDefault(l.Direction, mgl32.Vec3{0, -1, 0})
+		far := l.Range
+		if far <= 0 {
+			far = defaultSpotShadowFar
+		}
+		fov := mgl32.DegToRad(float32(math.Min(float64(l.OuterConeAngle*2), 170)))
+		view := mgl32.LookAtV(l.Position, l.Position.Add(dir), shadowUpVector(dir))
+		proj := mgl32.Perspective(fov, 1, spotShadowNear, far)
+		return proj.Mul4(view), true
+	default:
+		return
+	}
+}
+
+func (m *ShadowMapper) renderShadowMaps() {
+	m.stateMutex.Lock()
+	bias := m.bias
+	pcfRadius := shadowPcfRadius(m.quality)
+	m.stateMutex.Unlock()
+
+	shadows := make(map[Light]lightShadow)
+	for _, light := range m.lighting.Lights() {
+		if len(shadows) == MaxShadowCount {
+			break
+		}
+		if !light.Enabled() {
+			continue
+		}
+		if viewProj, ok := m.lightViewProjection(light); ok {
+			shadows[light] = lightShadow{
+				layer:     len(shadows),
+				viewProj:  viewProj,
+				bias:      bias,
+				pcfRadius: pcfRadius,
+			}
+		}
+	}
+
+	if len(shadows) > 0 {
+		m.beginRender()
+		var casters, instancedCasters []shadowCaster
+		for _, caster := range m.collectCasters() {
+			if _, ok := caster.(*InstancedShape3D); ok {
+				instancedCasters = append(instancedCasters, caster)
+			} else {
+				casters = append(casters, caster)
+			}
+		}
+		for _, shadow := range shadows {
+			gl.FramebufferTextureLayer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, m.texture, 0, int32(shadow.layer))
+			gl.Clear(gl.DEPTH_BUFFER_BIT)
+
+			m.shader.Activate()
+			gl.UniformMatrix4fv(m.lightViewProjLoc, 1, false, &shadow.viewProj[0])
+			for _, caster := range casters {
+				caster.drawDepth(m.shader, m.worldMatLoc)
+			}
+
+			if len(instancedCasters) > 0 {
+				m.instancedShader.Activate()
+				gl.UniformMatrix4fv(m.instancedLightViewProjLoc, 1, false, &shadow.viewProj[0])
+				for _, caster := range instancedCasters {
+					caster.drawDepth(m.instancedShader, m.instancedWorldMatLoc)
+				}
+			}
+		}
+		m.endRender()
+	}
+
+	m.lighting.setShadows(shadows)
+}
+
+func (m *ShadowMapper) beginRender() {
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &m.framebufferBak)
+	gl.GetIntegerv(gl.VIEWPORT, &m.viewportBak[0])
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, m.fbo)
+	gl.DrawBuffer(gl.NONE)
+	gl.ReadBuffer(gl.NONE)
+	gl.Viewport(0, 0, m.resolution, m.resolution)
+	gl.Enable(gl.DEPTH_TEST)
+	gl.Enable(gl.POLYGON_OFFSET_FILL)
+	gl.PolygonOffset(1.1, 4)
+}
+
+func (m *ShadowMapper) endRender() {
+	gl.Disable(gl.POLYGON_OFFSET_FILL)
+	gl.Disable(gl.DEPTH_TEST)
+
+	gl.BindVertexArray(0)
+	gl.UseProgram(0)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(m.framebufferBak))
+	gl.Viewport(m.viewportBak[0], m.viewportBak[1], m.viewportBak[2], m.viewportBak[3])
+}
+
+func (m *ShadowMapper) Lighting() *BasicLighting {
+	return m.lighting
+}
+
+func (m *ShadowMapper) Quality() (quality QualityLevel) {
+	m.stateMutex.Lock()
+	quality = m.quality
+	m.stateMutex.Unlock()
+	return
+}
+
+// SetQuality sets the quality level, which determines the resolution of
+// the shadow maps and the size of the PCF kernel used to soften the edges
+// of the shadows.
+func (m *ShadowMapper) SetQuality(quality QualityLevel) *ShadowMapper {
+	m.stateMutex.Lock()
+	m.quality = quality
+	m.qualityChanged = m.Initialized()
+	m.stateMutex.Unlock()
+	return m
+}
+
+func (m *ShadowMapper) Bias() (bias float32) {
+	m.stateMutex.Lock()
+	bias = m.bias
+	m.stateMutex.Unlock()
+	return
+}
+
+// SetBias sets the depth bias applied when sampling the shadow maps, which
+// can be increased to eliminate "shadow acne" or decreased to prevent
+// shadows from appearing detached from the objects casting them.
+func (m *ShadowMapper) SetBias(bias float32) *ShadowMapper {
+	m.stateMutex.Lock()
+	m.bias = bias
+	m.stateMutex.Unlock()
+	return m
+}
+
+func (m *ShadowMapper) Bounds() (center mgl32.Vec3, radius float32) {
+	m.stateMutex.Lock()
+	center, radius = m.boundsCenter, m.boundsRadius
+	m.stateMutex.Unlock()
+	return
+}
+
+// SetBounds sets the bounding sphere of the region of the scene in which
+// shadows are cast from directional lights.  Smaller bounds produce sharper
+// shadows.
+func (m *ShadowMapper) SetBounds(center mgl32.Vec3, radius float32) *ShadowMapper {
+	m.stateMutex.Lock()
+	m.boundsCenter = center
+	m.boundsRadius = radius
+	m.stateMutex.Unlock()
+	return m
+}
+
+/******************************************************************************
+ New ShadowMapper Function
+******************************************************************************/
+
+// NewShadowMapper creates a service that renders shadow maps for the given
+// lighting object.  It must be added to the window via AddService().
+func NewShadowMapper(lighting *BasicLighting, quality ...QualityLevel) *ShadowMapper {
+	if lighting == nil {
+		panic("lighting cannot be nil")
+	}
+
+	m := &ShadowMapper{
+		lighting:     lighting,
+		quality:      MediumQuality,
+		bias:         defaultShadowBias,
+		boundsRadius: defaultShadowRadius,
+	}
+
+	if len(quality) > 0 {
+		m.quality = quality[0]
+	}
+
+	m.SetName(defaultShadowMapperName)
+	m.enabled.Store(true)
+	return m
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func shadowMapResolution(quality QualityLevel) int32 {
+	switch quality {
+	case LowestQuality:
+		return 256
+	case VeryLowQuality:
+		return 512
+	case LowQuality, MediumQuality:
+		return 1024
+	case HighQuality, VeryHighQuality:
+		return 2048
+	default:
+		return 4096
+	}
+}
+
+func shadowPcfRadius(quality QualityLevel) int32 {
+	switch quality {
+	case LowestQuality, VeryLowQuality:
+		return 0
+	case LowQuality, MediumQuality, HighQuality:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func shadowUpVector(dir mgl32.Vec3) mgl32.Vec3 {
+	if math.Abs(float64(dir.Y())) > 0.99 {
+		return mgl32.Vec3{0, 0, 1}
+	}
+	return mgl32.Vec3{0, 1, 0}
+}
+
+func normalizeOrDefault(v, fallback mgl32.Vec3) mgl32.Vec3 {
+	if v.Len() < 1e-6 {
+		return fallback
+	}
+	return v.Normalize()
+}
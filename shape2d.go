@@ -149,6 +149,8 @@ func (s *Shape2D) Draw(deltaTime int64) (ok bool) {
 	if s.blurEnabled {
 		gl.BlendFunc(gl.ONE, gl.ONE)
 
+		var frameBufferBak int32
+		gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &frameBufferBak)
 		gl.BindFramebuffer(gl.FRAMEBUFFER, s.blurShapeFrameBuffer)
 
 		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, s.blurShapeTexture, 0)
@@ -171,7 +173,7 @@ func (s *Shape2D) Draw(deltaTime int64) (ok bool) {
 		s.renderBlurTexture(s.blurXTexture, s.blurTex1UniformLoc)
 
 		s.textureShader.Activate()
-		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(frameBufferBak))
 		s.renderBlurTexture(s.blurXYTexture, s.blurTex2UniformLoc)
 	} else {
 		gl.DrawArrays(s.drawMode, 0, s.vertexCount)
@@ -191,7 +193,7 @@ func (s *Shape2D) Resize(newWidth, newHeight int) {
 		s.viewport.SetWindowSize(newWidth, newHeight)
 	}
 
-	if s.blurEnabled {
+	if s.blurEnabled && s.viewport != nil {
 		s.stateMutex.Lock()
 		s.closeBlurVao()
 		s.initBlurVao()
@@ -666,6 +668,9 @@ func (s *Shape2D) initShapeVao() {
 }
 
 func (s *Shape2D) initBlurVao() {
+	var frameBufferBak int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &frameBufferBak)
+
 	gl.GenVertexArrays(1, &s.blurTextureVao)
 	gl.GenBuffers(1, &s.blurTextureVbo)
 
@@ -685,13 +690,17 @@ func (s *Shape2D) initBlurVao() {
 
 	gl.ActiveTexture(gl.TEXTURE0)
 
+	// Sized by the viewport, as the shape may be drawn into a RenderTarget
+	winWidth, winHeight := s.viewport.WindowSize()
+	width, height := int32(winWidth), int32(winHeight)
+
 	gl.GenTextures(1, &s.blurShapeTexture)
 	gl.BindTexture(gl.TEXTURE_2D, s.blurShapeTexture)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(s.Window().Width()), int32(s.Window().Height()), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
 
 	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, s.blurShapeTexture, 0)
 
@@ -701,7 +710,7 @@ func (s *Shape2D) initBlurVao() {
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(s.Window().Width()), int32(s.Window().Height()), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
 
 	gl.GenTextures(1, &s.blurXYTexture)
 	gl.BindTexture(gl.TEXTURE_2D, s.blurXYTexture)
@@ -709,9 +718,9 @@ func (s *Shape2D) initBlurVao() {
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(s.Window().Width()), int32(s.Window().Height()), 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, width, height, 0, gl.RGBA, gl.UNSIGNED_BYTE, nil)
 
-	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(frameBufferBak))
 	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
 	gl.BindVertexArray(0)
 }
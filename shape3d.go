@@ -2,10 +2,14 @@ package gfx
 
 import (
 	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+	"time"
 )
 
 const (
-	defaultShape3DName = "Shape3D"
+	defaultShape3DName  = "Shape3D"
+	defaultReflectivity = 0.5
 )
 
 /******************************************************************************
@@ -23,10 +27,26 @@ type Shape3D struct {
 	modelInstance *modelInstance
 	modelRenderer *modelRenderer
 
+	castShadows    bool
+	receiveShadows bool
+
+	environmentMap      *TextureCube
+	ownedEnvironmentMap ownedTextureCube
+	reflectivity        float32
+
 	cameraChanged   bool
 	lightingChanged bool
 
 	viewportBak [4]int32
+
+	sceneNode *SceneNode
+
+	skeleton *skeletonPlayer
+
+	debugMode         DebugMode
+	debugNormalLength float32
+	debugKeys         []*debugKeyBinding
+	debugRenderer     *debugRenderer
 }
 
 /******************************************************************************
@@ -40,10 +60,25 @@ func (s *Shape3D) Init() (ok bool) {
 
 	s.initViewport()
 	s.initModel()
+	s.addDebugKeyHandlers()
 
 	return s.WindowObjectBase.Init()
 }
 
+func (s *Shape3D) Update(deltaTime int64) (ok bool) {
+	if !s.WindowObjectBase.Update(deltaTime) {
+		return false
+	}
+
+	s.stateMutex.Lock()
+	if s.skeleton != nil {
+		s.skeleton.advance(deltaTime)
+	}
+	s.stateMutex.Unlock()
+
+	return true
+}
+
 func (s *Shape3D) Close() {
 	if !s.Initialized() {
 		return
@@ -57,6 +92,14 @@ func (s *Shape3D) Close() {
 		s.modelRenderer.close()
 	}
 
+	if s.debugRenderer != nil {
+		s.debugRenderer.close()
+		s.debugRenderer = nil
+	}
+
+	s.removeDebugKeyHandlers()
+	s.ownedEnvironmentMap.close()
+
 	s.WindowObjectBase.Close()
 }
 
@@ -72,6 +115,7 @@ func (s *Shape3D) Draw(deltaTime int64) (ok bool) {
 	s.beginDraw()
 	s.updateScene()
 	s.draw()
+	s.drawDebug()
 	s.endDraw()
 
 	return s.WindowObjectBase.drawChildren(deltaTime)
@@ -100,8 +144,12 @@ func (s *Shape3D) initViewport() {
 }
 
 func (s *Shape3D) initModel() {
-	s.modelInstance = newModelInstance(s.modelAsset, s)
-	s.modelRenderer = newModelRenderer(s.modelInstance)
+	s.initModelInstance(newModelInstance(s.modelAsset, s, nil))
+}
+
+func (s *Shape3D) initModelInstance(instance *modelInstance) {
+	s.modelInstance = instance
+	s.modelRenderer = newModelRenderer(instance)
 
 	if s.camera != nil {
 		s.modelRenderer.setCamera(s.camera)
@@ -134,6 +182,26 @@ func (s *Shape3D) updateScene() {
 		s.modelRenderer.setLighting(s.lighting)
 	}
 
+	shadowMap := uint32(0)
+	if lighting, ok := s.lighting.(*BasicLighting); ok {
+		shadowMap = lighting.shadowMap()
+	}
+	s.modelRenderer.setShadows(shadowMap, s.receiveShadows)
+
+	s.ownedEnvironmentMap.use(s.environmentMap, s.window.Assets())
+	environmentMap, environmentMaxLod := uint32(0), float32(0)
+	if s.environmentMap != nil {
+		environmentMap = s.environmentMap.GlName()
+		environmentMaxLod = s.environmentMap.maxLod()
+	}
+	s.modelRenderer.setEnvironment(environmentMap, environmentMaxLod, s.reflectivity)
+
+	if s.modelInstance.skinned() {
+		if player := s.skeletonPlayer(); player != nil {
+			s.modelRenderer.setJointMatrices(player.jointMatrices())
+		}
+	}
+
 	s.stateMutex.Unlock()
 }
 
@@ -141,6 +209,24 @@ func (s *Shape3D) draw() {
 	s.modelRenderer.render()
 }
 
+func (s *Shape3D) drawDebug() {
+	s.stateMutex.Lock()
+	mode := s.debugMode
+	camera := s.camera
+	normalLength := s.debugNormalLength
+	s.stateMutex.Unlock()
+
+	if mode == DebugNone || camera == nil {
+		return
+	}
+
+	if s.debugRenderer == nil {
+		s.debugRenderer = newDebugRenderer(s.window.Assets().Get(DebugLineShader).(Shader))
+	}
+
+	s.debugRenderer.render(mode, camera, s.modelInstance, normalLength)
+}
+
 func (s *Shape3D) endDraw() {
 	gl.Viewport(s.viewportBak[0], s.viewportBak[1], s.viewportBak[2], s.viewportBak[3])
 
@@ -196,13 +282,356 @@ func (s *Shape3D) SetLighting(lighting any) *Shape3D {
 	return s
 }
 
+func (s *Shape3D) CastShadows() (enabled bool) {
+	s.stateMutex.Lock()
+	enabled = s.castShadows
+	s.stateMutex.Unlock()
+	return
+}
+
+// SetCastShadows determines whether the shape is rendered into the shadow
+// maps generated by the ShadowMapper assigned to its lighting, which must
+// be a *BasicLighting object.  Defaults to true.
+func (s *Shape3D) SetCastShadows(enabled bool) *Shape3D {
+	s.stateMutex.Lock()
+	s.castShadows = enabled
+	s.stateMutex.Unlock()
+	return s
+}
+
+func (s *Shape3D) ReceiveShadows() (enabled bool) {
+	s.stateMutex.Lock()
+	enabled = s.receiveShadows
+	s.stateMutex.Unlock()
+	return
+}
+
+// SetReceiveShadows determines whether shadows cast by other shapes (or
+// this one) are drawn onto the shape.  Defaults to true.
+func (s *Shape3D) SetReceiveShadows(enabled bool) *Shape3D {
+	s.stateMutex.Lock()
+	s.receiveShadows = enabled
+	s.stateMutex.Unlock()
+	return s
+}
+
+func (s *Shape3D) EnvironmentMap() (environmentMap *TextureCube) {
+	s.stateMutex.Lock()
+	environmentMap = s.environmentMap
+	s.stateMutex.Unlock()
+	return
+}
+
+// SetEnvironmentMap sets the cube map reflected by the shape, in proportion
+// to its reflectivity, when rendered with one of the default 3D shaders
+// (other than Shape3DNoLightsShader), such as the texture of a Skybox.
+// Pass nil to disable reflections, which is the default.  As with
+// Skybox.SetTexture(), a cube map that is not in the window's asset library
+// is initialized when first drawn and closed by the shape.
+func (s *Shape3D) SetEnvironmentMap(environmentMap *TextureCube) *Shape3D {
+	s.stateMutex.Lock()
+	s.environmentMap = environmentMap
+	s.stateMutex.Unlock()
+	return s
+}
+
+func (s *Shape3D) Reflectivity() (reflectivity float32) {
+	s.stateMutex.Lock()
+	reflectivity = s.reflectivity
+	s.stateMutex.Unlock()
+	return
+}
+
+// SetReflectivity sets how much of the environment map is reflected by the
+// shape, from 0 (none) to 1 (a perfect mirror).  With PBR materials, the
+// reflection is further attenuated by the roughness (which blurs it) and
+// Fresnel factor of the surface.  Defaults to 0.5.
+func (s *Shape3D) SetReflectivity(reflectivity float32) *Shape3D {
+	s.stateMutex.Lock()
+	s.reflectivity = mgl32.Clamp(reflectivity, 0, 1)
+	s.stateMutex.Unlock()
+	return s
+}
+
+func (s *Shape3D) DebugMode() (mode DebugMode) {
+	s.stateMutex.Lock()
+	mode = s.debugMode
+	s.stateMutex.Unlock()
+	return
+}
+
+// SetDebugMode sets the debug visualizations rendered over the shape, such
+// as its wireframe, normals and bounding boxes, which can be combined (e.g.,
+// DebugWireframe | DebugFaceNormals), with DebugNone disabling them all.  A
+// camera must be assigned to the shape for them to be rendered.
+func (s *Shape3D) SetDebugMode(mode DebugMode) *Shape3D {
+	s.stateMutex.Lock()
+	s.debugMode = mode
+	s.stateMutex.Unlock()
+	return s
+}
+
+// ToggleDebugMode enables the given debug visualizations if they are
+// disabled, and vice versa.
+func (s *Shape3D) ToggleDebugMode(mode DebugMode) *Shape3D {
+	s.stateMutex.Lock()
+	s.debugMode ^= mode
+	s.stateMutex.Unlock()
+	return s
+}
+
+func (s *Shape3D) DebugNormalLength() (length float32) {
+	s.stateMutex.Lock()
+	length = s.debugNormalLength
+	s.stateMutex.Unlock()
+	return
+}
+
+// SetDebugNormalLength sets the length, in model units, of the lines
+// rendered for normals and tangent frames.  When 0 (the default), the
+// length is relative to the size of the largest mesh of the model.
+func (s *Shape3D) SetDebugNormalLength(length float32) *Shape3D {
+	s.stateMutex.Lock()
+	s.debugNormalLength = max(length, 0)
+	s.stateMutex.Unlock()
+	return s
+}
+
+// BindDebugKey toggles the given debug visualizations whenever the key is
+// pressed, via Window.AddKeyEventHandler(), once the shape has been added
+// to a window.
+func (s *Shape3D) BindDebugKey(key glfw.Key, mode DebugMode) *Shape3D {
+	s.stateMutex.Lock()
+	s.debugKeys = append(s.debugKeys, &debugKeyBinding{key: key, mode: mode})
+	s.stateMutex.Unlock()
+
+	s.addDebugKeyHandlers()
+	return s
+}
+
+// addDebugKeyHandlers Adds a key event handler to the window for each key
+// binding not already added.  The handlers are added without holding the
+// state mutex, as the window holds its own mutex when invoking them.
+func (s *Shape3D) addDebugKeyHandlers() {
+	s.stateMutex.Lock()
+	window := s.window
+	var bindings []*debugKeyBinding
+	if window != nil {
+		for _, binding := range s.debugKeys {
+			if !binding.added {
+				binding.added = true
+				bindings = append(bindings, binding)
+			}
+		}
+	}
+	s.stateMutex.Unlock()
+
+	for _, binding := range bindings {
+		mode := binding.mode
+		window.AddKeyEventHandler(binding, binding.key, glfw.Press, func(_ *Window, _ glfw.Key, _ glfw.Action) {
+			s.ToggleDebugMode(mode)
+		})
+	}
+}
+
+func (s *Shape3D) removeDebugKeyHandlers() {
+	s.stateMutex.Lock()
+	window := s.window
+	var bindings []*debugKeyBinding
+	for _, binding := range s.debugKeys {
+		if binding.added {
+			binding.added = false
+			bindings = append(bindings, binding)
+		}
+	}
+	s.stateMutex.Unlock()
+
+	if window == nil {
+		return
+	}
+
+	for _, binding := range bindings {
+		window.RemoveKeyEventHandlers(binding)
+	}
+}
+
 func (s *Shape3D) SetModel(model Model) *Shape3D {
 	s.stateMutex.Lock()
 	s.modelAsset = model
+	s.skeleton = nil
+	s.stateMutex.Unlock()
+	return s
+}
+
+// skeletonPlayer Returns the player of the animation clips of the model, if
+// it is a SkinnedModel, creating it on first use.  The state mutex must be
+// locked.
+func (s *Shape3D) skeletonPlayer() *skeletonPlayer {
+	if s.skeleton == nil {
+		if model, ok := s.modelAsset.(SkinnedModel); ok {
+			s.skeleton = newSkeletonPlayer(model)
+		}
+	}
+	return s.skeleton
+}
+
+// ClipNames returns the names of the animation clips of the model, if it is
+// a SkinnedModel that has been loaded.
+func (s *Shape3D) ClipNames() (names []string) {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		names = player.clipNames()
+	}
+	s.stateMutex.Unlock()
+	return
+}
+
+// PlayClip starts playing the named animation clip of the model, which must
+// be a SkinnedModel, from the beginning.  If a fade duration is given, the
+// pose of the skeleton is blended from the clip currently playing (if any)
+// to the new clip over that period of time.  The clip is looked up once the
+// model has been loaded, so it can be played before the shape is
+// initialized.
+func (s *Shape3D) PlayClip(name string, fade ...time.Duration) *Shape3D {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		fadeDuration := time.Duration(0)
+		if len(fade) > 0 {
+			fadeDuration = fade[0]
+		}
+		player.play(name, fadeDuration)
+	}
+	s.stateMutex.Unlock()
+	return s
+}
+
+// PauseClip pauses the clip currently playing, holding its pose.
+func (s *Shape3D) PauseClip() *Shape3D {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		player.playing = false
+	}
+	s.stateMutex.Unlock()
+	return s
+}
+
+// ResumeClip resumes playing the current clip, if paused.
+func (s *Shape3D) ResumeClip() *Shape3D {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil && player.current != nil {
+		player.playing = true
+	}
+	s.stateMutex.Unlock()
+	return s
+}
+
+// StopClip stops playing the current clip, returning the skeleton to its
+// rest pose.
+func (s *Shape3D) StopClip() *Shape3D {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		player.stop()
+	}
 	s.stateMutex.Unlock()
 	return s
 }
 
+// SeekClip moves the current clip to the given time, cancelling any fade
+// from the previous clip.
+func (s *Shape3D) SeekClip(t time.Duration) *Shape3D {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		player.seek(t)
+	}
+	s.stateMutex.Unlock()
+	return s
+}
+
+// ClipName returns the name of the current clip, if any.
+func (s *Shape3D) ClipName() (name string) {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil && player.current != nil {
+		name = player.current.name
+	}
+	s.stateMutex.Unlock()
+	return
+}
+
+// ClipTime returns the time within the current clip.
+func (s *Shape3D) ClipTime() (t time.Duration) {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		t = player.clipTime()
+	}
+	s.stateMutex.Unlock()
+	return
+}
+
+// ClipPlaying returns true if the current clip is playing; i.e., it has
+// not been paused, stopped or reached its end (when not looping).
+func (s *Shape3D) ClipPlaying() (playing bool) {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		playing = player.playing
+	}
+	s.stateMutex.Unlock()
+	return
+}
+
+func (s *Shape3D) ClipLooping() (looping bool) {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		looping = player.looping
+	}
+	s.stateMutex.Unlock()
+	return
+}
+
+// SetClipLooping determines whether clips restart once they reach their
+// end, rather than holding their final pose.  Defaults to true.
+func (s *Shape3D) SetClipLooping(looping bool) *Shape3D {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		player.looping = looping
+		player.dirty = true
+	}
+	s.stateMutex.Unlock()
+	return s
+}
+
+func (s *Shape3D) ClipSpeed() (speed float32) {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		speed = player.speed
+	}
+	s.stateMutex.Unlock()
+	return
+}
+
+// SetClipSpeed sets the rate at which clips are played, e.g., 0.5 for half
+// speed.  Defaults to 1.
+func (s *Shape3D) SetClipSpeed(speed float32) *Shape3D {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		player.speed = max(0, speed)
+	}
+	s.stateMutex.Unlock()
+	return s
+}
+
+// JointTransform returns the current transform of the named joint of the
+// model's skeleton, in model space, which can be used to attach other
+// objects to the joint (e.g., by applying the world matrix of the shape).
+// Returns false if the model has no such joint or has not been loaded.
+func (s *Shape3D) JointTransform(name string) (transform mgl32.Mat4, ok bool) {
+	s.stateMutex.Lock()
+	if player := s.skeletonPlayer(); player != nil {
+		transform, ok = player.jointTransform(name)
+	}
+	s.stateMutex.Unlock()
+	return
+}
+
 func (s *Shape3D) Meshes() []*meshInstance {
 	if s.modelInstance == nil {
 		return nil
@@ -213,6 +642,90 @@ func (s *Shape3D) Meshes() []*meshInstance {
 	return meshes
 }
 
+// SceneNode returns the node of the Scene the shape has been placed on, if
+// any, in which case the world matrix of the node is used in place of the
+// shape's own transform.
+func (s *Shape3D) SceneNode() *SceneNode {
+	s.stateMutex.Lock()
+	node := s.sceneNode
+	s.stateMutex.Unlock()
+	return node
+}
+
+// RayAt returns the world-space ray cast from the given window position
+// (normalized device coordinates, as with MouseState) through the shape's
+// viewport and camera, or false if the position is outside the viewport
+// or the shape has no camera.
+func (s *Shape3D) RayAt(x, y float32) (ray Ray, ok bool) {
+	s.stateMutex.Lock()
+	camera := s.camera
+	viewport := s.viewport
+	s.stateMutex.Unlock()
+
+	if camera == nil || viewport == nil {
+		return
+	}
+
+	vx, vy := viewport.WindowToViewport(x, y)
+	if vx < -1 || vx > 1 || vy < -1 || vy > 1 {
+		return
+	}
+
+	return UnprojectRay(camera.ViewProjection(), vx, vy), true
+}
+
+// Raycast returns the nearest intersection of the given world-space ray
+// with the faces of the shape, testing each mesh's bounding box first.
+// The shape must be initialized.
+func (s *Shape3D) Raycast(ray Ray) (hit RayHit, ok bool) {
+	for i, mesh := range s.Meshes() {
+		if meshHit, meshOk := mesh.raycast(ray); meshOk && (!ok || meshHit.Distance < hit.Distance) {
+			hit = meshHit
+			hit.MeshIndex = i
+			ok = true
+		}
+	}
+
+	if ok {
+		hit.Shape = s
+	}
+	return
+}
+
+// Pick returns the nearest intersection of the shape with the ray cast
+// from the given window position (see RayAt()).
+func (s *Shape3D) Pick(x, y float32) (hit RayHit, ok bool) {
+	if !s.Initialized() || !s.Enabled() || !s.Visible() {
+		return
+	}
+
+	ray, ok := s.RayAt(x, y)
+	if !ok {
+		return
+	}
+
+	return s.Raycast(ray)
+}
+
+/******************************************************************************
+ shadowCaster Implementation
+******************************************************************************/
+
+func (s *Shape3D) castsShadows(lighting *BasicLighting) bool {
+	if !s.Initialized() {
+		return false
+	}
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	return s.castShadows && s.lighting == any(lighting)
+}
+
+func (s *Shape3D) drawDepth(shader Shader, worldMatLoc int32) {
+	s.stateMutex.Lock()
+	s.modelRenderer.renderDepth(shader, worldMatLoc)
+	s.stateMutex.Unlock()
+}
+
 /******************************************************************************
  New Shape3D Function
 ******************************************************************************/
@@ -220,6 +733,9 @@ func (s *Shape3D) Meshes() []*meshInstance {
 func NewShape3D() *Shape3D {
 	m := &Shape3D{
 		WindowObjectBase: *NewWindowObject(),
+		castShadows:      true,
+		receiveShadows:   true,
+		reflectivity:     defaultReflectivity,
 	}
 
 	m.SetName(defaultShape3DName)
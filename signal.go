@@ -337,7 +337,7 @@ func (l *SignalLine) Draw(deltaTime int64) (ok bool) {
 	gl.BindVertexArray(l.vao)
 	gl.BindBuffer(gl.ARRAY_BUFFER, l.vbo)
 
-	gl.Viewport(0, 0, int32(l.window.Width()), int32(l.window.Height()))
+	gl.Viewport(l.fill.Viewport().Get())
 
 	gl.BufferSubData(gl.ARRAY_BUFFER, 0, len(l.vertices)*sizeOfFloat32, gl.Ptr(l.vertices))
 
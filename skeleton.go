@@ -0,0 +1,409 @@
+package gfx
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+	"math"
+	"time"
+)
+
+const (
+	// MaxJoints The maximum number of joints of a SkinnedModel that can be
+	// animated by the skinning shaders.  Models with more joints are
+	// rendered in their bind pose.
+	MaxJoints = 128
+)
+
+/******************************************************************************
+ SkinnedModel
+******************************************************************************/
+
+// SkinnedModel An optional extension of the Model interface, for models with
+// a skeleton whose joints deform the vertices, as animated by the model's
+// clips (see Shape3D.PlayClip()).  Each vertex is influenced by up to four
+// joints, which must be rendered with a shader supporting skinning, such as
+// Shape3DSkinnedShader.  See the gltf package for an example implementation.
+type SkinnedModel interface {
+	Model
+
+	// Joints shall return the joints of the skeleton, with parents appearing
+	// before their children.  May optionally return nil, in which case the
+	// model is rendered like any other Model.
+	Joints() []Joint
+
+	// JointIndices shall return a float array containing the indices (into
+	// Joints()) of the four joints influencing each vertex.
+	JointIndices() []float32
+
+	// JointWeights shall return a float array containing the weights of the
+	// four joints influencing each vertex, which should add up to 1, or be
+	// all 0 for vertices that are not influenced by any joint.
+	JointWeights() []float32
+
+	// AnimationClips shall return the animations of the skeleton.
+	AnimationClips() []*AnimationClip
+}
+
+/******************************************************************************
+ Joint
+******************************************************************************/
+
+// Joint A bone of a skeleton, which is transformed relative to its parent
+// joint (if any) and then, when animated, by the difference between its
+// current transform and its transform in the bind pose.
+type Joint struct {
+	Name string
+
+	// Parent is the index of the parent joint, or -1 for root joints.
+	Parent int
+
+	// InverseBindMatrix transforms vertices from model space to the local
+	// space of the joint, as it was when the skin was bound to it.
+	InverseBindMatrix mgl32.Mat4
+
+	// Translation, Rotation and Scale make up the transform of the joint,
+	// relative to its parent, when not animated (the rest pose).
+	Translation mgl32.Vec3
+	Rotation    mgl32.Quat
+	Scale       mgl32.Vec3
+}
+
+/******************************************************************************
+ AnimationClip
+******************************************************************************/
+
+// AnimationClip A named animation of a skeleton, made of keyframes for the
+// translation, rotation and/or scale of its joints.  Joints without keyframes
+// in the clip hold their rest pose.
+type AnimationClip struct {
+	Name     string
+	Channels []JointChannel
+}
+
+// Duration returns the time of the last keyframe of the clip.
+func (c *AnimationClip) Duration() (duration time.Duration) {
+	for _, channel := range c.Channels {
+		if n := len(channel.Translations); n > 0 {
+			duration = max(duration, channel.Translations[n-1].Time)
+		}
+		if n := len(channel.Rotations); n > 0 {
+			duration = max(duration, channel.Rotations[n-1].Time)
+		}
+		if n := len(channel.Scales); n > 0 {
+			duration = max(duration, channel.Scales[n-1].Time)
+		}
+	}
+	return
+}
+
+// JointChannel The keyframes of a single joint within an AnimationClip, each
+// set being in order of time and optional.
+type JointChannel struct {
+	Joint        int
+	Translations []Keyframe[mgl32.Vec3]
+	Rotations    []Keyframe[mgl32.Quat]
+	Scales       []Keyframe[mgl32.Vec3]
+}
+
+/******************************************************************************
+ jointPose
+******************************************************************************/
+
+type jointPose struct {
+	translation mgl32.Vec3
+	rotation    mgl32.Quat
+	scale       mgl32.Vec3
+}
+
+func (p jointPose) matrix() mgl32.Mat4 {
+	t := mgl32.Translate3D(p.translation[0], p.translation[1], p.translation[2])
+	s := mgl32.Scale3D(p.scale[0], p.scale[1], p.scale[2])
+	return t.Mul4(p.rotation.Normalize().Mat4()).Mul4(s)
+}
+
+func blendPoses(from, to jointPose, weight float32) jointPose {
+	return jointPose{
+		translation: interpolate(from.translation, to.translation, weight),
+		rotation:    interpolate(from.rotation, to.rotation, weight),
+		scale:       interpolate(from.scale, to.scale, weight),
+	}
+}
+
+/******************************************************************************
+ clipState
+******************************************************************************/
+
+// clipState The clip being played by a skeletonPlayer, which is looked up
+// by name once the model has been loaded.
+type clipState struct {
+	name string
+	clip *AnimationClip
+	time time.Duration
+}
+
+func (c *clipState) sample(joints []Joint, pose []jointPose, looping bool) {
+	for i, joint := range joints {
+		pose[i] = jointPose{joint.Translation, joint.Rotation, joint.Scale}
+	}
+
+	if c.clip == nil {
+		return
+	}
+
+	t := c.time
+	if duration := c.clip.Duration(); looping && duration > 0 {
+		t %= duration
+	}
+
+	for _, channel := range c.clip.Channels {
+		if channel.Joint < 0 || channel.Joint >= len(pose) {
+			continue
+		}
+		p := &pose[channel.Joint]
+		if len(channel.Translations) > 0 {
+			p.translation = sampleKeyframes(channel.Translations, t)
+		}
+		if len(channel.Rotations) > 0 {
+			p.rotation = sampleKeyframes(channel.Rotations, t)
+		}
+		if len(channel.Scales) > 0 {
+			p.scale = sampleKeyframes(channel.Scales, t)
+		}
+	}
+}
+
+/******************************************************************************
+ skeletonPlayer
+******************************************************************************/
+
+// skeletonPlayer Plays the animation clips of a SkinnedModel on behalf of a
+// Shape3D, cross-fading from the previous clip when requested, and computes
+// the resulting joint matrices.  Not thread-safe; guarded by the Shape3D.
+type skeletonPlayer struct {
+	model  SkinnedModel
+	joints []Joint
+	clips  map[string]*AnimationClip
+
+	current  *clipState
+	previous *clipState
+
+	fadeTime     time.Duration
+	fadeDuration time.Duration
+
+	playing bool
+	looping bool
+	speed   float32
+
+	pose      []jointPose
+	fadePose  []jointPose
+	globals   []mgl32.Mat4
+	jointMats []mgl32.Mat4
+	dirty     bool
+}
+
+// load Takes the joints and clips of the model, once available (e.g., once
+// an imported model has been loaded).
+func (p *skeletonPlayer) load() bool {
+	if p.joints != nil {
+		return true
+	}
+
+	joints := p.model.Joints()
+	if len(joints) == 0 {
+		return false
+	}
+
+	p.joints = joints
+	p.clips = make(map[string]*AnimationClip)
+	for _, clip := range p.model.AnimationClips() {
+		if _, exists := p.clips[clip.Name]; !exists {
+			p.clips[clip.Name] = clip
+		}
+	}
+
+	p.pose = make([]jointPose, len(joints))
+	p.fadePose = make([]jointPose, len(joints))
+	p.globals = make([]mgl32.Mat4, len(joints))
+	p.jointMats = make([]mgl32.Mat4, len(joints))
+	p.dirty = true
+	return true
+}
+
+func (p *skeletonPlayer) resolve(state *clipState) {
+	if state != nil && state.clip == nil && p.load() {
+		state.clip = p.clips[state.name]
+	}
+}
+
+func (p *skeletonPlayer) clipNames() (names []string) {
+	if p.load() {
+		for _, clip := range p.model.AnimationClips() {
+			names = append(names, clip.Name)
+		}
+	}
+	return
+}
+
+func (p *skeletonPlayer) play(name string, fade time.Duration) {
+	if p.current != nil && fade > 0 {
+		p.previous = p.current
+		p.fadeTime = 0
+		p.fadeDuration = fade
+	} else {
+		p.previous = nil
+	}
+
+	p.current = &clipState{name: name}
+	p.resolve(p.current)
+	p.playing = true
+	p.dirty = true
+}
+
+func (p *skeletonPlayer) stop() {
+	p.current = nil
+	p.previous = nil
+	p.playing = false
+	p.dirty = true
+}
+
+func (p *skeletonPlayer) seek(t time.Duration) {
+	if p.current != nil {
+		p.current.time = max(0, t)
+		p.previous = nil
+		p.dirty = true
+	}
+}
+
+// advance Moves the clip(s) forward by the given time, in microseconds.
+func (p *skeletonPlayer) advance(deltaTime int64) {
+	if !p.playing || p.current == nil {
+		return
+	}
+
+	elapsed := time.Duration(float64(deltaTime) * float64(p.speed) * float64(time.Microsecond))
+	if elapsed <= 0 {
+		return
+	}
+
+	p.resolve(p.current)
+	p.current.time += elapsed
+	if p.current.clip != nil && !p.looping {
+		if duration := p.current.clip.Duration(); p.current.time >= duration {
+			p.current.time = duration
+			p.playing = false
+		}
+	}
+
+	if p.previous != nil {
+		p.previous.time += elapsed
+		p.fadeTime += elapsed
+		if p.fadeTime >= p.fadeDuration {
+			p.previous = nil
+		}
+	}
+
+	p.dirty = true
+}
+
+// update Computes the pose of the skeleton and the resulting joint matrices,
+// if changed since last computed.
+func (p *skeletonPlayer) update() {
+	if !p.load() || !p.dirty {
+		return
+	}
+	p.dirty = false
+
+	current := p.current
+	if current == nil {
+		current = &clipState{}
+	}
+	p.resolve(current)
+	current.sample(p.joints, p.pose, p.looping)
+
+	if p.previous != nil && p.fadeDuration > 0 {
+		p.resolve(p.previous)
+		p.previous.sample(p.joints, p.fadePose, p.looping)
+		weight := float32(p.fadeTime) / float32(p.fadeDuration)
+		for i := range p.pose {
+			p.pose[i] = blendPoses(p.fadePose[i], p.pose[i], weight)
+		}
+	}
+
+	for i, joint := range p.joints {
+		local := p.pose[i].matrix()
+		if joint.Parent >= 0 && joint.Parent < i {
+			p.globals[i] = p.globals[joint.Parent].Mul4(local)
+		} else {
+			p.globals[i] = local
+		}
+		p.jointMats[i] = p.globals[i].Mul4(joint.InverseBindMatrix)
+	}
+}
+
+// jointMatrices Returns the matrices transforming the vertices influenced by
+// each joint from their bind pose to their current pose, or nil if the model
+// has no joints (or too many).
+func (p *skeletonPlayer) jointMatrices() []mgl32.Mat4 {
+	p.update()
+	if len(p.joints) > MaxJoints {
+		return nil
+	}
+	return p.jointMats
+}
+
+// jointTransform Returns the model-space transform of the named joint.
+func (p *skeletonPlayer) jointTransform(name string) (transform mgl32.Mat4, ok bool) {
+	p.update()
+	for i, joint := range p.joints {
+		if joint.Name == name {
+			return p.globals[i], true
+		}
+	}
+	return
+}
+
+func (p *skeletonPlayer) clipTime() time.Duration {
+	if p.current == nil {
+		return 0
+	}
+
+	p.resolve(p.current)
+	if p.current.clip != nil && p.looping {
+		if duration := p.current.clip.Duration(); duration > 0 {
+			return p.current.time % duration
+		}
+	}
+	return p.current.time
+}
+
+func newSkeletonPlayer(model SkinnedModel) *skeletonPlayer {
+	return &skeletonPlayer{
+		model:   model,
+		looping: true,
+		speed:   1,
+	}
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+// DecomposeMatrix Returns the translation, rotation and scale making up the
+// given affine transformation matrix, such as those of imported joints.
+func DecomposeMatrix(m mgl32.Mat4) (translation mgl32.Vec3, rotation mgl32.Quat, scale mgl32.Vec3) {
+	translation = m.Col(3).Vec3()
+	scale = mgl32.Vec3{m.Col(0).Vec3().Len(), m.Col(1).Vec3().Len(), m.Col(2).Vec3().Len()}
+	if m.Mat3().Det() < 0 {
+		scale[0] = -scale[0]
+	}
+
+	var rot mgl32.Mat3
+	for i := 0; i < 3; i++ {
+		column := m.Col(i).Vec3()
+		if scale[i] != 0 && !math.IsNaN(float64(scale[i])) {
+			column = column.Mul(1 / scale[i])
+		}
+		rot.SetCol(i, column)
+	}
+	rotation = mgl32.Mat4ToQuat(rot.Mat4()).Normalize()
+	return
+}
@@ -0,0 +1,258 @@
+package gfx
+
+import (
+	"github.com/go-gl/gl/v4.1-core/gl"
+)
+
+const (
+	defaultSkyboxName = "Skybox"
+)
+
+var skyboxVertices = []float32{
+	-1, 1, -1, -1, -1, -1, 1, -1, -1, 1, -1, -1, 1, 1, -1, -1, 1, -1, // -Z
+	-1, -1, 1, -1, -1, -1, -1, 1, -1, -1, 1, -1, -1, 1, 1, -1, -1, 1, // -X
+	1, -1, -1, 1, -1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, 1, -1, -1, // +X
+	-1, -1, 1, -1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, 1, -1, -1, 1, // +Z
+	-1, 1, -1, 1, 1, -1, 1, 1, 1, 1, 1, 1, -1, 1, 1, -1, 1, -1, // +Y
+	-1, -1, -1, -1, -1, 1, 1, -1, -1, 1, -1, -1, -1, -1, 1, 1, -1, 1, // -Y
+}
+
+/******************************************************************************
+ Skybox
+******************************************************************************/
+
+// Skybox A WindowObject that renders a TextureCube around the assigned
+// Camera, as if infinitely far away, such that it appears behind everything
+// else rendered with depth testing (e.g., Shape3D objects), regardless of
+// the order in which objects are drawn.  Only the camera's orientation is
+// taken into account, along with the rotation of the skybox itself, and
+// the texture is tinted by the color of the skybox (white by default).
+type Skybox struct {
+	WindowObjectBase
+
+	viewport     *Viewport
+	camera       Camera
+	texture      *TextureCube
+	ownedTexture ownedTextureCube
+
+	shader Shader
+	vao    uint32
+	vbo    uint32
+
+	viewProjMatUniformLoc int32
+	skyboxMapUniformLoc   int32
+	colorUniformLoc       int32
+
+	viewportBak [4]int32
+}
+
+/******************************************************************************
+ Object Implementation
+******************************************************************************/
+
+func (s *Skybox) Init() (ok bool) {
+	if s.Initialized() {
+		return true
+	}
+
+	s.initViewport()
+	s.initVertexVao()
+
+	return s.WindowObjectBase.Init()
+}
+
+func (s *Skybox) Close() {
+	if !s.Initialized() {
+		return
+	}
+
+	s.closeVertexVao()
+	s.ownedTexture.close()
+
+	s.WindowObjectBase.Close()
+}
+
+/******************************************************************************
+ DrawableObject Implementation
+******************************************************************************/
+
+func (s *Skybox) Draw(deltaTime int64) (ok bool) {
+	if !s.DrawableObjectBase.Draw(deltaTime) {
+		return false
+	}
+
+	s.beginDraw()
+	s.draw()
+	s.endDraw()
+
+	return s.WindowObjectBase.drawChildren(deltaTime)
+}
+
+/******************************************************************************
+ Resizer Implementation
+******************************************************************************/
+
+func (s *Skybox) Resize(newWidth, newHeight int) {
+	if s.viewport != nil {
+		s.viewport.SetWindowSize(newWidth, newHeight)
+	}
+
+	s.WindowObjectBase.Resize(newWidth, newHeight)
+}
+
+/******************************************************************************
+ Skybox Functions
+******************************************************************************/
+
+func (s *Skybox) initViewport() {
+	if s.viewport == nil {
+		s.viewport = NewViewport(s.window.Width(), s.window.Height())
+	}
+}
+
+func (s *Skybox) initVertexVao() {
+	s.shader = s.window.Assets().Get(SkyboxShader).(Shader)
+
+	s.viewProjMatUniformLoc = s.shader.GetUniformLocation("u_ViewProjMat")
+	s.skyboxMapUniformLoc = s.shader.GetUniformLocation("u_SkyboxMap")
+	s.colorUniformLoc = s.shader.GetUniformLocation("u_Color")
+
+	gl.GenVertexArrays(1, &s.vao)
+	gl.GenBuffers(1, &s.vbo)
+
+	gl.BindVertexArray(s.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, s.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(skyboxVertices)*sizeOfFloat32, gl.Ptr(skyboxVertices), gl.STATIC_DRAW)
+
+	posLoc := uint32(s.shader.GetAttribLocation("a_Position"))
+	gl.EnableVertexAttribArray(posLoc)
+	gl.VertexAttribPointerWithOffset(posLoc, 3, gl.FLOAT, false, 3*sizeOfFloat32, 0)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.BindVertexArray(0)
+}
+
+func (s *Skybox) closeVertexVao() {
+	gl.BindVertexArray(0)
+	gl.DeleteVertexArrays(1, &s.vao)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	gl.DeleteBuffers(1, &s.vbo)
+}
+
+func (s *Skybox) beginDraw() {
+	gl.Enable(gl.DEPTH_TEST)
+	gl.DepthFunc(gl.LEQUAL)
+	gl.DepthMask(false)
+	gl.GetIntegerv(gl.VIEWPORT, &s.viewportBak[0])
+}
+
+func (s *Skybox) draw() {
+	s.stateMutex.Lock()
+	camera := s.camera
+	texture := s.texture
+	viewport := s.viewport
+	color := s.color
+	s.stateMutex.Unlock()
+
+	s.ownedTexture.use(texture, s.window.Assets())
+
+	if camera == nil || texture == nil {
+		return
+	}
+
+	// Removing the translation keeps the camera at the center of the cube
+	rotation := s.WorldMatrix().Mat3().Mat4()
+	view := camera.View().Mat3().Mat4()
+	viewProjMat := camera.Projection().Mul4(view).Mul4(rotation)
+
+	gl.Viewport(viewport.Get())
+
+	s.shader.Activate()
+	gl.UniformMatrix4fv(s.viewProjMatUniformLoc, 1, false, &viewProjMat[0])
+	gl.Uniform4fv(s.colorUniformLoc, 1, &color[0])
+	gl.Uniform1i(s.skyboxMapUniformLoc, 0)
+
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, texture.GlName())
+
+	gl.BindVertexArray(s.vao)
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(skyboxVertices)/3))
+
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+}
+
+func (s *Skybox) endDraw() {
+	gl.Viewport(s.viewportBak[0], s.viewportBak[1], s.viewportBak[2], s.viewportBak[3])
+
+	gl.DepthMask(true)
+	gl.DepthFunc(gl.LESS)
+	gl.Disable(gl.DEPTH_TEST)
+
+	gl.BindVertexArray(0)
+
+	gl.UseProgram(0)
+}
+
+func (s *Skybox) Viewport() *Viewport {
+	s.stateMutex.Lock()
+	vp := s.viewport
+	s.stateMutex.Unlock()
+	return vp
+}
+
+func (s *Skybox) SetViewport(viewport *Viewport) *Skybox {
+	s.stateMutex.Lock()
+	s.viewport = viewport
+	s.stateMutex.Unlock()
+	return s
+}
+
+func (s *Skybox) Camera() Camera {
+	s.stateMutex.Lock()
+	cam := s.camera
+	s.stateMutex.Unlock()
+	return cam
+}
+
+func (s *Skybox) SetCamera(camera Camera) *Skybox {
+	s.stateMutex.Lock()
+	s.camera = camera
+	s.stateMutex.Unlock()
+	return s
+}
+
+func (s *Skybox) Texture() (texture *TextureCube) {
+	s.stateMutex.Lock()
+	texture = s.texture
+	s.stateMutex.Unlock()
+	return
+}
+
+// SetTexture sets the cube map rendered by the skybox, which will be
+// initialized when first drawn if it has not been already (e.g., by
+// adding it to the window's asset library).  A cube map initialized by
+// the skybox itself is also closed by it, when replaced or when the
+// skybox is closed.
+func (s *Skybox) SetTexture(texture *TextureCube) *Skybox {
+	s.stateMutex.Lock()
+	s.texture = texture
+	s.stateMutex.Unlock()
+	return s
+}
+
+/******************************************************************************
+ New Skybox Function
+******************************************************************************/
+
+// NewSkybox creates a skybox rendering the given cube map, which can also
+// be passed to Shape3D.SetEnvironmentMap() so that shapes reflect the sky.
+func NewSkybox(texture *TextureCube) *Skybox {
+	s := &Skybox{
+		WindowObjectBase: *NewWindowObject(),
+		texture:          texture,
+	}
+
+	s.SetName(defaultSkyboxName)
+	return s
+}
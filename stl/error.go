@@ -0,0 +1,32 @@
+package stl
+
+import (
+	"github.com/tonybillings/gfx/internal/importer"
+)
+
+const (
+	binaryParseErrorUnit = "triangle"
+)
+
+/******************************************************************************
+ ParseError
+******************************************************************************/
+
+// ParseError Describes a problem encountered while parsing an STL file,
+// which stops the load and is returned by Err().  Format is either
+// "ASCII STL" or "binary STL", or simply "STL" if the error occurred
+// before the format could be determined.  Line is the 1-based line
+// number (ASCII) or the 1-based triangle number (binary).
+type ParseError = importer.ParseError
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func newParseError(format, file string, line int, err error) *ParseError {
+	parseErr := importer.NewParseError(format, file, line, err)
+	if format == binaryFormat {
+		parseErr.Unit = binaryParseErrorUnit
+	}
+	return parseErr
+}
@@ -0,0 +1,22 @@
+package stl
+
+import "github.com/tonybillings/gfx/internal/basic"
+
+/******************************************************************************
+ Mesh
+******************************************************************************/
+
+// Mesh Contains the triangles of one solid of the STL file.
+type Mesh = basic.Mesh
+
+// Face A triangle, with separate indices for its positions and normals
+// since the normals are computed per face or smoothed across faces.
+type Face = basic.Face
+
+/******************************************************************************
+ New Mesh Function
+******************************************************************************/
+
+func NewMesh() *Mesh {
+	return basic.NewMesh()
+}
@@ -0,0 +1,456 @@
+// Package stl provides an importer for STL (stereolithography) models,
+// supporting both the ASCII and binary formats.  Each face is given the
+// normal stored in the file (or, if not provided, the normal derived from
+// its winding order); optionally, duplicate vertices can be welded and
+// smooth normals generated for faces meeting at less than a given angle.
+package stl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/tonybillings/gfx"
+	"github.com/tonybillings/gfx/internal/basic"
+	"github.com/tonybillings/gfx/internal/importer"
+	"io"
+	"math"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	defaultWeldTolerance = 1e-5
+	epsilon              = 1e-12
+)
+
+/******************************************************************************
+ Model
+******************************************************************************/
+
+type Model struct {
+	gfx.ModelBase
+
+	vertices   []float32
+	normals    []float32
+	uvs        []float32
+	tangents   []float32
+	bitangents []float32
+
+	meshes []*Mesh
+
+	material      *BasicMaterial
+	defaultShader gfx.Shader
+
+	weldVertices   bool
+	weldTolerance  float32
+	smoothingAngle float32
+
+	err    error
+	loaded atomic.Bool
+}
+
+type vertexKey [3]int64
+
+/******************************************************************************
+ gfx.Asset Implementation
+******************************************************************************/
+
+func (m *Model) Init() bool {
+	if m.Initialized() {
+		return true
+	}
+
+	if err := m.Load(); err != nil {
+		return false
+	}
+
+	if ok := m.material.Init(); !ok {
+		return false
+	}
+
+	if ok := m.initMeshes(); !ok {
+		return false
+	}
+
+	return m.AssetBase.Init()
+}
+
+func (m *Model) Close() {
+	if !m.Initialized() {
+		return
+	}
+
+	m.closeMeshes()
+	m.material.Close()
+
+	m.AssetBase.Close()
+}
+
+/******************************************************************************
+ gfx.Model Implementation
+******************************************************************************/
+
+func (m *Model) Vertices() []float32 {
+	return m.vertices
+}
+
+func (m *Model) Normals() []float32 {
+	return m.normals
+}
+
+func (m *Model) UVs() []float32 {
+	return m.uvs
+}
+
+func (m *Model) Tangents() []float32 {
+	return m.tangents
+}
+
+func (m *Model) Bitangents() []float32 {
+	return m.bitangents
+}
+
+func (m *Model) Meshes() []gfx.Mesh {
+	meshes := make([]gfx.Mesh, len(m.meshes))
+	for i, mesh := range m.meshes {
+		meshes[i] = mesh
+	}
+	return meshes
+}
+
+/******************************************************************************
+ Model Functions
+******************************************************************************/
+
+func (m *Model) loadFromSlice(slice []byte, filename string) {
+	if isBinary(slice) {
+		s, err := parseBinary(slice, filename)
+		if err != nil {
+			m.err = err
+			return
+		}
+		if s.name == "" {
+			s.name = m.Name()
+		}
+		m.build([]*solid{s})
+	} else {
+		solids, err := parseASCII(bufio.NewReader(bytes.NewReader(slice)), filename)
+		if err != nil {
+			m.err = err
+			return
+		}
+		m.build(solids)
+	}
+}
+
+func (m *Model) loadFromFile(name string) (ok bool) {
+	reader, closeFunc, err := importer.SourceReader(m, name)
+	defer closeFunc()
+
+	if err != nil {
+		m.err = newParseError(stlFormat, name, 0, err)
+		return true
+	}
+
+	if reader == nil {
+		return false
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		m.err = newParseError(stlFormat, name, 0, fmt.Errorf("file read error: %w", err))
+		return true
+	}
+
+	m.loadFromSlice(data, name)
+	return true
+}
+
+func (m *Model) loadFromString(stl string) {
+	solids, err := parseASCII(bufio.NewReader(strings.NewReader(stl)), m.Name())
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.build(solids)
+}
+
+func (m *Model) build(solids []*solid) {
+	m.uvs = []float32{0, 0} // STL has no texture coordinates
+	vertexIndices := make(map[vertexKey]int)
+
+	for _, s := range solids {
+		mesh := NewMesh()
+		mesh.SetName(s.name)
+
+		for _, tri := range s.triangles {
+			vertices := make([]int, 3)
+			for i, v := range tri.vertices {
+				if !m.weldVertices {
+					vertices[i] = m.addVertex(v)
+					continue
+				}
+
+				key := m.vertexKey(v)
+				if index, ok := vertexIndices[key]; ok {
+					vertices[i] = index
+				} else {
+					vertices[i] = m.addVertex(v)
+					vertexIndices[key] = vertices[i]
+				}
+			}
+
+			mesh.AddFace(basic.NewFace(vertices, nil, []int{0, 0, 0}, m.material))
+		}
+
+		if len(mesh.Triangles()) > 0 {
+			m.meshes = append(m.meshes, mesh)
+		}
+	}
+
+	if m.smoothingAngle > 0 {
+		m.computeSmoothNormals(solids)
+	} else {
+		m.computeFlatNormals(solids)
+	}
+}
+
+func (m *Model) addVertex(v mgl32.Vec3) int {
+	m.vertices = append(m.vertices, v[:]...)
+	return len(m.vertices)/3 - 1
+}
+
+func (m *Model) addNormal(n mgl32.Vec3) int {
+	t := perpendicular(n)
+	b := n.Cross(t)
+	m.normals = append(m.normals, n[:]...)
+	m.tangents = append(m.tangents, t[:]...)
+	m.bitangents = append(m.bitangents, b[:]...)
+	return len(m.normals)/3 - 1
+}
+
+func (m *Model) vertexKey(v mgl32.Vec3) vertexKey {
+	if m.weldTolerance <= 0 {
+		return vertexKey{int64(math.Float32bits(v[0])), int64(math.Float32bits(v[1])), int64(math.Float32bits(v[2]))}
+	}
+
+	tolerance := float64(m.weldTolerance)
+	return vertexKey{
+		int64(math.Round(float64(v[0]) / tolerance)),
+		int64(math.Round(float64(v[1]) / tolerance)),
+		int64(math.Round(float64(v[2]) / tolerance)),
+	}
+}
+
+func (m *Model) computeFlatNormals(solids []*solid) {
+	meshIndex := 0
+	for _, s := range solids {
+		if len(s.triangles) == 0 {
+			continue
+		}
+
+		for i, face := range m.meshes[meshIndex].Triangles() {
+			index := m.addNormal(faceNormal(s.triangles[i]))
+			face.SetNormalIndices([]int{index, index, index})
+		}
+
+		meshIndex++
+	}
+}
+
+// computeSmoothNormals Gives each face vertex the average normal of the
+// faces sharing its position (regardless of welding) whose normal lies
+// within the smoothing angle of the face's own normal.
+func (m *Model) computeSmoothNormals(solids []*solid) {
+	minCos := float32(math.Cos(float64(mgl32.DegToRad(m.smoothingAngle))))
+	faceNormals := make([][]mgl32.Vec3, len(solids))
+	adjacent := make(map[vertexKey][]mgl32.Vec3)
+
+	for i, s := range solids {
+		faceNormals[i] = make([]mgl32.Vec3, len(s.triangles))
+		for j, tri := range s.triangles {
+			n := faceNormal(tri)
+			faceNormals[i][j] = n
+			for _, v := range tri.vertices {
+				key := m.vertexKey(v)
+				adjacent[key] = append(adjacent[key], n)
+			}
+		}
+	}
+
+	meshIndex := 0
+	for i, s := range solids {
+		if len(s.triangles) == 0 {
+			continue
+		}
+
+		for j, face := range m.meshes[meshIndex].Triangles() {
+			n := faceNormals[i][j]
+			normals := make([]int, 3)
+			for k, v := range s.triangles[j].vertices {
+				sum := mgl32.Vec3{}
+				for _, other := range adjacent[m.vertexKey(v)] {
+					if other.Dot(n) >= minCos {
+						sum = sum.Add(other)
+					}
+				}
+				normals[k] = m.addNormal(normalizeOr(sum, n))
+			}
+			face.SetNormalIndices(normals)
+		}
+
+		meshIndex++
+	}
+}
+
+func (m *Model) initMeshes() bool {
+	ok := true
+	for _, mesh := range m.meshes {
+		ok = ok && mesh.Init()
+	}
+	return ok
+}
+
+func (m *Model) closeMeshes() {
+	for _, mesh := range m.meshes {
+		mesh.Close()
+	}
+}
+
+func (m *Model) SetDefaultShader(shader gfx.Shader) *Model {
+	m.defaultShader = shader
+	return m
+}
+
+// Material returns the material shared by every face of the model,
+// which can be used to set its color, etc.
+func (m *Model) Material() *BasicMaterial {
+	return m.material
+}
+
+func (m *Model) WeldVertices() bool {
+	return m.weldVertices
+}
+
+// SetWeldVertices enables/disables the merging of vertices whose positions
+// are within the weld tolerance of each other, which must be done before
+// the model is loaded.
+func (m *Model) SetWeldVertices(weld bool) *Model {
+	m.weldVertices = weld
+	return m
+}
+
+func (m *Model) WeldTolerance() float32 {
+	return m.weldTolerance
+}
+
+// SetWeldTolerance sets the distance within which vertex positions are
+// considered to be the same when welding/smoothing.
+func (m *Model) SetWeldTolerance(tolerance float32) *Model {
+	m.weldTolerance = tolerance
+	return m
+}
+
+func (m *Model) SmoothingAngle() float32 {
+	return m.smoothingAngle
+}
+
+// SetSmoothingAngle sets the threshold (in degrees) below which the normals
+// of adjacent faces are averaged to produce smooth shading, which must be
+// done before the model is loaded.  Zero (the default) produces flat normals.
+func (m *Model) SetSmoothingAngle(degrees float32) *Model {
+	m.smoothingAngle = degrees
+	return m
+}
+
+// Load parses the STL source, returning the error that stopped
+// the load, if any (see Err()).
+func (m *Model) Load() error {
+	if m.loaded.Load() {
+		return m.err
+	}
+
+	if m.defaultShader == nil {
+		srcLib := m.SourceLibrary()
+		if srcLib != nil {
+			if defaultShader := srcLib.Get(gfx.Shape3DShader); defaultShader != nil {
+				if shader, ok := defaultShader.(gfx.Shader); ok {
+					m.defaultShader = shader
+				}
+			}
+		}
+	}
+
+	m.material.SetSourceLibrary(m.SourceLibrary())
+	if m.material.AttachedShader() == nil && m.defaultShader != nil {
+		m.material.AttachShader(m.defaultShader)
+	}
+
+	switch source := m.Source().(type) {
+	case []byte:
+		m.loadFromSlice(source, m.Name())
+	case string:
+		if ok := m.loadFromFile(source); !ok {
+			m.loadFromString(source)
+		}
+	default:
+		m.err = newParseError(stlFormat, m.Name(), 0, fmt.Errorf("source type is not supported"))
+	}
+
+	if m.err == nil && len(m.meshes) == 0 {
+		m.err = newParseError(stlFormat, m.Name(), 0, fmt.Errorf("model contains no triangles"))
+	}
+
+	m.loaded.Store(true)
+	return m.err
+}
+
+// Err returns the error that caused loading to fail, which will
+// be a *ParseError, or nil if the model loaded successfully.
+func (m *Model) Err() error {
+	return m.err
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+// faceNormal Returns the normal stored in the file or, if not provided
+// (zero), the normal derived from the counter-clockwise winding order.
+func faceNormal(tri triangle) mgl32.Vec3 {
+	computed := tri.vertices[1].Sub(tri.vertices[0]).Cross(tri.vertices[2].Sub(tri.vertices[0]))
+	return normalizeOr(tri.normal, normalizeOr(computed, mgl32.Vec3{0, 0, 1}))
+}
+
+func normalizeOr(v, fallback mgl32.Vec3) mgl32.Vec3 {
+	if length := v.Len(); length > epsilon {
+		return v.Mul(1.0 / length)
+	}
+	return fallback
+}
+
+func perpendicular(n mgl32.Vec3) mgl32.Vec3 {
+	axis := mgl32.Vec3{1, 0, 0}
+	if n.X()*n.X() > 0.5 {
+		axis = mgl32.Vec3{0, 1, 0}
+	}
+	return normalizeOr(axis.Sub(n.Mul(n.Dot(axis))), mgl32.Vec3{1, 0, 0})
+}
+
+/******************************************************************************
+ New Model Function
+******************************************************************************/
+
+// NewModel creates a model from the given source, which can be the name of
+// an STL file (resolved via the source library, if set), the contents of
+// such a file (ASCII or binary) or a string containing ASCII STL.
+func NewModel[T gfx.ModelSource](name string, source T) *Model {
+	return &Model{
+		ModelBase: gfx.ModelBase{
+			AssetBase: *gfx.NewAssetBase(name, source),
+		},
+		material:      NewMaterial(),
+		weldTolerance: defaultWeldTolerance,
+	}
+}
@@ -0,0 +1,215 @@
+package stl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/go-gl/mathgl/mgl32"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	stlFormat            = "STL"
+	asciiFormat          = "ASCII STL"
+	binaryFormat         = "binary STL"
+	binaryHeaderLength   = 84
+	binaryTriangleLength = 50
+)
+
+/******************************************************************************
+ solid
+******************************************************************************/
+
+type triangle struct {
+	normal   mgl32.Vec3
+	vertices [3]mgl32.Vec3
+}
+
+type solid struct {
+	name      string
+	triangles []triangle
+}
+
+/******************************************************************************
+ Functions
+******************************************************************************/
+
+// isBinary Determines the format of the STL data.  As binary files may
+// also begin with "solid", the size implied by the triangle count in the
+// binary header takes precedence.
+func isBinary(data []byte) bool {
+	if len(data) >= binaryHeaderLength {
+		count := binary.LittleEndian.Uint32(data[80:84])
+		if uint64(binaryHeaderLength)+uint64(count)*binaryTriangleLength == uint64(len(data)) {
+			return true
+		}
+	}
+
+	return !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("solid"))
+}
+
+func parseBinary(data []byte, filename string) (*solid, *ParseError) {
+	if len(data) < binaryHeaderLength {
+		return nil, newParseError(binaryFormat, filename, 0, fmt.Errorf("header is truncated"))
+	}
+
+	count := int(binary.LittleEndian.Uint32(data[80:84]))
+	if available := (len(data) - binaryHeaderLength) / binaryTriangleLength; count > available {
+		return nil, newParseError(binaryFormat, filename, available+1, fmt.Errorf("expected %d triangles, got %d", count, available))
+	}
+
+	s := &solid{
+		name:      strings.TrimSpace(strings.TrimRight(string(data[:80]), "\x00")),
+		triangles: make([]triangle, count),
+	}
+
+	if strings.HasPrefix(s.name, "solid") { // some exporters mimic the ASCII format
+		s.name = strings.TrimSpace(strings.TrimPrefix(s.name, "solid"))
+	}
+
+	for i := 0; i < count; i++ {
+		offset := binaryHeaderLength + i*binaryTriangleLength
+		tri := &s.triangles[i]
+		tri.normal = readVec3(data[offset:])
+		for j := 0; j < 3; j++ {
+			tri.vertices[j] = readVec3(data[offset+12+j*12:])
+		}
+	}
+
+	return s, nil
+}
+
+func parseASCII(reader *bufio.Reader, filename string) ([]*solid, *ParseError) {
+	var solids []*solid
+	var current *solid
+	var tri *triangle
+	vertexCount := 0
+	lineNumber := 0
+
+	fail := func(format string, args ...any) ([]*solid, *ParseError) {
+		return nil, newParseError(asciiFormat, filename, lineNumber, fmt.Errorf(format, args...))
+	}
+
+	for {
+		lineNumber++
+
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, newParseError(asciiFormat, filename, 0, fmt.Errorf("file read error: %w", readErr))
+		}
+
+		if fields := strings.Fields(line); len(fields) > 0 {
+			switch strings.ToLower(fields[0]) {
+			case "solid":
+				if current != nil {
+					return fail("unexpected solid (missing endsolid)")
+				}
+				current = &solid{name: strings.Join(fields[1:], " ")}
+			case "endsolid":
+				if current == nil {
+					return fail("unexpected endsolid")
+				}
+				if tri != nil {
+					return fail("unexpected endsolid (missing endfacet)")
+				}
+				solids = append(solids, current)
+				current = nil
+			case "facet":
+				if current == nil {
+					return fail("facet outside of solid")
+				}
+				if tri != nil {
+					return fail("unexpected facet (missing endfacet)")
+				}
+				tri = &triangle{}
+				vertexCount = 0
+				if len(fields) > 1 {
+					if strings.ToLower(fields[1]) != "normal" {
+						return fail("expected 'normal', got '%s'", fields[1])
+					}
+					normal, err := parseVec3(fields[2:])
+					if err != nil {
+						return fail("%v", err)
+					}
+					tri.normal = normal
+				}
+			case "vertex":
+				if tri == nil {
+					return fail("vertex outside of facet")
+				}
+				if vertexCount == 3 {
+					return fail("facet has more than 3 vertices")
+				}
+				vertex, err := parseVec3(fields[1:])
+				if err != nil {
+					return fail("%v", err)
+				}
+				tri.vertices[vertexCount] = vertex
+				vertexCount++
+			case "endfacet":
+				if tri == nil {
+					return fail("unexpected endfacet")
+				}
+				if vertexCount != 3 {
+					return fail("facet has %d vertices, expected 3", vertexCount)
+				}
+				current.triangles = append(current.triangles, *tri)
+				tri = nil
+			case "outer", "endloop":
+				// loops carry no information beyond the vertices
+			default:
+				return fail("unexpected keyword '%s'", fields[0])
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if tri != nil {
+		return fail("unexpected end of file (missing endfacet)")
+	}
+
+	if current != nil { // tolerate a missing endsolid
+		solids = append(solids, current)
+	}
+
+	if len(solids) == 0 {
+		return nil, newParseError(asciiFormat, filename, 0, fmt.Errorf("no solids found"))
+	}
+
+	return solids, nil
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+func readVec3(data []byte) mgl32.Vec3 {
+	return mgl32.Vec3{
+		math.Float32frombits(binary.LittleEndian.Uint32(data[0:4])),
+		math.Float32frombits(binary.LittleEndian.Uint32(data[4:8])),
+		math.Float32frombits(binary.LittleEndian.Uint32(data[8:12])),
+	}
+}
+
+func parseVec3(fields []string) (vec mgl32.Vec3, err error) {
+	if len(fields) != 3 {
+		return vec, fmt.Errorf("expected 3 values, got %d", len(fields))
+	}
+
+	for i, field := range fields {
+		var value float64
+		if value, err = strconv.ParseFloat(field, 32); err != nil {
+			return vec, fmt.Errorf("invalid value '%s'", field)
+		}
+		vec[i] = float32(value)
+	}
+
+	return
+}
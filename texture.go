@@ -16,7 +16,8 @@ import (
 ******************************************************************************/
 
 // Texture assets represent texture maps that can be sampled by shaders by
-// binding them to sampler2D variables.
+// binding them to sampler2D variables (or samplerCube variables, in the case
+// of TextureCube).
 type Texture interface {
 	GlAsset
 
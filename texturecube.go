@@ -0,0 +1,590 @@
+package gfx
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	hdrSignature = "#?"
+)
+
+/******************************************************************************
+ CubeFace
+******************************************************************************/
+
+// CubeFace Identifies one of the six faces of a TextureCube, in the order
+// expected by NewTextureCube().
+type CubeFace int
+
+const (
+	CubeFacePositiveX CubeFace = iota // right
+	CubeFaceNegativeX                 // left
+	CubeFacePositiveY                 // top
+	CubeFaceNegativeY                 // bottom
+	CubeFacePositiveZ                 // back
+	CubeFaceNegativeZ                 // front
+)
+
+// direction Returns the (unnormalized) direction from the center of the cube
+// to the given point on the face, whose coordinates range from -1 to 1, with
+// the origin of t being at the top of the face, as defined by OpenGL.
+func (f CubeFace) direction(s, t float32) mgl32.Vec3 {
+	switch f {
+	case CubeFacePositiveX:
+		return mgl32.Vec3{1, -t, -s}
+	case CubeFaceNegativeX:
+		return mgl32.Vec3{-1, -t, s}
+	case CubeFacePositiveY:
+		return mgl32.Vec3{s, 1, t}
+	case CubeFaceNegativeY:
+		return mgl32.Vec3{s, -1, -t}
+	case CubeFacePositiveZ:
+		return mgl32.Vec3{s, -t, 1}
+	default:
+		return mgl32.Vec3{-s, -t, -1}
+	}
+}
+
+/******************************************************************************
+ TextureCube
+******************************************************************************/
+
+// TextureCube A cube map texture, made of six square faces and sampled by
+// shaders (via samplerCube variables) using a direction rather than texture
+// coordinates, as used by Skybox and for environment reflections (see
+// Shape3D.SetEnvironmentMap()).  Can be created from six images or from an
+// equirectangular panorama, either of which can be PNG/JPEG or Radiance HDR
+// (.hdr) images, the latter being stored with floating point precision.
+type TextureCube struct {
+	AssetBase
+
+	size int
+
+	glName        uint32
+	minFilterMode int32
+	magFilterMode int32
+	useMipMaps    bool
+}
+
+/******************************************************************************
+ Asset Implementation
+******************************************************************************/
+
+func (t *TextureCube) Init() bool {
+	if t.Initialized() {
+		return true
+	}
+
+	var faces [6]cubeImage
+	switch source := t.source.(type) {
+	case []any:
+		if len(source) != 6 {
+			panic("unexpected error: cube map must have six faces")
+		}
+		for i, face := range source {
+			faces[i] = t.decode(face)
+		}
+	default:
+		faces = t.createFaces(t.decode(source))
+	}
+
+	t.createFromFaces(faces)
+
+	return t.AssetBase.Init()
+}
+
+func (t *TextureCube) Close() {
+	if !t.Initialized() {
+		return
+	}
+
+	gl.DeleteTextures(1, &t.glName)
+	t.glName = 0
+
+	t.AssetBase.Close()
+}
+
+/******************************************************************************
+ Texture Implementation
+******************************************************************************/
+
+func (t *TextureCube) GlName() uint32 {
+	return t.glName
+}
+
+// Width returns the width (and height) of each face, in pixels.
+func (t *TextureCube) Width() int {
+	return t.size
+}
+
+// Height returns the height (and width) of each face, in pixels.
+func (t *TextureCube) Height() int {
+	return t.size
+}
+
+/******************************************************************************
+ TextureCube Functions
+******************************************************************************/
+
+// maxLod Returns the level of the smallest mipmap (1x1 pixel faces), or 0
+// if the cube map has no mipmaps, used to sample blurrier reflections of
+// the environment for rougher surfaces.
+func (t *TextureCube) maxLod() float32 {
+	if !t.useMipMaps || t.size <= 1 {
+		return 0
+	}
+	return float32(math.Floor(math.Log2(float64(t.size))))
+}
+
+// decode Returns the image represented by the given source, which can be
+// any of the types allowed by TextureSource.
+func (t *TextureCube) decode(source any) cubeImage {
+	switch src := source.(type) {
+	case []byte:
+		return t.decodeSlice(src)
+	case string:
+		reader, closeFunc := t.getSourceReader(src)
+		defer closeFunc()
+		if reader == nil {
+			panic(fmt.Errorf("texture file not found: %s", src))
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			panic(fmt.Errorf("read image error: %w", err))
+		}
+		return t.decodeSlice(data)
+	case color.RGBA:
+		img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+		img.Set(0, 0, src)
+		return &ldrImage{img}
+	case *image.RGBA:
+		return t.toLdrImage(src)
+	case *image.NRGBA:
+		return &ldrImage{src}
+	default:
+		panic("unexpected error: source type is not supported")
+	}
+}
+
+func (t *TextureCube) decodeSlice(slice []byte) cubeImage {
+	if bytes.HasPrefix(slice, []byte(hdrSignature)) {
+		img, err := decodeHdr(bufio.NewReader(bytes.NewReader(slice)), len(slice))
+		if err != nil {
+			panic(fmt.Errorf("decode HDR image error: %w", err))
+		}
+		return img
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(slice))
+	if err != nil {
+		panic(fmt.Errorf("decode image error: %w", err))
+	}
+	return t.toLdrImage(img)
+}
+
+func (t *TextureCube) toLdrImage(img image.Image) cubeImage {
+	if nrgba, ok := img.(*image.NRGBA); ok {
+		return &ldrImage{nrgba}
+	}
+
+	nrgba := image.NewNRGBA(img.Bounds())
+	draw.Draw(nrgba, nrgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return &ldrImage{nrgba}
+}
+
+// createFaces Projects the equirectangular panorama onto the six faces of
+// the cube, each being a quarter of the width of the panorama, with the
+// center of the panorama facing the negative Z axis.
+func (t *TextureCube) createFaces(panorama cubeImage) (faces [6]cubeImage) {
+	width, _ := panorama.size()
+	size := max(1, width/4)
+
+	for i := range faces {
+		face := CubeFace(i)
+		faces[i] = panorama.resample(size, func(x, y int) (u, v float32) {
+			sc := (float32(x)+0.5)/float32(size)*2 - 1
+			tc := (float32(y)+0.5)/float32(size)*2 - 1
+			dir := face.direction(sc, tc).Normalize()
+
+			u = 0.5 + float32(math.Atan2(float64(dir.X()), float64(-dir.Z())))/(2*math.Pi)
+			v = float32(math.Acos(float64(mgl32.Clamp(dir.Y(), -1, 1)))) / math.Pi
+			return
+		})
+	}
+	return
+}
+
+func (t *TextureCube) createFromFaces(faces [6]cubeImage) {
+	var name uint32
+	gl.GenTextures(1, &name)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, name)
+
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, t.minFilterMode)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, t.magFilterMode)
+	gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS)
+
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	for i, face := range faces {
+		face.upload(gl.TEXTURE_CUBE_MAP_POSITIVE_X + uint32(i))
+	}
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 4)
+
+	if t.useMipMaps {
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	}
+
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+	t.glName = name
+	t.size, _ = faces[0].size()
+}
+
+/******************************************************************************
+ ownedTextureCube
+******************************************************************************/
+
+// ownedTextureCube Tracks a cube map that an object initialized lazily and
+// must therefore close itself, which is the case when the cube map was not
+// added to the asset library of the object's window (as the library closes
+// its assets along with the window).
+type ownedTextureCube struct {
+	texture *TextureCube
+}
+
+// use Initializes the given cube map if needed, taking ownership of it when
+// it is not managed by the given asset library, and closes the previously
+// owned cube map if it has been replaced.
+func (o *ownedTextureCube) use(texture *TextureCube, assets *AssetLibrary) {
+	if o.texture != nil && o.texture != texture {
+		o.close()
+	}
+
+	if texture == nil || texture.Initialized() {
+		return
+	}
+
+	texture.Init()
+	if assets == nil || assets.Get(texture.Name()) != Asset(texture) {
+		o.texture = texture
+	}
+}
+
+func (o *ownedTextureCube) close() {
+	if o.texture != nil {
+		o.texture.Close()
+		o.texture = nil
+	}
+}
+
+/******************************************************************************
+ New TextureCube Functions
+******************************************************************************/
+
+// NewTextureCube creates a cube map from six square images of the same size,
+// in the order defined by CubeFace: +X (right), -X (left), +Y (top),
+// -Y (bottom), +Z (back) and -Z (front).  As with Texture2D, a string source
+// can be the name of a file, resolved via the source library if set.
+func NewTextureCube[T TextureSource](name string, faces [6]T, config ...*TextureConfig) *TextureCube {
+	sources := make([]any, len(faces))
+	for i, face := range faces {
+		sources[i] = face
+	}
+	return newTextureCube(name, sources, config...)
+}
+
+// NewTextureCubeFromPanorama creates a cube map from an equirectangular
+// (2:1) panorama, which is projected onto the faces of the cube when the
+// texture is initialized.
+func NewTextureCubeFromPanorama[T TextureSource](name string, panorama T, config ...*TextureConfig) *TextureCube {
+	return newTextureCube(name, panorama, config...)
+}
+
+func newTextureCube(name string, source any, config ...*TextureConfig) *TextureCube {
+	if len(config) == 0 {
+		config = append(config, NewTextureConfig(HighestQuality))
+	}
+
+	useMipMaps, minFilterMode, magFilterMode := config[0].GetFilterConfig()
+
+	return &TextureCube{
+		AssetBase: AssetBase{
+			name:   name,
+			source: source,
+		},
+		minFilterMode: minFilterMode,
+		magFilterMode: magFilterMode,
+		useMipMaps:    useMipMaps,
+		size:          1,
+	}
+}
+
+/******************************************************************************
+ cubeImage
+******************************************************************************/
+
+// cubeImage An image used to create the faces of a TextureCube, which is
+// either a regular (8-bit) image or a high dynamic range one.
+type cubeImage interface {
+	size() (width, height int)
+
+	// resample Creates a square image of the given size, with each pixel
+	// being sampled (bilinearly) from this image at the (normalized)
+	// coordinates returned by the given function.
+	resample(size int, coords func(x, y int) (u, v float32)) cubeImage
+
+	// upload Sends the image to the currently bound texture.
+	upload(target uint32)
+}
+
+/******************************************************************************
+ ldrImage
+******************************************************************************/
+
+type ldrImage struct {
+	img *image.NRGBA
+}
+
+func (i *ldrImage) size() (width, height int) {
+	return i.img.Rect.Dx(), i.img.Rect.Dy()
+}
+
+func (i *ldrImage) resample(size int, coords func(x, y int) (u, v float32)) cubeImage {
+	width, height := i.size()
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			u, v := coords(x, y)
+			var rgba [4]float32
+			bilinear(width, height, u, v, func(px, py int, weight float32) {
+				offset := i.img.PixOffset(i.img.Rect.Min.X+px, i.img.Rect.Min.Y+py)
+				for c := 0; c < 4; c++ {
+					rgba[c] += float32(i.img.Pix[offset+c]) * weight
+				}
+			})
+			offset := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(mgl32.Clamp(rgba[c]+0.5, 0, 255))
+			}
+		}
+	}
+	return &ldrImage{dst}
+}
+
+func (i *ldrImage) upload(target uint32) {
+	width, height := i.size()
+	img := i.img
+	if img.Stride != width*4 {
+		img = image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(img, img.Bounds(), i.img, i.img.Rect.Min, draw.Src)
+	}
+	gl.TexImage2D(target, 0, gl.RGBA8, int32(width), int32(height), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+}
+
+/******************************************************************************
+ hdrImage
+******************************************************************************/
+
+// hdrImage A high dynamic range image, such as those decoded from Radiance
+// HDR files, made of linear RGB values.
+type hdrImage struct {
+	width  int
+	height int
+	pix    []float32
+}
+
+func (i *hdrImage) size() (width, height int) {
+	return i.width, i.height
+}
+
+func (i *hdrImage) resample(size int, coords func(x, y int) (u, v float32)) cubeImage {
+	dst := &hdrImage{width: size, height: size, pix: make([]float32, size*size*3)}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			u, v := coords(x, y)
+			rgb := dst.pix[(y*size+x)*3 : (y*size+x)*3+3]
+			bilinear(i.width, i.height, u, v, func(px, py int, weight float32) {
+				offset := (py*i.width + px) * 3
+				for c := 0; c < 3; c++ {
+					rgb[c] += i.pix[offset+c] * weight
+				}
+			})
+		}
+	}
+	return dst
+}
+
+func (i *hdrImage) upload(target uint32) {
+	gl.TexImage2D(target, 0, gl.RGB16F, int32(i.width), int32(i.height), 0, gl.RGB, gl.FLOAT, gl.Ptr(i.pix))
+}
+
+// decodeHdr Decodes a Radiance HDR (RGBE) image, with either flat or run
+// length encoded scanlines, in the standard (-Y +X) orientation.
+func decodeHdr(reader *bufio.Reader, dataSize int) (*hdrImage, error) {
+	format := ""
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("invalid header: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if value, found := strings.CutPrefix(line, "FORMAT="); found {
+			format = value
+		}
+	}
+
+	if format != "" && format != "32-bit_rle_rgbe" {
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("invalid resolution: %w", err)
+	}
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[0] != "-Y" || fields[2] != "+X" {
+		return nil, fmt.Errorf("unsupported resolution: %s", strings.TrimSpace(line))
+	}
+	height, errY := strconv.Atoi(fields[1])
+	width, errX := strconv.Atoi(fields[3])
+	if errY != nil || errX != nil || width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid resolution: %s", strings.TrimSpace(line))
+	}
+
+	// Each scanline takes up a minimum number of bytes, which bounds the
+	// resolution by the size of the data before anything is allocated
+	if width > dataSize || height > dataSize/minHdrScanlineSize(width) {
+		return nil, fmt.Errorf("resolution exceeds the data size: %s", strings.TrimSpace(line))
+	}
+
+	img := &hdrImage{width: width, height: height, pix: make([]float32, width*height*3)}
+	scanline := make([]byte, width*4)
+	for y := 0; y < height; y++ {
+		if err = readHdrScanline(reader, scanline, width); err != nil {
+			return nil, fmt.Errorf("invalid scanline %d: %w", y, err)
+		}
+		for x := 0; x < width; x++ {
+			rgbe := scanline[x*4 : x*4+4]
+			if rgbe[3] == 0 {
+				continue
+			}
+			scale := float32(math.Ldexp(1, int(rgbe[3])-(128+8)))
+			offset := (y*width + x) * 3
+			img.pix[offset] = float32(rgbe[0]) * scale
+			img.pix[offset+1] = float32(rgbe[1]) * scale
+			img.pix[offset+2] = float32(rgbe[2]) * scale
+		}
+	}
+
+	return img, nil
+}
+
+// minHdrScanlineSize Returns the fewest bytes a scanline of the given width
+// can be encoded in: flat scanlines take four bytes per pixel, while each
+// component of a run length encoded scanline takes at least two bytes per
+// run of up to 127 values.
+func minHdrScanlineSize(width int) int {
+	if width < 8 || width > 0x7FFF {
+		return width * 4
+	}
+	return 4 + 4*2*((width+126)/127)
+}
+
+func readHdrScanline(reader *bufio.Reader, scanline []byte, width int) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return err
+	}
+
+	// Scanlines that are not run length encoded are stored as-is
+	if width < 8 || width > 0x7FFF || header[0] != 2 || header[1] != 2 || header[2]&0x80 != 0 {
+		copy(scanline, header)
+		_, err := io.ReadFull(reader, scanline[4:])
+		return err
+	}
+
+	if int(header[2])<<8|int(header[3]) != width {
+		return fmt.Errorf("scanline width mismatch")
+	}
+
+	// Otherwise, each component is encoded separately, as runs of the same
+	// value or of literal values
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			count, err := reader.ReadByte()
+			if err != nil {
+				return err
+			}
+
+			if count > 128 {
+				count -= 128
+				value, err := reader.ReadByte()
+				if err != nil {
+					return err
+				}
+				if x+int(count) > width {
+					return fmt.Errorf("run exceeds scanline")
+				}
+				for i := 0; i < int(count); i++ {
+					scanline[(x+i)*4+c] = value
+				}
+			} else {
+				if count == 0 || x+int(count) > width {
+					return fmt.Errorf("invalid run")
+				}
+				for i := 0; i < int(count); i++ {
+					value, err := reader.ReadByte()
+					if err != nil {
+						return err
+					}
+					scanline[(x+i)*4+c] = value
+				}
+			}
+
+			x += int(count)
+		}
+	}
+
+	return nil
+}
+
+/******************************************************************************
+ Utility Functions
+******************************************************************************/
+
+// bilinear Calls the given function for each of the four pixels surrounding
+// the given (normalized) coordinates, along with their weights, wrapping
+// around horizontally and clamping vertically.
+func bilinear(width, height int, u, v float32, sample func(x, y int, weight float32)) {
+	fx := u*float32(width) - 0.5
+	fy := mgl32.Clamp(v*float32(height)-0.5, 0, float32(height-1))
+
+	x0 := int(math.Floor(float64(fx)))
+	y0 := int(fy)
+	tx := fx - float32(x0)
+	ty := fy - float32(y0)
+
+	x1 := ((x0+1)%width + width) % width
+	x0 = (x0%width + width) % width
+	y1 := min(y0+1, height-1)
+
+	sample(x0, y0, (1-tx)*(1-ty))
+	sample(x1, y0, tx*(1-ty))
+	sample(x0, y1, (1-tx)*ty)
+	sample(x1, y1, tx*ty)
+}
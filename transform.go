@@ -298,15 +298,34 @@ func (t *ObjectTransform) WorldMatrix() mgl32.Mat4 {
 	rot := t.WorldRotation()
 	scale := t.WorldScale()
 
+	t.stateMutex.Lock()
+	rotQuat, rotQuatSet := t.rotationQuat, t.rotationQuatSet
+	t.stateMutex.Unlock()
+
+	return transformMatrix(tran, rot, scale, rotQuat, rotQuatSet)
+}
+
+// LocalMatrix returns the matrix composed of the transform's own position,
+// rotation and scale, ignoring those of its parent.
+func (t *ObjectTransform) LocalMatrix() mgl32.Mat4 {
+	t.stateMutex.Lock()
+	tran, rot, scale := t.position, t.rotation, t.scale
+	rotQuat, rotQuatSet := t.rotationQuat, t.rotationQuatSet
+	t.stateMutex.Unlock()
+
+	return transformMatrix(tran, rot, scale, rotQuat, rotQuatSet)
+}
+
+// transformMatrix Returns the matrix that scales, then rotates (using the
+// quaternion if set, otherwise the axis-angle rotation vector), and then
+// translates.
+func transformMatrix(tran, rot, scale mgl32.Vec3, rotQuat mgl32.Quat, rotQuatSet bool) mgl32.Mat4 {
 	tranMat := mgl32.Translate3D(tran.X(), tran.Y(), tran.Z())
 
 	var rotMat mgl32.Mat4
-	t.stateMutex.Lock()
-	if t.rotationQuatSet {
-		rotMat = t.rotationQuat.Mat4()
-		t.stateMutex.Unlock()
+	if rotQuatSet {
+		rotMat = rotQuat.Mat4()
 	} else {
-		t.stateMutex.Unlock()
 		rotLen := rot.Len()
 		if rotLen > 0.0001 {
 			rotMat = mgl32.HomogRotate3D(rotLen, rot.Normalize())
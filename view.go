@@ -138,7 +138,7 @@ func (v *View) defaultLayout() {
 	v.border.SetColor(Black)
 }
 
-func (v *View) SetTexture(texture *Texture2D) *View {
+func (v *View) SetTexture(texture Texture) *View {
 	v.fill.SetColor(White)
 	v.fill.SetTexture(texture)
 	return v
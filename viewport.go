@@ -49,6 +49,16 @@ func (v *Viewport) SetWindowSize(width, height int) *Viewport {
 	return v
 }
 
+// WindowSize returns the size of the window (or RenderTarget) that the
+// viewport is relative to, in pixels.
+func (v *Viewport) WindowSize() (width, height int) {
+	v.stateMutex.Lock()
+	width = v.winW
+	height = v.winH
+	v.stateMutex.Unlock()
+	return
+}
+
 func (v *Viewport) Get() (x, y, width, height int32) {
 	v.stateMutex.Lock()
 	x = int32(v.x)
@@ -59,6 +69,25 @@ func (v *Viewport) Get() (x, y, width, height int32) {
 	return
 }
 
+// WindowToViewport converts the given window position (normalized device
+// coordinates, as with MouseState) to the normalized device coordinates of
+// the viewport, which lie outside the range -1 to 1 when the position is
+// outside the viewport.
+func (v *Viewport) WindowToViewport(x, y float32) (viewportX, viewportY float32) {
+	v.stateMutex.Lock()
+	defer v.stateMutex.Unlock()
+
+	if v.w == 0 || v.h == 0 {
+		return x, y
+	}
+
+	pixelX := (x + 1) * 0.5 * float32(v.winW)
+	pixelY := (y + 1) * 0.5 * float32(v.winH)
+	viewportX = (pixelX-float32(v.x))/float32(v.w)*2 - 1
+	viewportY = (pixelY-float32(v.y))/float32(v.h)*2 - 1
+	return
+}
+
 /******************************************************************************
  New Viewport Function
 ******************************************************************************/
@@ -26,6 +26,7 @@ type Window struct {
 	borderless    bool
 	resizable     bool
 	multisampling bool
+	headless      bool
 	secondary     bool
 
 	width      int
@@ -64,13 +65,26 @@ type Window struct {
 	services []Service
 	assets   *AssetLibrary
 
+	postProcessor *postProcessor
+
+	// frameCount The number of frames ticked, used to invalidate pickCache.
+	frameCount uint64
+	pickCache  pick3DCache
+
 	objectInitQueue  []*asyncBoolInvocation
 	objectCloseQueue []*asyncVoidInvocation
 
 	serviceInitQueue  []*asyncBoolInvocation
 	serviceCloseQueue []*asyncVoidInvocation
 
-	pngExportRequest *asyncByteSliceInvocation
+	pngExportRequest    *asyncByteSliceInvocation
+	imageExportRequests []*asyncImageInvocation
+
+	offscreenFrameBuffer        uint32
+	offscreenColorBuffer        uint32
+	offscreenDepthBuffer        uint32
+	offscreenResolveFrameBuffer uint32
+	offscreenResolveColorBuffer uint32
 
 	labelCache map[string]*Texture2D
 
@@ -100,6 +114,10 @@ type WindowHints struct {
 	Borderless    bool
 	Resizable     bool
 	MultiSampling bool
+
+	// Headless windows are never shown; instead, they render to an
+	// offscreen framebuffer, which can still be exported via ToPNG().
+	Headless bool
 }
 
 func NewWindowHints(borderless, resizable, multisampling bool) *WindowHints {
@@ -163,6 +181,13 @@ func (w *Window) MultiSamplingEnabled() (enabled bool) {
 	return
 }
 
+func (w *Window) Headless() (headless bool) {
+	w.configMutex.Lock()
+	headless = w.headless
+	w.configMutex.Unlock()
+	return
+}
+
 func (w *Window) IsSecondary() (secondary bool) {
 	w.configMutex.Lock()
 	secondary = w.secondary
@@ -181,6 +206,10 @@ func (w *Window) Init(glwin *glfw.Window, ctx context.Context) {
 	w.glwin = glwin
 	w.doneChan = ctx.Done()
 
+	if w.headless {
+		w.initOffscreenFrameBuffer()
+	}
+
 	w.addAssetLibraryService()
 	w.registerFocusCallback()
 	w.registerMaximizeCallback()
@@ -236,8 +265,13 @@ func (w *Window) Close() {
 		w.stateMutex.Unlock()
 		return
 	}
+	w.cancelImageRequests()
+	w.postProcessor.close()
 	w.disposeAllObjects()
 	w.disposeAllServices()
+	if w.headless {
+		w.closeOffscreenFrameBuffer()
+	}
 	close(w.keyEventChan)
 	w.initialized.Store(false)
 	w.stateMutex.Unlock()
@@ -274,6 +308,93 @@ func (w *Window) enableWindowedMode() {
 	w.fullscreen = false
 }
 
+func (w *Window) initOffscreenFrameBuffer() {
+	width := int32(w.width)
+	height := int32(w.height)
+
+	samples := int32(0)
+	if w.multisampling {
+		samples = 4
+	}
+
+	gl.GenFramebuffers(1, &w.offscreenFrameBuffer)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, w.offscreenFrameBuffer)
+
+	gl.GenRenderbuffers(1, &w.offscreenColorBuffer)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, w.offscreenColorBuffer)
+	gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, samples, gl.RGBA8, width, height)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, w.offscreenColorBuffer)
+
+	gl.GenRenderbuffers(1, &w.offscreenDepthBuffer)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, w.offscreenDepthBuffer)
+	gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, samples, gl.DEPTH24_STENCIL8, width, height)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, w.offscreenDepthBuffer)
+
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		panic(fmt.Errorf("offscreen framebuffer incomplete: status 0x%x", status))
+	}
+
+	// Multisampled renderbuffers cannot be read directly, so they
+	// are resolved (blitted) into a single-sample framebuffer first
+	if samples > 0 {
+		gl.GenFramebuffers(1, &w.offscreenResolveFrameBuffer)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, w.offscreenResolveFrameBuffer)
+
+		gl.GenRenderbuffers(1, &w.offscreenResolveColorBuffer)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, w.offscreenResolveColorBuffer)
+		gl.RenderbufferStorage(gl.RENDERBUFFER, gl.RGBA8, width, height)
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, w.offscreenResolveColorBuffer)
+	}
+
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, w.offscreenFrameBuffer)
+	gl.Viewport(0, 0, width, height)
+}
+
+func (w *Window) closeOffscreenFrameBuffer() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	gl.DeleteFramebuffers(1, &w.offscreenFrameBuffer)
+	gl.DeleteRenderbuffers(1, &w.offscreenColorBuffer)
+	gl.DeleteRenderbuffers(1, &w.offscreenDepthBuffer)
+	w.offscreenFrameBuffer = 0
+	w.offscreenColorBuffer = 0
+	w.offscreenDepthBuffer = 0
+
+	if w.offscreenResolveFrameBuffer != 0 {
+		gl.DeleteFramebuffers(1, &w.offscreenResolveFrameBuffer)
+		gl.DeleteRenderbuffers(1, &w.offscreenResolveColorBuffer)
+		w.offscreenResolveFrameBuffer = 0
+		w.offscreenResolveColorBuffer = 0
+	}
+}
+
+func (w *Window) resizeOffscreenFrameBuffer() {
+	w.closeOffscreenFrameBuffer()
+	w.initOffscreenFrameBuffer()
+}
+
+// bindReadFrameBuffer binds the framebuffer from which the
+// most recently rendered frame can be read via glReadPixels.
+func (w *Window) bindReadFrameBuffer(width, height int32) {
+	if !w.headless {
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, 0)
+		return
+	}
+
+	if w.offscreenResolveFrameBuffer == 0 {
+		gl.BindFramebuffer(gl.READ_FRAMEBUFFER, w.offscreenFrameBuffer)
+		return
+	}
+
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, w.offscreenFrameBuffer)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, w.offscreenResolveFrameBuffer)
+	gl.BlitFramebuffer(0, 0, width, height, 0, 0, width, height, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, w.offscreenFrameBuffer)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, w.offscreenResolveFrameBuffer)
+}
+
 func (w *Window) getFontOrDefault(fontName string) Font {
 	asset := w.assets.Get(fontName)
 	if asset != nil {
@@ -361,6 +482,24 @@ func (w *Window) drawObjects(deltaTime int64) {
 	}
 }
 
+func (w *Window) prepareFrame() {
+	for _, s := range w.services {
+		if p, ok := s.(framePreparer); ok {
+			p.prepareFrame()
+		}
+	}
+}
+
+func (w *Window) captureFrame() {
+	w.handleImageRequests()
+
+	for _, s := range w.services {
+		if c, ok := s.(frameCapturer); ok {
+			c.captureFrame()
+		}
+	}
+}
+
 func (w *Window) closeObjects() {
 	for i := len(w.objectCloseQueue) - 1; i >= 0; i-- {
 		closeInv := w.objectCloseQueue[i]
@@ -468,6 +607,9 @@ func (w *Window) refreshLayout() {
 		return
 	} else if w.sizeChanged {
 		w.glwin.SetSize(w.width, w.height)
+		if w.headless {
+			w.resizeOffscreenFrameBuffer()
+		}
 		width := w.width
 		height := w.height
 		w.sizeChanged = false
@@ -492,6 +634,28 @@ func (w *Window) handlePngRequests() {
 	w.pngExportRequest = nil
 }
 
+func (w *Window) handleImageRequests() {
+	if len(w.imageExportRequests) == 0 {
+		return
+	}
+	img := w.imageExportRequests[0].Func()
+	for _, request := range w.imageExportRequests {
+		select {
+		case request.ReturnChan <- img:
+		default:
+		}
+		close(request.ReturnChan)
+	}
+	w.imageExportRequests = nil
+}
+
+func (w *Window) cancelImageRequests() {
+	for _, request := range w.imageExportRequests {
+		close(request.ReturnChan)
+	}
+	w.imageExportRequests = nil
+}
+
 func (w *Window) handleKeyEvents() {
 	for {
 		select {
@@ -549,15 +713,24 @@ func (w *Window) registerMaximizeCallback() {
 }
 
 func (w *Window) clearScreen() {
+	if w.headless {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, w.offscreenFrameBuffer)
+	}
 	gl.ClearColor(w.clearColorVec[0], w.clearColorVec[1], w.clearColorVec[2], w.clearColorVec[3])
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 }
 
 func (w *Window) refreshScreen() {
+	if w.headless {
+		gl.Flush()
+		return
+	}
 	w.glwin.SwapBuffers()
 }
 
 func (w *Window) tick(deltaTime int64) {
+	w.frameCount++
+
 	w.initServices()
 	w.closeServices()
 	w.updateServices(deltaTime)
@@ -566,7 +739,13 @@ func (w *Window) tick(deltaTime int64) {
 	w.closeObjects()
 
 	w.updateObjects(deltaTime)
+	w.prepareFrame()
+
+	w.postProcessor.begin(w, deltaTime)
 	w.drawObjects(deltaTime)
+	w.postProcessor.end()
+
+	w.captureFrame()
 }
 
 func (w *Window) AddKeyEventHandler(receiver any, key glfw.Key, action glfw.Action,
@@ -612,6 +791,10 @@ func (w *Window) RemoveKeyEventHandlers(receiver any) {
 
 func (w *Window) SetFullscreenEnabled(enabled bool) *Window {
 	w.configMutex.Lock()
+	if w.headless {
+		w.configMutex.Unlock()
+		return w
+	}
 	if enabled {
 		w.fullscreenRequested = true
 	} else {
@@ -724,6 +907,35 @@ func (w *Window) GetObject(name string) Object {
 	return nil
 }
 
+// Pick3D returns the nearest intersection of the Shape3D objects in the
+// window (including children of other objects and the shapes of Scene
+// objects) with the rays cast from the given position (normalized device
+// coordinates, as with MouseState) through the camera and viewport of each
+// shape.
+func (w *Window) Pick3D(x, y float32) (hit RayHit, ok bool) {
+	w.stateMutex.Lock()
+	objects := make([]WindowObject, len(w.windowObjects))
+	copy(objects, w.windowObjects)
+	w.stateMutex.Unlock()
+
+	return Pick3D(x, y, collectPickShapes(objects)...)
+}
+
+// pick3DOnce Same as Pick3D(), but only casts the rays once per frame for
+// a given position, returning the cached result to subsequent callers (as
+// when several BoundingShape3D objects test for occlusion).  Must only be
+// called while the window is being updated, i.e. by objects' Update().
+func (w *Window) pick3DOnce(x, y float32) (hit RayHit, ok bool) {
+	cache := &w.pickCache
+	if cache.frame == w.frameCount && cache.x == x && cache.y == y {
+		return cache.hit, cache.ok
+	}
+
+	hit, ok = Pick3D(x, y, collectPickShapes(w.windowObjects)...)
+	*cache = pick3DCache{frame: w.frameCount, x: x, y: y, hit: hit, ok: ok}
+	return
+}
+
 func (w *Window) AddObject(object any, waitForInit ...bool) {
 	w.stateMutex.Lock()
 
@@ -734,6 +946,10 @@ func (w *Window) AddObject(object any, waitForInit ...bool) {
 
 	var initInv *asyncBoolInvocation
 
+	if o, ok := object.(windowSetter); ok {
+		o.setWindow(w)
+	}
+
 	if o, ok := object.(WindowObject); ok {
 		o.SetWindow(w)
 		w.objects = append(w.objects, o)
@@ -771,6 +987,10 @@ func (w *Window) AddObjects(objects ...any) {
 	w.stateMutex.Lock()
 
 	for _, obj := range objects {
+		if o, ok := obj.(windowSetter); ok {
+			o.setWindow(w)
+		}
+
 		if o, ok := obj.(WindowObject); ok {
 			o.SetWindow(w)
 			w.objects = append(w.objects, o)
@@ -956,6 +1176,29 @@ func (w *Window) AddService(service Service) {
 	w.stateMutex.Unlock()
 }
 
+// AddEffect adds a post-processing effect to the end of the window's chain
+// of effects.  When at least one effect is enabled, the objects of the
+// window are drawn into an offscreen buffer, which is then passed through
+// each enabled effect, in the order they were added, before reaching the
+// screen.  Effects can be enabled/disabled at any time via SetEnabled().
+func (w *Window) AddEffect(effect Effect) {
+	w.postProcessor.add(w, effect)
+}
+
+func (w *Window) AddEffects(effects ...Effect) {
+	w.postProcessor.add(w, effects...)
+}
+
+// RemoveEffect removes the effect from the window's chain of effects, without
+// closing it, so that it can be added again later.
+func (w *Window) RemoveEffect(effect Effect) {
+	w.postProcessor.remove(effect)
+}
+
+func (w *Window) Effects() []Effect {
+	return w.postProcessor.list()
+}
+
 func (w *Window) RemoveService(service Service) {
 	if service == nil || service.Protected() {
 		return
@@ -1306,6 +1549,13 @@ func (w *Window) EnableMouseTracking() *Window {
 		w.mouseStateMutex.Unlock()
 	})
 
+	w.glwin.SetScrollCallback(func(window *glfw.Window, xOffset float64, yOffset float64) {
+		w.mouseStateMutex.Lock()
+		w.mouseState.ScrollX += float32(xOffset)
+		w.mouseState.ScrollY += float32(yOffset)
+		w.mouseStateMutex.Unlock()
+	})
+
 	w.mouseStateMutex.Lock()
 	w.mouseState.X = -999
 	w.mouseState.Y = -999
@@ -1330,28 +1580,32 @@ func (w *Window) Initialized() bool {
 	return w.initialized.Load()
 }
 
-func (w *Window) toPNG() []byte {
+func (w *Window) readPixels() *image.RGBA {
 	w.configMutex.Lock()
 	width := int32(w.width)
 	height := int32(w.height)
 	w.configMutex.Unlock()
 
+	w.bindReadFrameBuffer(width, height)
+
 	pixels := make([]byte, width*height*4)
 	gl.ReadPixels(0, 0, width, height, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
 
 	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
 
+	rowSize := width * 4
 	for y := int32(0); y < height; y++ {
-		for x := int32(0); x < width; x++ {
-			i := y*width*4 + x*4
-			j := (height-y-1)*width*4 + x*4
-			img.Pix[i+0] = pixels[j+0]
-			img.Pix[i+1] = pixels[j+1]
-			img.Pix[i+2] = pixels[j+2]
-			img.Pix[i+3] = pixels[j+3]
-		}
+		i := y * rowSize
+		j := (height - y - 1) * rowSize
+		copy(img.Pix[i:i+rowSize], pixels[j:j+rowSize])
 	}
 
+	return img
+}
+
+func (w *Window) toPNG() []byte {
+	img := w.readPixels()
+
 	var resultBuffer bytes.Buffer
 	if err := png.Encode(&resultBuffer, img); err != nil {
 		panic(err)
@@ -1368,6 +1622,30 @@ func (w *Window) ToPNG() []byte {
 	return *<-pngInv.ReturnChan
 }
 
+// ToImage returns the next frame rendered by the window, read back
+// from the framebuffer before it is presented.  Blocks until that
+// frame has been rendered, so do not call from the main routine.
+// Concurrent callers receive the same frame.  Returns nil if the
+// window is not running or stops before the frame is rendered.
+func (w *Window) ToImage() *image.RGBA {
+	imgInv := newAsyncImageInvocation(w.readPixels)
+	w.stateMutex.Lock()
+	if !w.initialized.Load() {
+		w.stateMutex.Unlock()
+		return nil
+	}
+	w.imageExportRequests = append(w.imageExportRequests, imgInv)
+	doneChan := w.doneChan
+	w.stateMutex.Unlock()
+
+	select {
+	case img := <-imgInv.ReturnChan:
+		return img
+	case <-doneChan:
+		return nil
+	}
+}
+
 func (w *Window) ClearLabelCache() {
 	w.stateMutex.Lock()
 	for _, texture := range w.labelCache {
@@ -1399,12 +1677,14 @@ func NewWindow(hints ...*WindowHints) *Window {
 		borderless:       winHints.Borderless,
 		resizable:        winHints.Resizable,
 		multisampling:    winHints.MultiSampling,
+		headless:         winHints.Headless,
 		clearColorVec:    mgl32.Vec4{0, 0, 0, 1},
 		clearColorRgba:   color.RGBA{A: 255},
 		opacity:          255,
 		hasFocus:         true,
 		labelCache:       make(map[string]*Texture2D),
 		keyEventHandlers: make(map[uint64][]*KeyEventHandler),
+		postProcessor:    newPostProcessor(),
 	}
 
 	w.SetWidth(defaultWinWidth)